@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-vrf/dkg/audit"
+)
+
+const usage = `usage: dkg-audit [-transcript FILE]
+
+Reads a JSON DKG transcript (from FILE, or stdin) and checks that the onchain
+key was produced by valid PVSS dealings. Exits nonzero if the audit fails.
+
+{
+  "offchainConfig": "0x...",
+  "onchainConfig":  "0x...",
+  "configDigest":   "0x...",
+  "f":              1,
+  "publicKey":      "0x...",
+  "hashes":         ["0x...", ...],
+  "shareRecords":   ["0x...", ...],
+  "previous":       { ...transcript of the DKG being refreshed or reshared... }
+}
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	transcriptFile := flag.String("transcript", "", "JSON DKG transcript; stdin if empty")
+	flag.Parse()
+	report, err := run(*transcriptFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	printReport(os.Stdout, report)
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
+
+func run(transcriptFile string) (*audit.Report, error) {
+	in := os.Stdin
+	if transcriptFile != "" {
+		f, err := os.Open(transcriptFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not open transcript")
+		}
+		defer f.Close()
+		in = f
+	}
+	var t audit.Transcript
+	if err := json.NewDecoder(in).Decode(&t); err != nil {
+		return nil, errors.Wrap(err, "could not parse transcript")
+	}
+	return audit.AuditTranscript(&t)
+}
+
+func printReport(w io.Writer, r *audit.Report) {
+	fmt.Fprintf(w, "config digest: %s\n", r.ConfigDigest)
+	fmt.Fprintf(w, "key ID:        0x%x\n", r.KeyID)
+	fmt.Fprintf(w, "mode:          %s\n", r.Mode)
+	fmt.Fprintf(w, "threshold:     %d\n", r.Threshold)
+	for _, s := range r.ShareRecords {
+		status := "valid"
+		if !s.Valid() {
+			status = "INVALID: " + s.Err
+		}
+		fmt.Fprintf(
+			w, "share record %s dealer %d listed onchain %t: %s\n",
+			s.Hash, s.Dealer, s.ListedOnchain, status,
+		)
+	}
+	if r.PublicKey != nil {
+		fmt.Fprintf(w, "public key:    %s\n", r.PublicKey)
+	}
+	for i, s := range r.PublicShares {
+		fmt.Fprintf(w, "public share %d: %s\n", i, s)
+	}
+	if r.Passed() {
+		fmt.Fprintln(w, "PASS")
+		return
+	}
+	for _, f := range r.Failures {
+		fmt.Fprintf(w, "failure: %s\n", f)
+	}
+	fmt.Fprintln(w, "FAIL")
+}
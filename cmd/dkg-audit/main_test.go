@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/smartcontractkit/chainlink-vrf/dkg"
+	"github.com/smartcontractkit/chainlink-vrf/dkg/audit"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+// writeTranscript writes a transcript of a fresh-key DKG among five players
+// whose onchain key lists a share record which wasn't provided.
+func writeTranscript(t *testing.T) string {
+	g, err := dkg.EncryptionGroupByName("AltBN-128 G₁")
+	if err != nil {
+		t.Fatal(err)
+	}
+	translator, err := dkg.TranslatorByName("translator from AltBN-128 G₁ to AltBN-128 G₂")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingGroup := dkg.Ed25519SigningGroup()
+	var epks, spks []kyber.Point
+	for i := 0; i < 5; i++ {
+		epks = append(epks, g.Point().Pick(g.RandomStream()))
+		spks = append(spks, signingGroup.Point().Pick(signingGroup.RandomStream()))
+	}
+	offchainConfig, err := dkg.OffchainConfig(epks, spks, g, translator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onchainConfig, err := dkg.OnchainConfig(dkg.KeyID{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	translationGroup, err := translator.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := translationGroup.Point().Base().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transcript, err := json.Marshal(&audit.Transcript{
+		OffchainConfig: offchainConfig,
+		OnchainConfig:  onchainConfig,
+		ConfigDigest:   common.Hash{1},
+		F:              1,
+		PublicKey:      pk,
+		Hashes:         []common.Hash{{3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "transcript.json")
+	if err := os.WriteFile(p, transcript, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRunReportsFailedAudit(t *testing.T) {
+	report, err := run(writeTranscript(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Passed() {
+		t.Fatal("audit of transcript missing its share record passed")
+	}
+	var out bytes.Buffer
+	printReport(&out, report)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if lines[len(lines)-1] != "FAIL" {
+		t.Fatalf("failed audit printed as\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "failure: no share record provided for onchain hash") {
+		t.Fatalf("audit failure not printed:\n%s", out.String())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if _, err := run(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("audited a missing transcript file")
+	}
+	p := filepath.Join(t.TempDir(), "transcript.json")
+	if err := os.WriteFile(p, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(p); err == nil {
+		t.Fatal("audited a transcript which isn't JSON")
+	}
+}
+
+func TestPrintPassedReport(t *testing.T) {
+	g, err := dkg.EncryptionGroupByName("AltBN-128 G₁")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &audit.Report{
+		ShareRecords: []audit.ShareRecord{{
+			Dealer: 1, ListedOnchain: true,
+			SignatureValid: true, ProofsValid: true, SecretValid: true,
+		}},
+		PublicKey:    g.Point().Base(),
+		PublicShares: []kyber.Point{g.Point().Base()},
+	}
+	var out bytes.Buffer
+	printReport(&out, report)
+	for _, want := range []string{
+		"dealer 1 listed onchain true: valid\n", "public key:", "public share 0:", "PASS\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("passed audit printed without %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "FAIL") {
+		t.Fatalf("passed audit printed as failed:\n%s", out.String())
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/dkg"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+const (
+	defaultEncryptionGroup = "AltBN-128 G₁"
+	defaultTranslator      = "translator from AltBN-128 G₁ to AltBN-128 G₂"
+)
+
+const usage = `usage:
+  dkg-key-backup export -dir DIR -config-digest HEX -key-id HEX -esk-file FILE \
+      -passphrase-file FILE -out FILE [-encryption-group NAME] [-translator NAME] \
+      [-wrapping-passphrase-file FILE -wrapping-key-id N]
+  dkg-key-backup import -dir DIR -esk-file FILE -passphrase-file FILE -in FILE \
+      [-encryption-group NAME] [-wrapping-passphrase-file FILE -wrapping-key-id N]
+
+export writes a passphrase-encrypted backup of the key snapshot a node stored
+in DIR. import validates a backup and writes it back to DIR as a key snapshot,
+which the node checks against the onchain key before using it.
+`
+
+type storeFlags struct {
+	dir                    *string
+	eskFile                *string
+	encryptionGroup        *string
+	wrappingPassphraseFile *string
+	wrappingKeyID          *uint
+	passphraseFile         *string
+}
+
+func newStoreFlags(fs *flag.FlagSet) *storeFlags {
+	return &storeFlags{
+		fs.String("dir", "", "directory of the node's file share persistence"),
+		fs.String("esk-file", "", "file containing the hex-encoded encryption secret key"),
+		fs.String(
+			"encryption-group", defaultEncryptionGroup, "name of the encryption group",
+		),
+		fs.String(
+			"wrapping-passphrase-file", "",
+			"file containing the passphrase wrapping the node's share records, if any",
+		),
+		fs.Uint("wrapping-key-id", 0, "key ID of the wrapping passphrase"),
+		fs.String("passphrase-file", "", "file containing the backup passphrase"),
+	}
+}
+
+func (s *storeFlags) open() (
+	dkg_types.DKGSharePersistence, kyber.Scalar, []byte, error,
+) {
+	if *s.dir == "" || *s.eskFile == "" || *s.passphraseFile == "" {
+		return nil, nil, nil, errors.Errorf("-dir, -esk-file and -passphrase-file are required")
+	}
+	group, err := dkg.EncryptionGroupByName(*s.encryptionGroup)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	eskHex, err := readSecretFile(*s.eskFile)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not read encryption secret key")
+	}
+	eskB, err := hex.DecodeString(strings.TrimPrefix(string(eskHex), "0x"))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not decode encryption secret key")
+	}
+	esk := group.Scalar()
+	if err := esk.UnmarshalBinary(eskB); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not unmarshal encryption secret key")
+	}
+	passphrase, err := readSecretFile(*s.passphraseFile)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not read backup passphrase")
+	}
+	fileDB, err := dkg.NewFileSharePersistence(*s.dir)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not open share persistence")
+	}
+	var db dkg_types.DKGSharePersistence = fileDB
+	if *s.wrappingPassphraseFile != "" {
+		wrappingPassphrase, err := readSecretFile(*s.wrappingPassphraseFile)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "could not read wrapping passphrase")
+		}
+		keyID := uint32(*s.wrappingKeyID)
+		keys, err := dkg.NewPassphraseKeySource(
+			keyID, map[uint32][]byte{keyID: wrappingPassphrase},
+		)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "could not construct wrapping key")
+		}
+		db = dkg.NewEncryptedSharePersistence(fileDB, keys)
+	}
+	return db, esk, passphrase, nil
+}
+
+func readSecretFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(b, "\r\n"), nil
+}
+
+func decodeHex32(name, s string) (rv [32]byte, err error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return rv, errors.Wrapf(err, "could not decode %s", name)
+	}
+	if len(b) != len(rv) {
+		return rv, errors.Errorf("%s must be %d bytes, got %d", name, len(rv), len(b))
+	}
+	copy(rv[:], b)
+	return rv, nil
+}
+
+func export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	store := newStoreFlags(fs)
+	cfgDgstHex := fs.String("config-digest", "", "config digest of the completed DKG")
+	keyIDHex := fs.String("key-id", "", "key ID of the distributed key")
+	translatorName := fs.String(
+		"translator", defaultTranslator, "name of the public key translator",
+	)
+	out := fs.String("out", "", "file to write the backup to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.Errorf("-out is required")
+	}
+	db, esk, passphrase, err := store.open()
+	if err != nil {
+		return err
+	}
+	cfgDgst, err := decodeHex32("config digest", *cfgDgstHex)
+	if err != nil {
+		return err
+	}
+	keyID, err := decodeHex32("key ID", *keyIDHex)
+	if err != nil {
+		return err
+	}
+	group, err := dkg.EncryptionGroupByName(*store.encryptionGroup)
+	if err != nil {
+		return err
+	}
+	translator, err := dkg.TranslatorByName(*translatorName)
+	if err != nil {
+		return err
+	}
+	backup, err := dkg.ExportStoredKeyBackup(
+		db, esk, group, translator, types.ConfigDigest(cfgDgst), keyID, passphrase,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not export key backup")
+	}
+	if err := os.WriteFile(*out, backup, 0o600); err != nil {
+		return errors.Wrap(err, "could not write key backup")
+	}
+	return nil
+}
+
+func restore(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	store := newStoreFlags(fs)
+	in := fs.String("in", "", "file to read the backup from")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.Errorf("-in is required")
+	}
+	db, esk, passphrase, err := store.open()
+	if err != nil {
+		return err
+	}
+	backup, err := os.ReadFile(*in)
+	if err != nil {
+		return errors.Wrap(err, "could not read key backup")
+	}
+	cfgDgst, keyID, err := dkg.RestoreStoredKeyBackup(
+		context.Background(), db, esk, backup, passphrase,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not import key backup")
+	}
+	fmt.Printf("restored key 0x%x from config digest %s\n", keyID, cfgDgst)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = export(os.Args[2:])
+	case "import":
+		err = restore(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
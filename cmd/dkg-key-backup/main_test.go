@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-vrf/dkg"
+)
+
+// testdata/key.backup holds the second of three players' shares of a key from
+// config digest 0x01 with key ID 0x02, under fixturePassphrase.
+const fixturePassphrase = "fixture passphrase"
+
+var (
+	fixtureCfgDgst = "01" + strings.Repeat("00", 31)
+	fixtureKeyID   = "02" + strings.Repeat("00", 31)
+)
+
+func writeFile(t *testing.T, dir, name, contents string) string {
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// testFiles returns the paths of a fresh encryption secret key, the fixture's
+// backup passphrase and a wrapping passphrase.
+func testFiles(t *testing.T) (esk, passphrase, wrapping string) {
+	dir := t.TempDir()
+	group, err := dkg.EncryptionGroupByName(defaultEncryptionGroup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eskB, err := group.Scalar().Pick(group.RandomStream()).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, dir, "esk", hex.EncodeToString(eskB)+"\n"),
+		writeFile(t, dir, "passphrase", fixturePassphrase+"\n"),
+		writeFile(t, dir, "wrapping", "wrapping passphrase")
+}
+
+func TestKeyBackupRoundTrip(t *testing.T) {
+	esk, passphrase, wrapping := testFiles(t)
+	dir, wrappedDir, out := t.TempDir(), t.TempDir(), t.TempDir()
+	backup := filepath.Join(out, "key.backup")
+	rewrapped := filepath.Join(out, "rewrapped.backup")
+
+	for _, step := range []struct {
+		name string
+		run  func([]string) error
+		args []string
+	}{
+		{"import fixture", restore, []string{
+			"-dir", dir, "-esk-file", esk, "-passphrase-file", passphrase,
+			"-in", filepath.Join("testdata", "key.backup"),
+		}},
+		{"export", export, []string{
+			"-dir", dir, "-esk-file", esk, "-passphrase-file", passphrase,
+			"-config-digest", fixtureCfgDgst, "-key-id", fixtureKeyID, "-out", backup,
+		}},
+		{"import into wrapped store", restore, []string{
+			"-dir", wrappedDir, "-esk-file", esk, "-passphrase-file", passphrase,
+			"-wrapping-passphrase-file", wrapping, "-wrapping-key-id", "3", "-in", backup,
+		}},
+		{"export from wrapped store", export, []string{
+			"-dir", wrappedDir, "-esk-file", esk, "-passphrase-file", passphrase,
+			"-wrapping-passphrase-file", wrapping, "-wrapping-key-id", "3",
+			"-config-digest", "0x" + fixtureCfgDgst, "-key-id", fixtureKeyID, "-out", rewrapped,
+		}},
+		{"import re-export", restore, []string{
+			"-dir", t.TempDir(), "-esk-file", esk, "-passphrase-file", passphrase,
+			"-in", rewrapped,
+		}},
+	} {
+		if err := step.run(step.args); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+	}
+
+	// The wrapped store's snapshot is unreadable without its passphrase.
+	err := export([]string{
+		"-dir", wrappedDir, "-esk-file", esk, "-passphrase-file", passphrase,
+		"-wrapping-passphrase-file", passphrase, "-wrapping-key-id", "3",
+		"-config-digest", fixtureCfgDgst, "-key-id", fixtureKeyID,
+		"-out", filepath.Join(out, "bad.backup"),
+	})
+	if err == nil {
+		t.Fatal("exported key backup with the wrong wrapping passphrase")
+	}
+}
+
+func TestKeyBackupErrors(t *testing.T) {
+	esk, passphrase, wrapping := testFiles(t)
+	dir := t.TempDir()
+	fixture := filepath.Join("testdata", "key.backup")
+	for _, tc := range []struct {
+		name string
+		run  func([]string) error
+		args []string
+	}{
+		{"wrong passphrase", restore, []string{
+			"-dir", dir, "-esk-file", esk, "-passphrase-file", wrapping, "-in", fixture,
+		}},
+		{"missing -in", restore, []string{
+			"-dir", dir, "-esk-file", esk, "-passphrase-file", passphrase,
+		}},
+		{"missing -dir", restore, []string{
+			"-esk-file", esk, "-passphrase-file", passphrase, "-in", fixture,
+		}},
+		{"missing -out", export, []string{
+			"-dir", dir, "-esk-file", esk, "-passphrase-file", passphrase,
+			"-config-digest", fixtureCfgDgst, "-key-id", fixtureKeyID,
+		}},
+		{"short config digest", export, []string{
+			"-dir", dir, "-esk-file", esk, "-passphrase-file", passphrase,
+			"-config-digest", "01", "-key-id", fixtureKeyID,
+			"-out", filepath.Join(dir, "out"),
+		}},
+		{"no stored key", export, []string{
+			"-dir", dir, "-esk-file", esk, "-passphrase-file", passphrase,
+			"-config-digest", fixtureCfgDgst, "-key-id", fixtureKeyID,
+			"-out", filepath.Join(dir, "out"),
+		}},
+		{"unknown encryption group", restore, []string{
+			"-dir", dir, "-esk-file", esk, "-passphrase-file", passphrase, "-in", fixture,
+			"-encryption-group", "no such group",
+		}},
+	} {
+		if err := tc.run(tc.args); err == nil {
+			t.Fatalf("%s: no error", tc.name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Fatalf("failed export wrote a key backup: %v", err)
+	}
+}
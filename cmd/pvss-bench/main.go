@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/altbn_128"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/multi_scalar"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
+
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+const usage = `usage: pvss-bench [-players N] [-threshold T] [-mults M]
+
+Times AltBN-128 G₁ fixed-base multiplication against variable-base
+multiplication, then times creation, verification and decryption of a PVSS
+share set for N players with threshold T. Finally it times verification of a
+partial VRF signature and recovery of a VRF output from T+1 of them, and
+projects the per-node cost of a DKG and of a VRF output for N players.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	players := flag.Int("players", 31, "number of players receiving shares")
+	threshold := flag.Int("threshold", 10, "threshold of the share set")
+	mults := flag.Int("mults", 1000, "number of multiplications to time")
+	flag.Parse()
+	if err := run(*players, *threshold, *mults); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(numPlayers, threshold, mults int) error {
+	if numPlayers < pvss.MinPlayers || numPlayers > int(player_idx.MaxPlayer) {
+		return errors.Errorf(
+			"-players must be between %d and %d", pvss.MinPlayers, player_idx.MaxPlayer,
+		)
+	}
+	if threshold < 0 || threshold >= numPlayers {
+		return errors.Errorf("-threshold must be nonnegative and less than -players")
+	}
+	if mults < 1 {
+		return errors.Errorf("-mults must be positive")
+	}
+	suite := &altbn_128.PairingSuite{}
+	group := suite.G1().(anon.Suite)
+
+	base := group.Point().Pick(group.RandomStream())
+	scalars := make([]kyber.Scalar, mults)
+	for i := range scalars {
+		scalars[i] = group.Scalar().Pick(group.RandomStream())
+	}
+	start := time.Now()
+	table := multi_scalar.NewFixedBaseTable(base, group.Scalar())
+	tableTime := time.Since(start)
+	start = time.Now()
+	for _, s := range scalars {
+		table.Mul(s)
+	}
+	fixedTime := time.Since(start) / time.Duration(mults)
+	start = time.Now()
+	for _, s := range scalars {
+		group.Point().Mul(s, base)
+	}
+	variableTime := time.Since(start) / time.Duration(mults)
+	fmt.Printf("fixed-base table construction:  %v\n", tableTime)
+	fmt.Printf("fixed-base multiplication:      %v/op\n", fixedTime)
+	fmt.Printf("variable-base multiplication:   %v/op (%.1fx slower)\n",
+		variableTime, float64(variableTime)/float64(fixedTime),
+	)
+
+	sks := make([]kyber.Scalar, numPlayers)
+	pks := make([]kyber.Point, numPlayers)
+	for i := range pks {
+		sks[i] = group.Scalar().Pick(group.RandomStream())
+		pks[i] = group.Point().Mul(sks[i], nil)
+	}
+	players, err := player_idx.PlayerIdxs(player_idx.Int(numPlayers))
+	if err != nil {
+		return err
+	}
+	translation := &point_translation.PairingTranslation{suite}
+	var domainSep types.ConfigDigest
+	start = time.Now()
+	shareSet, err := pvss.NewShareSet(
+		domainSep, player_idx.Int(threshold), players[0], group, translation, pks,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not create share set")
+	}
+	fmt.Printf("share set creation (%d players): %v\n", numPlayers, time.Since(start))
+	start = time.Now()
+	if err := shareSet.Verify(group, domainSep, pks); err != nil {
+		return errors.Wrap(err, "could not verify share set")
+	}
+	verifyTime := time.Since(start)
+	fmt.Printf("share set verification:         %v\n", verifyTime)
+	marshaled, err := shareSet.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "could not marshal share set")
+	}
+	fmt.Printf("marshaled share set size:       %d bytes\n", len(marshaled))
+	keys := key_store.NewInProcessKeyStore(sks[1], sks[1])
+	sk, err := keys.EncryptionKey(group)
+	if err != nil {
+		return errors.Wrap(err, "could not get encryption key")
+	}
+	start = time.Now()
+	if _, err := shareSet.Decrypt(*players[1], sk, group, domainSep); err != nil {
+		return errors.Wrap(err, "could not decrypt share")
+	}
+	fmt.Printf("share decryption:               %v\n", time.Since(start))
+
+	secret := kshare.NewPriPoly(group, threshold+1, nil, group.RandomStream())
+	hashPoint := altbn_128.NewHashProof([32]byte{}).HashPoint
+	partialSigs := make([]*kshare.PubShare, threshold+1)
+	for i := range partialSigs {
+		sigShare := players[i].Eval(secret)
+		partialSig := players[i].PubShare(group.Point().Mul(sigShare, hashPoint))
+		partialSigs[i] = &partialSig
+	}
+	pubShare := suite.G2().Point().Mul(players[0].Eval(secret), nil)
+	start = time.Now()
+	if !suite.Pair(hashPoint, pubShare).Equal(
+		suite.Pair(partialSigs[0].V, suite.G2().Point().Base()),
+	) {
+		return errors.Errorf("partial signature did not verify")
+	}
+	partialVerifyTime := time.Since(start)
+	fmt.Printf("partial signature verification: %v\n", partialVerifyTime)
+	start = time.Now()
+	if _, err := kshare.RecoverCommit(group, partialSigs, threshold+1, numPlayers); err != nil {
+		return errors.Wrap(err, "could not recover signature")
+	}
+	recoverTime := time.Since(start)
+	fmt.Printf("signature recovery (%d shares): %v\n", threshold+1, recoverTime)
+
+	fmt.Printf("projected DKG verification:     %v (%d share sets)\n",
+		verifyTime*time.Duration(threshold+1), threshold+1,
+	)
+	fmt.Printf("projected VRF output:           %v (%d partial signatures)\n",
+		partialVerifyTime*time.Duration(numPlayers)+recoverTime, numPlayers,
+	)
+	return nil
+}
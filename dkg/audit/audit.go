@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/dkg"
+)
+
+type Transcript struct {
+	OffchainConfig hexutil.Bytes   `json:"offchainConfig"`
+	OnchainConfig  hexutil.Bytes   `json:"onchainConfig"`
+	ConfigDigest   common.Hash     `json:"configDigest"`
+	F              int             `json:"f"`
+	PublicKey      hexutil.Bytes   `json:"publicKey"`
+	Hashes         []common.Hash   `json:"hashes"`
+	ShareRecords   []hexutil.Bytes `json:"shareRecords"`
+
+	Previous *Transcript `json:"previous,omitempty"`
+}
+
+func Audit(
+	p *dkg.PluginConfig,
+	cfgDgst types.ConfigDigest,
+	f int,
+	onchainKey dkg.OnchainKeyData,
+	shareRecords [][]byte,
+	previousKey *dkg.KeyData,
+) (*Report, error) {
+	return dkg.Audit(p, cfgDgst, f, onchainKey, shareRecords, previousKey)
+}
+
+func AuditTranscript(t *Transcript) (*Report, error) {
+	var previousKey *dkg.KeyData
+	if t.Previous != nil {
+		previous, err := AuditTranscript(t.Previous)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not audit previous DKG transcript")
+		}
+		if !previous.Passed() {
+			return nil, errors.Errorf(
+				"previous DKG transcript failed audit: %v", previous.Failures,
+			)
+		}
+		previousKey = previous.KeyData()
+	}
+	p, err := dkg.UnmarshalPluginConfig(t.OffchainConfig, t.OnchainConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read DKG config")
+	}
+	hashes := make([][32]byte, len(t.Hashes))
+	for i, h := range t.Hashes {
+		hashes[i] = h
+	}
+	records := make([][]byte, len(t.ShareRecords))
+	for i, r := range t.ShareRecords {
+		records[i] = r
+	}
+	return Audit(
+		p, types.ConfigDigest(t.ConfigDigest), t.F,
+		dkg.OnchainKeyData{t.PublicKey, hashes}, records, previousKey,
+	)
+}
+
+type (
+	Report      = dkg.AuditReport
+	ShareRecord = dkg.AuditedShareRecord
+)
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/smartcontractkit/chainlink-vrf/dkg"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+// notAShareRecord fails to parse as a share record, so an audit rejects it
+// without checking any proofs.
+var notAShareRecord = []byte("not a share record")
+
+// testTranscript returns a transcript of a fresh-key DKG among five players,
+// whose onchain key lists notAShareRecord and a hash with no share record.
+func testTranscript(t *testing.T) *Transcript {
+	g, err := dkg.EncryptionGroupByName("AltBN-128 G₁")
+	if err != nil {
+		t.Fatal(err)
+	}
+	translator, err := dkg.TranslatorByName("translator from AltBN-128 G₁ to AltBN-128 G₂")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingGroup := dkg.Ed25519SigningGroup()
+	var epks, spks []kyber.Point
+	for i := 0; i < 5; i++ {
+		epks = append(epks, g.Point().Pick(g.RandomStream()))
+		spks = append(spks, signingGroup.Point().Pick(signingGroup.RandomStream()))
+	}
+	offchainConfig, err := dkg.OffchainConfig(epks, spks, g, translator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onchainConfig, err := dkg.OnchainConfig(dkg.KeyID{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	translationGroup, err := translator.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := translationGroup.Point().Base().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Transcript{
+		OffchainConfig: offchainConfig,
+		OnchainConfig:  onchainConfig,
+		ConfigDigest:   common.Hash{1},
+		F:              1,
+		PublicKey:      pk,
+		Hashes:         []common.Hash{common.Hash(hash.GetHash(notAShareRecord)), {3}},
+		ShareRecords:   []hexutil.Bytes{notAShareRecord},
+	}
+}
+
+func checkFailure(t *testing.T, r *Report, failure string) {
+	t.Helper()
+	if r.Passed() || r.KeyData() != nil {
+		t.Fatal("audit passed")
+	}
+	if !strings.Contains(strings.Join(r.Failures, "\n"), failure) {
+		t.Fatalf("audit failed with %v, expected %q", r.Failures, failure)
+	}
+}
+
+func TestAuditTranscript(t *testing.T) {
+	r, err := AuditTranscript(testTranscript(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ConfigDigest[0] != 1 || r.KeyID[0] != 2 || r.Mode != "fresh key" || r.Threshold != 1 {
+		t.Fatalf("audit reported config %s, key ID 0x%x, mode %s, threshold %d",
+			r.ConfigDigest, r.KeyID, r.Mode, r.Threshold)
+	}
+	if len(r.ShareRecords) != 1 {
+		t.Fatalf("audited %d share records, expected 1", len(r.ShareRecords))
+	}
+	if s := r.ShareRecords[0]; !s.ListedOnchain || s.SignatureValid || s.Err == "" {
+		t.Fatalf("unparseable share record audited as %+v", s)
+	}
+	checkFailure(t, r, "no share record provided for onchain hash")
+	checkFailure(t, r, "is invalid")
+	checkFailure(t, r, "need at least 2")
+}
+
+func TestAuditTranscriptFailures(t *testing.T) {
+	tr := testTranscript(t)
+	tr.Hashes = append(tr.Hashes, tr.Hashes[0])
+	r, err := AuditTranscript(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFailure(t, r, "listed twice onchain")
+
+	tr = testTranscript(t)
+	tr.PublicKey = nil
+	r, err = AuditTranscript(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFailure(t, r, "no key reported onchain")
+
+	tr = testTranscript(t)
+	tr.PublicKey = []byte{1, 2, 3}
+	r, err = AuditTranscript(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFailure(t, r, "could not read onchain key")
+}
+
+func TestAuditTranscriptErrors(t *testing.T) {
+	tr := testTranscript(t)
+	tr.OffchainConfig = []byte("not a config")
+	if _, err := AuditTranscript(tr); err == nil {
+		t.Fatal("audited transcript with an invalid offchain config")
+	}
+
+	tr = testTranscript(t)
+	tr.F = 2
+	if _, err := AuditTranscript(tr); err == nil {
+		t.Fatal("audited transcript with too large a fault tolerance")
+	}
+
+	tr = testTranscript(t)
+	tr.Previous = testTranscript(t)
+	if _, err := AuditTranscript(tr); err == nil ||
+		!strings.Contains(err.Error(), "previous DKG transcript failed audit") {
+		t.Fatalf("audited transcript whose previous transcript fails: %v", err)
+	}
+
+	tr = testTranscript(t)
+	onchainConfig, err := dkg.RefreshOnchainConfig(dkg.KeyID{2}, [32]byte{9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.OnchainConfig = onchainConfig
+	if _, err := AuditTranscript(tr); err == nil {
+		t.Fatal("audited refresh transcript without the previous transcript")
+	}
+}
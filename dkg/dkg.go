@@ -1,15 +1,21 @@
 package dkg
 
 import (
+	"context"
+	"time"
+
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/anon"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	"github.com/smartcontractkit/chainlink-vrf/internal/signatures/secp256k1"
 	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
 )
 
@@ -33,6 +39,44 @@ func NewReportingPluginFactory(
 	)
 }
 
+func NewReportingPluginFactoryWithKeyStore(
+	keys KeyStore,
+	keyID KeyID,
+	contract OnchainContract,
+	logger commontypes.Logger,
+	keyConsumer KeyConsumer,
+	db dkg_types.DKGSharePersistence,
+) types.ReportingPluginFactory {
+	return dkg.NewReportingPluginFactoryWithKeyStore(
+		keys,
+		keyID,
+		contract,
+		logger,
+		keyConsumer,
+		db,
+	)
+}
+
+func NewInProcessKeyStore(esk EncryptionSecretKey, ssk SigningSecretKey) KeyStore {
+	return key_store.NewInProcessKeyStore(esk, ssk)
+}
+
+func Secp256k1EncryptionGroup() anon.Suite {
+	return secp256k1.NewBlakeKeccackSecp256k1()
+}
+
+func Ed25519SigningGroup() anon.Suite {
+	return dkg.SigningGroup
+}
+
+func Secp256k1SigningGroup() anon.Suite {
+	return secp256k1.NewBlakeKeccackSecp256k1()
+}
+
+func Secp256k1Translator() point_translation.PubKeyTranslation {
+	return point_translation.NewSameGroupTranslation(secp256k1.NewBlakeKeccackSecp256k1())
+}
+
 func NewOnchainContract(
 	dkg DKG, keyGroup kyber.Group,
 ) contract.OnchainContract {
@@ -48,10 +92,47 @@ func OffchainConfig(
 	return dkg.OffchainConfig(epks, spks, encryptionGroup, translator)
 }
 
+func OffchainConfigWithSigningGroup(
+	epks EncryptionPublicKeys,
+	spks SigningPublicKeys,
+	encryptionGroup anon.Suite,
+	translator point_translation.PubKeyTranslation,
+	signingGroup anon.Suite,
+) ([]byte, error) {
+	return dkg.OffchainConfigWithSigningGroup(
+		epks, spks, encryptionGroup, translator, signingGroup,
+	)
+}
+
+func ReshareOffchainConfig(
+	epks EncryptionPublicKeys,
+	spks SigningPublicKeys,
+	encryptionGroup anon.Suite,
+	translator point_translation.PubKeyTranslation,
+	previousPublicShares []kyber.Point,
+	previousPlayers map[commontypes.OracleID]commontypes.OracleID,
+) ([]byte, error) {
+	return dkg.ReshareOffchainConfig(
+		epks, spks, encryptionGroup, translator, previousPublicShares, previousPlayers,
+	)
+}
+
 func OnchainConfig(keyID KeyID) ([]byte, error) {
 	return dkg.OnchainConfig(keyID)
 }
 
+func RefreshOnchainConfig(
+	keyID KeyID, previousDigest types.ConfigDigest,
+) ([]byte, error) {
+	return dkg.RefreshOnchainConfig(keyID, previousDigest)
+}
+
+func ReshareOnchainConfig(
+	keyID KeyID, previousDigest types.ConfigDigest, previousThreshold int,
+) ([]byte, error) {
+	return dkg.ReshareOnchainConfig(keyID, previousDigest, previousThreshold)
+}
+
 func NewPluginConfig(
 	epks EncryptionPublicKeys,
 	spks SigningPublicKeys,
@@ -69,19 +150,228 @@ func SanityCheckConfigs(
 	return dkg.SanityCheckConfigs(p, rpf)
 }
 
+func SanityCheckConfigsWithF(
+	p *PluginConfig,
+	rpf types.ReportingPluginFactory,
+	f int,
+) error {
+	return dkg.SanityCheckConfigsWithF(p, rpf, f)
+}
+
+func WithThreshold(onchainConfig []byte, threshold int) ([]byte, error) {
+	return dkg.WithThreshold(onchainConfig, threshold)
+}
+
+func WithBiasResistance(onchainConfig []byte) ([]byte, error) {
+	return dkg.WithBiasResistance(onchainConfig)
+}
+
+// WithWeights gives the player at position i weights[i] key shares. A weighted
+// committee only supports plain fresh keys, without peer share recovery or key
+// backups; see PluginConfig.
+func WithWeights(offchainConfig []byte, weights []int) ([]byte, error) {
+	return dkg.WithWeights(offchainConfig, weights)
+}
+
+func FaultyDealers(rpf types.ReportingPluginFactory) ([]FaultyDealer, error) {
+	return dkg.FaultyDealers(rpf)
+}
+
+func PluginStatus(ctx context.Context, rpf types.ReportingPluginFactory) (*Status, error) {
+	return dkg.PluginStatus(ctx, rpf)
+}
+
+func SetTimeout(
+	rpf types.ReportingPluginFactory, timeout time.Duration, maxRetries int,
+) error {
+	return dkg.SetTimeout(rpf, timeout, maxRetries)
+}
+
+func Abort(rpf types.ReportingPluginFactory, reason string) error {
+	return dkg.Abort(rpf, reason)
+}
+
+func RestoreCompletedKeys(ctx context.Context, rpf types.ReportingPluginFactory) error {
+	return dkg.RestoreCompletedKeys(ctx, rpf)
+}
+
+func Attempts(rpf types.ReportingPluginFactory) ([]Attempt, error) {
+	return dkg.Attempts(rpf)
+}
+
+func SubscribeKeyEvents(
+	rpf types.ReportingPluginFactory,
+) (events <-chan KeyEvent, unsubscribe func(), err error) {
+	return dkg.SubscribeKeyEvents(rpf)
+}
+
+func AddKeyConsumer(rpf types.ReportingPluginFactory, k KeyConsumer) error {
+	return dkg.AddKeyConsumer(rpf, k)
+}
+
+func NewKeyBroadcaster(consumers ...KeyConsumer) *KeyBroadcaster {
+	return dkg.NewKeyBroadcaster(consumers...)
+}
+
+func ExportKeyBackup(
+	ctx context.Context, rpf types.ReportingPluginFactory, passphrase []byte,
+) ([]byte, error) {
+	return dkg.ExportKeyBackup(ctx, rpf, passphrase)
+}
+
+func ImportKeyBackup(
+	ctx context.Context, rpf types.ReportingPluginFactory, backup, passphrase []byte,
+) (*KeyData, error) {
+	return dkg.ImportKeyBackup(ctx, rpf, backup, passphrase)
+}
+
+func ExportStoredKeyBackup(
+	db dkg_types.DKGSharePersistence,
+	esk EncryptionSecretKey,
+	encryptionGroup anon.Suite,
+	translator point_translation.PubKeyTranslation,
+	cfgDgst types.ConfigDigest,
+	keyID KeyID,
+	passphrase []byte,
+) ([]byte, error) {
+	return dkg.ExportStoredKeyBackup(
+		db, esk, encryptionGroup, translator, cfgDgst, keyID, passphrase,
+	)
+}
+
+func RestoreStoredKeyBackup(
+	ctx context.Context,
+	db dkg_types.DKGSharePersistence,
+	esk EncryptionSecretKey,
+	backup, passphrase []byte,
+) (types.ConfigDigest, KeyID, error) {
+	return dkg.RestoreStoredKeyBackup(ctx, db, esk, backup, passphrase)
+}
+
+func MigrateShareRecords(
+	ctx context.Context,
+	db dkg_types.DKGSharePersistence,
+	signingGroup anon.Suite,
+	cfgDgst types.ConfigDigest,
+	keyID KeyID,
+) (migrated int, err error) {
+	return dkg.MigrateShareRecords(ctx, db, signingGroup, cfgDgst, keyID)
+}
+
+func EncryptionGroupByName(name string) (anon.Suite, error) {
+	return dkg.EncryptionGroupByName(name)
+}
+
+func SigningGroupByName(name string) (anon.Suite, error) {
+	return dkg.SigningGroupByName(name)
+}
+
+func TranslatorByName(name string) (point_translation.PubKeyTranslation, error) {
+	return dkg.TranslatorByName(name)
+}
+
+func NewEncryptedSharePersistence(
+	db dkg_types.DKGSharePersistence, keys KeySource,
+) EncryptedSharePersistence {
+	return persistence.NewEncryptedSharePersistence(db, keys)
+}
+
+func NewMemorySharePersistence() *MemorySharePersistence {
+	return persistence.NewMemorySharePersistence()
+}
+
+func NewFileSharePersistence(dir string) (*FileSharePersistence, error) {
+	return persistence.NewFileSharePersistence(dir)
+}
+
+func NewStaticKeySource(current uint32, keys map[uint32][]byte) (KeySource, error) {
+	return persistence.NewStaticKeySource(current, keys)
+}
+
+func NewPassphraseKeySource(
+	current uint32, passphrases map[uint32][]byte,
+) (KeySource, error) {
+	return persistence.NewPassphraseKeySource(current, passphrases)
+}
+
+func Audit(
+	p *PluginConfig,
+	cfgDgst types.ConfigDigest,
+	f int,
+	onchainKey OnchainKeyData,
+	shareRecords [][]byte,
+	previousKey *KeyData,
+) (*AuditReport, error) {
+	return dkg.Audit(p, cfgDgst, f, onchainKey, shareRecords, previousKey)
+}
+
 func UnmarshalPluginConfig(
 	offchainBinaryConfig, onchainBinaryConfig []byte) (*PluginConfig, error) {
 	return dkg.UnmarshalPluginConfig(offchainBinaryConfig, onchainBinaryConfig)
 }
 
+const (
+	InvalidShareSet    = dkg.InvalidShareSet
+	UndecryptableShare = dkg.UndecryptableShare
+)
+
+const (
+	Idle            = dkg.Idle
+	AwaitingQuorum  = dkg.AwaitingQuorum
+	RecoveringShare = dkg.RecoveringShare
+	Completed       = dkg.Completed
+	Aborted         = dkg.Aborted
+)
+
+const (
+	AttemptRunning   = dkg.AttemptRunning
+	AttemptCompleted = dkg.AttemptCompleted
+	AttemptTimedOut  = dkg.AttemptTimedOut
+	AttemptAborted   = dkg.AttemptAborted
+	AttemptFailed    = dkg.AttemptFailed
+)
+
+const KeySnapshotKey = key_store.KeySnapshotKey
+
+const (
+	NewKeyEvent         = dkg.NewKeyEvent
+	KeyInvalidatedEvent = dkg.KeyInvalidatedEvent
+)
+
+// PluginConfig is the combined offchain and onchain DKG config. A config with
+// player weights rejects key refresh, key reshare, bias-resistant dealing, peer
+// share recovery and key backups. Weighted key snapshots and completed keys
+// are still restored after a restart.
+type PluginConfig = dkg.PluginConfig
+
 type (
 	EncryptionPublicKeys = contract.EncryptionPublicKeys
 	EncryptionSecretKey  = contract.EncryptionSecretKey
 	SigningPublicKeys    = contract.SigningPublicKeys
 	SigningSecretKey     = contract.SigningSecretKey
-	PluginConfig         = dkg.PluginConfig
 	KeyConsumer          = dkg.KeyConsumer
 	KeyData              = dkg.KeyData
+	FaultyDealer         = dkg.FaultyDealer
+	FaultKind            = dkg.FaultKind
+	Status               = dkg.Status
+	Phase                = dkg.Phase
+	Attempt              = dkg.Attempt
+	AttemptOutcome       = dkg.AttemptOutcome
+	AuditReport          = dkg.AuditReport
+	AuditedShareRecord   = dkg.AuditedShareRecord
+	KeyBroadcaster       = dkg.KeyBroadcaster
+	KeyEvent             = dkg.KeyEvent
+	KeyEventType         = dkg.KeyEventType
+
+	KeyStore      = key_store.KeyStore
+	EncryptionKey = key_store.EncryptionKey
+	SigningKey    = key_store.SigningKey
+	KeyPurpose    = key_store.KeyPurpose
+
+	EncryptedSharePersistence = persistence.EncryptedSharePersistence
+	KeySource                 = persistence.KeySource
+	MemorySharePersistence    = persistence.MemorySharePersistence
+	FileSharePersistence      = persistence.FileSharePersistence
 
 	KeyID           = contract.KeyID
 	DKG             = contract.DKG
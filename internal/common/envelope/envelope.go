@@ -0,0 +1,230 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+// Magic prefixes every enveloped encoding. No unversioned share set, share
+// record or ciphertext can start with it: they start respectively with a
+// nonzero dealer index, a big-endian length below 10,000,000, and a nonzero
+// suite-name length.
+var Magic = [2]byte{0x00, 0xff}
+
+type Kind uint8
+
+const (
+	CipherText  Kind = 'C'
+	ShareSet    Kind = 'S'
+	ShareRecord Kind = 'R'
+
+	AggregatedCipherText Kind = 'A'
+	Transcript           Kind = 'T'
+
+	HidingCipherText         Kind = 'c'
+	HidingShareSet           Kind = 's'
+	HidingShareRecord        Kind = 'r'
+	ReconstructedShareRecord Kind = 'U'
+	PhaseReport              Kind = 'P'
+	DecryptionEvidence       Kind = 'D'
+)
+
+func (k Kind) String() string {
+	switch k {
+	case CipherText:
+		return "ciphertext"
+	case ShareSet:
+		return "share set"
+	case ShareRecord:
+		return "share record"
+	case AggregatedCipherText:
+		return "aggregated ciphertext"
+	case Transcript:
+		return "transcript"
+	case HidingCipherText:
+		return "hiding ciphertext"
+	case HidingShareSet:
+		return "hiding share set"
+	case HidingShareRecord:
+		return "hiding share record"
+	case ReconstructedShareRecord:
+		return "reconstructed share record"
+	case PhaseReport:
+		return "DKG phase report"
+	case DecryptionEvidence:
+		return "decryption evidence"
+	default:
+		return "unknown encoding"
+	}
+}
+
+// Version2 differs from Version1 only in ciphertexts, whose bit pairs carry
+// their proofs in commitment form so that they can be batch-verified.
+const (
+	LegacyVersion uint8 = 0
+	Version1      uint8 = 1
+	Version2      uint8 = 2
+
+	CurrentVersion = Version2
+)
+
+type Envelope struct {
+	Kind    Kind
+	Version uint8
+	Groups  []string
+	Fields  [][]byte
+}
+
+const headerLen = len(Magic) + 1 + 1
+
+func New(kind Kind, groups []kyber.Group, fields ...[]byte) *Envelope {
+	return NewVersion(kind, CurrentVersion, groups, fields...)
+}
+
+// NewVersion is New for data which can only be encoded in an older version,
+// such as a ciphertext decoded from that version.
+func NewVersion(
+	kind Kind, version uint8, groups []kyber.Group, fields ...[]byte,
+) *Envelope {
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		names[i] = g.String()
+	}
+	return &Envelope{kind, version, names, fields}
+}
+
+func IsEnveloped(data []byte) bool {
+	return len(data) >= len(Magic) && bytes.Equal(data[:len(Magic)], Magic[:])
+}
+
+func (e *Envelope) Marshal() ([]byte, error) {
+	if e.Version == LegacyVersion || e.Version > CurrentVersion {
+		return nil, errors.Errorf("can't envelope %s version %d", e.Kind, e.Version)
+	}
+	if len(e.Groups) > math.MaxUint8 {
+		return nil, errors.Errorf("too many groups to envelope %s", e.Kind)
+	}
+	if len(e.Fields) > math.MaxUint16 {
+		return nil, errors.Errorf("too many fields to envelope %s", e.Kind)
+	}
+	rv := bytes.NewBuffer(append(Magic[:], uint8(e.Kind), e.Version))
+	rv.WriteByte(uint8(len(e.Groups)))
+	for _, g := range e.Groups {
+		if len(g) > math.MaxUint8 {
+			return nil, errors.Errorf("group name %q too long to envelope", g)
+		}
+		rv.WriteByte(uint8(len(g)))
+		rv.WriteString(g)
+	}
+	numFields := make([]byte, 2)
+	binary.BigEndian.PutUint16(numFields, uint16(len(e.Fields)))
+	rv.Write(numFields)
+	for _, f := range e.Fields {
+		if uint64(len(f)) > math.MaxUint32 {
+			return nil, errors.Errorf("field too long to envelope %s", e.Kind)
+		}
+		fieldLen := make([]byte, 4)
+		binary.BigEndian.PutUint32(fieldLen, uint32(len(f)))
+		rv.Write(fieldLen)
+		rv.Write(f)
+	}
+	return rv.Bytes(), nil
+}
+
+func Unmarshal(data []byte, kind Kind) (e *Envelope, rem []byte, err error) {
+	if !IsEnveloped(data) {
+		return nil, nil, errors.Errorf("%s is not enveloped", kind)
+	}
+	if len(data) < headerLen {
+		return nil, nil, errors.Errorf("enveloped %s too short for header", kind)
+	}
+	e = &Envelope{Kind: Kind(data[len(Magic)]), Version: data[len(Magic)+1]}
+	if e.Kind != kind {
+		return nil, nil, errors.Errorf("expected enveloped %s, got %s", kind, e.Kind)
+	}
+	if e.Version == LegacyVersion || e.Version > CurrentVersion {
+		return nil, nil, errors.Errorf(
+			"unsupported %s version %d (newest supported version is %d)",
+			kind, e.Version, CurrentVersion,
+		)
+	}
+	data = data[headerLen:]
+	numGroups, data, err := readUint(data, 1)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not read number of groups in %s", kind)
+	}
+	e.Groups = make([]string, numGroups)
+	for i := range e.Groups {
+		var g []byte
+		g, data, err = readLenPrefixed(data, 1)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "could not read group name in %s", kind)
+		}
+		e.Groups[i] = string(g)
+	}
+	numFields, data, err := readUint(data, 2)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not read number of fields in %s", kind)
+	}
+	e.Fields = make([][]byte, numFields)
+	for i := range e.Fields {
+		e.Fields[i], data, err = readLenPrefixed(data, 4)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "could not read field %d of %s", i, kind)
+		}
+	}
+	return e, data, nil
+}
+
+func (e *Envelope) CheckGroups(groups ...kyber.Group) error {
+	if len(e.Groups) != len(groups) {
+		return errors.Errorf(
+			"%s names %d groups, need %d", e.Kind, len(e.Groups), len(groups),
+		)
+	}
+	for i, g := range groups {
+		if e.Groups[i] != g.String() {
+			return errors.Errorf(
+				`wrong group for unmarshalling %s: need "%s", got "%s"`,
+				e.Kind, g, e.Groups[i],
+			)
+		}
+	}
+	return nil
+}
+
+func (e *Envelope) CheckNumFields(atLeast int) error {
+	if len(e.Fields) < atLeast {
+		return errors.Errorf(
+			"%s version %d has %d fields, need at least %d",
+			e.Kind, e.Version, len(e.Fields), atLeast,
+		)
+	}
+	return nil
+}
+
+func readUint(data []byte, width int) (n uint32, rem []byte, err error) {
+	if len(data) < width {
+		return 0, nil, errors.Errorf("data too short for %d-byte integer", width)
+	}
+	for _, b := range data[:width] {
+		n = n<<8 | uint32(b)
+	}
+	return n, data[width:], nil
+}
+
+func readLenPrefixed(data []byte, prefixLen int) (read, rem []byte, err error) {
+	length, data, err := readUint(data, prefixLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	if uint64(len(data)) < uint64(length) {
+		return nil, nil, errors.Errorf("length %d longer than remaining data", length)
+	}
+	return data[:length], data[length:], nil
+}
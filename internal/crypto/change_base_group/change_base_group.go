@@ -60,8 +60,20 @@ func (g *changeBaseGroup) Lift(p kyber.Point) (*changeBasePoint, error) {
 	return &changeBasePoint{p.Clone(), g}, nil
 }
 
+func (g *changeBaseGroup) Unlift(p kyber.Point) (kyber.Point, error) {
+	cbp, ok := p.(*changeBasePoint)
+	if !ok || !g.equal(cbp.group) {
+		return nil, errors.Errorf("attempt to unlift point which doesn't belong to group")
+	}
+	return cbp.point.Clone(), nil
+}
+
+func (g *changeBaseGroup) PointDescription() string {
+	return fmt.Sprintf("〈%s〉⊂ (%s)", g.base, g.Suite)
+}
+
 func (p *changeBasePoint) description() string {
-	return fmt.Sprintf("〈%s〉⊂ (%s)", p.group.base, p.group.Suite)
+	return p.group.PointDescription()
 }
 
 func (p *changeBasePoint) MarshalBinary() ([]byte, error) {
@@ -7,6 +7,7 @@ import (
 	"go.dedis.ch/kyber/v3/share"
 	"go.dedis.ch/kyber/v3/sign/anon"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 )
 
@@ -32,13 +33,30 @@ func (c *CipherText) Verify(
 }
 
 func (c *CipherText) Decrypt(
-	sk kyber.Scalar,
+	sk key_store.EncryptionKey,
 	group anon.Suite, domainSep []byte, sharePublicCommitment kyber.Point,
 	receiver player_idx.PlayerIdx,
 ) (kyber.Scalar, error) {
 	return c.decrypt(sk, group, domainSep, sharePublicCommitment)
 }
 
+// ProveDecryption returns the shared secrets which decrypt c, with a proof that
+// sk computed them, so that others can check what c decrypts to.
+func (c *CipherText) ProveDecryption(
+	sk key_store.EncryptionKey, domainSep []byte,
+) (blindingTerms []kyber.Point, proof []byte, err error) {
+	return c.proveDecryption(sk, domainSep)
+}
+
+// DecryptWithProof decrypts c with shared secrets from ProveDecryption. It
+// returns an error if their proof fails, and otherwise a fault if they don't
+// decrypt c.
+func (c *CipherText) DecryptWithProof(
+	group anon.Suite, domainSep []byte, blindingTerms []kyber.Point, proof []byte,
+) (plaintextShare kyber.Scalar, fault, err error) {
+	return c.decryptWithProof(group, domainSep, blindingTerms, proof)
+}
+
 func (c *CipherText) Marshal() ([]byte, error) {
 	return c.marshal()
 }
@@ -54,3 +72,141 @@ func Unmarshal(suite anon.Suite, byteStream io.Reader) (*CipherText, error) {
 func (c *CipherText) Equal(c2 *CipherText) bool {
 	return c.equal(c2.cipherText)
 }
+
+func VerifyGroupMarshalsBigEndian(group kyber.Group) error {
+	return verifyGroupMarshalsBigEndian(group)
+}
+
+type BatchVerifier struct {
+	*batchVerifier
+}
+
+func NewBatchVerifier(group anon.Suite) *BatchVerifier {
+	return &BatchVerifier{newBatchVerifier(group)}
+}
+
+func (b *BatchVerifier) Add(
+	c *CipherText, domainSep []byte, pk, sharePublicCommitment kyber.Point,
+) error {
+	return b.add(c.cipherText, domainSep, pk, sharePublicCommitment)
+}
+
+func (b *BatchVerifier) Verify() error {
+	return b.verify()
+}
+
+type AggregatedCipherText struct {
+	*aggregatedCipherText
+}
+
+func Aggregate(cts ...*CipherText) (*AggregatedCipherText, error) {
+	inner := make([]*cipherText, len(cts))
+	for i, c := range cts {
+		inner[i] = c.cipherText
+	}
+	a, err := aggregate(inner)
+	if err != nil {
+		return nil, err
+	}
+	return &AggregatedCipherText{a}, nil
+}
+
+func (a *AggregatedCipherText) Decrypt(
+	sk key_store.EncryptionKey, group anon.Suite, sharePublicCommitment kyber.Point,
+) (kyber.Scalar, error) {
+	return a.decrypt(sk, group, sharePublicCommitment)
+}
+
+func (a *AggregatedCipherText) Receiver() player_idx.PlayerIdx {
+	return *a.receiver
+}
+
+func (a *AggregatedCipherText) EncryptionKey() kyber.Point {
+	return a.encryptionKey
+}
+
+func (a *AggregatedCipherText) NumSummands() int {
+	return a.numSummands
+}
+
+func (a *AggregatedCipherText) Marshal() ([]byte, error) {
+	return a.marshal()
+}
+
+// UnmarshalAggregated reads an aggregated ciphertext, which must sum exactly
+// numSummands ciphertexts.
+func UnmarshalAggregated(
+	suite anon.Suite, data []byte, numSummands int,
+) (*AggregatedCipherText, error) {
+	a, err := unmarshalAggregated(suite, data, numSummands)
+	if err != nil {
+		return nil, err
+	}
+	return &AggregatedCipherText{a}, nil
+}
+
+func (a *AggregatedCipherText) Equal(a2 *AggregatedCipherText) bool {
+	return a.equal(a2.aggregatedCipherText)
+}
+
+type HidingCipherText struct {
+	*hidingCipherText
+}
+
+func EncryptHiding(
+	domainSep []byte, group anon.Suite, secretPoly, blindingPoly *share.PriPoly,
+	receiver *player_idx.PlayerIdx, pk, pedersenBase kyber.Point,
+) (*HidingCipherText, error) {
+	c, err := newHidingCipherText(
+		domainSep, group, secretPoly, blindingPoly, receiver, pk, pedersenBase,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &HidingCipherText{c}, nil
+}
+
+func PedersenCommitment(
+	group kyber.Group, pedersenBase kyber.Point, secret, blinding kyber.Scalar,
+) kyber.Point {
+	return pedersenCommitment(group, pedersenBase, secret, blinding)
+}
+
+func (c *HidingCipherText) Verify(
+	group anon.Suite, domainSep []byte, pk, pedersenBase, shareHidingCommitment kyber.Point,
+) error {
+	return c.verify(group, domainSep, pk, pedersenBase, shareHidingCommitment)
+}
+
+func (c *HidingCipherText) SecretCipherText() *CipherText {
+	return &CipherText{c.secret}
+}
+
+func (c *HidingCipherText) Open(
+	sk key_store.EncryptionKey, group anon.Suite, pedersenBase,
+	shareHidingCommitment kyber.Point,
+) (secret, blinding kyber.Scalar, err error) {
+	return c.open(sk, group, pedersenBase, shareHidingCommitment)
+}
+
+func (c *HidingCipherText) Marshal() ([]byte, error) {
+	return c.marshal()
+}
+
+func UnmarshalHiding(suite anon.Suite, data []byte) (*HidingCipherText, error) {
+	c, err := unmarshalHiding(suite, data)
+	if err != nil {
+		return nil, err
+	}
+	return &HidingCipherText{c}, nil
+}
+
+func (c *HidingCipherText) Equal(c2 *HidingCipherText) bool {
+	return c.equal(c2.hidingCipherText)
+}
+
+func (b *BatchVerifier) AddHiding(
+	c *HidingCipherText, domainSep []byte, pk, pedersenBase, shareHidingCommitment kyber.Point,
+) error {
+	return c.addTo(b.batchVerifier, domainSep, pk, pedersenBase, shareHidingCommitment)
+}
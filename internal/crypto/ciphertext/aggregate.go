@@ -0,0 +1,249 @@
+package ciphertext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+type aggregatedCipherText struct {
+	blindingCommitments, cipherTextTerms []kyber.Point
+
+	receiver      *player_idx.PlayerIdx
+	encryptionKey kyber.Point
+	suite         anon.Suite
+
+	numSummands int
+}
+
+func aggregate(cts []*cipherText) (*aggregatedCipherText, error) {
+	if len(cts) == 0 {
+		return nil, errors.Errorf("can't aggregate zero ciphertexts")
+	}
+	if len(cts) > math.MaxUint16 {
+		return nil, errors.Errorf("too many ciphertexts to aggregate")
+	}
+	first := cts[0]
+	rv := &aggregatedCipherText{
+		make([]kyber.Point, len(first.cipherText)),
+		make([]kyber.Point, len(first.cipherText)),
+		first.receiver, first.encryptionKey, first.suite, len(cts),
+	}
+	for i, bitPair := range first.cipherText {
+		rv.blindingCommitments[i] = bitPair.blindingCommitment.Clone()
+		rv.cipherTextTerms[i] = bitPair.cipherTextTerm.Clone()
+	}
+	for ctIdx, c := range cts[1:] {
+		if !c.receiver.Equal(first.receiver) || !c.encryptionKey.Equal(first.encryptionKey) {
+			return nil, errors.Errorf(
+				"ciphertext %d is for a different receiver than ciphertext 0", ctIdx+1,
+			)
+		}
+		if c.suite.String() != first.suite.String() {
+			return nil, errors.Errorf(
+				"ciphertext %d is from a different group than ciphertext 0", ctIdx+1,
+			)
+		}
+		if len(c.cipherText) != len(first.cipherText) {
+			return nil, errors.Errorf(
+				"ciphertext %d has %d bit pairs, but ciphertext 0 has %d",
+				ctIdx+1, len(c.cipherText), len(first.cipherText),
+			)
+		}
+		for i, bitPair := range c.cipherText {
+			rv.blindingCommitments[i].Add(rv.blindingCommitments[i], bitPair.blindingCommitment)
+			rv.cipherTextTerms[i].Add(rv.cipherTextTerms[i], bitPair.cipherTextTerm)
+		}
+	}
+	return rv, nil
+}
+
+func (a *aggregatedCipherText) decrypt(
+	sk key_store.EncryptionKey, group anon.Suite, sharePublicCommitment kyber.Point,
+) (kyber.Scalar, error) {
+	if len(a.cipherTextTerms) > plaintextMaxSizeBytes*4 {
+		return nil, errors.Errorf("ciphertext too large (max %d pairs)",
+			plaintextMaxSizeBytes*4,
+		)
+	}
+	if !sk.PublicKey().Equal(a.encryptionKey) {
+		return nil, errors.Errorf("aggregated ciphertext is not encrypted to given key")
+	}
+	blindingTerms, err := sk.SharedSecrets(a.blindingCommitments)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unblind aggregated share ciphertext")
+	}
+	if len(blindingTerms) != len(a.cipherTextTerms) {
+		return nil, errors.Errorf(
+			"got %d blinding terms for %d bit pairs",
+			len(blindingTerms), len(a.cipherTextTerms),
+		)
+	}
+	plainTexts, err := a.plainTexts()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintextShare := group.Scalar().Zero()
+	four := group.Scalar().SetInt64(4)
+	fourPower := group.Scalar().One()
+	for i, blindingTerm := range blindingTerms {
+		plainText := group.Point().Sub(a.cipherTextTerms[i], blindingTerm)
+		plainTextB, err := plainText.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal aggregated bit pair plaintext")
+		}
+		numericPair, ok := plainTexts[string(plainTextB)]
+		if !ok {
+			return nil, errors.Errorf(
+				"aggregated bit pair %d decrypts outside range [0, %d]", i, 3*a.numSummands,
+			)
+		}
+		shiftedBitPair := group.Scalar().Mul(
+			group.Scalar().SetInt64(int64(numericPair)), fourPower,
+		)
+		plaintextShare = group.Scalar().Add(plaintextShare, shiftedBitPair)
+		fourPower = group.Scalar().Mul(fourPower, four)
+	}
+	if !group.Point().Mul(plaintextShare, nil).Equal(sharePublicCommitment) {
+		return nil, errors.Errorf(
+			"aggregated share does not match its public commitment",
+		)
+	}
+	return plaintextShare, nil
+}
+
+func (a *aggregatedCipherText) plainTexts() (map[string]int, error) {
+	rv := make(map[string]int, 3*a.numSummands+1)
+	generator := a.suite.Point().Base()
+	pt := a.suite.Point().Null()
+	for m := 0; m <= 3*a.numSummands; m++ {
+		ptB, err := pt.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal possible plaintext")
+		}
+		rv[string(ptB)] = m
+		pt = a.suite.Point().Add(pt, generator)
+	}
+	return rv, nil
+}
+
+func (a *aggregatedCipherText) marshal() ([]byte, error) {
+	encryptionKey, err := a.encryptionKey.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal "+ENCRYPTION_KEY)
+	}
+	if len(a.cipherTextTerms) > math.MaxUint16 {
+		return nil, errors.Errorf("too many pairs to marshal")
+	}
+	numSummands := make([]byte, 2)
+	binary.BigEndian.PutUint16(numSummands, uint16(a.numSummands))
+	pairs := new(bytes.Buffer)
+	for i, c := range a.cipherTextTerms {
+		for _, p := range []kyber.Point{a.blindingCommitments[i], c} {
+			if _, err := p.MarshalTo(pairs); err != nil {
+				return nil, errors.Wrap(err, "could not marshal aggregated bit pair")
+			}
+		}
+	}
+	return envelope.New(
+		envelope.AggregatedCipherText, []kyber.Group{a.suite},
+		a.receiver.Marshal(), encryptionKey, numSummands, pairs.Bytes(),
+	).Marshal()
+}
+
+func unmarshalAggregated(
+	suite anon.Suite, data []byte, numSummands int,
+) (*aggregatedCipherText, error) {
+	e, rem, err := envelope.Unmarshal(data, envelope.AggregatedCipherText)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal aggregated ciphertext")
+	}
+	if len(rem) > 0 {
+		return nil, errors.Errorf(
+			"overage of %d bytes in marshalled aggregated ciphertext", len(rem),
+		)
+	}
+	if err := e.CheckGroups(suite); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal aggregated ciphertext")
+	}
+	if err := e.CheckNumFields(4); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal aggregated ciphertext")
+	}
+	a := &aggregatedCipherText{suite: suite}
+
+	var idxRem []byte
+	a.receiver, idxRem, err = player_idx.Unmarshal(e.Fields[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal aggregated ciphertext's "+RECEIVER_INDEX)
+	}
+	if len(idxRem) > 0 {
+		return nil, errors.Errorf("overage in aggregated ciphertext's " + RECEIVER_INDEX)
+	}
+
+	a.encryptionKey = suite.Point()
+	if err := a.encryptionKey.UnmarshalBinary(e.Fields[1]); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal "+ENCRYPTION_KEY)
+	}
+
+	if len(e.Fields[2]) != 2 {
+		return nil, errors.Errorf("could not unmarshal number of aggregated ciphertexts")
+	}
+	a.numSummands = int(binary.BigEndian.Uint16(e.Fields[2]))
+	if a.numSummands == 0 {
+		return nil, errors.Errorf("aggregated ciphertext has no summands")
+	}
+	// Decryption tabulates every possible bit-pair sum, so the count must be
+	// checked before anything tries to decrypt.
+	if a.numSummands != numSummands {
+		return nil, errors.Errorf(
+			"aggregated ciphertext has %d summands, expected %d", a.numSummands, numSummands,
+		)
+	}
+
+	pairLen := 2 * suite.PointLen()
+	pairs := e.Fields[3]
+	if len(pairs)%pairLen != 0 || len(pairs)/pairLen > plaintextMaxSizeBytes*4 {
+		return nil, errors.Errorf("could not unmarshal aggregated bit pairs: bad length")
+	}
+	numPairs := len(pairs) / pairLen
+	a.blindingCommitments = make([]kyber.Point, numPairs)
+	a.cipherTextTerms = make([]kyber.Point, numPairs)
+	pointLen := suite.PointLen()
+	for i := 0; i < numPairs; i++ {
+		pair := pairs[i*pairLen : (i+1)*pairLen]
+		a.blindingCommitments[i] = suite.Point()
+		if err := a.blindingCommitments[i].UnmarshalBinary(pair[:pointLen]); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal aggregated blinding commitment")
+		}
+		a.cipherTextTerms[i] = suite.Point()
+		if err := a.cipherTextTerms[i].UnmarshalBinary(pair[pointLen:]); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal aggregated ciphertext point")
+		}
+	}
+	return a, nil
+}
+
+func (a *aggregatedCipherText) equal(a2 *aggregatedCipherText) bool {
+	if !a.receiver.Equal(a2.receiver) || !a.encryptionKey.Equal(a2.encryptionKey) ||
+		a.suite.String() != a2.suite.String() || a.numSummands != a2.numSummands ||
+		len(a.cipherTextTerms) != len(a2.cipherTextTerms) {
+		return false
+	}
+	for i, c := range a.cipherTextTerms {
+		if !c.Equal(a2.cipherTextTerms[i]) ||
+			!a.blindingCommitments[i].Equal(a2.blindingCommitments[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package ciphertext
+
+import (
+	"testing"
+
+	"go.dedis.ch/kyber/v3/share"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+)
+
+func TestUnmarshalAggregatedChecksSummands(t *testing.T) {
+	pk := testGroup.Point().Pick(testGroup.RandomStream())
+	players, err := player_idx.PlayerIdxs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cts := make([]*cipherText, 2)
+	for i := range cts {
+		f := share.NewPriPoly(testGroup, 2, nil, testGroup.RandomStream())
+		cts[i], _, err = newCipherText([]byte("aggregate test"), testGroup, f, players[0], pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := aggregate(cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := a.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := unmarshalAggregated(testGroup, m, len(cts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.equal(a) {
+		t.Fatal("aggregated ciphertext differs after round trip")
+	}
+	for _, numSummands := range []int{1, 3} {
+		if _, err := unmarshalAggregated(testGroup, m, numSummands); err == nil {
+			t.Fatalf("aggregate of %d ciphertexts read as aggregate of %d", len(cts), numSummands)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package ciphertext
+
+import (
+	"crypto/cipher"
+	"runtime"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/multi_scalar"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+// batchVerifier checks the share-encoding and bit-pair proofs of many
+// ciphertexts at once. Each proof's verification equations are scaled by
+// random weights and summed into one multi-scalar multiplication, which is
+// the identity if all the equations hold, and otherwise is the identity with
+// negligible probability. Bit pairs decoded from encodings which predate
+// commitment-form proofs can't be folded in, and are checked one by one.
+type batchVerifier struct {
+	group   anon.Suite
+	weights cipher.Stream
+	scalars []kyber.Scalar
+	points  []kyber.Point
+	// generatorScalar is the combined coefficient of the group generator
+	generatorScalar kyber.Scalar
+	cipherTexts     []*batchedCipherText
+	legacyBitPairs  []legacyBitPair
+}
+
+type batchedCipherText struct {
+	cipherText                          *cipherText
+	domainSep                           []byte
+	encryptionPK, sharePublicCommitment kyber.Point
+	bases                               *bitPairBases
+}
+
+type legacyBitPair struct{ ctIdx, pairIdx int }
+
+func newBatchVerifier(group anon.Suite) *batchVerifier {
+	return &batchVerifier{
+		group: group, weights: group.RandomStream(), generatorScalar: group.Scalar().Zero(),
+	}
+}
+
+func (b *batchVerifier) add(
+	c *cipherText, domainSep []byte, encryptionPK, sharePublicCommitment kyber.Point,
+) error {
+	if len(c.cipherText) > plaintextMaxSizeBytes*4 {
+		return errors.Errorf("ciphertext too large (max %d pairs)",
+			plaintextMaxSizeBytes*4,
+		)
+	}
+	domainSep = append([]byte{}, domainSep...)
+	combinedBlindingFactors := b.group.Point().Sub(
+		combinedCipherTexts(c.cipherText, b.group),
+		sharePublicCommitment,
+	)
+	edomain, err := c.cipherTextDomainSep(domainSep)
+	if err != nil {
+		return err
+	}
+	commitment, response, challenge, err := c.encodesShareProof.challenge(
+		b.group, edomain, encryptionPK, combinedBlindingFactors,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "could not verify overall share-encoding proof")
+	}
+
+	weight := b.weight()
+	b.scalars = append(b.scalars,
+		b.group.Scalar().Mul(weight, response),
+		b.neg(weight),
+		b.neg(b.group.Scalar().Mul(weight, challenge)),
+	)
+	b.points = append(b.points, encryptionPK, commitment, combinedBlindingFactors)
+	return b.addCipherText(c, domainSep, encryptionPK, sharePublicCommitment)
+}
+
+func (b *batchVerifier) addBitPairs(c *cipherText, domainSep []byte) error {
+	if len(c.cipherText) > plaintextMaxSizeBytes*4 {
+		return errors.Errorf("ciphertext too large (max %d pairs)",
+			plaintextMaxSizeBytes*4,
+		)
+	}
+	return b.addCipherText(c, append([]byte{}, domainSep...), c.encryptionKey, nil)
+}
+
+func (b *batchVerifier) addCipherText(
+	c *cipherText, domainSep []byte, encryptionPK, sharePublicCommitment kyber.Point,
+) error {
+	bc := &batchedCipherText{
+		c, domainSep, encryptionPK, sharePublicCommitment,
+		memBitPairBases(c.suite, c.encryptionKey),
+	}
+	ctIdx := len(b.cipherTexts)
+	b.cipherTexts = append(b.cipherTexts, bc)
+	pgroup, err := makeProductGroup(c.suite, c.encryptionKey)
+	if err != nil {
+		return errors.Wrapf(err, "while verifying bit pair encryption")
+	}
+	keyScalar := b.group.Scalar().Zero()
+	for pairIdx, bp := range c.cipherText {
+		if bp.commitmentProof == nil {
+			b.legacyBitPairs = append(b.legacyBitPairs, legacyBitPair{ctIdx, pairIdx})
+			continue
+		}
+		ring := newBitPairRing(
+			c.suite, pgroup, bc.bases,
+			encryptDomainSep(append([]byte{}, domainSep...), uint8(pairIdx)),
+			bp.blindingCommitment, bp.cipherTextTerm,
+		)
+		if err := b.addBitPairProof(ring, bp, keyScalar); err != nil {
+			return errors.Wrapf(err, "could not verify part of ciphertext")
+		}
+	}
+	b.scalars = append(b.scalars, keyScalar)
+	b.points = append(b.points, c.encryptionKey)
+	return nil
+}
+
+// addBitPairProof adds the weighted equations of a commitment-form bit-pair
+// proof. For the ith possible plaintext i*G, with challenge c and response s,
+// the generator term must be s*G+c*X, and the key term s*K+c*(C-i*G), where
+// X and C are the pair's blinding commitment and ciphertext term, and K is
+// the encryption key. The coefficient of K is accumulated in keyScalar.
+func (b *batchVerifier) addBitPairProof(
+	ring *bitPairRing, bp *elGamalBitPair, keyScalar kyber.Scalar,
+) error {
+	p := bp.commitmentProof
+	challenges, err := ring.challenges(p)
+	if err != nil {
+		return err
+	}
+	blindingScalar, cipherTextScalar := b.group.Scalar().Zero(), b.group.Scalar().Zero()
+	for i, c := range challenges {
+		w, v := b.weight(), b.weight()
+		s := p.responses[i]
+		b.generatorScalar.Add(b.generatorScalar, b.group.Scalar().Mul(w, s))
+		plaintext := b.group.Scalar().Mul(b.group.Scalar().SetInt64(int64(i)), c)
+		b.generatorScalar.Sub(b.generatorScalar, plaintext.Mul(plaintext, v))
+		keyScalar.Add(keyScalar, b.group.Scalar().Mul(v, s))
+		blindingScalar.Add(blindingScalar, b.group.Scalar().Mul(w, c))
+		cipherTextScalar.Add(cipherTextScalar, b.group.Scalar().Mul(v, c))
+		b.scalars = append(b.scalars, b.neg(w), b.neg(v))
+		b.points = append(b.points, p.commitments[2*i], p.commitments[2*i+1])
+	}
+	b.scalars = append(b.scalars, blindingScalar, cipherTextScalar)
+	b.points = append(b.points, bp.blindingCommitment, bp.cipherTextTerm)
+	return nil
+}
+
+func (b *batchVerifier) weight() kyber.Scalar {
+	return b.group.Scalar().Pick(b.weights)
+}
+
+// neg negates s by subtraction, since not every group's scalars implement Neg
+func (b *batchVerifier) neg(s kyber.Scalar) kyber.Scalar {
+	return b.group.Scalar().Sub(b.group.Scalar().Zero(), s)
+}
+
+func (b *batchVerifier) verify() error {
+	if len(b.cipherTexts) == 0 {
+		return nil
+	}
+	scalars := append(b.scalars, b.generatorScalar)
+	points := append(b.points, b.group.Point().Base())
+	combinedProofs, err := multi_scalar.Mul(scalars, points)
+	if err != nil {
+		return errors.Wrap(err, "could not combine ciphertext proofs")
+	}
+	if !combinedProofs.Equal(b.group.Point().Null()) {
+		return b.pinpointFailure()
+	}
+	return b.verifyLegacyBitPairs()
+}
+
+func (b *batchVerifier) pinpointFailure() error {
+	for ctIdx, c := range b.cipherTexts {
+		var err error
+		if c.sharePublicCommitment == nil {
+			err = c.cipherText.verifyBitPairs(c.domainSep)
+		} else {
+			err = c.cipherText.verify(
+				b.group, c.domainSep, c.encryptionPK, c.sharePublicCommitment,
+			)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "ciphertext %d failed to verify", ctIdx)
+		}
+	}
+	return errors.Errorf(
+		"batched ciphertext proofs failed to verify, but each verifies individually",
+	)
+}
+
+func (b *batchVerifier) verifyLegacyBitPairs() error {
+	tasks := make(chan legacyBitPair)
+	failures := make([]error, len(b.cipherTexts))
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				c := b.cipherTexts[t.ctIdx]
+				err := c.cipherText.cipherText[t.pairIdx].verify(
+					encryptDomainSep(append([]byte{}, c.domainSep...), uint8(t.pairIdx)),
+					c.cipherText.encryptionKey, c.bases,
+				)
+				if err != nil {
+					lock.Lock()
+					if failures[t.ctIdx] == nil {
+						failures[t.ctIdx] = err
+					}
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	for _, t := range b.legacyBitPairs {
+		tasks <- t
+	}
+	close(tasks)
+	wg.Wait()
+	for ctIdx, err := range failures {
+		if err != nil {
+			return errors.Wrapf(
+				errors.Wrapf(err, "part of ciphertext failed to verify"),
+				"ciphertext %d failed to verify", ctIdx,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package ciphertext
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/sign/anon"
+
+	"github.com/smartcontractkit/chainlink-vrf/altbn_128"
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+)
+
+var testGroup = (&altbn_128.PairingSuite{}).G1().(anon.Suite)
+
+type testCipherTexts struct {
+	domainSep   []byte
+	pk          kyber.Point
+	commitments []kyber.Point
+	cipherTexts []*cipherText
+}
+
+func newTestCipherTexts(t testing.TB, n int) *testCipherTexts {
+	pk := testGroup.Point().Pick(testGroup.RandomStream())
+	f := share.NewPriPoly(testGroup, 2, nil, testGroup.RandomStream())
+	players, err := player_idx.PlayerIdxs(player_idx.Int(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := &testCipherTexts{domainSep: []byte("batch verifier test"), pk: pk}
+	for _, p := range players {
+		c, _, err := newCipherText(rv.domainSep, testGroup, f, p, pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv.cipherTexts = append(rv.cipherTexts, c)
+		rv.commitments = append(rv.commitments, p.EvalPoint(f.Commit(nil)))
+	}
+	return rv
+}
+
+func (c *testCipherTexts) batchVerify() error {
+	b := newBatchVerifier(testGroup)
+	for i, ct := range c.cipherTexts {
+		if err := b.add(ct, c.domainSep, c.pk, c.commitments[i]); err != nil {
+			return err
+		}
+	}
+	return b.verify()
+}
+
+// signLegacyBitPair returns a bit pair with a proof in the encoding which predates
+// commitment-form proofs.
+func signLegacyBitPair(
+	t *testing.T, domainSep []byte, pk kyber.Point, b int,
+) *elGamalBitPair {
+	pgroup, err := makeProductGroup(testGroup, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := testGroup.Scalar().Pick(testGroup.RandomStream())
+	blindingCommitment := testGroup.Point().Mul(x, nil)
+	cipherTextTerm := testGroup.Point().Add(
+		testGroup.Point().Mul(x, pk), memPlainTexts(testGroup)[b],
+	)
+	var ring anon.Set
+	for _, pt := range memPlainTexts(testGroup) {
+		p, err := pgroup.NewPoint([]kyber.Point{
+			blindingCommitment, testGroup.Point().Sub(cipherTextTerm, pt),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ring = append(ring, p)
+	}
+	proof := anon.Sign(pgroup, domainSep, ring, nil, b, x)
+	return &elGamalBitPair{blindingCommitment, cipherTextTerm, proof, nil, testGroup}
+}
+
+func TestBatchVerifier(t *testing.T) {
+	c := newTestCipherTexts(t, 2)
+	if err := c.batchVerify(); err != nil {
+		t.Fatal(err)
+	}
+	g := testGroup
+	for _, tc := range []struct {
+		name   string
+		tamper func(*elGamalBitPair) func()
+	}{
+		{"commitment", func(bp *elGamalBitPair) func() {
+			p := bp.commitmentProof.commitments[5]
+			bp.commitmentProof.commitments[5] = g.Point().Add(p, g.Point().Base())
+			return func() { bp.commitmentProof.commitments[5] = p }
+		}},
+		{"response", func(bp *elGamalBitPair) func() {
+			s := bp.commitmentProof.responses[2]
+			bp.commitmentProof.responses[2] = g.Scalar().Add(s, g.Scalar().One())
+			return func() { bp.commitmentProof.responses[2] = s }
+		}},
+		{"blinding commitment", func(bp *elGamalBitPair) func() {
+			p := bp.blindingCommitment
+			bp.blindingCommitment = g.Point().Add(p, g.Point().Base())
+			return func() { bp.blindingCommitment = p }
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			restore := tc.tamper(c.cipherTexts[1].cipherText[7])
+			defer restore()
+			err := c.batchVerify()
+			if err == nil {
+				t.Fatal("batch verifier accepted a tampered bit-pair proof")
+			}
+			if !strings.Contains(err.Error(), "ciphertext 1 failed to verify") {
+				t.Fatalf("failure not pinned to tampered ciphertext: %s", err)
+			}
+		})
+	}
+
+	c.commitments[0] = g.Point().Add(c.commitments[0], g.Point().Base())
+	if c.batchVerify() == nil {
+		t.Fatal("batch verifier accepted ciphertext of the wrong share")
+	}
+}
+
+func TestBatchVerifierChecksLegacyBitPairs(t *testing.T) {
+	c := newTestCipherTexts(t, 1)
+	domainSep := []byte("legacy bit pairs")
+	legacy := &cipherText{
+		receiver: c.cipherTexts[0].receiver, encryptionKey: c.pk, suite: testGroup,
+	}
+	for pairIdx := 0; pairIdx < 4; pairIdx++ {
+		legacy.cipherText = append(legacy.cipherText, signLegacyBitPair(
+			t, encryptDomainSep(append([]byte{}, domainSep...), uint8(pairIdx)), c.pk, pairIdx,
+		))
+	}
+	verify := func() error {
+		b := newBatchVerifier(testGroup)
+		if err := b.add(c.cipherTexts[0], c.domainSep, c.pk, c.commitments[0]); err != nil {
+			return err
+		}
+		if err := b.addBitPairs(legacy, domainSep); err != nil {
+			return err
+		}
+		return b.verify()
+	}
+	if err := verify(); err != nil {
+		t.Fatal(err)
+	}
+	legacy.cipherText[3].proof[0] ^= 1
+	if verify() == nil {
+		t.Fatal("batch verifier accepted a tampered legacy bit-pair proof")
+	}
+}
+
+func TestCipherTextEncodingFollowsBitPairProofs(t *testing.T) {
+	c := newTestCipherTexts(t, 1)
+	m, err := c.cipherTexts[0].marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m[len(envelope.Magic)+1] != envelope.Version2 {
+		t.Fatalf("ciphertext with commitment-form proofs encoded as version %d",
+			m[len(envelope.Magic)+1])
+	}
+	got, err := unmarshal(testGroup, bytes.NewReader(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.equal(c.cipherTexts[0]) {
+		t.Fatal("ciphertext differs after round trip")
+	}
+	if err := got.verify(testGroup, c.domainSep, c.pk, c.commitments[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unmarshal(testGroup, bytes.NewReader(m[:len(m)-1])); err == nil {
+		t.Fatal("accepted truncated ciphertext")
+	}
+
+	legacy := &cipherText{
+		cipherText:    []*elGamalBitPair{signLegacyBitPair(t, []byte{0}, c.pk, 2)},
+		receiver:      c.cipherTexts[0].receiver,
+		encryptionKey: c.pk,
+		suite:         testGroup,
+	}
+	err = legacy.proveFinalDLKnowledge(
+		nil, testGroup, c.pk, testGroup.Scalar().Pick(testGroup.RandomStream()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = legacy.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m[len(envelope.Magic)+1] != envelope.Version1 {
+		t.Fatalf("ciphertext with legacy proofs encoded as version %d",
+			m[len(envelope.Magic)+1])
+	}
+	got, err = unmarshal(testGroup, bytes.NewReader(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.equal(legacy) {
+		t.Fatal("legacy ciphertext differs after round trip")
+	}
+	if err := got.verifyBitPairs([]byte{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+package ciphertext
+
+import (
+	"testing"
+
+	"go.dedis.ch/kyber/v3/share"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+)
+
+func BenchmarkEncrypt(b *testing.B) {
+	pk := testGroup.Point().Pick(testGroup.RandomStream())
+	f := share.NewPriPoly(testGroup, 2, nil, testGroup.RandomStream())
+	players, err := player_idx.PlayerIdxs(1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := newCipherText([]byte("benchmark"), testGroup, f, players[0], pk); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkVerify compares checking each proof of four ciphertexts in turn
+// with checking them in one batch.
+func BenchmarkVerify(b *testing.B) {
+	c := newTestCipherTexts(b, 4)
+	b.Run("individually", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j, ct := range c.cipherTexts {
+				if err := ct.verify(testGroup, c.domainSep, c.pk, c.commitments[j]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := c.batchVerify(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
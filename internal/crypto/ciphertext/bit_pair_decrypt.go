@@ -4,12 +4,14 @@ import (
 	"github.com/pkg/errors"
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/anon"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 )
 
 var _ = ((*CipherText)(nil)).Decrypt
 
 func (c *cipherText) decrypt(
-	sk kyber.Scalar,
+	sk key_store.EncryptionKey,
 	group anon.Suite, domainSep []byte, sharePublicCommitment kyber.Point,
 ) (plaintextShare kyber.Scalar, err error) {
 	if len(c.cipherText) > plaintextMaxSizeBytes*4 {
@@ -17,11 +19,43 @@ func (c *cipherText) decrypt(
 			plaintextMaxSizeBytes*4,
 		)
 	}
-	encryptionPK := group.Point().Mul(sk, nil)
+	encryptionPK := sk.PublicKey()
 	err = c.verify(group, domainSep, encryptionPK, sharePublicCommitment)
 	if err != nil {
 		return nil, errors.Wrap(err, "refusing to decrypt unverifiable share")
 	}
+	return c.decryptVerified(sk, group)
+}
+
+func (c *cipherText) decryptVerified(
+	sk key_store.EncryptionKey, group anon.Suite,
+) (plaintextShare kyber.Scalar, err error) {
+	if len(c.cipherText) > plaintextMaxSizeBytes*4 {
+		return nil, errors.Errorf("ciphertext too large (max %d pairs)",
+			plaintextMaxSizeBytes*4,
+		)
+	}
+	if !sk.PublicKey().Equal(c.encryptionKey) {
+		return nil, errors.Errorf("ciphertext is not encrypted to given key")
+	}
+
+	blindingTerms, err := sk.SharedSecrets(c.blindingCommitments())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unblind share ciphertext")
+	}
+	return c.unblind(group, blindingTerms)
+}
+
+// unblind decrypts c with the shared secrets of its bit pairs' blinding
+// commitments.
+func (c *cipherText) unblind(
+	group anon.Suite, blindingTerms []kyber.Point,
+) (plaintextShare kyber.Scalar, err error) {
+	if len(blindingTerms) != len(c.cipherText) {
+		return nil, errors.Errorf(
+			"got %d blinding terms for %d bit pairs", len(blindingTerms), len(c.cipherText),
+		)
+	}
 
 	plaintextShare = group.Scalar()
 
@@ -34,8 +68,8 @@ func (c *cipherText) decrypt(
 
 	fourPower := group.Scalar().One()
 
-	for _, bitPair := range c.cipherText {
-		numericPair, err := bitPair.decrypt(sk)
+	for i, bitPair := range c.cipherText {
+		numericPair, err := bitPair.decrypt(blindingTerms[i])
 		if err != nil {
 
 			return nil, errors.Wrapf(err, "could not decrypt %+v as part of share", bitPair)
@@ -54,3 +88,47 @@ func (c *cipherText) decrypt(
 	}
 	return plaintextShare, nil
 }
+
+func (c *cipherText) blindingCommitments() []kyber.Point {
+	rv := make([]kyber.Point, len(c.cipherText))
+	for i, bitPair := range c.cipherText {
+		rv[i] = bitPair.blindingCommitment
+	}
+	return rv
+}
+
+// proveDecryption returns the shared secrets which unblind c, with a proof
+// that sk computed them.
+func (c *cipherText) proveDecryption(
+	sk key_store.EncryptionKey, domainSep []byte,
+) (blindingTerms []kyber.Point, proof []byte, err error) {
+	if !sk.PublicKey().Equal(c.encryptionKey) {
+		return nil, nil, errors.Errorf("ciphertext is not encrypted to given key")
+	}
+	blindingTerms, proof, err = sk.ProveSharedSecrets(c.blindingCommitments(), domainSep)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not prove shared secrets of share ciphertext")
+	}
+	return blindingTerms, proof, nil
+}
+
+// decryptWithProof decrypts c with shared secrets from proveDecryption. It
+// returns an error if the proof fails, and otherwise a fault if the shared
+// secrets don't decrypt c.
+func (c *cipherText) decryptWithProof(
+	group anon.Suite, domainSep []byte, blindingTerms []kyber.Point, proof []byte,
+) (plaintextShare kyber.Scalar, fault, err error) {
+	if len(c.cipherText) > plaintextMaxSizeBytes*4 {
+		return nil, nil, errors.Errorf("ciphertext too large (max %d pairs)",
+			plaintextMaxSizeBytes*4,
+		)
+	}
+	err = key_store.VerifySharedSecrets(
+		group, c.encryptionKey, c.blindingCommitments(), blindingTerms, domainSep, proof,
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not verify shared secrets of share ciphertext")
+	}
+	plaintextShare, fault = c.unblind(group, blindingTerms)
+	return plaintextShare, fault, nil
+}
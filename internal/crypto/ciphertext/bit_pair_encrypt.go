@@ -18,6 +18,7 @@ func encrypt(
 	two := group.Scalar().Add(one, one)
 	four := two.Add(two, two)
 	fourPower := one
+	bases := memBitPairBases(group, pk)
 
 	for byteIdx := len(plaintext) - 1; byteIdx >= 0; byteIdx-- {
 		cbyte := plaintext[byteIdx]
@@ -29,7 +30,7 @@ func encrypt(
 			pairCipherText, blindingSecret, err := newElGamalBitPair(
 				group,
 				encryptDomainSep(domainSep, uint8(len(cipherText))),
-				bitPair, pk,
+				bitPair, pk, bases,
 			)
 			if err != nil {
 
@@ -50,13 +51,11 @@ func encryptDomainSep(domainSep []byte, pairIdx uint8) []byte {
 }
 
 func combinedCipherTexts(cipherText []*elGamalBitPair, s anon.Suite) kyber.Point {
-	rv := cipherText[0].cipherTextTerm.Clone().Null()
-	fourPower := s.Scalar().One()
-	two := s.Scalar().Add(fourPower, fourPower)
-	four := two.Add(two, two)
-	for _, bitpair := range cipherText {
-		rv = rv.Add(rv, rv.Clone().Mul(fourPower, bitpair.cipherTextTerm))
-		fourPower = fourPower.Mul(fourPower, four)
+	rv := s.Point().Null()
+	for pairIdx := len(cipherText) - 1; pairIdx >= 0; pairIdx-- {
+		rv = rv.Add(rv, rv)
+		rv = rv.Add(rv, rv)
+		rv = rv.Add(rv, cipherText[pairIdx].cipherTextTerm)
 	}
 	return rv
 }
@@ -7,17 +7,24 @@ import (
 	"github.com/pkg/errors"
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/anon"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
 )
 
 type elGamalBitPair struct {
 	blindingCommitment, cipherTextTerm kyber.Point
 
-	proof bitPairProof
+	// Exactly one of proof and commitmentProof is set. Pairs decoded from
+	// encodings older than envelope.Version2 have a proof; new ones have a
+	// commitmentProof.
+	proof           bitPairProof
+	commitmentProof *bitPairCommitmentProof
 
 	suite anon.Suite
 }
 
-func newElGamalBitPair(suite anon.Suite, domainSep []byte, b int, pk kyber.Point,
+func newElGamalBitPair(
+	suite anon.Suite, domainSep []byte, b int, pk kyber.Point, bases *bitPairBases,
 ) (bp *elGamalBitPair, blindingSecret kyber.Scalar, err error) {
 	if reflect.TypeOf(pk) != reflect.TypeOf(suite.Point()) {
 		return nil, nil, errors.Errorf(
@@ -29,17 +36,19 @@ func newElGamalBitPair(suite anon.Suite, domainSep []byte, b int, pk kyber.Point
 		return nil, nil, errors.Errorf("can only encode 0b00, 0b01, 0b10 or 0b11, got 0b%#b", b)
 	}
 	x := suite.Scalar().Pick(suite.RandomStream())
-	blindingCommitment := suite.Point().Mul(x, nil)
-	plaintextTerm := suite.Point().Mul(suite.Scalar().SetInt64(int64(b)), nil)
-	blindingTerm := suite.Point().Mul(x, pk)
+	blindingCommitment := bases.generator.Mul(x)
+	plaintextTerm := memPlainTexts(suite)[b]
+	blindingTerm := bases.encryptionKey.Mul(x)
 	cipherTextTerm := suite.Point().Add(blindingTerm, plaintextTerm)
-	proof, err := proveBitPair(suite, domainSep, pk, blindingCommitment, cipherTextTerm, b, x)
+	proof, err := proveBitPair(
+		suite, domainSep, bases, pk, blindingCommitment, cipherTextTerm, b, x,
+	)
 	if err != nil {
 
 		return nil, nil, errors.Wrapf(err, "while constructing encrypted bit pair")
 	}
-	rv := &elGamalBitPair{blindingCommitment, cipherTextTerm, proof, suite}
-	if err := rv.verify(domainSep, pk); err != nil {
+	rv := &elGamalBitPair{blindingCommitment, cipherTextTerm, nil, proof, suite}
+	if err := rv.verify(domainSep, pk, bases); err != nil {
 
 		pkm, err2 := pk.MarshalBinary()
 		if err2 != nil {
@@ -59,22 +68,30 @@ func newElGamalBitPair(suite anon.Suite, domainSep []byte, b int, pk kyber.Point
 
 var _ = (&CipherText{}).Verify
 
-func (e *elGamalBitPair) verify(domainSep []byte, encryptionKey kyber.Point) error {
+func (e *elGamalBitPair) verify(
+	domainSep []byte, encryptionKey kyber.Point, bases *bitPairBases,
+) error {
 	pgroup, err := makeProductGroup(e.suite, encryptionKey)
 	if err != nil {
 		return errors.Wrapf(err, "while verifying bit pair encryption")
 	}
-	return e.proof.verify(e.suite, domainSep, pgroup, e.blindingCommitment, e.cipherTextTerm,
-		encryptionKey)
+	if e.commitmentProof != nil {
+		return e.commitmentProof.verify(e.suite, domainSep, pgroup, bases,
+			e.blindingCommitment, e.cipherTextTerm)
+	}
+	return e.proof.verify(e.suite, domainSep, pgroup, bases, e.blindingCommitment,
+		e.cipherTextTerm)
 }
 
-func (e *elGamalBitPair) decrypt(sk kyber.Scalar) (int, error) {
-	if reflect.TypeOf(sk) != reflect.TypeOf(e.suite.Scalar()) {
-		return 0, errors.Errorf("need scalar of type %T, got type %T", e.suite.Scalar(), sk)
+func (e *elGamalBitPair) decrypt(blindingTerm kyber.Point) (int, error) {
+	if reflect.TypeOf(blindingTerm) != reflect.TypeOf(e.suite.Point()) {
+		return 0, errors.Errorf(
+			"need point of type %T, got type %T", e.suite.Point(), blindingTerm,
+		)
 	}
 	plainText := e.suite.Point()
 
-	plainText.Sub(e.cipherTextTerm, plainText.Mul(sk, e.blindingCommitment))
+	plainText.Sub(e.cipherTextTerm, blindingTerm)
 	for i, pt := range memPlainTexts(e.suite) {
 		if pt.Equal(plainText) {
 			return i, nil
@@ -99,7 +116,14 @@ func (e *elGamalBitPair) marshal() (m []byte, err error) {
 		return nil, errors.Wrapf(err, "could not marshal ciphertext point")
 	}
 
-	rv[cursor] = e.proof[:]
+	if e.commitmentProof != nil {
+		rv[cursor], err = e.commitmentProof.marshal()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rv[cursor] = e.proof[:]
+	}
 	if cursor != len(rv)-1 {
 		panic("return values out of registration")
 	}
@@ -107,9 +131,9 @@ func (e *elGamalBitPair) marshal() (m []byte, err error) {
 	return bytes.Join(rv, nil), nil
 }
 
-func unmarshalElGamalBitPair(suite anon.Suite, d []byte,
+func unmarshalElGamalBitPair(suite anon.Suite, d []byte, version uint8,
 ) (e *elGamalBitPair, err error) {
-	if len(d) < elGamalBitPairMarshalLength(suite) {
+	if len(d) < elGamalBitPairMarshalLength(suite, version) {
 		return nil, errors.Errorf("marshal data too short to contain elGamalBitPair")
 	}
 	e = &elGamalBitPair{suite: suite}
@@ -127,22 +151,45 @@ func unmarshalElGamalBitPair(suite anon.Suite, d []byte,
 	}
 	remainder = remainder[pointLen:]
 
+	if version >= envelope.Version2 {
+		e.commitmentProof, err = unmarshalBitPairCommitmentProof(
+			suite, remainder[:bitPairCommitmentProofLen(suite)],
+		)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
 	proofLen := bitPairProofLen(suite)
 	e.proof = append([]byte{}, remainder[:proofLen]...)
 
 	return e, nil
 }
 
-func elGamalBitPairMarshalLength(suite anon.Suite) int {
+func elGamalBitPairMarshalLength(suite anon.Suite, version uint8) int {
 	pointLen := suite.PointLen()
+	proofLen := bitPairProofLen(suite)
+	if version >= envelope.Version2 {
+		proofLen = bitPairCommitmentProofLen(suite)
+	}
 	return pointLen +
 		pointLen +
-		bitPairProofLen(suite)
+		proofLen
+}
+
+// encodingVersion is the oldest envelope version which can encode e.
+func (e *elGamalBitPair) encodingVersion() uint8 {
+	if e.commitmentProof != nil {
+		return envelope.Version2
+	}
+	return envelope.Version1
 }
 
 func (e *elGamalBitPair) equal(e2 *elGamalBitPair) bool {
 	return e.blindingCommitment.Equal(e2.blindingCommitment) &&
 		e.cipherTextTerm.Equal(e2.cipherTextTerm) &&
 		bytes.Equal(e.proof[:], e2.proof[:]) &&
+		(e.commitmentProof == nil) == (e2.commitmentProof == nil) &&
+		(e.commitmentProof == nil || e.commitmentProof.equal(e2.commitmentProof)) &&
 		e.suite.String() == e2.suite.String()
 }
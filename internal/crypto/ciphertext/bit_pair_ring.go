@@ -0,0 +1,146 @@
+package ciphertext
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/product_group"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+type bitPairRing struct {
+	suite              anon.Suite
+	pgroup             *product_group.ProductGroup
+	pgroupDescription  string
+	bases              *bitPairBases
+	message            []byte
+	blindingCommitment kyber.Point
+	blindingTerms      []kyber.Point
+}
+
+func newBitPairRing(
+	suite anon.Suite, pgroup *product_group.ProductGroup, bases *bitPairBases,
+	message []byte, blindingCommitment, cipherTextTerm kyber.Point,
+) *bitPairRing {
+	var blindingTerms []kyber.Point
+	for _, possiblePlaintextTerm := range memPlainTexts(suite) {
+		blindingTerms = append(blindingTerms,
+			cipherTextTerm.Clone().Sub(cipherTextTerm, possiblePlaintextTerm),
+		)
+	}
+	return &bitPairRing{
+		suite, pgroup, pgroup.String(), bases, message, blindingCommitment, blindingTerms,
+	}
+}
+
+func (r *bitPairRing) sign(mine int, secret kyber.Scalar) (*bitPairCommitmentProof, error) {
+	n := len(r.blindingTerms)
+	u := r.pgroup.Scalar().Pick(r.pgroup.RandomStream())
+	p := &bitPairCommitmentProof{make([]kyber.Point, 2*n), make([]kyber.Scalar, n)}
+	p.commitments[2*mine] = r.bases.generator.Mul(u)
+	p.commitments[2*mine+1] = r.bases.encryptionKey.Mul(u)
+	c, err := r.challenge(p.commitments[2*mine], p.commitments[2*mine+1])
+	if err != nil {
+		return nil, err
+	}
+	for i := (mine + 1) % n; i != mine; i = (i + 1) % n {
+		p.responses[i] = r.pgroup.Scalar().Pick(r.pgroup.RandomStream())
+		p.commitments[2*i], p.commitments[2*i+1] = r.commitment(i, p.responses[i], c)
+		c, err = r.challenge(p.commitments[2*i], p.commitments[2*i+1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.responses[mine] = r.pgroup.Scalar()
+	p.responses[mine].Mul(secret, c).Sub(u, p.responses[mine])
+	return p, nil
+}
+
+func (r *bitPairRing) verify(sig []byte) error {
+	n := len(r.blindingTerms)
+	scalarLen := r.pgroup.ScalarLen()
+	if len(sig) < (n+1)*scalarLen {
+		return errors.Errorf("bit-pair proof too short")
+	}
+	scalars := make([]kyber.Scalar, n+1)
+	for i := range scalars {
+		scalars[i] = r.pgroup.Scalar()
+		if err := scalars[i].UnmarshalBinary(sig[i*scalarLen : (i+1)*scalarLen]); err != nil {
+			return errors.Wrap(err, "could not unmarshal bit-pair proof")
+		}
+	}
+	c0, s := scalars[0], scalars[1:]
+	ci := c0
+	for i := 0; i < n; i++ {
+		var err error
+		ci, err = r.challenge(r.commitment(i, s[i], ci))
+		if err != nil {
+			return err
+		}
+	}
+	if !ci.Equal(c0) {
+		return errors.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// verifyCommitments checks a proof in commitment form, one ring member at a
+// time. batchVerifier checks the same equations in bulk.
+func (r *bitPairRing) verifyCommitments(p *bitPairCommitmentProof) error {
+	challenges, err := r.challenges(p)
+	if err != nil {
+		return err
+	}
+	for i, c := range challenges {
+		generatorTerm, keyTerm := r.commitment(i, p.responses[i], c)
+		if !generatorTerm.Equal(p.commitments[2*i]) || !keyTerm.Equal(p.commitments[2*i+1]) {
+			return errors.Errorf("invalid signature")
+		}
+	}
+	return nil
+}
+
+// challenges returns the challenge for each ring member, each of which is the
+// hash of the previous member's commitments.
+func (r *bitPairRing) challenges(p *bitPairCommitmentProof) ([]kyber.Scalar, error) {
+	n := len(r.blindingTerms)
+	if len(p.commitments) != 2*n || len(p.responses) != n {
+		return nil, errors.Errorf("bit-pair proof has wrong number of terms")
+	}
+	rv := make([]kyber.Scalar, n)
+	for i := range rv {
+		prev := (i + n - 1) % n
+		var err error
+		rv[i], err = r.challenge(p.commitments[2*prev], p.commitments[2*prev+1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+func (r *bitPairRing) commitment(i int, s, c kyber.Scalar) (kyber.Point, kyber.Point) {
+	generatorTerm := r.bases.generator.Mul(s)
+	generatorTerm.Add(generatorTerm, r.suite.Point().Mul(c, r.blindingCommitment))
+	keyTerm := r.bases.encryptionKey.Mul(s)
+	keyTerm.Add(keyTerm, r.suite.Point().Mul(c, r.blindingTerms[i]))
+	return generatorTerm, keyTerm
+}
+
+func (r *bitPairRing) challenge(generatorTerm, keyTerm kyber.Point) (kyber.Scalar, error) {
+	h := r.pgroup.XOF(r.message)
+	for _, p := range []kyber.Point{generatorTerm, keyTerm} {
+		pB, err := p.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal bit-pair proof commitment")
+		}
+		if _, err := h.Write(pB); err != nil {
+			return nil, errors.Wrap(err, "could not hash bit-pair proof commitment")
+		}
+	}
+	if _, err := h.Write([]byte(r.pgroupDescription)); err != nil {
+		return nil, errors.Wrap(err, "could not hash bit-pair proof commitment")
+	}
+	return r.pgroup.Scalar().Pick(h), nil
+}
@@ -0,0 +1,74 @@
+package ciphertext
+
+import (
+	"testing"
+)
+
+func TestBitPairProofs(t *testing.T) {
+	pk := testGroup.Point().Pick(testGroup.RandomStream())
+	bases := memBitPairBases(testGroup, pk)
+	pgroup, err := makeProductGroup(testGroup, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domainSep := []byte("bit pair")
+	wrongDomain := []byte("bit pain")
+	for b := 0; b < 4; b++ {
+		bp, _, err := newElGamalBitPair(testGroup, domainSep, b, pk, bases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := bp.commitmentProof
+		if err := bp.verify(wrongDomain, pk, bases); err == nil {
+			t.Fatalf("accepted proof for %d under wrong domain separator", b)
+		}
+		m, err := p.marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := unmarshalBitPairCommitmentProof(testGroup, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.equal(p) {
+			t.Fatalf("proof for %d changed in round trip", b)
+		}
+		if _, err := unmarshalBitPairCommitmentProof(testGroup, m[1:]); err == nil {
+			t.Fatal("accepted truncated proof")
+		}
+		got.responses[b] = testGroup.Scalar().Add(got.responses[b], testGroup.Scalar().One())
+		err = got.verify(testGroup, domainSep, pgroup, bases, bp.blindingCommitment, bp.cipherTextTerm)
+		if err == nil {
+			t.Fatalf("accepted proof for %d with tampered response", b)
+		}
+		otherCipherText := testGroup.Point().Add(bp.cipherTextTerm, testGroup.Point().Base())
+		err = p.verify(testGroup, domainSep, pgroup, bases, bp.blindingCommitment, otherCipherText)
+		if err == nil {
+			t.Fatalf("accepted proof for %d for a different ciphertext", b)
+		}
+	}
+	if _, _, err := newElGamalBitPair(testGroup, domainSep, 4, pk, bases); err == nil {
+		t.Fatal("encrypted a value which is not a bit pair")
+	}
+}
+
+// The hand-written ring proof must accept and reject the same proofs as the
+// kyber ring signature which produced bit-pair proofs before it.
+func TestLegacyBitPairProofs(t *testing.T) {
+	pk := testGroup.Point().Pick(testGroup.RandomStream())
+	bases := memBitPairBases(testGroup, pk)
+	domainSep := []byte("legacy bit pair")
+	for b := 0; b < 4; b++ {
+		bp := signLegacyBitPair(t, domainSep, pk, b)
+		if err := bp.verify(domainSep, pk, bases); err != nil {
+			t.Fatalf("rejected legacy proof for %d: %s", b, err)
+		}
+		if err := bp.verify(append(domainSep, 0), pk, bases); err == nil {
+			t.Fatalf("accepted legacy proof for %d under wrong domain separator", b)
+		}
+		bp.proof[len(bp.proof)-1] ^= 1
+		if err := bp.verify(domainSep, pk, bases); err == nil {
+			t.Fatalf("accepted tampered legacy proof for %d", b)
+		}
+	}
+}
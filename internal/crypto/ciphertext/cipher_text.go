@@ -26,28 +26,35 @@ func newCipherText(
 	domainSep []byte, group anon.Suite, f *share.PriPoly,
 	receiver *player_idx.PlayerIdx, pk kyber.Point,
 ) (rv *cipherText, secretShare kyber.Scalar, err error) {
+	rv, secretShare, _, err = newBlindedCipherText(domainSep, group, f, receiver, pk)
+	return rv, secretShare, err
+}
+
+func newBlindedCipherText(
+	domainSep []byte, group anon.Suite, f *share.PriPoly,
+	receiver *player_idx.PlayerIdx, pk kyber.Point,
+) (rv *cipherText, secretShare, totalBlindingSecret kyber.Scalar, err error) {
 	rv = &cipherText{suite: group, receiver: receiver, encryptionKey: pk}
 	secretShare, rawShare, err := getShareBits(receiver, f)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
-	var totalBlindingSecret kyber.Scalar
 
 	rv.cipherText, totalBlindingSecret, err = encrypt(domainSep, group, rawShare, pk)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "could not encrypt secret share")
+		return nil, nil, nil, errors.Wrapf(err, "could not encrypt secret share")
 	}
 
 	if err := rv.proveFinalDLKnowledge(domainSep, group, pk, totalBlindingSecret); err != nil {
 
-		return nil, nil, errors.Wrap(err, "could not prove ciphertext encodes secret share")
+		return nil, nil, nil, errors.Wrap(err, "could not prove ciphertext encodes secret share")
 	}
 	blindingPK := group.Point().Mul(secretShare, nil)
 	if err := rv.verify(group, domainSep, pk, blindingPK); err != nil {
 
 		panic(err)
 	}
-	return rv, secretShare, nil
+	return rv, secretShare, totalBlindingSecret, nil
 
 }
 
@@ -68,6 +75,15 @@ var _ = ((*CipherText)(nil)).Verify
 
 func (c *cipherText) verify(
 	group anon.Suite, domainSep []byte, encryptionPK, sharePublicCommitment kyber.Point,
+) error {
+	if err := c.verifyEncodesShare(group, domainSep, encryptionPK, sharePublicCommitment); err != nil {
+		return err
+	}
+	return c.verifyBitPairs(domainSep)
+}
+
+func (c *cipherText) verifyEncodesShare(
+	group anon.Suite, domainSep []byte, encryptionPK, sharePublicCommitment kyber.Point,
 ) error {
 	if len(c.cipherText) > plaintextMaxSizeBytes*4 {
 		return errors.Errorf("ciphertext too large (max %d pairs)",
@@ -90,10 +106,20 @@ func (c *cipherText) verify(
 	if err != nil {
 		return errors.Wrapf(err, "could not verify overall share-encoding proof")
 	}
+	return nil
+}
+
+func (c *cipherText) verifyBitPairs(domainSep []byte) error {
+	if len(c.cipherText) > plaintextMaxSizeBytes*4 {
+		return errors.Errorf("ciphertext too large (max %d pairs)",
+			plaintextMaxSizeBytes*4,
+		)
+	}
+	bases := memBitPairBases(c.suite, c.encryptionKey)
 	for pairIdx, bitPair := range c.cipherText {
 		err := bitPair.verify(
 			encryptDomainSep(domainSep, uint8(pairIdx)),
-			c.encryptionKey,
+			c.encryptionKey, bases,
 		)
 		if err != nil {
 
@@ -9,8 +9,10 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 
+	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/anon"
 )
 
@@ -25,59 +27,33 @@ const (
 )
 
 func (c *cipherText) marshal() (m []byte, err error) {
-	rv := new(bytes.Buffer)
-
-	suiteName := []byte(c.suite.String())
-	if len(suiteName) > math.MaxUint8 {
-		return nil, errors.Errorf("suite name too long")
-	}
-
-	if err2 := hw(rv, []byte{uint8(len(suiteName))}, SUITE_DESC_LEN); err2 != nil {
-		return nil, err2
-	}
-
-	if err2 := hw(rv, suiteName, CRYPTOGRAPHIC_SUITE_DESCRIPTION); err2 != nil {
-		return nil, err2
-	}
-
-	if err2 := hw(rv, c.receiver.Marshal(), RECEIVER_INDEX); err2 != nil {
-		return nil, err2
-	}
-
-	n, err := c.encryptionKey.MarshalTo(rv)
+	encryptionKey, err := c.encryptionKey.MarshalBinary()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not marshal "+ENCRYPTION_KEY)
 	}
-	if n != c.suite.PointLen() {
+	if len(encryptionKey) != c.suite.PointLen() {
 		return nil, errors.Errorf("could not marshal " + ENCRYPTION_KEY +
 			"; wrote wrong number of bytes for it")
 	}
 
-	if len(c.encodesShareProof) > math.MaxUint16 {
-		return nil, errors.Errorf("encodesShareProof too long")
-	}
-	proofLen := make([]byte, 2)
-	binary.BigEndian.PutUint16(proofLen, uint16(len(c.encodesShareProof)))
-	if err := hw(rv, proofLen, SHARE_PROOF_LEN); err != nil {
-		return nil, err
-	}
-
-	if err := hw(rv, c.encodesShareProof, SHARE_PROOF); err != nil {
-		return nil, err
+	proof, err := c.encodesShareProof.compact(c.suite, c.encryptionKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal "+SHARE_PROOF)
 	}
 
-	numPairs := len(c.cipherText)
-	if numPairs > math.MaxUint16 {
+	if len(c.cipherText) > math.MaxUint16 {
 		return nil, errors.Errorf("too many pairs to marshal")
 	}
-	bigEndNp := make([]byte, 2)
-	binary.BigEndian.PutUint16(bigEndNp, uint16(numPairs))
-	if err := hw(rv, bigEndNp, NUM_PAIRS); err != nil {
-		return nil, err
+	version := envelope.CurrentVersion
+	if len(c.cipherText) > 0 {
+		version = c.cipherText[0].encodingVersion()
 	}
-
-	ctl := elGamalBitPairMarshalLength(c.suite)
+	ctl := elGamalBitPairMarshalLength(c.suite, version)
+	pairs := new(bytes.Buffer)
 	for _, ct := range c.cipherText {
+		if ct.encodingVersion() != version {
+			return nil, errors.Errorf("ciphertext mixes bit-pair proof encodings")
+		}
 		ctm, err := ct.marshal()
 		if err != nil {
 			return nil, errors.Wrap(err, "could not marshal ciphertext")
@@ -85,12 +61,15 @@ func (c *cipherText) marshal() (m []byte, err error) {
 		if len(ctm) != ctl {
 			return nil, errors.Errorf("elGamalBitPair marshaled to wrong length")
 		}
-		if err := hw(rv, ctm, "ciphertext bit pair"); err != nil {
+		if err := hw(pairs, ctm, "ciphertext bit pair"); err != nil {
 			return nil, err
 		}
 	}
 
-	return rv.Bytes(), nil
+	return envelope.NewVersion(
+		envelope.CipherText, version, []kyber.Group{c.suite},
+		c.receiver.Marshal(), encryptionKey, proof, pairs.Bytes(),
+	).Marshal()
 }
 
 func hw(rv io.Writer, d []byte, errmsg string) (err error) {
@@ -105,6 +84,65 @@ func hw(rv io.Writer, d []byte, errmsg string) (err error) {
 }
 
 func unmarshal(suite anon.Suite, byteStream io.Reader) (c *cipherText, err error) {
+	data, err := io.ReadAll(byteStream)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read ciphertext for unmarshalling")
+	}
+	if !envelope.IsEnveloped(data) {
+		return unmarshalLegacy(suite, bytes.NewReader(data))
+	}
+	e, rem, err := envelope.Unmarshal(data, envelope.CipherText)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal ciphertext")
+	}
+	if len(rem) > 0 {
+		return nil, errors.Errorf("overage of %d bytes in marshalled ciphertext", len(rem))
+	}
+	if err := e.CheckGroups(suite); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal ciphertext")
+	}
+	if err := e.CheckNumFields(4); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal ciphertext")
+	}
+	c = &cipherText{suite: suite}
+
+	var idxRem []byte
+	c.receiver, idxRem, err = player_idx.Unmarshal(e.Fields[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal ciphertext's "+RECEIVER_INDEX)
+	}
+	if len(idxRem) > 0 {
+		return nil, errors.Errorf("overage in ciphertext's " + RECEIVER_INDEX)
+	}
+
+	c.encryptionKey = suite.Point()
+	if err := c.encryptionKey.UnmarshalBinary(e.Fields[1]); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal "+ENCRYPTION_KEY)
+	}
+
+	c.encodesShareProof, err = expandDLKnowledgeProof(suite, c.encryptionKey, e.Fields[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal "+SHARE_PROOF)
+	}
+
+	ctl := elGamalBitPairMarshalLength(suite, e.Version)
+	pairs := e.Fields[3]
+	if len(pairs)%ctl != 0 || len(pairs)/ctl > math.MaxUint16 {
+		return nil, errors.Errorf("could not unmarshal ciphertext bit pairs: bad length")
+	}
+	c.cipherText = make([]*elGamalBitPair, len(pairs)/ctl)
+	for bpIdx := range c.cipherText {
+		c.cipherText[bpIdx], err = unmarshalElGamalBitPair(
+			suite, pairs[bpIdx*ctl:(bpIdx+1)*ctl], e.Version,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal cipher text")
+		}
+	}
+	return c, nil
+}
+
+func unmarshalLegacy(suite anon.Suite, byteStream io.Reader) (c *cipherText, err error) {
 	c = &cipherText{suite: suite}
 
 	var strLen [1]byte
@@ -119,7 +157,7 @@ func unmarshal(suite anon.Suite, byteStream io.Reader) (c *cipherText, err error
 		return nil, errors.Errorf(`wrong suite for unmarshalling: need "%s", got "%s"`, suite, str)
 	}
 
-	idx := make([]byte, player_idx.MarshalLen)
+	idx := make([]byte, player_idx.NarrowMarshalLen)
 	if err2 := hr(byteStream, idx, RECEIVER_INDEX); err2 != nil {
 		return nil, err2
 	}
@@ -153,13 +191,13 @@ func unmarshal(suite anon.Suite, byteStream io.Reader) (c *cipherText, err error
 	}
 	numPairs := binary.BigEndian.Uint16(rawNumPairs[:])
 
-	ctm := make([]byte, elGamalBitPairMarshalLength(suite))
+	ctm := make([]byte, elGamalBitPairMarshalLength(suite, envelope.LegacyVersion))
 	c.cipherText = make([]*elGamalBitPair, numPairs)
 	for bpIdx := uint16(0); bpIdx < numPairs; bpIdx++ {
 		if err2 := hr(byteStream, ctm[:], "ciphertext bit pair"); err2 != nil {
 			return nil, err2
 		}
-		c.cipherText[bpIdx], err = unmarshalElGamalBitPair(suite, ctm)
+		c.cipherText[bpIdx], err = unmarshalElGamalBitPair(suite, ctm, envelope.LegacyVersion)
 		if err != nil {
 			return nil, errors.Wrap(err, "could not unmarshal cipher text")
 		}
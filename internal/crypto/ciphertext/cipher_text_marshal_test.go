@@ -0,0 +1,77 @@
+package ciphertext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+)
+
+// marshalLegacy encodes c as ciphertexts were encoded before they were
+// enveloped. c's bit pairs must carry legacy proofs.
+func marshalLegacy(t *testing.T, c *cipherText) []byte {
+	rv := append([]byte{byte(len(c.suite.String()))}, c.suite.String()...)
+	rv = append(rv, c.receiver.Marshal()...)
+	pk, err := c.encryptionKey.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv = append(rv, pk...)
+	rv = binary.BigEndian.AppendUint16(rv, uint16(len(c.encodesShareProof)))
+	rv = append(rv, c.encodesShareProof...)
+	rv = binary.BigEndian.AppendUint16(rv, uint16(len(c.cipherText)))
+	for _, bp := range c.cipherText {
+		m, err := bp.marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv = append(rv, m...)
+	}
+	return rv
+}
+
+func TestLegacyCipherTextDecodes(t *testing.T) {
+	c := newTestCipherTexts(t, 1).cipherTexts[0]
+	domainSep := []byte("legacy ciphertext")
+	c.cipherText = nil
+	for pairIdx := 0; pairIdx < 4; pairIdx++ {
+		c.cipherText = append(c.cipherText, signLegacyBitPair(
+			t, encryptDomainSep(append([]byte{}, domainSep...), uint8(pairIdx)),
+			c.encryptionKey, pairIdx,
+		))
+	}
+
+	legacy := marshalLegacy(t, c)
+	if envelope.IsEnveloped(legacy) {
+		t.Fatal("legacy ciphertext encoding looks enveloped")
+	}
+	got, err := unmarshal(testGroup, bytes.NewReader(legacy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.equal(c) {
+		t.Fatal("legacy ciphertext decoded to a different ciphertext")
+	}
+
+	// Re-encoding keeps the legacy proofs, so it needs envelope.Version1.
+	m, err := got.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, _, err := envelope.Unmarshal(m, envelope.CipherText); err != nil ||
+		e.Version != envelope.Version1 {
+		t.Fatalf("legacy ciphertext not re-encoded as version 1: %v", err)
+	}
+	got, err = unmarshal(testGroup, bytes.NewReader(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.equal(c) {
+		t.Fatal("re-encoded legacy ciphertext decoded to a different ciphertext")
+	}
+
+	if _, err := unmarshal(testGroup, bytes.NewReader(legacy[:len(legacy)-1])); err == nil {
+		t.Fatal("truncated legacy ciphertext decoded")
+	}
+}
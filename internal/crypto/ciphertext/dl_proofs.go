@@ -56,6 +56,71 @@ func (p dLKnowledgeProof) verify(
 		"could not verify share proof")
 }
 
+func (p dLKnowledgeProof) challenge(
+	group anon.Suite, domainSep []byte, blindingPK, signingPK kyber.Point,
+) (commitment kyber.Point, response, challenge kyber.Scalar, err error) {
+	g, err := change_base_group.NewChangeBaseGroup(group, blindingPK)
+	if err != nil {
+		return nil, nil, nil,
+			errors.Wrapf(err, "could not create cyclic group from given generator")
+	}
+	pk, err := g.Lift(signingPK)
+	if err != nil {
+		return nil, nil, nil,
+			errors.Wrapf(err, "could not create point pair for DL proof")
+	}
+	pkB, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not marshal point for DL proof")
+	}
+	_, liftedCommitment, response, challenge, err := schnorr.Challenge(g, pkB, domainSep, p)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not verify share proof")
+	}
+	commitment, err = g.Unlift(liftedCommitment)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not verify share proof")
+	}
+	return commitment, response, challenge, nil
+}
+
+func (p dLKnowledgeProof) compact(group anon.Suite, blindingPK kyber.Point) ([]byte, error) {
+	description, err := dLProofDescription(group, blindingPK)
+	if err != nil {
+		return nil, err
+	}
+	pointLen := group.PointLen()
+	if len(p) < pointLen+len(description) ||
+		!bytes.Equal(p[pointLen:pointLen+len(description)], description) {
+		return nil, errors.Errorf("share proof does not contain its group description")
+	}
+	return bytes.Join([][]byte{p[:pointLen], p[pointLen+len(description):]}, nil), nil
+}
+
+func expandDLKnowledgeProof(
+	group anon.Suite, blindingPK kyber.Point, compact []byte,
+) (dLKnowledgeProof, error) {
+	description, err := dLProofDescription(group, blindingPK)
+	if err != nil {
+		return nil, err
+	}
+	pointLen := group.PointLen()
+	if len(compact) < pointLen {
+		return nil, errors.Errorf("compact share proof too short")
+	}
+	return bytes.Join(
+		[][]byte{compact[:pointLen], description, compact[pointLen:]}, nil,
+	), nil
+}
+
+func dLProofDescription(group anon.Suite, blindingPK kyber.Point) ([]byte, error) {
+	g, err := change_base_group.NewChangeBaseGroup(group, blindingPK)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create cyclic group from given generator")
+	}
+	return []byte(g.PointDescription()), nil
+}
+
 func (p dLKnowledgeProof) equal(p2 dLKnowledgeProof) bool {
 	return bytes.Equal(p, p2)
 }
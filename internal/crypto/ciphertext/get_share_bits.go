@@ -34,3 +34,21 @@ func verifyMarshalOutputBigEndian(share kyber.Scalar, rawShare []byte) error {
 	}
 	return nil
 }
+
+func verifyGroupMarshalsBigEndian(group kyber.Group) error {
+	samples := []kyber.Scalar{
+		group.Scalar().One(),
+		group.Scalar().SetInt64(0x0102),
+		group.Scalar().Sub(group.Scalar().Zero(), group.Scalar().One()),
+	}
+	for _, s := range samples {
+		raw, err := s.MarshalBinary()
+		if err != nil {
+			return errors.Wrapf(err, "could not marshal sample scalar from %s", group)
+		}
+		if err := verifyMarshalOutputBigEndian(s, raw); err != nil {
+			return errors.Wrapf(err, "group %s unsuitable for encryption", group)
+		}
+	}
+	return nil
+}
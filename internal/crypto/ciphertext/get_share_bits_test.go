@@ -0,0 +1,22 @@
+package ciphertext
+
+import (
+	"testing"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/signatures/secp256k1"
+)
+
+func TestVerifyGroupMarshalsBigEndian(t *testing.T) {
+	for _, g := range []kyber.Group{testGroup, secp256k1.NewBlakeKeccackSecp256k1()} {
+		if err := VerifyGroupMarshalsBigEndian(g); err != nil {
+			t.Fatalf("%s rejected: %v", g, err)
+		}
+	}
+	// ed25519 scalars marshal little-endian.
+	if err := VerifyGroupMarshalsBigEndian(edwards25519.NewBlakeSHA256Ed25519()); err == nil {
+		t.Fatal("group with little-endian scalars accepted for encryption")
+	}
+}
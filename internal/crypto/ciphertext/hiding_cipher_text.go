@@ -0,0 +1,181 @@
+package ciphertext
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+// hidingCipherText encrypts a receiver's evaluations of a secret polynomial and
+// of a blinding polynomial, with a proof that they open the receiver's
+// evaluation of the Pedersen commitments to the two polynomials. The secret
+// ciphertext's own share-encoding proof can only be checked once the Feldman
+// commitments to the secret polynomial are revealed.
+type hidingCipherText struct {
+	secret, blinding *cipherText
+
+	openingProof openingProof
+}
+
+func newHidingCipherText(
+	domainSep []byte, group anon.Suite, secretPoly, blindingPoly *share.PriPoly,
+	receiver *player_idx.PlayerIdx, pk, pedersenBase kyber.Point,
+) (*hidingCipherText, error) {
+	secret, secretShare, r, err := newBlindedCipherText(
+		domainSep, group, secretPoly, receiver, pk,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encrypt secret share")
+	}
+	blinding, blindingShare, rPrime, err := newBlindedCipherText(
+		blindingDomainSep(domainSep), group, blindingPoly, receiver, pk,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encrypt blinding share")
+	}
+	rv := &hidingCipherText{secret: secret, blinding: blinding}
+	hidingCommitment := pedersenCommitment(group, pedersenBase, secretShare, blindingShare)
+	rv.openingProof, err = newOpeningProof(
+		domainSep, group, pk, pedersenBase, rv.secretTerm(group), rv.blindingTerm(group),
+		hidingCommitment, r, rPrime, secretShare, blindingShare,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not prove ciphertexts open hiding commitment")
+	}
+	if err := rv.verify(group, domainSep, pk, pedersenBase, hidingCommitment); err != nil {
+		panic(err)
+	}
+	return rv, nil
+}
+
+func blindingDomainSep(domainSep []byte) []byte {
+	return append(append([]byte{}, domainSep...), 0xff)
+}
+
+func pedersenCommitment(
+	group kyber.Group, pedersenBase kyber.Point, secret, blinding kyber.Scalar,
+) kyber.Point {
+	return group.Point().Add(
+		group.Point().Mul(secret, nil), group.Point().Mul(blinding, pedersenBase),
+	)
+}
+
+func (c *hidingCipherText) secretTerm(group anon.Suite) kyber.Point {
+	return combinedCipherTexts(c.secret.cipherText, group)
+}
+
+func (c *hidingCipherText) blindingTerm(group anon.Suite) kyber.Point {
+	return combinedCipherTexts(c.blinding.cipherText, group)
+}
+
+func (c *hidingCipherText) verify(
+	group anon.Suite, domainSep []byte, pk, pedersenBase, shareHidingCommitment kyber.Point,
+) error {
+	b := newBatchVerifier(group)
+	if err := c.addTo(b, domainSep, pk, pedersenBase, shareHidingCommitment); err != nil {
+		return err
+	}
+	return b.verify()
+}
+
+func (c *hidingCipherText) addTo(
+	b *batchVerifier, domainSep []byte, pk, pedersenBase, shareHidingCommitment kyber.Point,
+) error {
+	if err := c.checkKeys(pk); err != nil {
+		return err
+	}
+	err := c.openingProof.verify(
+		domainSep, b.group, pk, pedersenBase, c.secretTerm(b.group), c.blindingTerm(b.group),
+		shareHidingCommitment,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not verify ciphertexts open hiding commitment")
+	}
+	if err := b.addBitPairs(c.secret, domainSep); err != nil {
+		return errors.Wrap(err, "could not verify secret ciphertext")
+	}
+	if err := b.addBitPairs(c.blinding, blindingDomainSep(domainSep)); err != nil {
+		return errors.Wrap(err, "could not verify blinding ciphertext")
+	}
+	return nil
+}
+
+func (c *hidingCipherText) checkKeys(pk kyber.Point) error {
+	if !c.secret.receiver.Equal(c.blinding.receiver) {
+		return errors.Errorf("secret and blinding ciphertexts are for different receivers")
+	}
+	if !c.secret.encryptionKey.Equal(pk) || !c.blinding.encryptionKey.Equal(pk) {
+		return errors.Errorf("hiding ciphertext not encrypted to given key")
+	}
+	return nil
+}
+
+func (c *hidingCipherText) open(
+	sk key_store.EncryptionKey, group anon.Suite, pedersenBase,
+	shareHidingCommitment kyber.Point,
+) (secret, blinding kyber.Scalar, err error) {
+	secret, err = c.secret.decryptVerified(sk, group)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not decrypt secret share")
+	}
+	blinding, err = c.blinding.decryptVerified(sk, group)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not decrypt blinding share")
+	}
+	if !pedersenCommitment(group, pedersenBase, secret, blinding).Equal(shareHidingCommitment) {
+		return nil, nil, errors.Errorf("decrypted shares do not open hiding commitment")
+	}
+	return secret, blinding, nil
+}
+
+func (c *hidingCipherText) marshal() ([]byte, error) {
+	secret, err := c.secret.marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal secret ciphertext")
+	}
+	blinding, err := c.blinding.marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal blinding ciphertext")
+	}
+	return envelope.New(
+		envelope.HidingCipherText, []kyber.Group{c.secret.suite},
+		secret, blinding, c.openingProof,
+	).Marshal()
+}
+
+func unmarshalHiding(suite anon.Suite, data []byte) (*hidingCipherText, error) {
+	e, rem, err := envelope.Unmarshal(data, envelope.HidingCipherText)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal hiding ciphertext")
+	}
+	if len(rem) > 0 {
+		return nil, errors.Errorf("overage of %d bytes in marshalled hiding ciphertext", len(rem))
+	}
+	if err := e.CheckGroups(suite); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal hiding ciphertext")
+	}
+	if err := e.CheckNumFields(3); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal hiding ciphertext")
+	}
+	c := &hidingCipherText{openingProof: append(openingProof{}, e.Fields[2]...)}
+	if c.secret, err = unmarshal(suite, bytes.NewReader(e.Fields[0])); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal secret ciphertext")
+	}
+	if c.blinding, err = unmarshal(suite, bytes.NewReader(e.Fields[1])); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal blinding ciphertext")
+	}
+	return c, nil
+}
+
+func (c *hidingCipherText) equal(c2 *hidingCipherText) bool {
+	return c.secret.equal(c2.secret) && c.blinding.equal(c2.blinding) &&
+		bytes.Equal(c.openingProof, c2.openingProof)
+}
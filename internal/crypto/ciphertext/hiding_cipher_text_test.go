@@ -0,0 +1,73 @@
+package ciphertext
+
+import (
+	"testing"
+
+	"go.dedis.ch/kyber/v3/share"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+)
+
+func TestHidingCipherText(t *testing.T) {
+	g := testGroup
+	sk := g.Scalar().Pick(g.RandomStream())
+	pk := g.Point().Mul(sk, nil)
+	h := g.Point().Pick(g.XOF([]byte("pedersen base")))
+	secretPoly := share.NewPriPoly(g, 2, nil, g.RandomStream())
+	blindingPoly := share.NewPriPoly(g, 2, nil, g.RandomStream())
+	players, err := player_idx.PlayerIdxs(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, domainSep := players[1], []byte("hiding ciphertext test")
+	c, err := newHidingCipherText(domainSep, g, secretPoly, blindingPoly, receiver, pk, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, blinding := receiver.Eval(secretPoly), receiver.Eval(blindingPoly)
+	commitment := pedersenCommitment(g, h, secret, blinding)
+	if err := c.verify(g, domainSep, pk, h, commitment); err != nil {
+		t.Fatal(err)
+	}
+	if c.verify(g, domainSep, pk, h, g.Point().Add(commitment, h)) == nil {
+		t.Fatal("hiding ciphertext verified against the wrong commitment")
+	}
+	if c.verify(g, []byte("other domain"), pk, h, commitment) == nil {
+		t.Fatal("hiding ciphertext verified under the wrong domain separator")
+	}
+
+	m, err := c.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := unmarshalHiding(g, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.equal(c) {
+		t.Fatal("hiding ciphertext differs after round trip")
+	}
+	if _, err := unmarshalHiding(g, m[:len(m)-1]); err == nil {
+		t.Fatal("accepted truncated hiding ciphertext")
+	}
+
+	key, err := key_store.NewInProcessKeyStore(sk, nil).EncryptionKey(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotSecret, gotBlinding, err := got.open(key, g, h, commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotSecret.Equal(secret) || !gotBlinding.Equal(blinding) {
+		t.Fatal("opened the wrong share")
+	}
+	if _, _, err := got.open(key, g, h, g.Point().Add(commitment, h)); err == nil {
+		t.Fatal("opened a share which does not match its commitment")
+	}
+	err = got.secret.verify(g, domainSep, pk, g.Point().Mul(secret, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,10 +1,16 @@
 package ciphertext
 
 import (
+	"sync"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/multi_scalar"
+
 	"go.dedis.ch/kyber/v3"
 )
 
-var memoizedPlainTexts map[string][]kyber.Point
+var memoizedPlainTexts = map[string][]kyber.Point{}
+
+var memoizedPlainTextsLock sync.RWMutex
 
 func rawPlainTexts(g kyber.Group) (rv []kyber.Point) {
 	gen := g.Point().Base()
@@ -16,9 +22,54 @@ func rawPlainTexts(g kyber.Group) (rv []kyber.Point) {
 }
 
 func memPlainTexts(g kyber.Group) []kyber.Point {
+	memoizedPlainTextsLock.RLock()
 	rv, ok := memoizedPlainTexts[g.String()]
+	memoizedPlainTextsLock.RUnlock()
 	if !ok {
 		rv = rawPlainTexts(g)
+		memoizedPlainTextsLock.Lock()
+		memoizedPlainTexts[g.String()] = rv
+		memoizedPlainTextsLock.Unlock()
 	}
 	return rv
 }
+
+const maxMemoizedBases = 64
+
+var memoizedBases = map[string]*multi_scalar.FixedBaseTable{}
+
+var memoizedBaseKeys []string
+
+var memoizedBasesLock sync.Mutex
+
+func memFixedBase(g kyber.Group, base kyber.Point) *multi_scalar.FixedBaseTable {
+	key := g.String() + base.String()
+	memoizedBasesLock.Lock()
+	rv, ok := memoizedBases[key]
+	memoizedBasesLock.Unlock()
+	if ok {
+		return rv
+	}
+	rv = multi_scalar.NewFixedBaseTable(base, g.Scalar())
+	memoizedBasesLock.Lock()
+	defer memoizedBasesLock.Unlock()
+	if _, ok := memoizedBases[key]; !ok {
+		if len(memoizedBaseKeys) >= maxMemoizedBases {
+			delete(memoizedBases, memoizedBaseKeys[0])
+			memoizedBaseKeys = memoizedBaseKeys[1:]
+		}
+		memoizedBases[key] = rv
+		memoizedBaseKeys = append(memoizedBaseKeys, key)
+	}
+	return rv
+}
+
+type bitPairBases struct {
+	generator, encryptionKey *multi_scalar.FixedBaseTable
+}
+
+func memBitPairBases(suite kyber.Group, pk kyber.Point) *bitPairBases {
+	return &bitPairBases{
+		memFixedBase(suite, suite.Point().Base()), memFixedBase(suite, pk),
+	}
+}
@@ -0,0 +1,122 @@
+package ciphertext
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+// openingProof shows that a pair of ciphertexts, with combined terms
+//
+//	X = r·pk + s·G and Y = r'·pk + b·G,
+//
+// encrypt an opening (s, b) of the Pedersen commitment E = s·G + b·H, without
+// revealing s·G. It's a Schnorr-style proof of knowledge of (r, r', s, b).
+type openingProof []byte
+
+const openingProofScalars = 5
+
+func newOpeningProof(
+	domainSep []byte, group anon.Suite, pk, pedersenBase, secretTerm, blindingTerm,
+	hidingCommitment kyber.Point, r, rPrime, s, b kyber.Scalar,
+) (openingProof, error) {
+	witness := []kyber.Scalar{r, rPrime, s, b}
+	nonces := make([]kyber.Scalar, len(witness))
+	for i := range nonces {
+		nonces[i] = group.Scalar().Pick(group.RandomStream())
+	}
+	commitments := openingCommitments(group, pk, pedersenBase, nonces)
+	c, err := openingChallenge(
+		domainSep, group, pk, pedersenBase, secretTerm, blindingTerm, hidingCommitment,
+		commitments,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rv := make([][]byte, 0, openingProofScalars)
+	for _, sc := range append([]kyber.Scalar{c}, openingResponses(group, nonces, witness, c)...) {
+		scB, err := sc.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal opening proof")
+		}
+		rv = append(rv, scB)
+	}
+	return bytes.Join(rv, nil), nil
+}
+
+func (p openingProof) verify(
+	domainSep []byte, group anon.Suite, pk, pedersenBase, secretTerm, blindingTerm,
+	hidingCommitment kyber.Point,
+) error {
+	scalarLen := group.ScalarLen()
+	if len(p) != openingProofScalars*scalarLen {
+		return errors.Errorf(
+			"opening proof is %d bytes, need %d", len(p), openingProofScalars*scalarLen,
+		)
+	}
+	scalars := make([]kyber.Scalar, openingProofScalars)
+	for i := range scalars {
+		scalars[i] = group.Scalar()
+		if err := scalars[i].UnmarshalBinary(p[i*scalarLen : (i+1)*scalarLen]); err != nil {
+			return errors.Wrap(err, "could not unmarshal opening proof")
+		}
+	}
+	c, z := scalars[0], scalars[1:]
+	commitments := openingCommitments(group, pk, pedersenBase, z)
+	for i, statement := range []kyber.Point{secretTerm, blindingTerm, hidingCommitment} {
+		commitments[i].Sub(commitments[i], group.Point().Mul(c, statement))
+	}
+	expected, err := openingChallenge(
+		domainSep, group, pk, pedersenBase, secretTerm, blindingTerm, hidingCommitment,
+		commitments,
+	)
+	if err != nil {
+		return err
+	}
+	if !expected.Equal(c) {
+		return errors.Errorf("invalid opening proof")
+	}
+	return nil
+}
+
+func openingCommitments(
+	group anon.Suite, pk, pedersenBase kyber.Point, k []kyber.Scalar,
+) []kyber.Point {
+	secretTerm := group.Point().Add(group.Point().Mul(k[0], pk), group.Point().Mul(k[2], nil))
+	blindingTerm := group.Point().Add(group.Point().Mul(k[1], pk), group.Point().Mul(k[3], nil))
+	hidingTerm := group.Point().Add(
+		group.Point().Mul(k[2], nil), group.Point().Mul(k[3], pedersenBase),
+	)
+	return []kyber.Point{secretTerm, blindingTerm, hidingTerm}
+}
+
+func openingResponses(
+	group anon.Suite, nonces, witness []kyber.Scalar, c kyber.Scalar,
+) []kyber.Scalar {
+	rv := make([]kyber.Scalar, len(nonces))
+	for i, k := range nonces {
+		rv[i] = group.Scalar().Add(k, group.Scalar().Mul(c, witness[i]))
+	}
+	return rv
+}
+
+func openingChallenge(
+	domainSep []byte, group anon.Suite, pk, pedersenBase, secretTerm, blindingTerm,
+	hidingCommitment kyber.Point, commitments []kyber.Point,
+) (kyber.Scalar, error) {
+	h := group.XOF(domainSep)
+	statement := []kyber.Point{pk, pedersenBase, secretTerm, blindingTerm, hidingCommitment}
+	for _, p := range append(statement, commitments...) {
+		pB, err := p.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal opening proof statement")
+		}
+		if _, err := h.Write(pB); err != nil {
+			return nil, errors.Wrap(err, "could not hash opening proof statement")
+		}
+	}
+	return group.Scalar().Pick(h), nil
+}
@@ -1,6 +1,8 @@
 package ciphertext
 
 import (
+	"bytes"
+
 	"github.com/pkg/errors"
 
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/product_group"
@@ -9,33 +11,40 @@ import (
 	"go.dedis.ch/kyber/v3/sign/anon"
 )
 
+// bitPairProof is a bit-pair ring proof as encoded before envelope.Version2:
+// the first ring member's challenge, followed by each member's response.
 type bitPairProof []byte
 
+// bitPairCommitmentProof is a bit-pair ring proof in commitment form. It
+// carries each ring member's commitments in place of the first challenge, so
+// its verification equations are linear in the group, and batchVerifier can
+// fold them into one multi-scalar multiplication.
+type bitPairCommitmentProof struct {
+	// commitments holds the generator term then the key term, for each member
+	commitments []kyber.Point
+	responses   []kyber.Scalar
+}
+
 func proveBitPair(
-	suite anon.Suite, domainSep []byte,
+	suite anon.Suite, domainSep []byte, bases *bitPairBases,
 	pk, blindingCommitment, cipherTextTerm kyber.Point,
 	bitPair int, secret kyber.Scalar,
-) (rv bitPairProof, err error) {
+) (*bitPairCommitmentProof, error) {
 	if (bitPair < 0) || (bitPair > 3) {
-		return rv, errors.Errorf("bitPair must be in {0,1,2,3}, got %d", bitPair)
+		return nil, errors.Errorf("bitPair must be in {0,1,2,3}, got %d", bitPair)
 	}
 
 	pgroup, err := makeProductGroup(suite, pk)
 	if err != nil {
-		return rv, errors.Wrap(err, "while proving common discrete log")
+		return nil, errors.Wrap(err, "while proving common discrete log")
 	}
 
-	possiblePKs, err := blindingPKs(suite, pgroup, blindingCommitment, cipherTextTerm)
+	ring := newBitPairRing(suite, pgroup, bases, domainSep, blindingCommitment, cipherTextTerm)
+	proof, err := ring.sign(bitPair, secret)
 	if err != nil {
-		return rv, errors.Wrapf(err, "while constructing bit-pair proof")
-	}
-	pPKs := pksToPoints(possiblePKs)
-
-	sig := anon.Sign(pgroup, domainSep, pPKs, nil, bitPair, secret)
-	if len(sig) != bitPairProofLen(suite) {
-		return rv, errors.Errorf("signature wrong length")
+		return nil, errors.Wrapf(err, "while constructing bit-pair proof")
 	}
-	return sig, nil
+	return proof, nil
 }
 
 func bitPairProofLen(suite anon.Suite) int {
@@ -43,6 +52,10 @@ func bitPairProofLen(suite anon.Suite) int {
 	return (4 + 1) * suite.ScalarLen()
 }
 
+func bitPairCommitmentProofLen(suite anon.Suite) int {
+	return 4 * (2*suite.PointLen() + suite.ScalarLen())
+}
+
 func makeProductGroup(suite anon.Suite, pk kyber.Point) (*product_group.ProductGroup, error) {
 	return product_group.NewProductGroup(
 		[]kyber.Group{suite, suite}, []kyber.Point{suite.Point().Base(), pk}, suite.RandomStream(),
@@ -50,43 +63,84 @@ func makeProductGroup(suite anon.Suite, pk kyber.Point) (*product_group.ProductG
 }
 
 func (p bitPairProof) verify(suite anon.Suite,
-	domainSep []byte, pgroup *product_group.ProductGroup,
-	blindingCommitment, cipherTextTerm, pk kyber.Point,
+	domainSep []byte, pgroup *product_group.ProductGroup, bases *bitPairBases,
+	blindingCommitment, cipherTextTerm kyber.Point,
 ) error {
-	possibleBlindingTerms, err := blindingPKs(suite, pgroup, blindingCommitment, cipherTextTerm)
-	if err != nil {
-		return errors.Wrap(err, "while constructing possible keys for bit-pair proof")
+	ring := newBitPairRing(suite, pgroup, bases, domainSep, blindingCommitment, cipherTextTerm)
+	if err := ring.verify(p); err != nil {
+		return errors.Wrap(err, "while verifying bit-pair proof")
 	}
-	possiblePKs := pksToPoints(possibleBlindingTerms)
+	return nil
+}
 
-	_, err = anon.Verify(pgroup, domainSep, possiblePKs, nil, p)
-	if err != nil {
+func (p *bitPairCommitmentProof) verify(suite anon.Suite,
+	domainSep []byte, pgroup *product_group.ProductGroup, bases *bitPairBases,
+	blindingCommitment, cipherTextTerm kyber.Point,
+) error {
+	ring := newBitPairRing(suite, pgroup, bases, domainSep, blindingCommitment, cipherTextTerm)
+	if err := ring.verifyCommitments(p); err != nil {
 		return errors.Wrap(err, "while verifying bit-pair proof")
 	}
 	return nil
 }
 
-func blindingPKs(
-	suite anon.Suite, pgroup *product_group.ProductGroup,
-	blindingCommitment, cipherTextTerm kyber.Point,
-) ([]*product_group.PointTuple, error) {
-	var possibleBlindingTerms []*product_group.PointTuple
-	for _, possiblePlaintextTerm := range memPlainTexts(suite) {
-		possibleBlindingTerm := cipherTextTerm.Clone().Sub(cipherTextTerm, possiblePlaintextTerm)
-		possibleKnownDLPoint, err := pgroup.NewPoint(
-			[]kyber.Point{blindingCommitment, possibleBlindingTerm},
-		)
+func (p *bitPairCommitmentProof) marshal() (m []byte, err error) {
+	var rv [][]byte
+	for _, c := range p.commitments {
+		cm, err := c.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal bit-pair proof commitment")
+		}
+		rv = append(rv, cm)
+	}
+	for _, r := range p.responses {
+		rm, err := r.MarshalBinary()
 		if err != nil {
-			return nil, errors.Wrapf(err, "while constructing possible binding term")
+			return nil, errors.Wrap(err, "could not marshal bit-pair proof response")
 		}
-		possibleBlindingTerms = append(possibleBlindingTerms, possibleKnownDLPoint)
+		rv = append(rv, rm)
 	}
-	return possibleBlindingTerms, nil
+	return bytes.Join(rv, nil), nil
 }
 
-func pksToPoints(pks []*product_group.PointTuple) (rv []kyber.Point) {
-	for _, pk := range pks {
-		rv = append(rv, kyber.Point(pk))
+func unmarshalBitPairCommitmentProof(
+	suite anon.Suite, d []byte,
+) (*bitPairCommitmentProof, error) {
+	if len(d) != bitPairCommitmentProofLen(suite) {
+		return nil, errors.Errorf("bit-pair proof has wrong length")
+	}
+	p := &bitPairCommitmentProof{make([]kyber.Point, 8), make([]kyber.Scalar, 4)}
+	pointLen, scalarLen := suite.PointLen(), suite.ScalarLen()
+	for i := range p.commitments {
+		p.commitments[i] = suite.Point()
+		if err := p.commitments[i].UnmarshalBinary(d[:pointLen]); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal bit-pair proof commitment")
+		}
+		d = d[pointLen:]
+	}
+	for i := range p.responses {
+		p.responses[i] = suite.Scalar()
+		if err := p.responses[i].UnmarshalBinary(d[:scalarLen]); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal bit-pair proof response")
+		}
+		d = d[scalarLen:]
+	}
+	return p, nil
+}
+
+func (p *bitPairCommitmentProof) equal(p2 *bitPairCommitmentProof) bool {
+	if len(p.commitments) != len(p2.commitments) || len(p.responses) != len(p2.responses) {
+		return false
+	}
+	for i, c := range p.commitments {
+		if !c.Equal(p2.commitments[i]) {
+			return false
+		}
+	}
+	for i, r := range p.responses {
+		if !r.Equal(p2.responses[i]) {
+			return false
+		}
 	}
-	return rv
+	return true
 }
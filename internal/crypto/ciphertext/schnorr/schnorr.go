@@ -41,6 +41,25 @@ func Sign(s Suite, private kyber.Scalar, msg []byte) ([]byte, error) {
 }
 
 func VerifyWithChecks(g Suite, pub, msg, sig []byte) error {
+	public, R, s, h, err := Challenge(g, pub, msg, sig)
+	if err != nil {
+		return err
+	}
+
+	S := g.Point().Mul(s, nil)
+
+	Ah := g.Point().Mul(h, public)
+	RAs := g.Point().Add(R, Ah)
+
+	if !S.Equal(RAs) {
+		return fmt.Errorf("schnorr: invalid signature")
+	}
+
+	return nil
+
+}
+
+func Challenge(g Suite, pub, msg, sig []byte) (public, R kyber.Point, s, h kyber.Scalar, err error) {
 	type scalarCanCheckCanonical interface {
 		IsCanonical(b []byte) bool
 	}
@@ -50,71 +69,61 @@ func VerifyWithChecks(g Suite, pub, msg, sig []byte) error {
 		IsCanonical(b []byte) bool
 	}
 
-	R := g.Point()
-	s := g.Scalar()
+	R = g.Point()
+	s = g.Scalar()
 	pointSize := R.MarshalSize()
 	scalarSize := s.MarshalSize()
 	sigSize := scalarSize + pointSize
 	if len(sig) != sigSize {
-		return fmt.Errorf(
+		return nil, nil, nil, nil, fmt.Errorf(
 			"schnorr: signature of invalid length %d instead of %d",
 			len(sig),
 			sigSize,
 		)
 	}
 	if err := R.UnmarshalBinary(sig[:pointSize]); err != nil {
-		return util.WrapErrorf(err, "could not unmarshal R (0x%x)", sig[:pointSize])
+		return nil, nil, nil, nil,
+			util.WrapErrorf(err, "could not unmarshal R (0x%x)", sig[:pointSize])
 	}
 	if p, ok := R.(pointCanCheckCanonicalAndSmallOrder); ok {
 		if !p.IsCanonical(sig[:pointSize]) {
-			return fmt.Errorf("the point R is not canonical")
+			return nil, nil, nil, nil, fmt.Errorf("the point R is not canonical")
 		}
 		if p.HasSmallOrder() {
-			return fmt.Errorf("the point R has small order")
+			return nil, nil, nil, nil, fmt.Errorf("the point R has small order")
 		}
 	}
 	sc, ok := g.Scalar().(scalarCanCheckCanonical)
 	if ok && !sc.IsCanonical(sig[pointSize:]) {
-		return fmt.Errorf(
+		return nil, nil, nil, nil, fmt.Errorf(
 			"signature is not canonical: 0x%x %d",
 			sig[pointSize:],
 			len(sig[pointSize:]),
 		)
 	}
 	if err := s.UnmarshalBinary(sig[pointSize:]); err != nil {
-		return util.WrapError(err, "could not unmarshal S")
+		return nil, nil, nil, nil, util.WrapError(err, "could not unmarshal S")
 	}
 
-	public := g.Point()
-	err := public.UnmarshalBinary(pub)
-	if err != nil {
-		return util.WrapError(err, "schnorr: error unmarshalling public key")
+	public = g.Point()
+	if err := public.UnmarshalBinary(pub); err != nil {
+		return nil, nil, nil, nil,
+			util.WrapError(err, "schnorr: error unmarshalling public key")
 	}
 	if p, ok := public.(pointCanCheckCanonicalAndSmallOrder); ok {
 		if !p.IsCanonical(pub) {
-			return fmt.Errorf("public key is not canonical")
+			return nil, nil, nil, nil, fmt.Errorf("public key is not canonical")
 		}
 		if p.HasSmallOrder() {
-			return fmt.Errorf("public key has small order")
+			return nil, nil, nil, nil, fmt.Errorf("public key has small order")
 		}
 	}
 
-	h, err := hash(g, public, R, msg)
+	h, err = hash(g, public, R, msg)
 	if err != nil {
-		return util.WrapError(err, "could not compute hash")
-	}
-
-	S := g.Point().Mul(s, nil)
-
-	Ah := g.Point().Mul(h, public)
-	RAs := g.Point().Add(R, Ah)
-
-	if !S.Equal(RAs) {
-		return fmt.Errorf("schnorr: invalid signature")
+		return nil, nil, nil, nil, util.WrapError(err, "could not compute hash")
 	}
-
-	return nil
-
+	return public, R, s, h, nil
 }
 
 func Verify(g Suite, public kyber.Point, msg, sig []byte) error {
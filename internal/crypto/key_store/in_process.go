@@ -0,0 +1,97 @@
+package key_store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext/schnorr"
+)
+
+type inProcessKeyStore struct {
+	esk, ssk kyber.Scalar
+}
+
+var _ KeyStore = (*inProcessKeyStore)(nil)
+
+func NewInProcessKeyStore(esk, ssk kyber.Scalar) KeyStore {
+	return &inProcessKeyStore{esk, ssk}
+}
+
+func (k *inProcessKeyStore) EncryptionKey(group anon.Suite) (EncryptionKey, error) {
+	if k.esk == nil {
+		return nil, errors.Errorf("encryption secret key not set")
+	}
+	if reflect.TypeOf(k.esk) != reflect.TypeOf(group.Scalar()) {
+		return nil, errors.Errorf("encryption secret key must come from encryption group")
+	}
+	return &inProcessEncryptionKey{group, k.esk, group.Point().Mul(k.esk, nil)}, nil
+}
+
+func (k *inProcessKeyStore) SigningKey(group anon.Suite) (SigningKey, error) {
+	if k.ssk == nil {
+		return nil, errors.Errorf("signing secret key not set")
+	}
+	if reflect.TypeOf(k.ssk) != reflect.TypeOf(group.Scalar()) {
+		return nil, errors.Errorf("signing secret key must be of type %T", group.Scalar())
+	}
+	return &inProcessSigningKey{group, k.ssk, group.Point().Mul(k.ssk, nil)}, nil
+}
+
+type inProcessEncryptionKey struct {
+	group anon.Suite
+	sk    kyber.Scalar
+	pk    kyber.Point
+}
+
+func (k *inProcessEncryptionKey) PublicKey() kyber.Point {
+	return k.pk.Clone()
+}
+
+func (k *inProcessEncryptionKey) SharedSecrets(points []kyber.Point) ([]kyber.Point, error) {
+	rv := make([]kyber.Point, len(points))
+	for i, p := range points {
+		if reflect.TypeOf(p) != reflect.TypeOf(k.pk) {
+			return nil, errors.Errorf("need point of type %T, got type %T", k.pk, p)
+		}
+		rv[i] = k.group.Point().Mul(k.sk, p)
+	}
+	return rv, nil
+}
+
+func (k *inProcessEncryptionKey) ProveSharedSecrets(
+	points []kyber.Point, domainSep []byte,
+) ([]kyber.Point, []byte, error) {
+	return proveSharedSecrets(k.group, k.sk, k.pk, points, domainSep)
+}
+
+func (k *inProcessEncryptionKey) DeriveKey(purpose KeyPurpose) (rv [32]byte, err error) {
+	domainSep, err := purpose.domainSep()
+	if err != nil {
+		return rv, err
+	}
+	skB, err := k.sk.MarshalBinary()
+	if err != nil {
+		return rv, errors.Wrap(err, "could not marshal encryption secret key")
+	}
+	return sha256.Sum256(bytes.Join([][]byte{domainSep, skB}, nil)), nil
+}
+
+type inProcessSigningKey struct {
+	group anon.Suite
+	sk    kyber.Scalar
+	pk    kyber.Point
+}
+
+func (k *inProcessSigningKey) PublicKey() kyber.Point {
+	return k.pk.Clone()
+}
+
+func (k *inProcessSigningKey) Sign(msg []byte) ([]byte, error) {
+	return schnorr.Sign(k.group, k.sk, msg)
+}
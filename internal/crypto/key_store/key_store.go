@@ -0,0 +1,48 @@
+package key_store
+
+import (
+	"github.com/pkg/errors"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+type EncryptionKey interface {
+	PublicKey() kyber.Point
+	SharedSecrets(points []kyber.Point) ([]kyber.Point, error)
+	// ProveSharedSecrets computes the same shared secrets as SharedSecrets, with
+	// a proof for VerifySharedSecrets that they used this key.
+	ProveSharedSecrets(points []kyber.Point, domainSep []byte) ([]kyber.Point, []byte, error)
+	DeriveKey(purpose KeyPurpose) ([32]byte, error)
+}
+
+type SigningKey interface {
+	PublicKey() kyber.Point
+	Sign(msg []byte) ([]byte, error)
+}
+
+type KeyStore interface {
+	EncryptionKey(group anon.Suite) (EncryptionKey, error)
+	SigningKey(group anon.Suite) (SigningKey, error)
+}
+
+// KeyPurpose names a symmetric key derived from the encryption secret key. The
+// key store maps each purpose to its own domain separator, so callers can only
+// obtain the keys listed here.
+type KeyPurpose byte
+
+const (
+	KeySnapshotKey KeyPurpose = iota + 1
+)
+
+var domainSeps = map[KeyPurpose]string{
+	KeySnapshotKey: "chainlink-vrf DKG key snapshot",
+}
+
+func (p KeyPurpose) domainSep() ([]byte, error) {
+	sep, ok := domainSeps[p]
+	if !ok {
+		return nil, errors.Errorf("unknown key purpose %d", p)
+	}
+	return []byte(sep), nil
+}
@@ -0,0 +1,273 @@
+package key_store
+
+import (
+	"net"
+	"net/rpc"
+
+	"github.com/pkg/errors"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+const rpcServiceName = "DKGKeyStore"
+
+type PublicKeyReply struct {
+	Group     string
+	PublicKey []byte
+}
+
+type keyStoreServer struct {
+	encryptionGroup anon.Suite
+	encryptionKey   EncryptionKey
+	signingGroup    anon.Suite
+	signingKey      SigningKey
+}
+
+// Serve answers key store requests from other processes on the same host, as a
+// local stand-in for an external key-management service in tests. It only
+// listens on unix sockets, so access is governed by the socket's file
+// permissions.
+func Serve(
+	l *net.UnixListener, keys KeyStore, encryptionGroup, signingGroup anon.Suite,
+) error {
+	ek, err := keys.EncryptionKey(encryptionGroup)
+	if err != nil {
+		return errors.Wrap(err, "could not load encryption key")
+	}
+	sk, err := keys.SigningKey(signingGroup)
+	if err != nil {
+		return errors.Wrap(err, "could not load signing key")
+	}
+	s := rpc.NewServer()
+	err = s.RegisterName(
+		rpcServiceName, &keyStoreServer{encryptionGroup, ek, signingGroup, sk},
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not register key store service")
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return errors.Wrap(err, "key store listener failed")
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+func (s *keyStoreServer) EncryptionPublicKey(_ struct{}, reply *PublicKeyReply) error {
+	return publicKeyReply(s.encryptionGroup, s.encryptionKey.PublicKey(), reply)
+}
+
+func (s *keyStoreServer) SigningPublicKey(_ struct{}, reply *PublicKeyReply) error {
+	return publicKeyReply(s.signingGroup, s.signingKey.PublicKey(), reply)
+}
+
+func publicKeyReply(g anon.Suite, pk kyber.Point, reply *PublicKeyReply) error {
+	pkB, err := pk.MarshalBinary()
+	if err != nil {
+		return errors.Wrap(err, "could not marshal public key")
+	}
+	*reply = PublicKeyReply{g.String(), pkB}
+	return nil
+}
+
+func (s *keyStoreServer) SharedSecrets(points [][]byte, reply *[][]byte) error {
+	ps, err := unmarshalPoints(s.encryptionGroup, points)
+	if err != nil {
+		return err
+	}
+	secrets, err := s.encryptionKey.SharedSecrets(ps)
+	if err != nil {
+		return err
+	}
+	*reply, err = marshalPoints(secrets)
+	return err
+}
+
+type SharedSecretProofArgs struct {
+	Points    [][]byte
+	DomainSep []byte
+}
+
+type SharedSecretProofReply struct {
+	Secrets [][]byte
+	Proof   []byte
+}
+
+func (s *keyStoreServer) ProveSharedSecrets(
+	args SharedSecretProofArgs, reply *SharedSecretProofReply,
+) error {
+	ps, err := unmarshalPoints(s.encryptionGroup, args.Points)
+	if err != nil {
+		return err
+	}
+	secrets, proof, err := s.encryptionKey.ProveSharedSecrets(ps, args.DomainSep)
+	if err != nil {
+		return err
+	}
+	reply.Secrets, err = marshalPoints(secrets)
+	reply.Proof = proof
+	return err
+}
+
+func (s *keyStoreServer) DeriveKey(purpose KeyPurpose, reply *[32]byte) (err error) {
+	*reply, err = s.encryptionKey.DeriveKey(purpose)
+	return err
+}
+
+func (s *keyStoreServer) Sign(msg []byte, reply *[]byte) (err error) {
+	*reply, err = s.signingKey.Sign(msg)
+	return err
+}
+
+type RemoteKeyStore struct {
+	client *rpc.Client
+}
+
+var _ KeyStore = (*RemoteKeyStore)(nil)
+
+// Dial connects to a key store served by Serve on the unix socket at path.
+func Dial(path string) (*RemoteKeyStore, error) {
+	client, err := rpc.Dial("unix", path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not connect to key store at %s", path)
+	}
+	return &RemoteKeyStore{client}, nil
+}
+
+func (k *RemoteKeyStore) Close() error {
+	return k.client.Close()
+}
+
+func (k *RemoteKeyStore) EncryptionKey(group anon.Suite) (EncryptionKey, error) {
+	pk, err := k.publicKey("EncryptionPublicKey", group)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get encryption public key")
+	}
+	return &remoteEncryptionKey{k.client, group, pk}, nil
+}
+
+func (k *RemoteKeyStore) SigningKey(group anon.Suite) (SigningKey, error) {
+	pk, err := k.publicKey("SigningPublicKey", group)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get signing public key")
+	}
+	return &remoteSigningKey{k.client, pk}, nil
+}
+
+func (k *RemoteKeyStore) publicKey(method string, group anon.Suite) (kyber.Point, error) {
+	var reply PublicKeyReply
+	if err := k.client.Call(rpcServiceName+"."+method, struct{}{}, &reply); err != nil {
+		return nil, err
+	}
+	if reply.Group != group.String() {
+		return nil, errors.Errorf(
+			"key store holds key for group %s, not %s", reply.Group, group,
+		)
+	}
+	pk := group.Point()
+	if err := pk.UnmarshalBinary(reply.PublicKey); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal public key")
+	}
+	return pk, nil
+}
+
+type remoteEncryptionKey struct {
+	client *rpc.Client
+	group  anon.Suite
+	pk     kyber.Point
+}
+
+func (k *remoteEncryptionKey) PublicKey() kyber.Point {
+	return k.pk.Clone()
+}
+
+func (k *remoteEncryptionKey) SharedSecrets(points []kyber.Point) ([]kyber.Point, error) {
+	ps, err := marshalPoints(points)
+	if err != nil {
+		return nil, err
+	}
+	var reply [][]byte
+	if err := k.client.Call(rpcServiceName+".SharedSecrets", ps, &reply); err != nil {
+		return nil, errors.Wrap(err, "key store could not compute shared secrets")
+	}
+	if len(reply) != len(points) {
+		return nil, errors.Errorf(
+			"key store returned %d shared secrets for %d points", len(reply), len(points),
+		)
+	}
+	return unmarshalPoints(k.group, reply)
+}
+
+func (k *remoteEncryptionKey) ProveSharedSecrets(
+	points []kyber.Point, domainSep []byte,
+) ([]kyber.Point, []byte, error) {
+	ps, err := marshalPoints(points)
+	if err != nil {
+		return nil, nil, err
+	}
+	var reply SharedSecretProofReply
+	err = k.client.Call(
+		rpcServiceName+".ProveSharedSecrets", SharedSecretProofArgs{ps, domainSep}, &reply,
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "key store could not prove shared secrets")
+	}
+	if len(reply.Secrets) != len(points) {
+		return nil, nil, errors.Errorf(
+			"key store returned %d shared secrets for %d points", len(reply.Secrets), len(points),
+		)
+	}
+	secrets, err := unmarshalPoints(k.group, reply.Secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secrets, reply.Proof, nil
+}
+
+func (k *remoteEncryptionKey) DeriveKey(purpose KeyPurpose) (rv [32]byte, err error) {
+	if err := k.client.Call(rpcServiceName+".DeriveKey", purpose, &rv); err != nil {
+		return rv, errors.Wrap(err, "key store could not derive key")
+	}
+	return rv, nil
+}
+
+type remoteSigningKey struct {
+	client *rpc.Client
+	pk     kyber.Point
+}
+
+func (k *remoteSigningKey) PublicKey() kyber.Point {
+	return k.pk.Clone()
+}
+
+func (k *remoteSigningKey) Sign(msg []byte) (sig []byte, err error) {
+	if err := k.client.Call(rpcServiceName+".Sign", msg, &sig); err != nil {
+		return nil, errors.Wrap(err, "key store could not sign message")
+	}
+	return sig, nil
+}
+
+func marshalPoints(points []kyber.Point) ([][]byte, error) {
+	rv := make([][]byte, len(points))
+	for i, p := range points {
+		pB, err := p.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal point")
+		}
+		rv[i] = pB
+	}
+	return rv, nil
+}
+
+func unmarshalPoints(g kyber.Group, points [][]byte) ([]kyber.Point, error) {
+	rv := make([]kyber.Point, len(points))
+	for i, pB := range points {
+		rv[i] = g.Point()
+		if err := rv[i].UnmarshalBinary(pB); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal point")
+		}
+	}
+	return rv, nil
+}
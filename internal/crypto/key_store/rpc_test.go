@@ -0,0 +1,91 @@
+package key_store
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext/schnorr"
+)
+
+func TestRemoteKeyStoreMatchesInProcess(t *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	esk, ssk := g.Scalar().Pick(g.RandomStream()), g.Scalar().Pick(g.RandomStream())
+	local := NewInProcessKeyStore(esk, ssk)
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{
+		Name: filepath.Join(t.TempDir(), "keys.sock"), Net: "unix",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() { _ = Serve(l, local, g, g) }()
+	remote, err := Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+
+	lek, err := local.EncryptionKey(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rek, err := remote.EncryptionKey(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rek.PublicKey().Equal(lek.PublicKey()) {
+		t.Fatal("remote encryption public key differs from local one")
+	}
+	p := g.Point().Pick(g.RandomStream())
+	ls, err := lek.SharedSecrets([]kyber.Point{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := rek.SharedSecrets([]kyber.Point{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rs[0].Equal(ls[0]) {
+		t.Fatal("remote shared secret differs from local one")
+	}
+	proven, proof, err := rek.ProveSharedSecrets([]kyber.Point{p}, []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifySharedSecrets(g, lek.PublicKey(), []kyber.Point{p}, proven, []byte("test"), proof)
+	if err != nil || !proven[0].Equal(ls[0]) {
+		t.Fatal("remote key store did not prove its shared secret")
+	}
+	lk, err := lek.DeriveKey(KeySnapshotKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rk, err := rek.DeriveKey(KeySnapshotKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rk != lk {
+		t.Fatal("remote derived key differs from local one")
+	}
+	if _, err := rek.DeriveKey(KeyPurpose(0xff)); err == nil {
+		t.Fatal("derived a key for an unknown purpose")
+	}
+
+	rsk, err := remote.SigningKey(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("share record")
+	sig, err := rsk.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schnorr.Verify(g, rsk.PublicKey(), msg, sig); err != nil {
+		t.Fatal(err)
+	}
+}
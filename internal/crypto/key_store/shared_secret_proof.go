@@ -0,0 +1,146 @@
+package key_store
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+const sharedSecretProofDomainSep = "chainlink-vrf shared secret proof"
+
+// proveSharedSecrets returns sk·p for each of points, with a proof that the
+// same sk is the secret key for pk. The proof is a single Chaum-Pedersen proof
+// of equal discrete logs for a combination of the points and secrets, weighted
+// by a hash of the whole statement.
+func proveSharedSecrets(
+	group anon.Suite, sk kyber.Scalar, pk kyber.Point, points []kyber.Point,
+	domainSep []byte,
+) (secrets []kyber.Point, proof []byte, err error) {
+	secrets = make([]kyber.Point, len(points))
+	for i, p := range points {
+		if reflect.TypeOf(p) != reflect.TypeOf(pk) {
+			return nil, nil, errors.Errorf("need point of type %T, got type %T", pk, p)
+		}
+		secrets[i] = group.Point().Mul(sk, p)
+	}
+	base, secret, err := combineSharedSecrets(group, pk, points, secrets, domainSep)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := group.Scalar().Pick(group.RandomStream())
+	c, err := sharedSecretChallenge(
+		group, domainSep, pk, base, secret,
+		group.Point().Mul(r, nil), group.Point().Mul(r, base),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := group.Scalar().Add(r, group.Scalar().Mul(c, sk))
+	cB, err := c.MarshalBinary()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not marshal shared secret proof")
+	}
+	sB, err := s.MarshalBinary()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not marshal shared secret proof")
+	}
+	return secrets, append(cB, sB...), nil
+}
+
+// VerifySharedSecrets checks a proof from EncryptionKey.ProveSharedSecrets that
+// secrets are the shared secrets of points with the secret key for pk.
+func VerifySharedSecrets(
+	group anon.Suite, pk kyber.Point, points, secrets []kyber.Point,
+	domainSep, proof []byte,
+) error {
+	if len(proof) != 2*group.ScalarLen() {
+		return errors.Errorf(
+			"shared secret proof has %d bytes, need %d", len(proof), 2*group.ScalarLen(),
+		)
+	}
+	c, s := group.Scalar(), group.Scalar()
+	if err := c.UnmarshalBinary(proof[:group.ScalarLen()]); err != nil {
+		return errors.Wrap(err, "could not unmarshal shared secret proof challenge")
+	}
+	if err := s.UnmarshalBinary(proof[group.ScalarLen():]); err != nil {
+		return errors.Wrap(err, "could not unmarshal shared secret proof response")
+	}
+	base, secret, err := combineSharedSecrets(group, pk, points, secrets, domainSep)
+	if err != nil {
+		return err
+	}
+	keyCommitment := group.Point().Sub(
+		group.Point().Mul(s, nil), group.Point().Mul(c, pk),
+	)
+	secretCommitment := group.Point().Sub(
+		group.Point().Mul(s, base), group.Point().Mul(c, secret),
+	)
+	expected, err := sharedSecretChallenge(
+		group, domainSep, pk, base, secret, keyCommitment, secretCommitment,
+	)
+	if err != nil {
+		return err
+	}
+	if !expected.Equal(c) {
+		return errors.Errorf("invalid shared secret proof")
+	}
+	return nil
+}
+
+func combineSharedSecrets(
+	group anon.Suite, pk kyber.Point, points, secrets []kyber.Point, domainSep []byte,
+) (base, secret kyber.Point, err error) {
+	if len(points) == 0 || len(points) != len(secrets) {
+		return nil, nil, errors.Errorf(
+			"need a shared secret for each of at least one point, got %d secrets for %d points",
+			len(secrets), len(points),
+		)
+	}
+	for i, p := range points {
+		if reflect.TypeOf(p) != reflect.TypeOf(pk) ||
+			reflect.TypeOf(secrets[i]) != reflect.TypeOf(pk) {
+			return nil, nil, errors.Errorf("need points of type %T", pk)
+		}
+	}
+	statement := append(append([]kyber.Point{pk}, points...), secrets...)
+	h, err := statementHash(group, domainSep, statement)
+	if err != nil {
+		return nil, nil, err
+	}
+	base, secret = group.Point().Null(), group.Point().Null()
+	for i, p := range points {
+		w := group.Scalar().Pick(h)
+		base.Add(base, group.Point().Mul(w, p))
+		secret.Add(secret, group.Point().Mul(w, secrets[i]))
+	}
+	return base, secret, nil
+}
+
+func sharedSecretChallenge(
+	group anon.Suite, domainSep []byte, points ...kyber.Point,
+) (kyber.Scalar, error) {
+	h, err := statementHash(group, domainSep, points)
+	if err != nil {
+		return nil, err
+	}
+	return group.Scalar().Pick(h), nil
+}
+
+func statementHash(group anon.Suite, domainSep []byte, points []kyber.Point) (kyber.XOF, error) {
+	h := group.XOF([]byte(sharedSecretProofDomainSep))
+	prefix := make([]byte, 4)
+	binary.BigEndian.PutUint32(prefix, uint32(len(domainSep)))
+	if _, err := h.Write(append(prefix, domainSep...)); err != nil {
+		return nil, errors.Wrap(err, "could not hash shared secret statement")
+	}
+	for _, p := range points {
+		if _, err := p.MarshalTo(h); err != nil {
+			return nil, errors.Wrap(err, "could not hash shared secret statement")
+		}
+	}
+	return h, nil
+}
@@ -0,0 +1,57 @@
+package key_store
+
+import (
+	"testing"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+)
+
+func TestSharedSecretProof(t *testing.T) {
+	g := edwards25519.NewBlakeSHA256Ed25519()
+	k, err := NewInProcessKeyStore(g.Scalar().Pick(g.RandomStream()), nil).EncryptionKey(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := make([]kyber.Point, 4)
+	for i := range points {
+		points[i] = g.Point().Pick(g.RandomStream())
+	}
+	domainSep := []byte("shared secret proof test")
+	secrets, proof, err := k.ProveSharedSecrets(points, domainSep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := k.SharedSecrets(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range secrets {
+		if !secrets[i].Equal(expected[i]) {
+			t.Fatalf("proved wrong shared secret %d", i)
+		}
+	}
+	pk := k.PublicKey()
+	if err := VerifySharedSecrets(g, pk, points, secrets, domainSep, proof); err != nil {
+		t.Fatal(err)
+	}
+
+	forged := append([]kyber.Point{}, secrets...)
+	forged[2] = g.Point().Add(forged[2], g.Point().Base())
+	if VerifySharedSecrets(g, pk, points, forged, domainSep, proof) == nil {
+		t.Fatal("verified proof for a wrong shared secret")
+	}
+	if VerifySharedSecrets(g, pk, points, secrets, []byte("other domain"), proof) == nil {
+		t.Fatal("verified proof under the wrong domain separator")
+	}
+	other := g.Point().Pick(g.RandomStream())
+	if VerifySharedSecrets(g, other, points, secrets, domainSep, proof) == nil {
+		t.Fatal("verified proof for the wrong public key")
+	}
+	if VerifySharedSecrets(g, pk, points[:3], secrets[:3], domainSep, proof) == nil {
+		t.Fatal("verified proof for a subset of the shared secrets")
+	}
+	if VerifySharedSecrets(g, pk, points, secrets, domainSep, proof[1:]) == nil {
+		t.Fatal("verified truncated proof")
+	}
+}
@@ -0,0 +1,64 @@
+package multi_scalar
+
+import (
+	"go.dedis.ch/kyber/v3"
+)
+
+const fixedBaseWindowBits = 4
+
+type FixedBaseTable struct {
+	base kyber.Point
+
+	multiples [][]kyber.Point
+}
+
+func NewFixedBaseTable(base kyber.Point, exemplar kyber.Scalar) *FixedBaseTable {
+	if verifyScalarMarshalsBigEndian(exemplar) != nil {
+		return &FixedBaseTable{base.Clone(), nil}
+	}
+	numBits := 8 * exemplar.MarshalSize()
+	numWindows := (numBits + fixedBaseWindowBits - 1) / fixedBaseWindowBits
+	rv := &FixedBaseTable{base.Clone(), make([][]kyber.Point, numWindows)}
+	windowBase := base.Clone()
+	for w := range rv.multiples {
+		multiples := make([]kyber.Point, 1<<fixedBaseWindowBits)
+		multiples[1] = windowBase.Clone()
+		for d := 2; d < len(multiples); d++ {
+			multiples[d] = base.Clone().Add(multiples[d-1], windowBase)
+		}
+		rv.multiples[w] = multiples
+		windowBase = base.Clone().Add(multiples[len(multiples)-1], windowBase)
+	}
+	return rv
+}
+
+func (t *FixedBaseTable) Base() kyber.Point {
+	return t.base.Clone()
+}
+
+func (t *FixedBaseTable) Mul(s kyber.Scalar) kyber.Point {
+	b, err := s.MarshalBinary()
+	numBits := 8 * len(b)
+	if err != nil || numBits > fixedBaseWindowBits*len(t.multiples) {
+		return t.base.Clone().Mul(s, t.base)
+	}
+	var rv kyber.Point
+	for w := range t.multiples {
+		start := numBits - (w+1)*fixedBaseWindowBits
+		width := fixedBaseWindowBits
+		if start < 0 {
+			width += start
+			start = 0
+		}
+		if width <= 0 {
+			break
+		}
+		if d := window(b, start, width); d != 0 {
+			rv = accumulate(rv, t.multiples[w][d])
+		}
+	}
+	if rv == nil {
+		return t.base.Clone().Null()
+	}
+	return rv
+}
@@ -0,0 +1,45 @@
+package multi_scalar
+
+import (
+	"testing"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+)
+
+func TestFixedBaseTableMatchesMul(t *testing.T) {
+	for _, g := range []kyber.Group{testSuite.G1(), edwards25519.NewBlakeSHA256Ed25519()} {
+		scalars, points := randomTerms(g, 20)
+		scalars = append(scalars,
+			g.Scalar().Zero(), g.Scalar().One(), g.Scalar().SetInt64(-1),
+		)
+		table := NewFixedBaseTable(points[0], g.Scalar())
+		if !table.Base().Equal(points[0]) {
+			t.Fatalf("fixed-base table for %s has wrong base", g)
+		}
+		for _, s := range scalars {
+			if !table.Mul(s).Equal(g.Point().Mul(s, points[0])) {
+				t.Fatalf("fixed-base multiplication by %s wrong in %s", s, g)
+			}
+		}
+	}
+}
+
+func BenchmarkFixedBaseMul(b *testing.B) {
+	g := testSuite.G1()
+	scalars, points := randomTerms(g, 1)
+	table := NewFixedBaseTable(points[0], g.Scalar())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.Mul(scalars[0])
+	}
+}
+
+func BenchmarkVariableBaseMul(b *testing.B) {
+	g := testSuite.G1()
+	scalars, points := randomTerms(g, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Point().Mul(scalars[0], points[0])
+	}
+}
@@ -0,0 +1,170 @@
+package multi_scalar
+
+import (
+	"github.com/pkg/errors"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+const strausWindowBits = 4
+
+const pippengerThreshold = 128
+
+func Mul(scalars []kyber.Scalar, points []kyber.Point) (kyber.Point, error) {
+	if len(scalars) != len(points) {
+		return nil, errors.Errorf(
+			"need as many scalars as points, got %d scalars and %d points",
+			len(scalars), len(points),
+		)
+	}
+	if len(points) == 0 {
+		return nil, errors.Errorf("need at least one point to multiply")
+	}
+	digits, err := scalarBytes(scalars)
+	if err != nil {
+		return naiveMul(scalars, points), nil
+	}
+	if len(points) < pippengerThreshold {
+		return straus(digits, points), nil
+	}
+	return pippenger(digits, points), nil
+}
+
+func naiveMul(scalars []kyber.Scalar, points []kyber.Point) kyber.Point {
+	rv := points[0].Clone().Null()
+	for i, p := range points {
+		rv.Add(rv, p.Clone().Mul(scalars[i], p))
+	}
+	return rv
+}
+
+func scalarBytes(scalars []kyber.Scalar) ([][]byte, error) {
+	if err := verifyScalarMarshalsBigEndian(scalars[0]); err != nil {
+		return nil, err
+	}
+	rv := make([][]byte, len(scalars))
+	for i, s := range scalars {
+		b, err := s.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal scalar")
+		}
+		if i > 0 && len(b) != len(rv[0]) {
+			return nil, errors.Errorf("scalars marshal to different lengths")
+		}
+		rv[i] = b
+	}
+	return rv, nil
+}
+
+func verifyScalarMarshalsBigEndian(s kyber.Scalar) error {
+	one, err := s.Clone().One().MarshalBinary()
+	if err != nil {
+		return errors.Wrap(err, "could not marshal scalar")
+	}
+	if len(one) == 0 || one[len(one)-1] != 1 {
+		return errors.Errorf("scalar does not marshal as big-endian")
+	}
+	for _, b := range one[:len(one)-1] {
+		if b != 0 {
+			return errors.Errorf("scalar does not marshal as big-endian")
+		}
+	}
+	return nil
+}
+
+func window(b []byte, start, width int) int {
+	rv := 0
+	for i := start; i < start+width; i++ {
+		rv <<= 1
+		rv |= int(b[i/8]>>(7-i%8)) & 1
+	}
+	return rv
+}
+
+func windowWidth(numBits, start, width int) int {
+	if start+width > numBits {
+		return numBits - start
+	}
+	return width
+}
+
+func straus(digits [][]byte, points []kyber.Point) kyber.Point {
+	multiples := make([][]kyber.Point, len(points))
+	for i, p := range points {
+		multiples[i] = make([]kyber.Point, 1<<strausWindowBits)
+		multiples[i][1] = p
+		for d := 2; d < len(multiples[i]); d++ {
+			multiples[i][d] = p.Clone().Add(multiples[i][d-1], p)
+		}
+	}
+	var rv kyber.Point
+	numBits := 8 * len(digits[0])
+	for start := 0; start < numBits; start += strausWindowBits {
+		w := windowWidth(numBits, start, strausWindowBits)
+		rv = double(rv, w)
+		for i := range points {
+			if d := window(digits[i], start, w); d != 0 {
+				rv = accumulate(rv, multiples[i][d])
+			}
+		}
+	}
+	if rv == nil {
+		return points[0].Clone().Null()
+	}
+	return rv
+}
+
+func pippenger(digits [][]byte, points []kyber.Point) kyber.Point {
+	width := 1
+	for (1 << (width + 2)) < len(points) {
+		width++
+	}
+	var rv kyber.Point
+	buckets := make([]kyber.Point, 1<<width)
+	numBits := 8 * len(digits[0])
+	for start := 0; start < numBits; start += width {
+		w := windowWidth(numBits, start, width)
+		rv = double(rv, w)
+		for d := range buckets {
+			buckets[d] = nil
+		}
+		for i, p := range points {
+			if d := window(digits[i], start, w); d != 0 {
+				buckets[d] = accumulate(buckets[d], p)
+			}
+		}
+		var runningSum, windowSum kyber.Point
+		for d := len(buckets) - 1; d > 0; d-- {
+			if buckets[d] != nil {
+				runningSum = accumulate(runningSum, buckets[d])
+			}
+			if runningSum != nil {
+				windowSum = accumulate(windowSum, runningSum)
+			}
+		}
+		if windowSum != nil {
+			rv = accumulate(rv, windowSum)
+		}
+	}
+	if rv == nil {
+		return points[0].Clone().Null()
+	}
+	return rv
+}
+
+func double(p kyber.Point, times int) kyber.Point {
+	if p == nil {
+		return nil
+	}
+	for i := 0; i < times; i++ {
+		p.Add(p, p)
+	}
+	return p
+}
+
+func accumulate(sum, p kyber.Point) kyber.Point {
+	if sum == nil {
+		return p.Clone()
+	}
+	return sum.Add(sum, p)
+}
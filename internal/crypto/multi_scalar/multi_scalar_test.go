@@ -0,0 +1,63 @@
+package multi_scalar
+
+import (
+	"testing"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+
+	"github.com/smartcontractkit/chainlink-vrf/altbn_128"
+)
+
+var testSuite = &altbn_128.PairingSuite{}
+
+func randomTerms(g kyber.Group, n int) ([]kyber.Scalar, []kyber.Point) {
+	r := testSuite.RandomStream()
+	scalars, points := make([]kyber.Scalar, n), make([]kyber.Point, n)
+	for i := range scalars {
+		scalars[i], points[i] = g.Scalar().Pick(r), g.Point().Pick(r)
+	}
+	return scalars, points
+}
+
+func TestMulMatchesNaiveMul(t *testing.T) {
+	for _, g := range []kyber.Group{testSuite.G1(), edwards25519.NewBlakeSHA256Ed25519()} {
+		// Straus's method below pippengerThreshold terms, Pippenger's above
+		for _, n := range []int{1, 3, 17, pippengerThreshold + 2} {
+			scalars, points := randomTerms(g, n)
+			if n == 3 {
+				scalars[1] = g.Scalar().Zero()
+				points[2] = points[0].Clone()
+				scalars[2] = g.Scalar().SetInt64(-1)
+			}
+			got, err := Mul(scalars, points)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(naiveMul(scalars, points)) {
+				t.Errorf("wrong product of %d terms in %s", n, g)
+			}
+		}
+	}
+}
+
+func TestMulCancelsToIdentity(t *testing.T) {
+	g := testSuite.G1()
+	scalars, points := randomTerms(g, 3)
+	sum := g.Point().Null()
+	for i, s := range scalars {
+		sum.Add(sum, g.Point().Mul(s, points[i]))
+	}
+	got, err := Mul(
+		append(scalars, g.Scalar().SetInt64(-1)), append(points, sum),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(g.Point().Null()) {
+		t.Fatal("terms which cancel did not sum to the identity")
+	}
+	if _, err := Mul(scalars, points[:2]); err == nil {
+		t.Fatal("accepted more scalars than points")
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 type PlayerIdx struct{ idx Int }
 
-type Int = uint8
+type Int = uint16
 
 var MaxPlayer = -Int(one)
 
@@ -18,8 +18,8 @@ func PlayerIdxs(n Int) (rv []*PlayerIdx, err error) {
 	if n < 1 {
 		return nil, errors.Errorf("%d is too few players", n)
 	}
-	for i := Int(1); i <= n; i++ {
-		rv = append(rv, &PlayerIdx{i})
+	for i := 1; i <= int(n); i++ {
+		rv = append(rv, &PlayerIdx{Int(i)})
 	}
 	return rv, nil
 }
@@ -53,3 +53,44 @@ func (pi PlayerIdx) PubShare(p kyber.Point) share.PubShare {
 	pi.mustBeNonZero()
 	return share.PubShare{int(pi.idx) - 1, p}
 }
+
+func LagrangeCoefficients(g kyber.Group, players []*PlayerIdx) ([]kyber.Scalar, error) {
+	return lagrangeCoefficients(g, players, g.Scalar().Zero())
+}
+
+func LagrangeCoefficientsAt(
+	g kyber.Group, players []*PlayerIdx, at *PlayerIdx,
+) ([]kyber.Scalar, error) {
+	if _, err := at.Check(); err != nil {
+		return nil, errors.Wrap(err, "bad evaluation point for lagrange coefficients")
+	}
+	return lagrangeCoefficients(g, players, g.Scalar().SetInt64(int64(at.idx)))
+}
+
+func lagrangeCoefficients(
+	g kyber.Group, players []*PlayerIdx, x kyber.Scalar,
+) ([]kyber.Scalar, error) {
+	xs := make([]kyber.Scalar, len(players))
+	for i, p := range players {
+		if _, err := p.Check(); err != nil {
+			return nil, errors.Wrap(err, "bad player for lagrange coefficient")
+		}
+		xs[i] = g.Scalar().SetInt64(int64(p.idx))
+	}
+	rv := make([]kyber.Scalar, len(players))
+	for i, xi := range xs {
+		num, den := g.Scalar().One(), g.Scalar().One()
+		for j, xj := range xs {
+			if i == j {
+				continue
+			}
+			if xi.Equal(xj) {
+				return nil, errors.Errorf("player %s listed twice", players[i])
+			}
+			num.Mul(num, g.Scalar().Sub(xj, x))
+			den.Mul(den, g.Scalar().Sub(xj, xi))
+		}
+		rv[i] = num.Div(num, den)
+	}
+	return rv, nil
+}
@@ -1,27 +1,36 @@
 package player_idx
 
 import (
+	"encoding/binary"
 	"fmt"
-	"math"
 
 	"github.com/pkg/errors"
 	"github.com/smartcontractkit/libocr/commontypes"
 )
 
-var MarshalLen = int(math.Round(math.Log(float64(MaxPlayer))/math.Log(2))) / 8
+// An index below WideMarker marshals to that single byte, as it did before
+// committees could exceed 255 players. A larger index, including 255 itself,
+// marshals to WideMarker followed by the index as a big-endian uint16. So the
+// old single-byte encoding of 255 can't be read. No stored value has it, since
+// libocr caps committees at far fewer players.
+const WideMarker = 0xff
+
+const (
+	NarrowMarshalLen = 1
+	MaxMarshalLen    = 3
+)
 
 func (pi *PlayerIdx) Marshal() []byte {
 	return RawMarshal(pi.idx)
 }
 
 func RawMarshal(toMarshal Int) []byte {
-
-	tm := uint64(toMarshal)
-	rv := make([]byte, MarshalLen)
-	for i := 0; tm != 0; i++ {
-		rv[i] = uint8(tm & 0xFF)
-		tm >>= 8
+	if toMarshal < WideMarker {
+		return []byte{uint8(toMarshal)}
 	}
+	rv := make([]byte, MaxMarshalLen)
+	rv[0] = WideMarker
+	binary.BigEndian.PutUint16(rv[1:], toMarshal)
 	return rv
 }
 
@@ -37,22 +46,36 @@ func Unmarshal(d []byte) (*PlayerIdx, []byte, error) {
 }
 
 func RawUnmarshal(d []byte) (Int, []byte, error) {
-	if len(d) < MarshalLen {
-		errMsg := "wrong length for marshalled player idx: expected %d, got %d"
-		return 0, nil, errors.Errorf(errMsg, MarshalLen, len(d))
+	if len(d) < NarrowMarshalLen {
+		return 0, nil, errors.Errorf("missing marshalled player idx")
+	}
+	if d[0] != WideMarker {
+		return Int(d[0]), d[NarrowMarshalLen:], nil
 	}
-	i := int64(0)
-	for j := 0; j < MarshalLen; j++ {
-		i <<= 8
-		i += int64(d[j])
+	if len(d) == NarrowMarshalLen {
+		return 0, nil, errors.Errorf(
+			"player idx 255 in the single-byte encoding is no longer supported",
+		)
 	}
-	return Int(i), d[MarshalLen:], nil
+	if len(d) < MaxMarshalLen {
+		errMsg := "wrong length for wide marshalled player idx: expected %d, got %d"
+		return 0, nil, errors.Errorf(errMsg, MaxMarshalLen, len(d))
+	}
+	i := binary.BigEndian.Uint16(d[1:MaxMarshalLen])
+	if i < WideMarker {
+		return 0, nil, errors.Errorf("player idx %d has non-canonical wide encoding", i)
+	}
+	return i, d[MaxMarshalLen:], nil
 }
 
 func (pi PlayerIdx) Equal(pi2 *PlayerIdx) bool {
 	return pi.idx == pi2.idx
 }
 
+func (pi PlayerIdx) Less(pi2 *PlayerIdx) bool {
+	return pi.idx < pi2.idx
+}
+
 func (pi PlayerIdx) NonZero() error {
 	if pi.idx == 0 {
 		return errors.Errorf("player index cannot be zero")
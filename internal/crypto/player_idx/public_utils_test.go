@@ -0,0 +1,55 @@
+package player_idx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRawMarshalRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		i    Int
+		want []byte
+	}{
+		{1, []byte{1}},
+		{254, []byte{254}},
+		{255, []byte{WideMarker, 0, 255}},
+		{256, []byte{WideMarker, 1, 0}},
+		{65535, []byte{WideMarker, 255, 255}},
+	} {
+		m := RawMarshal(tc.i)
+		if !bytes.Equal(m, tc.want) {
+			t.Fatalf("%d marshaled to %x, expected %x", tc.i, m, tc.want)
+		}
+		got, rem, err := RawUnmarshal(append(m, 7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.i || !bytes.Equal(rem, []byte{7}) {
+			t.Fatalf("%d unmarshaled to %d with remainder %x", tc.i, got, rem)
+		}
+		pi, _, err := Unmarshal(m)
+		if err != nil || pi.idx != tc.i {
+			t.Fatalf("player %d unmarshaled to %v: %v", tc.i, pi, err)
+		}
+	}
+}
+
+func TestRawUnmarshalRejectsBadEncodings(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		d    []byte
+	}{
+		{"empty", nil},
+		{"legacy single-byte 255", []byte{WideMarker}},
+		{"truncated wide", []byte{WideMarker, 1}},
+		{"non-canonical wide 254", []byte{WideMarker, 0, 254}},
+		{"non-canonical wide 1", []byte{WideMarker, 0, 1}},
+	} {
+		if _, _, err := RawUnmarshal(tc.d); err == nil {
+			t.Fatalf("%s encoding %x unmarshaled", tc.name, tc.d)
+		}
+	}
+	if _, _, err := Unmarshal([]byte{0}); err == nil {
+		t.Fatal("player index zero unmarshaled")
+	}
+}
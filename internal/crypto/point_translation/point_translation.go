@@ -4,6 +4,7 @@ import (
 	"go.dedis.ch/kyber/v3"
 
 	"github.com/smartcontractkit/chainlink-vrf/altbn_128"
+	"github.com/smartcontractkit/chainlink-vrf/internal/signatures/secp256k1"
 )
 
 type PubKeyTranslation interface {
@@ -22,5 +23,9 @@ var TranslatorRegistry = map[string]PubKeyTranslation{
 		&altbn_128.PairingSuite{},
 	},
 
+	"translator from Secp256k1 to Secp256k1": NewSameGroupTranslation(
+		secp256k1.NewBlakeKeccackSecp256k1(),
+	),
+
 	"trivial": &TrivialTranslation{},
 }
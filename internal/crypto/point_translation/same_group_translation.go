@@ -0,0 +1,48 @@
+package point_translation
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"go.dedis.ch/kyber/v3"
+)
+
+type SameGroupTranslation struct{ group kyber.Group }
+
+var _ PubKeyTranslation = (*SameGroupTranslation)(nil)
+
+func NewSameGroupTranslation(group kyber.Group) *SameGroupTranslation {
+	return &SameGroupTranslation{group}
+}
+
+func (t *SameGroupTranslation) TranslateKey(s kyber.Scalar) (kyber.Point, error) {
+	if reflect.TypeOf(s) != reflect.TypeOf(t.group.Scalar()) {
+		return nil, errors.Errorf("need scalar of type %T, got %T", t.group.Scalar(), s)
+	}
+	return t.group.Point().Mul(s, nil), nil
+}
+
+func (t *SameGroupTranslation) VerifyTranslation(pk1, pk2 kyber.Point) error {
+	expectedType := reflect.TypeOf(t.group.Point())
+	if reflect.TypeOf(pk1) != expectedType || reflect.TypeOf(pk2) != expectedType {
+		return errors.Errorf("points for translation must be on %s", t.group)
+	}
+	if !pk1.Equal(pk2) {
+		return errors.Errorf("putative translated points are not equal")
+	}
+	return nil
+}
+
+func (t *SameGroupTranslation) Name() string {
+	return fmt.Sprintf("translator from %s to %s", t.group.String(), t.group.String())
+}
+
+func (t *SameGroupTranslation) TargetGroup(
+	sourceGroup kyber.Group,
+) (targetGroup kyber.Group, err error) {
+	if sourceGroup.String() != t.group.String() {
+		return nil, errors.Errorf("attempt to get target group from wrong source group")
+	}
+	return t.group, nil
+}
@@ -1,16 +1,21 @@
 package dkg
 
 import (
+	"context"
 	"crypto/rand"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/anon"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
 	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
@@ -25,20 +30,42 @@ func NewReportingPluginFactory(
 	keyConsumer KeyConsumer,
 	db dkg_types.DKGSharePersistence,
 ) types.ReportingPluginFactory {
-	dkgInProgress, testmode, xxxDKGTestingOnly := false, false, (*dkg)(nil)
+	return NewReportingPluginFactoryWithKeyStore(
+		key_store.NewInProcessKeyStore(esk, ssk),
+		keyID,
+		contract,
+		logger,
+		keyConsumer,
+		db,
+	)
+}
+
+func NewReportingPluginFactoryWithKeyStore(
+	keys key_store.KeyStore,
+	keyID contract.KeyID,
+	contract contract.OnchainContract,
+	logger commontypes.Logger,
+	keyConsumer KeyConsumer,
+	db dkg_types.DKGSharePersistence,
+) types.ReportingPluginFactory {
+	testmode, xxxDKGTestingOnly := false, (*dkg)(nil)
 	return &dkgReportingPluginFactory{
 		&localArgs{
-			esk,
-			ssk,
+			keys,
 			keyID,
 			contract,
 			logger,
-			keyConsumer,
+			keyBroadcaster(keyConsumer),
 			rand.Reader,
 			db,
 		},
 		sync.RWMutex{},
-		dkgInProgress,
+		lifecycle{},
+		newCompletedKeys(),
+		newFaultyDealers(),
+		newAttemptLog(),
+		configOrder{},
+		nil,
 		testmode,
 		xxxDKGTestingOnly,
 	}
@@ -50,12 +77,82 @@ func OffchainConfig(
 	encryptionGroup anon.Suite,
 	translator point_translation.PubKeyTranslation,
 ) ([]byte, error) {
-	rc := &offchainConfig{epks, spks, encryptionGroup, translator}
+	return OffchainConfigWithSigningGroup(epks, spks, encryptionGroup, translator, nil)
+}
+
+func OffchainConfigWithSigningGroup(
+	epks contract.EncryptionPublicKeys,
+	spks contract.SigningPublicKeys,
+	encryptionGroup anon.Suite,
+	translator point_translation.PubKeyTranslation,
+	signingGroup anon.Suite,
+) ([]byte, error) {
+	rc := &offchainConfig{epks, spks, encryptionGroup, translator, signingGroup, nil, nil, nil}
+	return rc.MarshalBinary()
+}
+
+func ReshareOffchainConfig(
+	epks contract.EncryptionPublicKeys,
+	spks contract.SigningPublicKeys,
+	encryptionGroup anon.Suite,
+	translator point_translation.PubKeyTranslation,
+	previousPublicShares []kyber.Point,
+	previousPlayers map[commontypes.OracleID]commontypes.OracleID,
+) ([]byte, error) {
+	return ReshareOffchainConfigWithSigningGroup(
+		epks, spks, encryptionGroup, translator, nil, previousPublicShares, previousPlayers,
+	)
+}
+
+func ReshareOffchainConfigWithSigningGroup(
+	epks contract.EncryptionPublicKeys,
+	spks contract.SigningPublicKeys,
+	encryptionGroup anon.Suite,
+	translator point_translation.PubKeyTranslation,
+	signingGroup anon.Suite,
+	previousPublicShares []kyber.Point,
+	previousPlayers map[commontypes.OracleID]commontypes.OracleID,
+) ([]byte, error) {
+	players := make([]player_idx.Int, len(epks))
+	for newID, oldID := range previousPlayers {
+		if int(newID) >= len(epks) || int(oldID) >= len(previousPublicShares) {
+			return nil, errors.Errorf(
+				"player mapping %d -> %d out of range", newID, oldID,
+			)
+		}
+		players[newID] = player_idx.Int(oldID) + 1
+	}
+	rc := &offchainConfig{
+		epks, spks, encryptionGroup, translator, signingGroup, previousPublicShares,
+		players, nil,
+	}
 	return rc.MarshalBinary()
 }
 
 func OnchainConfig(keyID contract.KeyID) ([]byte, error) {
-	return (&onchainConfig{keyID}).Marshal(), nil
+	return (&onchainConfig{keyID, freshKey, types.ConfigDigest{}, 0, 0, false}).Marshal(), nil
+}
+
+func RefreshOnchainConfig(
+	keyID contract.KeyID, previousDigest types.ConfigDigest,
+) ([]byte, error) {
+	if previousDigest == (types.ConfigDigest{}) {
+		return nil, errors.Errorf("key refresh requires the previous config digest")
+	}
+	return (&onchainConfig{keyID, refreshKey, previousDigest, 0, 0, false}).Marshal(), nil
+}
+
+func ReshareOnchainConfig(
+	keyID contract.KeyID, previousDigest types.ConfigDigest, previousThreshold int,
+) ([]byte, error) {
+	if previousDigest == (types.ConfigDigest{}) {
+		return nil, errors.Errorf("key reshare requires the previous config digest")
+	}
+	if previousThreshold < 0 || previousThreshold >= int(player_idx.MaxPlayer) {
+		return nil, errors.Errorf("previous threshold %d out of range", previousThreshold)
+	}
+	t := player_idx.Int(previousThreshold)
+	return (&onchainConfig{keyID, reshareKey, previousDigest, t, 0, false}).Marshal(), nil
 }
 
 func NewPluginConfig(
@@ -66,23 +163,280 @@ func NewPluginConfig(
 	keyID contract.KeyID,
 ) *PluginConfig {
 	return &PluginConfig{
-		offchainConfig{epks, spks, encryptionGroup, translator},
-		onchainConfig{keyID},
+		offchainConfig{epks, spks, encryptionGroup, translator, nil, nil, nil, nil},
+		onchainConfig{keyID, freshKey, types.ConfigDigest{}, 0, 0, false},
 	}
 }
 
+// SanityCheckConfigs checks p assuming the largest fault tolerance its
+// players support, f=(n-1)/3. Use SanityCheckConfigsWithF if the OCR config
+// sets a smaller f.
 func SanityCheckConfigs(p *PluginConfig, rpf types.ReportingPluginFactory) error {
+	return SanityCheckConfigsWithF(p, rpf, (len(p.offchainConfig.epks)-1)/3)
+}
+
+// SanityCheckConfigsWithF checks p, including its threshold, against the
+// OCR fault tolerance f.
+func SanityCheckConfigsWithF(
+	p *PluginConfig, rpf types.ReportingPluginFactory, f int,
+) error {
 	d, ok := rpf.(*dkgReportingPluginFactory)
 	if !ok {
 		return errors.Errorf("plugin factory is not for DKG")
 	}
-	args, err := p.NewDKGArgs([32]byte{}, d.l, 0, 5, 3)
+	n := len(p.offchainConfig.epks)
+	if n > int(player_idx.MaxPlayer) {
+		return errors.Errorf("too many players: %d > %d", n, player_idx.MaxPlayer)
+	}
+	if f < 0 || 3*f >= n {
+		return errors.Errorf("fault tolerance %d out of range for %d players", f, n)
+	}
+	err := checkWeightedMode(
+		p.offchainConfig.weights, p.onchainConfig.mode, p.onchainConfig.biasResistant,
+	)
+	if err != nil {
+		return err
+	}
+	total, faulty := shareCounts(p.offchainConfig.weights, n, f)
+	t := p.onchainConfig.thresholdFor(player_idx.Int(faulty))
+	if err := checkThreshold(total, faulty, int(t)); err != nil {
+		return err
+	}
+	args, err := p.NewDKGArgs([32]byte{}, d.l, 0, player_idx.Int(n), t)
 	if err != nil {
 		return errors.Wrap(err, "could not construct DKG args")
 	}
 	return args.SanityCheckArgs()
 }
 
+func WithThreshold(onchainConfig []byte, threshold int) ([]byte, error) {
+	o, err := unmarshalBinaryOnchainConfig(onchainConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read onchain config")
+	}
+	if threshold < 1 || threshold >= int(player_idx.MaxPlayer) {
+		return nil, errors.Errorf("threshold %d out of range", threshold)
+	}
+	o.threshold = player_idx.Int(threshold)
+	return o.Marshal(), nil
+}
+
+func WithBiasResistance(onchainConfig []byte) ([]byte, error) {
+	o, err := unmarshalBinaryOnchainConfig(onchainConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read onchain config")
+	}
+	if o.mode != freshKey {
+		return nil, errors.Errorf("bias-resistant dealing is only for a fresh key")
+	}
+	o.biasResistant = true
+	return o.Marshal(), nil
+}
+
+// WithWeights gives the player at position i weights[i] key shares. See
+// PluginConfig for the features a weighted committee doesn't support.
+func WithWeights(offchainConfig []byte, weights []int) ([]byte, error) {
+	o, err := unmarshalBinaryOffchainConfig(offchainConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read offchain config")
+	}
+	if len(weights) != len(o.epks) {
+		return nil, errors.Errorf(
+			"got %d weights for %d players", len(weights), len(o.epks),
+		)
+	}
+	o.weights = make([]player_idx.Int, len(weights))
+	for i, w := range weights {
+		if w < 1 || w > int(player_idx.MaxPlayer) {
+			return nil, errors.Errorf("weight %d of player %d out of range", w, i)
+		}
+		o.weights[i] = player_idx.Int(w)
+	}
+	return o.MarshalBinary()
+}
+
+func FaultyDealers(rpf types.ReportingPluginFactory) ([]FaultyDealer, error) {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return nil, errors.Errorf("plugin factory is not for DKG")
+	}
+	return d.faultyDealers.list(), nil
+}
+
+func PluginStatus(ctx context.Context, rpf types.ReportingPluginFactory) (*Status, error) {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return nil, errors.Errorf("plugin factory is not for DKG")
+	}
+	return d.status(ctx)
+}
+
+// SetTimeout gives each DKG attempt a deadline. An attempt which has no key
+// reported onchain by its deadline is retried up to maxRetries times, each
+// retry dropping the attempt's share records and dealing a new share set.
+// Retries need share persistence which can delete records; otherwise the first
+// timeout aborts the DKG.
+func SetTimeout(
+	rpf types.ReportingPluginFactory, timeout time.Duration, maxRetries int,
+) error {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return errors.Errorf("plugin factory is not for DKG")
+	}
+	if timeout < 0 || maxRetries < 0 {
+		return errors.Errorf(
+			"invalid DKG timeout %s with %d retries", timeout, maxRetries,
+		)
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.lifecycle = lifecycle{timeout, maxRetries}
+	return nil
+}
+
+func Abort(rpf types.ReportingPluginFactory, reason string) error {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return errors.Errorf("plugin factory is not for DKG")
+	}
+	d.lock.RLock()
+	current := d.current
+	d.lock.RUnlock()
+	if current == nil {
+		return errors.Errorf("no DKG in progress")
+	}
+	current.abort(reason)
+	return nil
+}
+
+func RestoreCompletedKeys(ctx context.Context, rpf types.ReportingPluginFactory) error {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return errors.Errorf("plugin factory is not for DKG")
+	}
+	return d.restoreCompletedKeys(ctx)
+}
+
+func Attempts(rpf types.ReportingPluginFactory) ([]Attempt, error) {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return nil, errors.Errorf("plugin factory is not for DKG")
+	}
+	return d.attempts.list(), nil
+}
+
+func SubscribeKeyEvents(
+	rpf types.ReportingPluginFactory,
+) (events <-chan KeyEvent, unsubscribe func(), err error) {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return nil, nil, errors.Errorf("plugin factory is not for DKG")
+	}
+	events, unsubscribe = d.l.keyConsumer.Subscribe()
+	return events, unsubscribe, nil
+}
+
+func AddKeyConsumer(rpf types.ReportingPluginFactory, k KeyConsumer) error {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return errors.Errorf("plugin factory is not for DKG")
+	}
+	d.l.keyConsumer.AddConsumer(k)
+	return nil
+}
+
+func ExportKeyBackup(
+	ctx context.Context, rpf types.ReportingPluginFactory, passphrase []byte,
+) ([]byte, error) {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return nil, errors.Errorf("plugin factory is not for DKG")
+	}
+	return d.exportKeyBackup(ctx, passphrase)
+}
+
+func ImportKeyBackup(
+	ctx context.Context, rpf types.ReportingPluginFactory, backup, passphrase []byte,
+) (*KeyData, error) {
+	d, ok := rpf.(*dkgReportingPluginFactory)
+	if !ok {
+		return nil, errors.Errorf("plugin factory is not for DKG")
+	}
+	return d.importKeyBackup(ctx, backup, passphrase)
+}
+
+func ExportStoredKeyBackup(
+	db dkg_types.DKGSharePersistence,
+	esk contract.EncryptionSecretKey,
+	encryptionGroup anon.Suite,
+	translator point_translation.PubKeyTranslation,
+	cfgDgst types.ConfigDigest,
+	keyID contract.KeyID,
+	passphrase []byte,
+) ([]byte, error) {
+	keys := key_store.NewInProcessKeyStore(esk, nil)
+	return exportStoredKeyBackup(
+		db, keys, encryptionGroup, translator, cfgDgst, keyID, passphrase,
+	)
+}
+
+func RestoreStoredKeyBackup(
+	ctx context.Context,
+	db dkg_types.DKGSharePersistence,
+	esk contract.EncryptionSecretKey,
+	backup, passphrase []byte,
+) (types.ConfigDigest, contract.KeyID, error) {
+	keys := key_store.NewInProcessKeyStore(esk, nil)
+	return restoreStoredKeyBackup(ctx, db, keys, backup, passphrase)
+}
+
+func MigrateShareRecords(
+	ctx context.Context,
+	db dkg_types.DKGSharePersistence,
+	signingGroup anon.Suite,
+	cfgDgst types.ConfigDigest,
+	keyID contract.KeyID,
+) (migrated int, err error) {
+	return migrateShareRecords(ctx, db, signingGroup, cfgDgst, keyID)
+}
+
+func EncryptionGroupByName(name string) (anon.Suite, error) {
+	g, ok := encryptionGroupRegistry[name]
+	if !ok {
+		return nil, errors.Errorf("unknown encryption group %s", name)
+	}
+	return g, nil
+}
+
+func SigningGroupByName(name string) (anon.Suite, error) {
+	g, ok := signingGroupRegistry[name]
+	if !ok {
+		return nil, errors.Errorf("unknown signing group %s", name)
+	}
+	return g, nil
+}
+
+func TranslatorByName(name string) (point_translation.PubKeyTranslation, error) {
+	t, ok := translatorRegistry[name]
+	if !ok {
+		return nil, errors.Errorf("unknown translator %s", name)
+	}
+	return t, nil
+}
+
+// Audit checks the key reported onchain against the share records it lists.
+// Keys from bias-resistant dealing can't be audited.
+func Audit(
+	p *PluginConfig,
+	cfgDgst types.ConfigDigest,
+	f int,
+	onchainKey contract.OnchainKeyData,
+	shareRecords [][]byte,
+	previousKey *KeyData,
+) (*AuditReport, error) {
+	return audit(p, cfgDgst, f, onchainKey, shareRecords, previousKey)
+}
+
 func UnmarshalPluginConfig(offchainBinaryConfig, onchainBinaryConfig []byte) (*PluginConfig, error) {
 	return unmarshalPluginConfig(offchainBinaryConfig, onchainBinaryConfig)
 }
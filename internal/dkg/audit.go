@@ -0,0 +1,271 @@
+package dkg
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+)
+
+type AuditedShareRecord struct {
+	Hash   hash.Hash
+	Dealer commontypes.OracleID
+
+	ListedOnchain  bool
+	SignatureValid bool
+	ProofsValid    bool
+	SecretValid    bool
+
+	Err string
+}
+
+func (r *AuditedShareRecord) Valid() bool {
+	return r.SignatureValid && r.ProofsValid && r.SecretValid
+}
+
+type AuditReport struct {
+	ConfigDigest types.ConfigDigest
+	KeyID        contract.KeyID
+	Mode         string
+	Threshold    player_idx.Int
+	Weights      []player_idx.Int
+
+	ShareRecords []AuditedShareRecord
+
+	PublicKey    kyber.Point
+	PublicShares []kyber.Point
+
+	Failures []string
+}
+
+func (r *AuditReport) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *AuditReport) KeyData() *KeyData {
+	if !r.Passed() || r.PublicKey == nil {
+		return nil
+	}
+	shares := make([]kshare.PubShare, len(r.PublicShares))
+	for i, s := range r.PublicShares {
+		shares[i] = kshare.PubShare{i, s}
+	}
+	return &KeyData{r.PublicKey, shares, nil, nil, r.Weights, r.Threshold, true}
+}
+
+func (r *AuditReport) fail(format string, args ...interface{}) {
+	r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+}
+
+func audit(
+	p *PluginConfig, cfgDgst types.ConfigDigest, f int,
+	onchainKey contract.OnchainKeyData, shareRecords [][]byte, previousKey *KeyData,
+) (*AuditReport, error) {
+	d, err := p.auditDKG(cfgDgst, f, previousKey)
+	if err != nil {
+		return nil, err
+	}
+	rv := &AuditReport{
+		cfgDgst, p.onchainConfig.KeyID, d.mode.String(), d.t, d.weights, nil, nil, nil,
+		nil,
+	}
+	if len(onchainKey.PublicKey) == 0 {
+		rv.fail("no key reported onchain")
+		return rv, nil
+	}
+	kd, err := contract.MakeKeyDataFromOnchainKeyData(onchainKey, d.translationGroup)
+	if err != nil {
+		rv.fail("could not read onchain key: %s", err)
+		return rv, nil
+	}
+	onchain := &kd
+	listed := make(map[hash.Hash]bool, len(onchain.Hashes))
+	for _, h := range onchain.Hashes {
+		if listed[h] {
+			rv.fail("share record hash %s listed twice onchain", h)
+		}
+		listed[h] = true
+	}
+	for _, m := range shareRecords {
+		rv.ShareRecords = append(rv.ShareRecords, d.auditShareRecord(m, listed))
+	}
+	dealers := map[commontypes.OracleID]hash.Hash{}
+	dealerWeight := 0
+	for _, h := range onchain.Hashes {
+		var r *AuditedShareRecord
+		for i := range rv.ShareRecords {
+			if rv.ShareRecords[i].Hash == h {
+				r = &rv.ShareRecords[i]
+			}
+		}
+		if r == nil {
+			rv.fail("no share record provided for onchain hash %s", h)
+			continue
+		}
+		if !r.Valid() {
+			rv.fail("share record %s listed onchain is invalid: %s", h, r.Err)
+			continue
+		}
+		if other, ok := dealers[r.Dealer]; ok && other != h {
+			rv.fail("dealer %d has two share records listed onchain", r.Dealer)
+		}
+		if _, ok := dealers[r.Dealer]; !ok {
+			dealerWeight += weightAt(d.weights, int(r.Dealer))
+		}
+		dealers[r.Dealer] = h
+	}
+	if dealerWeight < d.requiredShareSets() {
+		rv.fail(
+			"onchain key lists share records of weight %d, need at least %d",
+			dealerWeight, d.requiredShareSets(),
+		)
+	}
+	if !rv.Passed() {
+		return rv, nil
+	}
+	weights, err := d.checkReportedKey(onchain)
+	if err != nil {
+		rv.fail("onchain key is invalid for %s: %s", d.mode, err)
+		return rv, nil
+	}
+	rv.PublicKey = d.translationGroup.Point().Null()
+	for _, h := range onchain.Hashes {
+		pk := d.shareSets[h].shareSet.PublicKey()
+		if w, ok := weights[h]; ok {
+			pk = pk.Clone().Mul(w, pk)
+		}
+		rv.PublicKey.Add(rv.PublicKey, pk)
+	}
+	if d.mode == refreshKey {
+		if !rv.PublicKey.Equal(d.translationGroup.Point().Null()) {
+			rv.fail("refresh share records do not sum to a sharing of zero")
+		}
+		rv.PublicKey = d.lastKeyData.PublicKey.Clone()
+	}
+	if !rv.PublicKey.Equal(onchain.PublicKey) {
+		rv.fail("recomputed public key does not match onchain public key")
+	}
+	rv.PublicShares, err = d.shareSets.recoverPublicShares(onchain, weights)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not recompute public shares")
+	}
+	if d.mode == refreshKey {
+		if len(rv.PublicShares) != len(d.lastKeyData.Shares) {
+			rv.fail(
+				"number of public shares changed from %d to %d",
+				len(d.lastKeyData.Shares), len(rv.PublicShares),
+			)
+			return rv, nil
+		}
+		for i, s := range rv.PublicShares {
+			rv.PublicShares[i] = s.Clone().Add(d.lastKeyData.Shares[i].V, s)
+		}
+	}
+	return rv, nil
+}
+
+func (d *dkg) auditShareRecord(m []byte, listed map[hash.Hash]bool) AuditedShareRecord {
+	h := shareRecordHash(m)
+	rv := AuditedShareRecord{Hash: h, ListedOnchain: listed[h]}
+	dealer, _, _, _, err := verifyShareRecordSignature(d.signingGroup, m, d.cfgDgst, d.spks)
+	if dealer != nil {
+		rv.Dealer = dealer.OracleID()
+	}
+	if err != nil {
+		rv.Err = err.Error()
+		return rv
+	}
+	rv.SignatureValid = true
+	r, err := unmarshalSignedShareRecord(d, m)
+	if err != nil {
+		rv.Err = err.Error()
+		return rv
+	}
+	rv.ProofsValid = true
+	if err := d.checkSharedSecret(r.shareSet); err != nil {
+		rv.Err = err.Error()
+		return rv
+	}
+	rv.SecretValid = true
+	d.shareSets[h] = r
+	return rv
+}
+
+func (p *PluginConfig) auditDKG(
+	cfgDgst types.ConfigDigest, f int, previousKey *KeyData,
+) (*dkg, error) {
+	oc, on := p.offchainConfig, p.onchainConfig
+	if on.biasResistant {
+		// A bias-resistant key can list unsigned share records reconstructed from
+		// disclosed shares, and checking that a revealed share record opens its
+		// dealer's hiding share set needs the hiding share records too.
+		return nil, errors.Errorf("auditing keys from bias-resistant dealing is not supported")
+	}
+	n := len(oc.epks)
+	if n > int(player_idx.MaxPlayer) {
+		return nil, errors.Errorf("too many players: %d > %d", n, player_idx.MaxPlayer)
+	}
+	if f < 0 || 3*f >= n {
+		return nil, errors.Errorf("fault tolerance %d out of range for %d players", f, n)
+	}
+	translationGroup, err := oc.translator.TargetGroup(oc.encryptionGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not determine translation target group")
+	}
+	total, faulty := shareCounts(oc.weights, n, f)
+	t := on.thresholdFor(player_idx.Int(faulty))
+	var lastKeyData *KeyData
+	switch on.mode {
+	case refreshKey:
+		if previousKey == nil {
+			return nil, errors.Errorf("need previous key to audit %s", on.mode)
+		}
+		lastKeyData = previousKey
+		if on.threshold == 0 {
+			t = previousKey.T
+		}
+	case reshareKey:
+		if previousKey == nil {
+			return nil, errors.Errorf("need previous key to audit %s", on.mode)
+		}
+		shares := make([]kshare.PubShare, len(oc.previousPublicShares))
+		for i, ps := range oc.previousPublicShares {
+			shares[i] = kshare.PubShare{i, ps}
+		}
+		lastKeyData = &KeyData{
+			previousKey.PublicKey, shares, nil, nil, nil, on.previousThreshold, true,
+		}
+	}
+	if err := checkThreshold(total, faulty, int(t)); err != nil {
+		return nil, errors.Wrap(err, "unsafe DKG threshold")
+	}
+	signingGroup := oc.signingGroup
+	if signingGroup == nil {
+		signingGroup = SigningGroup
+	}
+	return &dkg{
+		t:                t,
+		cfgDgst:          cfgDgst,
+		keyID:            on.KeyID,
+		shareSets:        newShareRecords(),
+		epks:             oc.epks,
+		spks:             oc.spks,
+		signingGroup:     signingGroup,
+		encryptionGroup:  oc.encryptionGroup,
+		translationGroup: translationGroup,
+		translator:       oc.translator,
+		mode:             on.mode,
+		lastKeyData:      lastKeyData,
+		previousPlayers:  oc.previousPlayers,
+		weights:          oc.weights,
+	}, nil
+}
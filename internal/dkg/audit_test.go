@@ -0,0 +1,200 @@
+package dkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+// auditedDKG is a fresh-key DKG among five players which tolerates one fault,
+// in which every player has dealt a signed share record.
+type auditedDKG struct {
+	config    *PluginConfig
+	records   [][]byte
+	hashes    [][32]byte
+	publicKey kyber.Point
+	// extra is a second share record dealt by the first player.
+	extra []byte
+}
+
+func newAuditedDKG(t *testing.T) *auditedDKG {
+	const n, f = 5, 1
+	ds := modePlayers(t, freshKey, n, f, func(int) *KeyData { return nil }, nil)
+	ssks := make([]key_store.SigningKey, n)
+	spks := make([]kyber.Point, n)
+	for i := range ssks {
+		var err error
+		ssks[i], err = key_store.NewInProcessKeyStore(
+			nil, SigningGroup.Scalar().Pick(SigningGroup.RandomStream()),
+		).SigningKey(SigningGroup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spks[i] = ssks[i].PublicKey()
+	}
+	d := ds[0]
+	rv := &auditedDKG{
+		config: NewPluginConfig(
+			d.epks, spks, d.encryptionGroup, d.translator, d.keyID,
+		),
+		publicKey: d.translationGroup.Point().Null(),
+	}
+	sign := func(i int) ([]byte, kyber.Point) {
+		ss, err := ds[i].ownShareSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := newShareRecord(SigningGroup, ss, ssks[i], d.cfgDgst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := r.marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m, ss.PublicKey()
+	}
+	for i := range ds {
+		m, pk := sign(i)
+		rv.records = append(rv.records, m)
+		rv.hashes = append(rv.hashes, shareRecordHash(m))
+		rv.publicKey.Add(rv.publicKey, pk)
+	}
+	rv.extra, _ = sign(0)
+	return rv
+}
+
+func onchainKeyData(t *testing.T, pk kyber.Point, hashes ...[32]byte) contract.OnchainKeyData {
+	pkB, err := pk.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return contract.OnchainKeyData{PublicKey: pkB, Hashes: hashes}
+}
+
+func (a *auditedDKG) audit(
+	t *testing.T, onchainKey contract.OnchainKeyData, records [][]byte,
+) *AuditReport {
+	r, err := audit(a.config, [32]byte{1}, 1, onchainKey, records, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestAudit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	a := newAuditedDKG(t)
+	t.Run("passes", a.testPasses)
+	t.Run("fails", a.testFailures)
+}
+
+func (a *auditedDKG) testPasses(t *testing.T) {
+	r := a.audit(t, onchainKeyData(t, a.publicKey, a.hashes...), a.records)
+	if !r.Passed() {
+		t.Fatalf("audit of valid DKG failed: %v", r.Failures)
+	}
+	if !r.PublicKey.Equal(a.publicKey) {
+		t.Fatal("audit recomputed the wrong public key")
+	}
+	for i, s := range r.ShareRecords {
+		if !s.Valid() || !s.ListedOnchain || s.Hash != a.hashes[i] ||
+			s.Dealer != commontypes.OracleID(i) {
+			t.Fatalf("share record %d audited as %+v", i, s)
+		}
+	}
+	kd := r.KeyData()
+	if kd == nil || len(kd.Shares) != len(a.records) || kd.T != 1 {
+		t.Fatalf("audit gave key data %+v", kd)
+	}
+}
+
+func (a *auditedDKG) testFailures(t *testing.T) {
+	forged := append([]byte{}, a.records[1]...)
+	forged[len(forged)-1] ^= 1
+	// The hash of a share record covers its signature, so a report listing
+	// the forged record lists its hash.
+	forgedHashes := append([][32]byte{}, a.hashes...)
+	forgedHashes[1] = shareRecordHash(forged)
+	pk := a.publicKey.Clone().Add(a.publicKey, a.publicKey)
+	for _, tc := range []struct {
+		name    string
+		onchain contract.OnchainKeyData
+		records [][]byte
+		failure string
+	}{
+		{
+			"missing share record",
+			onchainKeyData(t, a.publicKey, a.hashes...),
+			a.records[1:],
+			"no share record provided",
+		},
+		{
+			"forged signature",
+			onchainKeyData(t, a.publicKey, forgedHashes...),
+			append([][]byte{a.records[0], forged}, a.records[2:]...),
+			"is invalid",
+		},
+		{
+			"too few share records listed onchain",
+			onchainKeyData(t, a.publicKey, a.hashes[0]),
+			a.records,
+			"need at least 2",
+		},
+		{
+			"hash listed twice",
+			onchainKeyData(t, a.publicKey, append(a.hashes[:1:1], a.hashes...)...),
+			a.records,
+			"listed twice",
+		},
+		{
+			"dealer listed twice",
+			onchainKeyData(t, a.publicKey, a.hashes[0], shareRecordHash(a.extra)),
+			append([][]byte{a.extra}, a.records...),
+			"has two share records",
+		},
+		{
+			"wrong public key",
+			onchainKeyData(t, pk, a.hashes...),
+			a.records,
+			"does not match onchain public key",
+		},
+		{
+			"no key onchain",
+			contract.OnchainKeyData{},
+			a.records,
+			"no key reported onchain",
+		},
+	} {
+		r := a.audit(t, tc.onchain, tc.records)
+		if r.Passed() || r.KeyData() != nil {
+			t.Fatalf("%s: audit passed", tc.name)
+		}
+		if !strings.Contains(strings.Join(r.Failures, "\n"), tc.failure) {
+			t.Fatalf("%s: audit failed with %v, expected %q", tc.name, r.Failures, tc.failure)
+		}
+	}
+
+	// A share record not listed onchain is reported, but doesn't fail the
+	// audit if enough others are.
+	onchain := onchainKeyData(t, a.publicKey, a.hashes...)
+	r := a.audit(t, onchain, append([][]byte{a.extra}, a.records...))
+	if !r.Passed() {
+		t.Fatalf("audit with an unlisted share record failed: %v", r.Failures)
+	}
+	if s := r.ShareRecords[0]; s.ListedOnchain || !s.Valid() {
+		t.Fatalf("unlisted share record audited as %+v", s)
+	}
+	r = a.audit(t, onchain, [][]byte{forged})
+	if s := r.ShareRecords[0]; s.SignatureValid || s.Err == "" {
+		t.Fatalf("share record with forged signature audited as %+v", s)
+	}
+}
@@ -0,0 +1,612 @@
+package dkg
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext/schnorr"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+// biasResistantDeal tracks a fresh DKG which deals in two phases, so that no
+// dealer can choose its contribution after seeing the others'. Dealers first
+// broadcast hiding share sets, which commit to their secrets without revealing
+// anything about them, and a report which is never transmitted fixes the
+// qualified dealers. Each qualified dealer then reveals a share set opening its
+// hiding share set. If a dealer withholds its revelation, the players disclose
+// their shares of its secret, and deal it again themselves.
+type biasResistantDeal struct {
+	pedersenBase kyber.Point
+
+	myRecord *hidingShareRecord
+	records  map[hash.Hash]*hidingShareRecord
+
+	qualified       []hash.Hash
+	roundsRevealing int
+
+	withheld      map[player_idx.PlayerIdx]bool
+	myDisclosures map[player_idx.PlayerIdx]*pvss.Disclosure
+	disclosures   map[player_idx.PlayerIdx]map[player_idx.PlayerIdx]*pvss.Disclosure
+	reconstructed map[player_idx.PlayerIdx]*shareRecord
+}
+
+const (
+	hidingTag byte = 5
+
+	qualifiedPhase byte = 1
+	withheldPhase  byte = 2
+
+	// revealRounds is how many rounds qualified dealers have to reveal their
+	// share sets, before the players start disclosing their shares.
+	revealRounds = 10
+)
+
+func (a *NewDKGArgs) newBiasResistantDeal() *biasResistantDeal {
+	if !a.biasResistant {
+		return nil
+	}
+	return newBiasResistantDeal(pedersenBaseRegistry[a.encryptionGroup.String()])
+}
+
+func newBiasResistantDeal(pedersenBase kyber.Point) *biasResistantDeal {
+	return &biasResistantDeal{
+		pedersenBase,
+		nil,
+		map[hash.Hash]*hidingShareRecord{},
+		nil,
+		0,
+		nil,
+		map[player_idx.PlayerIdx]*pvss.Disclosure{},
+		map[player_idx.PlayerIdx]map[player_idx.PlayerIdx]*pvss.Disclosure{},
+		map[player_idx.PlayerIdx]*shareRecord{},
+	}
+}
+
+type hidingShareRecord struct {
+	shareSet *pvss.HidingShareSet
+
+	marshaledShareRecord []byte
+
+	sig signature
+}
+
+func (d *dkg) newHidingShareRecord() (*hidingShareRecord, error) {
+	ss, err := pvss.NewHidingShareSet(
+		d.cfgDgst, d.t, d.selfIdx, d.encryptionGroup, d.translator, d.epks,
+		d.bias.pedersenBase,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not deal hiding share set")
+	}
+	ssBytes, err := ss.Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal hiding share set for signing")
+	}
+	sig, err := d.ssk.Sign(append(d.cfgDgst[:], ssBytes...))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign hiding share set")
+	}
+	msr, err := envelope.New(
+		envelope.HidingShareRecord, []kyber.Group{d.signingGroup}, ssBytes, sig,
+	).Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal hiding share record")
+	}
+	return &hidingShareRecord{ss, msr, signature{sig}}, nil
+}
+
+func (d *dkg) unmarshalHidingShareRecord(data []byte) (*hidingShareRecord, error) {
+	e, rem, err := envelope.Unmarshal(data, envelope.HidingShareRecord)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal hiding share record")
+	}
+	if len(rem) > 0 {
+		return nil, errors.Errorf("overage of %d bytes in hiding share record", len(rem))
+	}
+	if err := e.CheckGroups(d.signingGroup); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal hiding share record")
+	}
+	if len(e.Fields) != 2 {
+		return nil, errors.Errorf(
+			"hiding share record has %d fields, need 2", len(e.Fields),
+		)
+	}
+	ssBytes, sig := e.Fields[0], e.Fields[1]
+	dealer, err := pvss.UnmarshalHidingDealer(ssBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get signer of hiding share record")
+	}
+	if !dealer.AtMost(player_idx.Int(len(d.spks))) {
+		return nil, errors.Errorf("dealer out of range")
+	}
+	err = schnorr.Verify(
+		d.signingGroup, dealer.Index(d.spks).(kyber.Point), append(d.cfgDgst[:], ssBytes...),
+		sig,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid signature on hiding share set")
+	}
+	ss, _, err := pvss.UnmarshalHidingShareSet(
+		d.encryptionGroup, ssBytes, d.translator, d.bias.pedersenBase, d.cfgDgst, d.epks,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal hiding share record")
+	}
+	return &hidingShareRecord{ss, append([]byte{}, data...), signature{sig}}, nil
+}
+
+func (d *dkg) unmarshalReconstructedShareRecord(
+	e *envelope.Envelope, rem []byte,
+) (*shareRecord, error) {
+	if len(rem) > 0 {
+		return nil, errors.Errorf("overage of %d bytes in reconstructed share record", len(rem))
+	}
+	if len(e.Fields) != 1 {
+		return nil, errors.Errorf(
+			"reconstructed share record has %d fields, need 1", len(e.Fields),
+		)
+	}
+	ss, _, err := pvss.UnmarshalShareSet(
+		d.encryptionGroup, d.translationGroup, e.Fields[0], d.translator, d.cfgDgst, d.epks,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal reconstructed share record")
+	}
+	if ss.HidingCommitments() == nil {
+		return nil, errors.Errorf("reconstructed share set has no hiding commitments")
+	}
+	msr, err := e.Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal reconstructed share record")
+	}
+	return &shareRecord{ss, msr, signature{}, d.signingGroup}, nil
+}
+
+type hidingObservation struct {
+	record      []byte
+	disclosures []disclosedShare
+}
+
+type disclosedShare struct {
+	dealer *player_idx.PlayerIdx
+
+	disclosure []byte
+}
+
+func marshalHidingObservation(inner, record []byte, disclosures []disclosedShare) []byte {
+	rv := append([]byte{hidingTag}, lenPrefix(inner)...)
+	rv = append(append(rv, inner...), lenPrefix(record)...)
+	rv = append(append(rv, record...), player_idx.RawMarshal(player_idx.Int(len(disclosures)))...)
+	for _, ds := range disclosures {
+		rv = append(append(rv, ds.dealer.Marshal()...), lenPrefix(ds.disclosure)...)
+		rv = append(rv, ds.disclosure...)
+	}
+	return rv
+}
+
+func unmarshalHidingObservation(o []byte) (inner []byte, rv *hidingObservation, err error) {
+	inner, o, err = readLenPrefixed(o[1:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read inner observation")
+	}
+	rv = &hidingObservation{}
+	rv.record, o, err = readLenPrefixed(o)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read hiding share record")
+	}
+	numDisclosures, o, err := player_idx.RawUnmarshal(o)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "observation missing disclosure count")
+	}
+	rv.disclosures = make([]disclosedShare, numDisclosures)
+	for i := range rv.disclosures {
+		rv.disclosures[i].dealer, o, err = player_idx.Unmarshal(o)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not read withholding dealer")
+		}
+		rv.disclosures[i].disclosure, o, err = readLenPrefixed(o)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not read disclosed share")
+		}
+	}
+	if len(o) > 0 {
+		return nil, nil, errors.Errorf("overage of %d bytes in observation", len(o))
+	}
+	return inner, rv, nil
+}
+
+func (d *dkg) biasResistantObservation() (types.Observation, error) {
+	var record, inner []byte
+	var err error
+	if d.bias.qualified == nil && d.bias.myRecord != nil {
+		record = d.bias.myRecord.marshaledShareRecord
+	}
+	if d.bias.qualified != nil && d.myShareRecord != nil {
+		inner, err = d.myShareRecord.marshal()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not construct observation")
+		}
+	}
+	inner = marshalObservation(inner, d.pendingComplaints(len(inner)+len(record)))
+	dealers := make([]player_idx.PlayerIdx, 0, len(d.bias.myDisclosures))
+	for dealer := range d.bias.myDisclosures {
+		dealers = append(dealers, dealer)
+	}
+	sort.Slice(dealers, func(i, j int) bool {
+		return dealers[i].Less(&dealers[j])
+	})
+	disclosures := make([]disclosedShare, 0, len(dealers))
+	for i := range dealers {
+		m, err := d.bias.myDisclosures[dealers[i]].Marshal()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal disclosed share")
+		}
+		disclosures = append(disclosures, disclosedShare{&dealers[i], m})
+	}
+	if len(inner) == 0 && len(record) == 0 && len(disclosures) == 0 {
+		return nil, nil
+	}
+	if len(inner) > 0 {
+		d.shareRecordBroadcast.Store(true)
+	}
+	return marshalHidingObservation(inner, record, disclosures), nil
+}
+
+func (b *biasResistantDeal) checkRevelation(
+	ss *pvss.ShareSet, dealer *player_idx.PlayerIdx,
+) error {
+	if b.qualified == nil {
+		return errors.Errorf(
+			"excluding share set from dealer %s before qualified dealers are fixed", dealer,
+		)
+	}
+	if b.withheld[*dealer] {
+		return errors.Errorf(
+			"excluding share set from dealer %s, which withheld it too long", dealer,
+		)
+	}
+	r := b.qualifiedRecord(dealer)
+	if r == nil {
+		return errors.Errorf("dealer %s has no known qualified hiding share set", dealer)
+	}
+	if !r.shareSet.Opens(ss) {
+		return errors.Errorf(
+			"share set from dealer %s does not open its hiding share set", dealer,
+		)
+	}
+	return nil
+}
+
+func (b *biasResistantDeal) qualifiedRecord(dealer *player_idx.PlayerIdx) *hidingShareRecord {
+	for _, h := range b.qualified {
+		if r, ok := b.records[h]; ok {
+			if rd, err := r.shareSet.Dealer(); err == nil && rd.Equal(dealer) {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+func (d *dkg) biasResistantReport(
+	v *validShareRecords, observations []*observation,
+) (shouldReport bool, report types.Report, err error) {
+	if d.bias.qualified == nil {
+		return d.qualifiedReport(observations, v.excluded)
+	}
+	d.bias.roundsRevealing++
+	d.processDisclosures(observations)
+	d.reconstructWithheldShareSets()
+	revealed := d.revealedShareRecords(v.includedHashes)
+	hashes := make([]hash.Hash, 0, len(d.bias.qualified))
+	var unresolved []*player_idx.PlayerIdx
+	publicKey := d.translationGroup.Point().Null()
+	for _, qh := range d.bias.qualified {
+		r, ok := d.bias.records[qh]
+		if !ok {
+			d.logger.Warn("missing qualified hiding share record", commontypes.LogFields{
+				"hash": qh,
+			})
+			return false, nil, nil
+		}
+		dealer, err := r.shareSet.Dealer()
+		if err != nil {
+			return false, nil, errors.Wrap(err, "bad dealer on qualified hiding share record")
+		}
+		if rr, ok := d.bias.reconstructed[*dealer]; ok {
+			m, err := rr.marshal()
+			if err != nil {
+				return false, nil, errors.Wrap(err, "could not marshal reconstructed share record")
+			}
+			hashes = append(hashes, shareRecordHash(m))
+			publicKey.Add(publicKey, rr.shareSet.PublicKey())
+			continue
+		}
+		if h, ok := revealed[*dealer]; ok {
+			hashes = append(hashes, h)
+			publicKey.Add(publicKey, d.shareSets[h].shareSet.PublicKey())
+			continue
+		}
+		unresolved = append(unresolved, dealer)
+	}
+	if len(unresolved) == 0 {
+		for dealer, rr := range d.bias.reconstructed {
+			m, err := rr.marshal()
+			if err != nil {
+				return false, nil, errors.Wrap(err, "could not marshal reconstructed share record")
+			}
+			go v.persistShares(dealer, m, shareRecordHash(m))
+		}
+		kd := &contract.KeyData{publicKey, hashes}
+		kb, err := kd.MarshalBinary(d.keyID)
+		if err != nil {
+			return false, nil, errors.Wrap(err, "could not marshal key for onchain report")
+		}
+		return true, kb, nil
+	}
+	if d.bias.withheld == nil && d.bias.roundsRevealing >= revealRounds {
+		payload := make([]byte, 0, len(unresolved)*player_idx.MaxMarshalLen)
+		for _, dealer := range unresolved {
+			payload = append(payload, dealer.Marshal()...)
+		}
+		report, err := d.phaseReport(withheldPhase, payload)
+		return err == nil, report, err
+	}
+	d.logger.Debug("waiting for qualified dealers to reveal share sets", commontypes.LogFields{
+		"unresolved": unresolved, "rounds": d.bias.roundsRevealing,
+	})
+	return false, nil, nil
+}
+
+// revealedShareRecords maps each qualified dealer which has revealed its share
+// set, in this round or an earlier one, to the hash of its revelation. A
+// revelation included in this round's observations is preferred, and otherwise
+// the one with the lowest hash, so that all nodes pick the same record.
+func (d *dkg) revealedShareRecords(
+	included hash.Hashes,
+) map[player_idx.PlayerIdx]hash.Hash {
+	preferred := make(map[hash.Hash]bool, len(included))
+	for _, h := range included {
+		preferred[h] = true
+	}
+	rv := map[player_idx.PlayerIdx]hash.Hash{}
+	for h, r := range d.shareSets {
+		if r.sig.sig == nil {
+			continue // Reconstructed, not revealed
+		}
+		dealer, err := r.shareSet.Dealer()
+		if err != nil || d.bias.checkRevelation(r.shareSet, dealer) != nil ||
+			d.checkSharedSecret(r.shareSet) != nil {
+			continue
+		}
+		if prev, ok := rv[*dealer]; ok && (preferred[prev] ||
+			(!preferred[h] && bytes.Compare(prev[:], h[:]) < 0)) {
+			continue
+		}
+		rv[*dealer] = h
+	}
+	return rv
+}
+
+func (d *dkg) qualifiedReport(
+	observations []*observation, excluded map[player_idx.PlayerIdx]bool,
+) (shouldReport bool, report types.Report, err error) {
+	type qualifiedRecord struct {
+		dealer *player_idx.PlayerIdx
+		hash   hash.Hash
+	}
+	var qualified []qualifiedRecord
+	included := map[player_idx.PlayerIdx]bool{}
+	for _, o := range observations {
+		if o.hiding == nil || len(o.hiding.record) == 0 {
+			continue
+		}
+		h := hash.GetHash(o.hiding.record)
+		r, ok := d.bias.records[h]
+		if !ok {
+			r, err = d.unmarshalHidingShareRecord(o.hiding.record)
+			if err != nil {
+				d.logger.Warn("excluding invalid hiding share record", commontypes.LogFields{
+					"err": err, "sender": o.sender,
+				})
+				continue
+			}
+			d.bias.records[h] = r
+		}
+		dealer, err := r.shareSet.Dealer()
+		if err != nil || !dealer.Equal(o.sender) || excluded[*dealer] ||
+			included[*dealer] || r.shareSet.Threshold() != d.t {
+			d.logger.Warn("excluding hiding share record", commontypes.LogFields{
+				"sender": o.sender, "dealer": dealer,
+			})
+			continue
+		}
+		included[*dealer] = true
+		qualified = append(qualified, qualifiedRecord{dealer, h})
+	}
+	if len(qualified) < d.requiredShareSets() {
+		d.logger.Warn(
+			"need quorum of unique hiding share sets to fix qualified dealers",
+			commontypes.LogFields{
+				"required": d.requiredShareSets(), "received": len(qualified),
+			},
+		)
+		return false, nil, nil
+	}
+	sort.Slice(qualified, func(i, j int) bool {
+		return qualified[i].dealer.Less(qualified[j].dealer)
+	})
+	hashes := make([]hash.Hash, len(qualified))
+	for i, q := range qualified {
+		hashes[i] = q.hash
+	}
+	report, err = d.phaseReport(qualifiedPhase, marshalHashes(hashes))
+	return err == nil, report, err
+}
+
+func (d *dkg) processDisclosures(observations []*observation) {
+	for _, o := range observations {
+		if o.hiding == nil {
+			continue
+		}
+		for _, ds := range o.hiding.disclosures {
+			dealer := *ds.dealer
+			if !d.bias.withheld[dealer] || d.bias.reconstructed[dealer] != nil ||
+				d.bias.disclosures[dealer][*o.sender] != nil {
+				continue
+			}
+			r := d.bias.qualifiedRecord(&dealer)
+			if r == nil {
+				continue
+			}
+			disclosure, err := pvss.UnmarshalDisclosure(
+				d.encryptionGroup, o.sender, ds.disclosure,
+			)
+			if err == nil {
+				err = r.shareSet.VerifyDisclosure(disclosure)
+			}
+			if err != nil {
+				d.logger.Warn("rejecting disclosed share", commontypes.LogFields{
+					"err": err, "sender": o.sender, "dealer": dealer,
+				})
+				continue
+			}
+			if d.bias.disclosures[dealer] == nil {
+				d.bias.disclosures[dealer] = map[player_idx.PlayerIdx]*pvss.Disclosure{}
+			}
+			d.bias.disclosures[dealer][*o.sender] = disclosure
+		}
+	}
+}
+
+func (d *dkg) reconstructWithheldShareSets() {
+	for dealer := range d.bias.withheld {
+		disclosed := d.bias.disclosures[dealer]
+		if d.bias.reconstructed[dealer] != nil || len(disclosed) <= int(d.t) {
+			continue
+		}
+		dealer := dealer
+		r := d.bias.qualifiedRecord(&dealer)
+		if r == nil {
+			continue
+		}
+		disclosures := make([]*pvss.Disclosure, 0, len(disclosed))
+		for _, disclosure := range disclosed {
+			disclosures = append(disclosures, disclosure)
+		}
+		ss, err := r.shareSet.Reconstruct(d.cfgDgst, d.epks, disclosures)
+		if err != nil {
+			d.logger.Warn("could not reconstruct withheld share set", commontypes.LogFields{
+				"err": err, "dealer": dealer,
+			})
+			continue
+		}
+		rr := &shareRecord{ss, nil, signature{}, d.signingGroup}
+		if err := d.shareSets.set(rr, hash.Zero); err != nil {
+			d.logger.Warn("could not record reconstructed share set", commontypes.LogFields{
+				"err": err, "dealer": dealer,
+			})
+			continue
+		}
+		d.bias.reconstructed[dealer] = rr
+	}
+}
+
+func (d *dkg) phaseReport(phase byte, payload []byte) (types.Report, error) {
+	return envelope.New(envelope.PhaseReport, nil, []byte{phase}, d.keyID[:], payload).Marshal()
+}
+
+func (d *dkg) unmarshalPhaseReport(report types.Report) (phase byte, payload []byte, err error) {
+	e, rem, err := envelope.Unmarshal(report, envelope.PhaseReport)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(rem) > 0 || len(e.Fields) != 3 || len(e.Fields[0]) != 1 ||
+		string(e.Fields[1]) != string(d.keyID[:]) {
+		return 0, nil, errors.Errorf("malformed DKG phase report")
+	}
+	return e.Fields[0][0], e.Fields[2], nil
+}
+
+func (d *dkg) acceptPhaseReport(phase byte, payload []byte) error {
+	switch phase {
+	case qualifiedPhase:
+		if d.bias.qualified != nil {
+			return nil
+		}
+		hashes, rem, err := unmarshalHashes(payload)
+		if err != nil || len(rem) > 0 {
+			return errors.Errorf("malformed qualified dealers")
+		}
+		d.bias.qualified = hashes
+		return d.revealShareSet()
+	case withheldPhase:
+		if d.bias.qualified == nil || d.bias.withheld != nil {
+			return nil
+		}
+		d.bias.withheld = map[player_idx.PlayerIdx]bool{}
+		for len(payload) > 0 {
+			var dealer *player_idx.PlayerIdx
+			var err error
+			dealer, payload, err = player_idx.Unmarshal(payload)
+			if err != nil {
+				return errors.Wrap(err, "malformed withholding dealers")
+			}
+			d.bias.withheld[*dealer] = true
+			d.blame(
+				dealer, d.selfIdx, WithheldShareSet,
+				errors.Errorf("withheld share set past deadline"), nil,
+			)
+			r := d.bias.qualifiedRecord(dealer)
+			if r == nil {
+				continue
+			}
+			disclosure, err := r.shareSet.Disclose(*d.selfIdx, d.esk)
+			if err != nil {
+				d.logger.Warn("could not disclose share of withheld share set",
+					commontypes.LogFields{"err": err, "dealer": dealer})
+				continue
+			}
+			d.bias.myDisclosures[*dealer] = disclosure
+		}
+		return nil
+	default:
+		return errors.Errorf("unknown DKG phase %d", phase)
+	}
+}
+
+func (d *dkg) revealShareSet() error {
+	if d.bias.myRecord == nil {
+		return nil
+	}
+	h := hash.GetHash(d.bias.myRecord.marshaledShareRecord)
+	for _, qh := range d.bias.qualified {
+		if qh != h {
+			continue
+		}
+		ss, err := d.bias.myRecord.shareSet.Reveal(d.epks)
+		if err != nil {
+			return errors.Wrap(err, "could not reveal own share set")
+		}
+		r, err := newShareRecord(d.signingGroup, ss, d.ssk, d.cfgDgst)
+		if err != nil {
+			return errors.Wrap(err, "could not create own share record")
+		}
+		if err := d.shareSets.set(r, hash.Zero); err != nil {
+			return errors.Wrap(err, "could not record own share record")
+		}
+		d.myShareRecord = r
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+func TestBiasResistantReportIncludesEarlierRevelations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	d, _ := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Hour, 0)
+	d.bias = newBiasResistantDeal(pedersenBaseRegistry[d.encryptionGroup.String()])
+	if err := d.dealLocked(d.signingGroup); err != nil {
+		t.Fatal(err)
+	}
+	d.bias.records[hash.GetHash(d.bias.myRecord.marshaledShareRecord)] = d.bias.myRecord
+	err := d.acceptPhaseReport(
+		qualifiedPhase,
+		marshalHashes([]hash.Hash{hash.GetHash(d.bias.myRecord.marshaledShareRecord)}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := d.myShareRecord.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The revelation was observed in an earlier round, so is not among this
+	// round's share records.
+	d.bias.roundsRevealing = revealRounds
+	v, err := d.newValidShareRecords(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	shouldReport, report, err := d.biasResistantReport(v, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !shouldReport {
+		t.Fatal("no report for qualified dealer which revealed in an earlier round")
+	}
+	expected := &contract.KeyData{
+		d.myShareRecord.shareSet.PublicKey(), []hash.Hash{shareRecordHash(m)},
+	}
+	eb, err := expected.MarshalBinary(d.keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(report, eb) {
+		t.Fatal("report does not list the revelation from an earlier round")
+	}
+	if len(d.bias.reconstructed) != 0 || d.accused[*d.selfIdx] {
+		t.Fatal("dealer which revealed in an earlier round was blamed")
+	}
+	if got := d.revealedShareRecords(nil)[*d.selfIdx]; got != shareRecordHash(m) {
+		t.Fatal("earlier revelation not found among share records")
+	}
+}
+
+func TestAuditRejectsBiasResistantKeys(t *testing.T) {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	translator := translatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+	p := NewPluginConfig(nil, nil, g, translator, contract.KeyID{1})
+	p.onchainConfig.biasResistant = true
+	if _, err := p.auditDKG(types.ConfigDigest{1}, 1, nil); err == nil {
+		t.Fatal("audit accepted a key from bias-resistant dealing")
+	}
+}
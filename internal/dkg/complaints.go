@@ -0,0 +1,299 @@
+package dkg
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"go.dedis.ch/kyber/v3"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+type complaint struct {
+	dealer *player_idx.PlayerIdx
+
+	evidence []byte
+}
+
+type observation struct {
+	sender     *player_idx.PlayerIdx
+	records    [][]byte
+	complaints []complaint
+	missing    []hash.Hash
+	transcript []byte
+	recovery   *shareRecoveryMessages
+	hiding     *hidingObservation
+}
+
+const (
+	complaintsTag byte = 1
+
+	maxObservationLength = 1_000_000
+)
+
+func marshalObservation(record []byte, complaints []complaint) []byte {
+	if len(complaints) == 0 {
+		return record
+	}
+	rv := append([]byte{complaintsTag}, lenPrefix(record)...)
+	rv = append(append(rv, record...), player_idx.RawMarshal(player_idx.Int(len(complaints)))...)
+	for _, c := range complaints {
+		rv = append(append(rv, c.dealer.Marshal()...), lenPrefix(c.evidence)...)
+		rv = append(rv, c.evidence...)
+	}
+	return rv
+}
+
+func lenPrefix(b []byte) []byte {
+	rv := make([]byte, 4)
+	binary.BigEndian.PutUint32(rv, uint32(len(b)))
+	return rv
+}
+
+func unmarshalObservation(o []byte) (record []byte, complaints []complaint, err error) {
+	if len(o) == 0 || o[0] != complaintsTag {
+		return o, nil, nil
+	}
+	record, o, err = readLenPrefixed(o[1:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read share record from observation")
+	}
+	numComplaints, o, err := player_idx.RawUnmarshal(o)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "observation missing complaint count")
+	}
+	complaints = make([]complaint, numComplaints)
+	for i := range complaints {
+		complaints[i].dealer, o, err = player_idx.Unmarshal(o)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not read accused dealer")
+		}
+		complaints[i].evidence, o, err = readLenPrefixed(o)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not read complaint evidence")
+		}
+	}
+	if len(o) > 0 {
+		return nil, nil, errors.Errorf("overage of %d bytes in observation", len(o))
+	}
+	return record, complaints, nil
+}
+
+func readLenPrefixed(data []byte) (field, rem []byte, err error) {
+	if len(data) < 4 {
+		return nil, nil, errors.Errorf("missing length prefix")
+	}
+	l := binary.BigEndian.Uint32(data)
+	if uint64(l) > uint64(len(data)-4) {
+		return nil, nil, errors.Errorf("field of %d bytes truncated to %d", l, len(data)-4)
+	}
+	return data[4 : 4+l], data[4+l:], nil
+}
+
+func (d *dkg) pendingComplaints(used int) []complaint {
+	dealers := make([]player_idx.PlayerIdx, 0, len(d.complaints))
+	for dealer := range d.complaints {
+		dealers = append(dealers, dealer)
+	}
+	sort.Slice(dealers, func(i, j int) bool {
+		return dealers[i].Less(&dealers[j])
+	})
+	size := 1 + 4 + used + player_idx.MaxMarshalLen
+	var rv []complaint
+	for i := range dealers {
+		evidence := d.complaints[dealers[i]]
+		size += len(dealers[i].Marshal()) + 4 + len(evidence)
+		if size > maxObservationLength {
+			d.logger.Warn("complaints do not fit in observation", commontypes.LogFields{
+				"sent": len(rv), "pending": len(dealers),
+			})
+			break
+		}
+		rv = append(rv, complaint{&dealers[i], evidence})
+	}
+	return rv
+}
+
+func (d *dkg) accuse(
+	dealer *player_idx.PlayerIdx, kind FaultKind, fault error, evidence []byte,
+) {
+	if !d.accused[*dealer] {
+		d.complaints[*dealer] = evidence
+	}
+	d.blame(dealer, d.selfIdx, kind, fault, evidence)
+}
+
+func (d *dkg) accuseSigner(record []byte, fault error) {
+	dealer, _, _, rem, err := verifyShareRecordSignature(
+		d.signingGroup, record, d.cfgDgst, d.spks,
+	)
+	if err != nil || len(rem) > 0 {
+		return
+	}
+	d.accuse(dealer, InvalidShareSet, fault, record)
+}
+
+// checkEvidence returns the fault which evidence shows in dealer's share set,
+// or an error if it shows none.
+func (d *dkg) checkEvidence(
+	dealer *player_idx.PlayerIdx, evidence []byte,
+) (kind FaultKind, fault error, err error) {
+	if e, rem, err := envelope.Unmarshal(evidence, envelope.DecryptionEvidence); err == nil {
+		return d.checkDecryptionEvidence(dealer, e, rem)
+	}
+	r, fault, err := d.signedEvidence(dealer, evidence)
+	if err != nil || fault != nil {
+		return InvalidShareSet, fault, err
+	}
+	if err := d.checkSharedSecret(r.shareSet); err != nil {
+		return InvalidShareSet, err, nil
+	}
+	return 0, nil, errors.Errorf("evidence is a valid share set")
+}
+
+// signedEvidence returns the share record which dealer signed in evidence, or
+// the fault which makes it invalid.
+func (d *dkg) signedEvidence(
+	dealer *player_idx.PlayerIdx, evidence []byte,
+) (r *shareRecord, fault error, err error) {
+	signer, _, _, rem, err := verifyShareRecordSignature(
+		d.signingGroup, evidence, d.cfgDgst, d.spks,
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "evidence is not a signed share record")
+	}
+	if len(rem) > 0 {
+		return nil, nil, errors.Errorf("overage of %d bytes in evidence", len(rem))
+	}
+	if !signer.Equal(dealer) {
+		return nil, nil, errors.Errorf("evidence is signed by %s, not %s", signer, dealer)
+	}
+	r, _, err = d.recoverShareRecord(evidence)
+	if err != nil {
+		return nil, err, nil
+	}
+	return r, nil, nil
+}
+
+// newDecryptionEvidence shows that receiver's share in r does not decrypt to
+// a share matching the dealer's commitments. It carries the shared secrets
+// which unblind the share's ciphertext, with a proof that they come from the
+// receiver's encryption key, so any player can repeat the decryption.
+func (d *dkg) newDecryptionEvidence(
+	r *shareRecord, receiver *player_idx.PlayerIdx,
+) ([]byte, error) {
+	record, err := r.marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal undecryptable share record")
+	}
+	blindingTerms, proof, err := r.shareSet.ProveDecryption(*receiver, d.esk, d.cfgDgst)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not prove decryption of share")
+	}
+	fields := [][]byte{record, receiver.Marshal(), proof}
+	for _, b := range blindingTerms {
+		bB, err := b.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal shared secret")
+		}
+		fields = append(fields, bB)
+	}
+	return envelope.New(
+		envelope.DecryptionEvidence, []kyber.Group{d.encryptionGroup}, fields...,
+	).Marshal()
+}
+
+func (d *dkg) checkDecryptionEvidence(
+	dealer *player_idx.PlayerIdx, e *envelope.Envelope, rem []byte,
+) (kind FaultKind, fault error, err error) {
+	if len(rem) > 0 {
+		return 0, nil, errors.Errorf("overage of %d bytes in decryption evidence", len(rem))
+	}
+	if err := e.CheckGroups(d.encryptionGroup); err != nil {
+		return 0, nil, err
+	}
+	if err := e.CheckNumFields(4); err != nil {
+		return 0, nil, err
+	}
+	r, fault, err := d.signedEvidence(dealer, e.Fields[0])
+	if err != nil || fault != nil {
+		return InvalidShareSet, fault, err
+	}
+	receiver, idxRem, err := player_idx.Unmarshal(e.Fields[1])
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "could not read receiver of undecryptable share")
+	}
+	if len(idxRem) > 0 {
+		return 0, nil, errors.Errorf("overage of %d bytes in receiver index", len(idxRem))
+	}
+	blindingTerms := make([]kyber.Point, len(e.Fields)-3)
+	for i := range blindingTerms {
+		blindingTerms[i] = d.encryptionGroup.Point()
+		if err := blindingTerms[i].UnmarshalBinary(e.Fields[i+3]); err != nil {
+			return 0, nil, errors.Wrap(err, "could not unmarshal shared secret")
+		}
+	}
+	fault, err = r.shareSet.CheckDecryption(*receiver, d.cfgDgst, blindingTerms, e.Fields[2])
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "could not check decryption evidence")
+	}
+	if fault == nil {
+		return 0, nil, errors.Errorf("evidence decrypts to a valid share")
+	}
+	return UndecryptableShare, fault, nil
+}
+
+// checkOwnShares accuses the dealer of r if it gave this player a share which
+// does not decrypt, so that it can be excluded before the key is reported.
+func (d *dkg) checkOwnShares(r *shareRecord, dealer *player_idx.PlayerIdx) {
+	if d.recovery != nil {
+		return // The encryption key does not match the config
+	}
+	held, err := d.ownShares()
+	if err != nil {
+		return
+	}
+	for _, idx := range held {
+		_, err := r.shareSet.Decrypt(*idx, d.esk, d.encryptionGroup, d.cfgDgst)
+		if err == nil {
+			continue
+		}
+		evidence, eerr := d.newDecryptionEvidence(r, idx)
+		if eerr != nil {
+			d.logger.Warn("could not construct evidence of undecryptable share",
+				commontypes.LogFields{"err": eerr, "decryptionErr": err, "dealer": dealer})
+			return
+		}
+		if _, _, eerr := d.checkEvidence(dealer, evidence); eerr != nil {
+			// The shared secrets decrypt the share, so the failure was local.
+			d.logger.Warn("could not decrypt own share", commontypes.LogFields{
+				"err": err, "dealer": dealer,
+			})
+			return
+		}
+		d.accuse(dealer, UndecryptableShare, err, evidence)
+		return
+	}
+}
+
+func (d *dkg) blameUndecryptable(
+	r *shareRecord, receiver player_idx.PlayerIdx, fault error,
+) {
+	dealer, err := r.shareSet.Dealer()
+	if err != nil {
+		return
+	}
+	evidence, err := d.newDecryptionEvidence(r, &receiver)
+	if err != nil {
+		evidence, err = r.marshal()
+		if err != nil {
+			evidence = nil
+		}
+	}
+	d.blame(dealer, d.selfIdx, UndecryptableShare, fault, evidence)
+}
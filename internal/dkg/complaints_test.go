@@ -0,0 +1,150 @@
+package dkg
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+)
+
+func TestObservationMarshalling(t *testing.T) {
+	players, err := player_idx.PlayerIdxs(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := []byte("share record")
+	if o := marshalObservation(record, nil); !bytes.Equal(o, record) {
+		t.Fatal("observation without complaints is not just the share record")
+	}
+	complaints := []complaint{{players[1], []byte("evidence")}, {players[2], nil}}
+	o := marshalObservation(record, complaints)
+	gotRecord, got, err := unmarshalObservation(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotRecord, record) || len(got) != len(complaints) {
+		t.Fatal("observation differs after round trip")
+	}
+	for i, c := range got {
+		if !c.dealer.Equal(complaints[i].dealer) ||
+			!bytes.Equal(c.evidence, complaints[i].evidence) {
+			t.Fatalf("complaint %d differs after round trip", i)
+		}
+	}
+	if _, _, err := unmarshalObservation(o[:len(o)-1]); err == nil {
+		t.Fatal("truncated observation unmarshalled")
+	}
+	if _, _, err := unmarshalObservation(append(o, 0)); err == nil {
+		t.Fatal("observation with trailing bytes unmarshalled")
+	}
+}
+
+// complaintPlayer returns a player whose share record has been dealt.
+func complaintPlayer(t *testing.T) *dkg {
+	d, _ := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Hour, 0)
+	d.faultyDealers = newFaultyDealers()
+	if err := d.initializeShareSets(d.signingGroup); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestCheckEvidence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	d := complaintPlayer(t)
+	other, err := player_idx.PlayerIdxs(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := d.myShareRecord.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.checkEvidence(d.selfIdx, record); err == nil {
+		t.Fatal("valid share set accepted as evidence")
+	}
+	if _, _, err := d.checkEvidence(other[1], record); err == nil {
+		t.Fatal("evidence signed by another dealer accepted")
+	}
+
+	evidence, err := d.newDecryptionEvidence(d.myShareRecord, d.selfIdx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.checkEvidence(d.selfIdx, evidence); err == nil {
+		t.Fatal("decryption evidence for a decryptable share accepted")
+	}
+	e, _, err := envelope.Unmarshal(evidence, envelope.DecryptionEvidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Fields[3], err = d.encryptionGroup.Point().Pick(d.encryptionGroup.RandomStream()).
+		MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := e.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.checkEvidence(d.selfIdx, forged); err == nil {
+		t.Fatal("decryption evidence with a forged shared secret accepted")
+	}
+
+	d.t = 2 // The share set now has the wrong threshold
+	kind, fault, err := d.checkEvidence(d.selfIdx, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind != InvalidShareSet || fault == nil {
+		t.Fatalf("invalid share set not shown by evidence: %s, %v", kind, fault)
+	}
+}
+
+func TestComplaintsExcludeDealer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	d := complaintPlayer(t)
+	accuser, err := player_idx.PlayerIdxs(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := d.myShareRecord.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &observation{
+		sender: accuser[1], complaints: []complaint{{d.selfIdx, record}},
+	}
+
+	v, err := d.newValidShareRecords(d.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.processComplaints(o)
+	if v.excluded[*d.selfIdx] || d.accused[*d.selfIdx] {
+		t.Fatal("dealer excluded on unfounded complaint")
+	}
+
+	d.t = 2
+	v.processComplaints(o)
+	d.t = 1
+	if !v.excluded[*d.selfIdx] || !d.accused[*d.selfIdx] {
+		t.Fatal("dealer not excluded on founded complaint")
+	}
+	faulty := d.faultyDealers.list()
+	if len(faulty) != 1 || faulty[0].Kind != InvalidShareSet ||
+		faulty[0].Accuser != accuser[1].OracleID() ||
+		!bytes.Equal(faulty[0].Evidence, record) {
+		t.Fatalf("faulty dealer not recorded with complaint: %+v", faulty)
+	}
+	if _, err := v.validateShareRecord(d.myShareRecord, d.selfIdx); err == nil {
+		t.Fatal("share set from excluded dealer accepted")
+	}
+}
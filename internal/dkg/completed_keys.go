@@ -0,0 +1,146 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+)
+
+// A completed key record names the config digest of the latest key completed
+// for a key ID, and the groups needed to read its key snapshot. It lets a
+// restarted node hand keys from earlier configs back to its key consumers.
+
+var completedKeyVersionNum uint8 = 1
+
+func (ck *completedKey) marshal() []byte {
+	group := []byte(ck.encryptionGroup.String())
+	translator := []byte(ck.translator.Name())
+	return bytes.Join([][]byte{
+		{completedKeyVersionNum},
+		ck.cfgDgst[:],
+		lenPrefix(group),
+		group,
+		lenPrefix(translator),
+		translator,
+	}, nil)
+}
+
+func unmarshalCompletedKey(data []byte) (*completedKey, error) {
+	var ck completedKey
+	if len(data) < 1+len(ck.cfgDgst) || data[0] != completedKeyVersionNum {
+		return nil, errors.Errorf("unknown completed key record version")
+	}
+	copy(ck.cfgDgst[:], data[1:])
+	group, data, err := readLenPrefixed(data[1+len(ck.cfgDgst):])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read encryption group name")
+	}
+	translator, data, err := readLenPrefixed(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read translator name")
+	}
+	if len(data) != 0 {
+		return nil, errors.Errorf("%d extra bytes in completed key record", len(data))
+	}
+	var ok bool
+	ck.encryptionGroup, ok = encryptionGroupRegistry[string(group)]
+	if !ok {
+		return nil, errors.Errorf("unknown encryption group %s", group)
+	}
+	ck.translator, ok = translatorRegistry[string(translator)]
+	if !ok {
+		return nil, errors.Errorf("unknown translator %s", translator)
+	}
+	return &ck, nil
+}
+
+func (d *dkgReportingPluginFactory) persistCompletedKey(
+	ctx context.Context, keyID contract.KeyID, ck completedKey,
+) error {
+	db, ok := d.l.shareDB.(dkg_types.CompletedKeyPersistence)
+	if !ok {
+		return nil
+	}
+	return db.WriteCompletedKey(ctx, keyID, ck.marshal())
+}
+
+// restoreCompletedKeys loads the key snapshot for each persisted completed key,
+// checks it against the onchain key, and passes it to the key consumers.
+func (d *dkgReportingPluginFactory) restoreCompletedKeys(ctx context.Context) error {
+	db, ok := d.l.shareDB.(dkg_types.CompletedKeyPersistence)
+	if !ok {
+		return nil
+	}
+	records, err := db.CompletedKeys()
+	if err != nil {
+		d.l.logger.Warn("could not read some completed keys", commontypes.LogFields{
+			"err": err, "readable": len(records),
+		})
+	}
+	for keyID, record := range records {
+		ck, err := d.loadCompletedKey(ctx, keyID, record)
+		if err != nil {
+			d.l.logger.Warn("could not restore completed key", commontypes.LogFields{
+				"err": err, "keyID": keyID,
+			})
+			continue
+		}
+		if ck == nil {
+			continue
+		}
+		d.lock.Lock()
+		_, known := d.completedKeys[keyID]
+		inProgress := d.current != nil && d.current.keyID == keyID
+		if !known && !inProgress {
+			d.completedKeys[keyID] = *ck
+		}
+		d.lock.Unlock()
+		if !known && !inProgress {
+			d.l.keyConsumer.forDigest(ck.cfgDgst).NewKey(keyID, ck.keyData.Clone())
+		}
+	}
+	return nil
+}
+
+func (d *dkgReportingPluginFactory) loadCompletedKey(
+	ctx context.Context, keyID contract.KeyID, record []byte,
+) (*completedKey, error) {
+	ck, err := unmarshalCompletedKey(record)
+	if err != nil {
+		return nil, err
+	}
+	translationGroup, err := ck.translator.TargetGroup(ck.encryptionGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not determine translation target group")
+	}
+	esk, err := d.l.keys.EncryptionKey(ck.encryptionGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load encryption key")
+	}
+	kd, hashes, err := readKeySnapshot(
+		d.l.shareDB, esk, ck.encryptionGroup, translationGroup, ck.cfgDgst, keyID,
+	)
+	if err != nil || kd == nil {
+		return nil, err
+	}
+	onchain, err := d.l.contract.KeyData(ctx, keyID, ck.cfgDgst)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get onchain key to validate key snapshot")
+	}
+	if onchain.PublicKey == nil {
+		return nil, errors.Errorf(
+			"no key reported onchain for config digest %s", ck.cfgDgst,
+		)
+	}
+	if err := checkKeySnapshot(kd, hashes, &onchain, ck.translator); err != nil {
+		return nil, err
+	}
+	ck.keyData = kd
+	return ck, nil
+}
@@ -0,0 +1,236 @@
+package dkg
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+const (
+	dataAvailabilityTag byte = 2
+	transcriptTag       byte = 4
+
+	maxQueryLength = player_idx.MaxMarshalLen + hash.Size*maxRequestedHashes
+
+	maxRequestedHashes = 255
+)
+
+func marshalHashes(hs []hash.Hash) []byte {
+	rv := make([]byte, 0, player_idx.MaxMarshalLen+hash.Size*len(hs))
+	rv = append(rv, player_idx.RawMarshal(player_idx.Int(len(hs)))...)
+	for _, h := range hs {
+		rv = append(rv, h[:]...)
+	}
+	return rv
+}
+
+func unmarshalHashes(data []byte) (hs []hash.Hash, rem []byte, err error) {
+	n, data, err := player_idx.RawUnmarshal(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "missing hash count")
+	}
+	numHashes := int(n)
+	if len(data) < hash.Size*numHashes {
+		return nil, nil, errors.Errorf(
+			"%d hashes truncated to %d bytes", numHashes, len(data),
+		)
+	}
+	hs = make([]hash.Hash, numHashes)
+	for i := range hs {
+		copy(hs[i][:], data[hash.Size*i:])
+	}
+	return hs, data[hash.Size*numHashes:], nil
+}
+
+func marshalQuery(requested []hash.Hash) types.Query {
+	if len(requested) == 0 {
+		return nil
+	}
+	return marshalHashes(requested)
+}
+
+func unmarshalQuery(q types.Query) ([]hash.Hash, error) {
+	if len(q) == 0 {
+		return nil, nil
+	}
+	hs, rem, err := unmarshalHashes(q)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read requested share records from query")
+	}
+	if len(rem) > 0 {
+		return nil, errors.Errorf("overage of %d bytes in query", len(rem))
+	}
+	return hs, nil
+}
+
+func marshalDataAvailabilityObservation(missing []hash.Hash, records [][]byte) []byte {
+	rv := append([]byte{dataAvailabilityTag}, marshalHashes(missing)...)
+	for _, r := range records {
+		rv = append(append(rv, lenPrefix(r)...), r...)
+	}
+	return rv
+}
+
+func unmarshalDataAvailabilityObservation(
+	o []byte,
+) (missing []hash.Hash, records [][]byte, err error) {
+	if len(o) == 0 || o[0] != dataAvailabilityTag {
+		return nil, nil, errors.Errorf("not a data-availability observation")
+	}
+	missing, o, err = unmarshalHashes(o[1:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read missing share records")
+	}
+	for len(o) > 0 {
+		var r []byte
+		r, o, err = readLenPrefixed(o)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not read share record")
+		}
+		records = append(records, r)
+	}
+	return missing, records, nil
+}
+
+func (rs shareRecords) missing(hs []hash.Hash) []hash.Hash {
+	var rv []hash.Hash
+	for _, h := range hs {
+		if _, ok := rs[h]; !ok {
+			rv = append(rv, h)
+		}
+	}
+	return rv
+}
+
+func (d *dkg) missingShareRecords(hs []hash.Hash) []hash.Hash {
+	if d.transcript.covers(hs) {
+		return nil
+	}
+	return d.shareSets.missing(hs)
+}
+
+func (d *dkg) awaitingShareRecords(ctx context.Context) bool {
+	keyData, err := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
+	if err != nil {
+		return false
+	}
+	missing := d.missingShareRecords(keyData.Hashes)
+	if len(missing) == 0 {
+		return false
+	}
+	d.logger.Info("requesting share records for reported key from peers",
+		commontypes.LogFields{"missing": hash.Hashes(missing)},
+	)
+	return true
+}
+
+func (d *dkg) dataAvailabilityQuery(ctx context.Context) types.Query {
+	keyData, err := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
+	if err != nil {
+		return nil
+	}
+	onchain := make(map[hash.Hash]bool, len(keyData.Hashes))
+	for _, h := range keyData.Hashes {
+		onchain[h] = true
+	}
+	requested := d.missingShareRecords(keyData.Hashes)
+	for _, h := range d.requestedHashes {
+		if onchain[h] {
+			requested = append(requested, h)
+		}
+	}
+	requested = sortedUniqueHashes(requested)
+	if len(requested) > maxRequestedHashes {
+		requested = requested[:maxRequestedHashes]
+	}
+	return marshalQuery(requested)
+}
+
+func (d *dkg) dataAvailabilityObservation(
+	ctx context.Context, q types.Query,
+) (types.Observation, error) {
+	keyData, err := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
+	if err != nil {
+		return nil, errors.Wrap(err, "digest marked as complete, but key data is unavailable")
+	}
+	missing := d.missingShareRecords(keyData.Hashes)
+	if len(missing) > maxRequestedHashes {
+		missing = missing[:maxRequestedHashes]
+	}
+	requested, err := unmarshalQuery(q)
+	if err != nil {
+		d.logger.Warn("ignoring malformed data-availability query", commontypes.LogFields{
+			"err": err,
+		})
+		requested = nil
+	}
+	onchain := make(map[hash.Hash]bool, len(keyData.Hashes))
+	for _, h := range keyData.Hashes {
+		onchain[h] = true
+	}
+	recovery := d.shareRecoveryMessages()
+	size := 1 + player_idx.MaxMarshalLen + hash.Size*len(missing) + recovery.size()
+	if len(requested) > 0 && d.transcript.covers(keyData.Hashes) {
+		t, err := d.transcript.marshal()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal transcript of reported key")
+		}
+		if size+len(t) <= maxObservationLength {
+			return recovery.wrap(marshalTranscriptObservation(missing, t)), nil
+		}
+	}
+	var records [][]byte
+	for i := range requested {
+		h := requested[(i+int(d.selfIdx.OracleID()))%len(requested)]
+		r, ok := d.shareSets[h]
+		if !ok || !onchain[h] {
+			continue
+		}
+		m, err := r.marshal()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not marshal requested share record %s", h)
+		}
+		if size+4+len(m) > maxObservationLength {
+			d.logger.Debug("requested share records do not fit in observation",
+				commontypes.LogFields{"sent": len(records), "requested": len(requested)},
+			)
+			break
+		}
+		size += 4 + len(m)
+		records = append(records, m)
+	}
+	if len(missing) == 0 && len(records) == 0 {
+		return recovery.wrap(nil), nil
+	}
+	return recovery.wrap(marshalDataAvailabilityObservation(missing, records)), nil
+}
+
+func (v *validShareRecords) processDataAvailabilityRequests(observations []*observation) {
+	var requested []hash.Hash
+	for _, o := range observations {
+		requested = append(requested, o.missing...)
+	}
+	v.d.requestedHashes = sortedUniqueHashes(requested)
+}
+
+func sortedUniqueHashes(hs []hash.Hash) []hash.Hash {
+	seen := make(map[hash.Hash]bool, len(hs))
+	rv := make([]hash.Hash, 0, len(hs))
+	for _, h := range hs {
+		if !seen[h] {
+			seen[h] = true
+			rv = append(rv, h)
+		}
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		return string(rv[i][:]) < string(rv[j][:])
+	})
+	return rv
+}
@@ -0,0 +1,202 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+func testHashes(first, n int) []hash.Hash {
+	rv := make([]hash.Hash, n)
+	for i := range rv {
+		rv[i][0], rv[i][1] = byte((first+i)>>8), byte(first+i)
+	}
+	return rv
+}
+
+func checkHashes(t *testing.T, got, want []hash.Hash) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d hashes, expected %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("hash %d is %s, expected %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDataAvailabilityQueryRoundTrip(t *testing.T) {
+	if q := marshalQuery(nil); q != nil {
+		t.Fatalf("empty request marshaled as %x", q)
+	}
+	if hs, err := unmarshalQuery(nil); err != nil || hs != nil {
+		t.Fatalf("empty query unmarshaled as %v: %v", hs, err)
+	}
+	// 255 hashes need the wide encoding of their count.
+	for _, n := range []int{1, 3, maxRequestedHashes} {
+		requested := testHashes(1, n)
+		q := marshalQuery(requested)
+		if len(q) > maxQueryLength {
+			t.Fatalf("query for %d hashes is %d bytes, over the %d byte limit",
+				n, len(q), maxQueryLength)
+		}
+		hs, err := unmarshalQuery(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkHashes(t, hs, requested)
+		if _, err := unmarshalQuery(q[:len(q)-1]); err == nil {
+			t.Fatal("unmarshaled truncated query")
+		}
+		if _, err := unmarshalQuery(append(q, 0)); err == nil {
+			t.Fatal("unmarshaled query with trailing bytes")
+		}
+	}
+}
+
+func TestDataAvailabilityObservationRoundTrip(t *testing.T) {
+	missing := testHashes(1, 2)
+	records := [][]byte{[]byte("a"), {}, []byte("share record")}
+	o := marshalDataAvailabilityObservation(missing, records)
+	gotMissing, gotRecords, err := unmarshalDataAvailabilityObservation(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHashes(t, gotMissing, missing)
+	if len(gotRecords) != len(records) {
+		t.Fatalf("got %d share records, expected %d", len(gotRecords), len(records))
+	}
+	for i := range records {
+		if !bytes.Equal(gotRecords[i], records[i]) {
+			t.Fatalf("share record %d is %q, expected %q", i, gotRecords[i], records[i])
+		}
+	}
+
+	if _, _, err := unmarshalDataAvailabilityObservation(o[:len(o)-1]); err == nil {
+		t.Fatal("unmarshaled observation with a truncated share record")
+	}
+	if _, _, err := unmarshalDataAvailabilityObservation(o[1:]); err == nil {
+		t.Fatal("unmarshaled observation without its tag")
+	}
+	if _, _, err := unmarshalDataAvailabilityObservation(o[:2]); err == nil {
+		t.Fatal("unmarshaled observation with truncated missing hashes")
+	}
+}
+
+// availabilityPlayer returns a player in a DKG whose onchain key lists the
+// given hashes, holding share records of the given lengths for the first
+// of them.
+func availabilityPlayer(t *testing.T, hashes []hash.Hash, recordLens ...int) *dkg {
+	d, c := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Hour, 0)
+	c.kd = contract.KeyData{PublicKey: d.translationGroup.Point().Base(), Hashes: hashes}
+	for i, l := range recordLens {
+		r := &shareRecord{marshaledShareRecord: bytes.Repeat([]byte{byte(i)}, l)}
+		if err := d.shareSets.set(r, hashes[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return d
+}
+
+func TestDataAvailabilityQueryRequestsMissingRecords(t *testing.T) {
+	ctx := context.Background()
+	hashes := testHashes(1, 4)
+	d := availabilityPlayer(t, hashes, 1, 1)
+	// Peers' requests are repeated only for hashes listed onchain.
+	d.requestedHashes = []hash.Hash{hashes[0], hashes[3], {0xff}}
+	got, err := unmarshalQuery(d.dataAvailabilityQuery(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHashes(t, got, []hash.Hash{hashes[0], hashes[2], hashes[3]})
+
+	d = availabilityPlayer(t, testHashes(1, maxRequestedHashes+10))
+	q := d.dataAvailabilityQuery(ctx)
+	if len(q) > maxQueryLength {
+		t.Fatalf("query is %d bytes, over the %d byte limit", len(q), maxQueryLength)
+	}
+	got, err = unmarshalQuery(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHashes(t, got, testHashes(1, maxRequestedHashes))
+
+	d = availabilityPlayer(t, hashes, 1, 1, 1, 1)
+	if q := d.dataAvailabilityQuery(ctx); q != nil {
+		t.Fatalf("player holding every share record queried for %x", q)
+	}
+}
+
+func TestDataAvailabilityObservationBoundsRecords(t *testing.T) {
+	ctx := context.Background()
+	const recordLen = maxObservationLength / 3
+	hashes := testHashes(1, 5)
+	d := availabilityPlayer(t, hashes, recordLen, recordLen, recordLen, recordLen, recordLen)
+	o, err := d.dataAvailabilityObservation(ctx, marshalQuery(hashes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o) > maxObservationLength {
+		t.Fatalf("observation is %d bytes, over the %d byte limit", len(o), maxObservationLength)
+	}
+	missing, records, err := unmarshalDataAvailabilityObservation(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 0 || len(records) != 2 {
+		t.Fatalf("observation has %d missing hashes and %d share records, expected 0 and 2",
+			len(missing), len(records))
+	}
+	for i, r := range records {
+		if len(r) != recordLen || r[0] != byte(i) {
+			t.Fatalf("share record %d in observation is not the one requested", i)
+		}
+	}
+
+	// Records not listed onchain are not sent, and nor are those not held.
+	o, err = d.dataAvailabilityObservation(ctx, marshalQuery([]hash.Hash{{0xff}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o) != 0 {
+		t.Fatalf("observation of %d bytes for a share record not listed onchain", len(o))
+	}
+}
+
+func TestDataAvailabilityObservationCountsWideHashCount(t *testing.T) {
+	ctx := context.Background()
+	// With maxRequestedHashes missing, their count takes its wide encoding,
+	// and a record exactly filling what remains of the observation fits.
+	held := hash.Hash{0xee}
+	hashes := append([]hash.Hash{held}, testHashes(1, maxRequestedHashes)...)
+	fixed := 1 + 3 + hash.Size*maxRequestedHashes + 4
+	for _, l := range []int{maxObservationLength - fixed, maxObservationLength - fixed + 1} {
+		d := availabilityPlayer(t, hashes, l)
+		requested := marshalQuery([]hash.Hash{held})
+		o, err := d.dataAvailabilityObservation(ctx, requested)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(o) > maxObservationLength {
+			t.Fatalf("observation is %d bytes, over the %d byte limit",
+				len(o), maxObservationLength)
+		}
+		missing, records, err := unmarshalDataAvailabilityObservation(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(missing) != maxRequestedHashes {
+			t.Fatalf("observation lists %d missing hashes, expected %d",
+				len(missing), maxRequestedHashes)
+		}
+		if fits := l == maxObservationLength-fixed; fits != (len(records) == 1) {
+			t.Fatalf("share record of %d bytes sent: %t", l, len(records) == 1)
+		}
+	}
+}
@@ -5,17 +5,21 @@ import (
 	"io"
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
 	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
 	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
 
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/group/edwards25519"
@@ -40,23 +44,53 @@ type dkg struct {
 
 	myShareRecord *shareRecord
 
-	esk  kyber.Scalar
+	shareRecordBroadcast atomic.Bool
+	validShareSets       int
+
+	esk  key_store.EncryptionKey
 	epks []kyber.Point
-	ssk  kyber.Scalar
+	ssk  key_store.SigningKey
 	spks []kyber.Point
 
+	weights []player_idx.Int
+
+	signingGroup anon.Suite
+
 	encryptionGroup anon.Suite
 
 	translationGroup kyber.Group
 
 	translator point_translation.PubKeyTranslation
 
-	lastKeyData *KeyData
+	mode dkgMode
+
+	lastKeyData     *KeyData
+	previousPlayers []player_idx.Int
 
 	contract onchainContract
 
 	completed   bool
-	dkgComplete func()
+	keyData     *KeyData
+	dkgComplete func(*dkg, *KeyData)
+
+	lifecycle lifecycle
+	attempt   Attempt
+	attempts  *attemptLog
+	retries   int
+	aborted   bool
+
+	accused       map[player_idx.PlayerIdx]bool
+	complaints    map[player_idx.PlayerIdx][]byte
+	faultyDealers *faultyDealers
+
+	requestedHashes []hash.Hash
+	transcript      *keyTranscript
+	faultyOracles   int
+
+	recovery          *shareRecovery
+	recoveryResponses map[player_idx.PlayerIdx]*shareRecoveryResponse
+
+	bias *biasResistantDeal
 
 	db dkg_types.DKGSharePersistence
 
@@ -71,6 +105,10 @@ type dkg struct {
 var _ types.ReportingPlugin = (*dkg)(nil)
 
 func (a *NewDKGArgs) SanityCheckArgs() error {
+	return a.sanityCheckArgs(false)
+}
+
+func (a *NewDKGArgs) sanityCheckArgs(recovering bool) error {
 	if a.encryptionGroup == nil {
 		return errors.Errorf("encryption group not set")
 	}
@@ -82,10 +120,13 @@ func (a *NewDKGArgs) SanityCheckArgs() error {
 	}
 	n := len(a.epks)
 	if n > int(player_idx.MaxPlayer) {
-		return errors.Errorf("too many players")
+		return errors.Errorf("too many players: %d > %d", n, player_idx.MaxPlayer)
 	}
-	if int(a.t) > n {
-		return errors.Errorf("threshold can't exceed number of players")
+	if err := checkWeights(a.weights, n); err != nil {
+		return err
+	}
+	if total, _ := shareCounts(a.weights, n, 0); int(a.t) > total {
+		return errors.Errorf("threshold can't exceed number of shares")
 	}
 	if _, err := a.selfIdx.Check(); err != nil {
 		return errors.Wrap(err, "self index invalid")
@@ -93,10 +134,13 @@ func (a *NewDKGArgs) SanityCheckArgs() error {
 	if !a.selfIdx.AtMost(player_idx.Int(n)) {
 		return errors.Errorf("player index can't exceed number of players")
 	}
-	if reflect.TypeOf(a.esk) != reflect.TypeOf(a.encryptionGroup.Scalar()) {
-		return errors.Errorf("encryption secret key must come from encryption group")
+	if err := ciphertext.VerifyGroupMarshalsBigEndian(a.encryptionGroup); err != nil {
+		return err
 	}
 	exampleEncryptionPK := a.encryptionGroup.Point()
+	if reflect.TypeOf(a.esk.PublicKey()) != reflect.TypeOf(exampleEncryptionPK) {
+		return errors.Errorf("encryption secret key must come from encryption group")
+	}
 	for _, epk := range a.epks {
 		if reflect.TypeOf(epk) != reflect.TypeOf(exampleEncryptionPK) {
 			return errors.Errorf(
@@ -105,39 +149,76 @@ func (a *NewDKGArgs) SanityCheckArgs() error {
 			)
 		}
 	}
-	if !a.selfIdx.Index(a.epks).(kyber.Point).Equal(a.encryptionGroup.Point().Mul(a.esk, nil)) {
+	if !recovering && !a.encryptionKeyMatches() {
 		return errors.Errorf("secret encryption key does not match public encryption key")
 	}
 	if len(a.spks) != n {
 		return errors.Errorf("must be exactly one signing key for each player")
 	}
-	if reflect.TypeOf(a.ssk) != reflect.TypeOf(a.signingGroup().Scalar()) {
-		return errors.Errorf("signing secret key must be of type %T", a.signingGroup().Scalar())
+	if _, ok := signingGroupRegistry[a.signingGroup().String()]; !ok &&
+		a.xxxTestingOnlySigningGroup == nil {
+		return errors.Errorf("unsupported signing group %s", a.signingGroup())
 	}
 	exampleSigningPK := a.signingGroup().Point()
+	if reflect.TypeOf(a.ssk.PublicKey()) != reflect.TypeOf(exampleSigningPK) {
+		return errors.Errorf("signing secret key must be of type %T", a.signingGroup().Scalar())
+	}
 	for _, spk := range a.spks {
 		if reflect.TypeOf(spk) != reflect.TypeOf(exampleSigningPK) {
 			return errors.Errorf("signing public keys must be of type %T", exampleSigningPK)
 		}
 	}
-	if !a.selfIdx.Index(a.spks).(kyber.Point).Equal(a.signingGroup().Point().Mul(a.ssk, nil)) {
+	if !a.selfIdx.Index(a.spks).(kyber.Point).Equal(a.ssk.PublicKey()) {
 		return errors.Errorf("secret signing key does not match public signing key")
 	}
 	if n < pvss.MinPlayers {
 		return errors.Errorf("not enough players (need at least %d)", pvss.MinPlayers)
 	}
+	if a.biasResistant {
+		if a.mode != freshKey {
+			return errors.Errorf("bias-resistant dealing is only for a fresh key")
+		}
+		if _, ok := pedersenBaseRegistry[a.encryptionGroup.String()]; !ok {
+			return errors.Errorf(
+				"no hiding-commitment base for encryption group %s", a.encryptionGroup,
+			)
+		}
+	}
+	if err := checkWeightedMode(a.weights, a.mode, a.biasResistant); err != nil {
+		return err
+	}
+	if err := a.sanityCheckPreviousKey(n); err != nil {
+		return errors.Wrapf(err, "previous key is incompatible with %s", a.mode)
+	}
 	return nil
 }
 
+func (a *NewDKGArgs) encryptionKeyMatches() bool {
+	return a.selfIdx.Index(a.epks).(kyber.Point).Equal(a.esk.PublicKey())
+}
+
 func (a *NewDKGArgs) signingGroup() anon.Suite {
 	if a.xxxTestingOnlySigningGroup != nil {
 		return a.xxxTestingOnlySigningGroup
 	}
+	if a.configuredSigningGroup != nil {
+		return a.configuredSigningGroup
+	}
 	return SigningGroup
 }
 
 func (d *dkg) keyReportedOnchain(ctx context.Context) bool {
-	kd, err := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
+	return keyReported(ctx, d.contract, d.keyID, d.cfgDgst)
+}
+
+func (a *NewDKGArgs) keyReportedOnchain(ctx context.Context) bool {
+	return keyReported(ctx, a.contract, a.keyID, a.cfgDgst)
+}
+
+func keyReported(
+	ctx context.Context, c onchainContract, keyID contract.KeyID, cfgDgst types.ConfigDigest,
+) bool {
+	kd, err := c.KeyData(ctx, keyID, cfgDgst)
 	return err == nil && kd.PublicKey != nil && len(kd.Hashes) > 0
 }
 
@@ -148,45 +229,101 @@ func (d *dkg) recoverDistributedKeyShare(ctx context.Context) (err error) {
 	}
 	if d.shareSets.allKeysPresent(kd.Hashes) {
 
-		finalShare, err := d.shareSets.recoverDistributedKeyShare(
-			d.esk, *d.selfIdx, &kd, d.encryptionGroup, d.cfgDgst,
-		)
+		weights, err := d.checkReportedKey(&kd)
 		if err != nil {
-			return errors.Wrap(err, "could not recover distribute key from shares")
+			return errors.Wrapf(err, "reported key is invalid for %s", d.mode)
 		}
 
-		shares, err := d.shareSets.recoverPublicShares(&kd)
+		held, err := d.ownShares()
 		if err != nil {
-			return errors.Wrap(err, "could not get public shares to report to consumer")
+			return err
 		}
-		players, err := player_idx.PlayerIdxs(player_idx.Int(len(shares)))
-		if err != nil {
-			return errors.Wrap(err, "could not construct players for pubshares")
+		undecryptable := false
+		blame := func(r *shareRecord, receiver player_idx.PlayerIdx, fault error) {
+			undecryptable = true
+			d.blameUndecryptable(r, receiver, fault)
 		}
-		pubShares := make([]kshare.PubShare, len(players))
-		for i, playerIdx := range players {
-
-			pubShares[i] = playerIdx.PubShare(shares[i])
+		finalShares := make([]*kshare.PriShare, len(held))
+		for i, idx := range held {
+			finalShares[i], err = d.shareSets.recoverDistributedKeyShare(
+				d.esk, *idx, &kd, d.encryptionGroup, d.cfgDgst, weights, blame,
+			)
+			if err != nil && undecryptable && len(d.weights) == 0 {
+				// A share in the reported key can't be decrypted, but the other
+				// players can still reshare this player's share of the key.
+				d.logger.Warn("could not decrypt key share; recovering it from peers",
+					commontypes.LogFields{"err": err})
+				d.recovery = newShareRecovery(d.esk.PublicKey())
+				return nil
+			}
+			if err != nil {
+				return errors.Wrap(err, "could not recover distribute key from shares")
+			}
 		}
 
-		keyData := &KeyData{
-			kd.PublicKey,
-			pubShares,
-			&SecretShare{*d.selfIdx, finalShare.V},
-			d.t,
-			true,
+		shares, err := d.shareSets.recoverPublicShares(&kd, weights)
+		if err != nil {
+			return errors.Wrap(err, "could not get public shares to report to consumer")
 		}
-
-		d.keyConsumer.NewKey(d.keyID, keyData)
-		d.completed = true
-		d.dkgComplete()
-		return nil
+		if d.mode == refreshKey {
+			finalShares[0], shares, err = d.refreshShares(finalShares[0], shares)
+			if err != nil {
+				return errors.Wrap(err, "could not refresh key shares")
+			}
+		}
+		return d.completeKey(ctx, &kd, finalShares, shares, "")
+	}
+	if d.transcript.covers(kd.Hashes) {
+		return d.recoverKeyFromTranscript(ctx, &kd)
 	}
 	return errors.Errorf(
 		"do not yet have all shares required for reconstruction of given key",
 	)
 }
 
+func (d *dkg) completeKey(
+	ctx context.Context, kd *contract.KeyData, secretShares []*kshare.PriShare,
+	shares []kyber.Point, reason string,
+) error {
+	players, err := player_idx.PlayerIdxs(player_idx.Int(len(shares)))
+	if err != nil {
+		return errors.Wrap(err, "could not construct players for pubshares")
+	}
+	pubShares := make([]kshare.PubShare, len(players))
+	for i, playerIdx := range players {
+
+		pubShares[i] = playerIdx.PubShare(shares[i])
+	}
+
+	secrets := make([]*SecretShare, len(secretShares))
+	for i, s := range secretShares {
+		secrets[i] = &SecretShare{*players[s.I], s.V}
+	}
+
+	keyData := &KeyData{
+		kd.PublicKey,
+		pubShares,
+		secrets[0],
+		secrets[1:],
+		d.weights,
+		d.t,
+		true,
+	}
+
+	err = writeKeySnapshot(ctx, d.db, d.esk, d.cfgDgst, d.keyID, keyData, kd.Hashes)
+	if err != nil {
+		d.logger.Warn("could not persist key snapshot", commontypes.LogFields{
+			"err": err,
+		})
+	}
+	d.keyConsumer.NewKey(d.keyID, keyData)
+	d.keyData = keyData
+	d.completed = true
+	d.finishAttemptLocked(AttemptCompleted, reason)
+	d.dkgComplete(d, keyData)
+	return nil
+}
+
 var SigningGroup anon.Suite = edwards25519.NewBlakeSHA256Ed25519()
 
 type onchainContract interface {
@@ -0,0 +1,100 @@
+package dkg
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+)
+
+type FaultKind uint8
+
+const (
+	InvalidShareSet FaultKind = iota + 1
+	UndecryptableShare
+	WithheldShareSet
+)
+
+func (k FaultKind) String() string {
+	switch k {
+	case InvalidShareSet:
+		return "invalid share set"
+	case UndecryptableShare:
+		return "undecryptable share"
+	case WithheldShareSet:
+		return "withheld share set"
+	default:
+		return fmt.Sprintf("unknown fault %d", uint8(k))
+	}
+}
+
+type FaultyDealer struct {
+	Dealer       commontypes.OracleID
+	Accuser      commontypes.OracleID
+	ConfigDigest types.ConfigDigest
+	Kind         FaultKind
+	Detail       string
+	Evidence     []byte
+}
+
+type faultyDealers struct {
+	lock    sync.RWMutex
+	dealers []FaultyDealer
+	seen    map[types.ConfigDigest]map[commontypes.OracleID]bool
+}
+
+func newFaultyDealers() *faultyDealers {
+	return &faultyDealers{
+		sync.RWMutex{},
+		nil,
+		map[types.ConfigDigest]map[commontypes.OracleID]bool{},
+	}
+}
+
+func (f *faultyDealers) add(fd FaultyDealer) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.seen[fd.ConfigDigest] == nil {
+		f.seen[fd.ConfigDigest] = map[commontypes.OracleID]bool{}
+	}
+	if f.seen[fd.ConfigDigest][fd.Dealer] {
+		return
+	}
+	f.seen[fd.ConfigDigest][fd.Dealer] = true
+	f.dealers = append(f.dealers, fd)
+}
+
+func (f *faultyDealers) list() []FaultyDealer {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	rv := make([]FaultyDealer, len(f.dealers))
+	for i, fd := range f.dealers {
+		rv[i] = fd
+		rv[i].Evidence = append([]byte{}, fd.Evidence...)
+	}
+	return rv
+}
+
+func (d *dkg) blame(
+	dealer, accuser *player_idx.PlayerIdx, kind FaultKind, detail error,
+	evidence []byte,
+) {
+	if d.accused[*dealer] {
+		return
+	}
+	d.accused[*dealer] = true
+	d.logger.Warn("excluding faulty dealer", commontypes.LogFields{
+		"dealer": dealer, "accuser": accuser, "fault": kind.String(), "err": detail,
+	})
+	d.faultyDealers.add(FaultyDealer{
+		dealer.OracleID(),
+		accuser.OracleID(),
+		d.cfgDgst,
+		kind,
+		detail.Error(),
+		evidence,
+	})
+}
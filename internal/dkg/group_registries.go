@@ -1,16 +1,37 @@
 package dkg
 
 import (
+	"crypto/sha256"
+
+	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/anon"
 
 	"github.com/smartcontractkit/chainlink-vrf/altbn_128"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+	"github.com/smartcontractkit/chainlink-vrf/internal/signatures/secp256k1"
 )
 
 var translatorRegistry = point_translation.TranslatorRegistry
 
 var altBN128Pairing = &altbn_128.PairingSuite{}
 
+var secp256k1Suite = secp256k1.NewBlakeKeccackSecp256k1()
+
 var encryptionGroupRegistry = map[string]anon.Suite{
 	"AltBN-128 G₁": altBN128Pairing.G1().(anon.Suite),
+	"Secp256k1":    secp256k1Suite,
+}
+
+var signingGroupRegistry = map[string]anon.Suite{
+	SigningGroup.String(): SigningGroup,
+	"Secp256k1":           secp256k1Suite,
+}
+
+// pedersenBaseRegistry gives the second base for hiding commitments in each
+// encryption group. It must be hashed to the curve, so that no one knows its
+// discrete log with respect to the group's base point.
+var pedersenBaseRegistry = map[string]kyber.Point{
+	"AltBN-128 G₁": altbn_128.NewHashProof(
+		sha256.Sum256([]byte("chainlink-vrf DKG Pedersen commitment base")),
+	).HashPoint,
 }
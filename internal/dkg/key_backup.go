@@ -0,0 +1,410 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+const keyBackupMagic = "vrfdkgbk"
+
+var keyBackupVersionNum uint8 = 1
+
+type keyBackup struct {
+	cfgDgst          types.ConfigDigest
+	keyID            contract.KeyID
+	encryptionGroup  anon.Suite
+	translator       point_translation.PubKeyTranslation
+	translationGroup kyber.Group
+	keyData          *KeyData
+	hashes           []hash.Hash
+}
+
+func (b *keyBackup) marshal(passphrase []byte) ([]byte, error) {
+	keyContext, err := b.marshalContext()
+	if err != nil {
+		return nil, err
+	}
+	header := bytes.Join(
+		[][]byte{[]byte(keyBackupMagic), {keyBackupVersionNum}, lenPrefix(keyContext)},
+		nil,
+	)
+	ct, err := b.keyData.SecretShare.EncryptWithContext(
+		passphrase, append(append([]byte{}, header...), keyContext...),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encrypt secret share for key backup")
+	}
+	return bytes.Join([][]byte{header, keyContext, ct}, nil), nil
+}
+
+func (b *keyBackup) marshalContext() ([]byte, error) {
+	kd := b.keyData
+	pk, err := kd.PublicKey.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal public key")
+	}
+	if len(kd.Shares) > int(player_idx.MaxPlayer) {
+		return nil, errors.Errorf("too many public shares to marshal")
+	}
+	encryptionGroup := []byte(b.encryptionGroup.String())
+	translator := []byte(b.translator.Name())
+	rv := [][]byte{
+		b.cfgDgst[:],
+		b.keyID[:],
+		lenPrefix(encryptionGroup),
+		encryptionGroup,
+		lenPrefix(translator),
+		translator,
+		player_idx.RawMarshal(kd.T),
+		lenPrefix(pk),
+		pk,
+		kd.SecretShare.Idx.Marshal(),
+		player_idx.RawMarshal(player_idx.Int(len(kd.Shares))),
+	}
+	for _, s := range kd.Shares {
+		ps, err := s.V.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal public share")
+		}
+		rv = append(rv, lenPrefix(ps), ps)
+	}
+	var numHashes [4]byte
+	binary.BigEndian.PutUint32(numHashes[:], uint32(len(b.hashes)))
+	rv = append(rv, numHashes[:])
+	for _, h := range b.hashes {
+		rv = append(rv, append([]byte{}, h[:]...))
+	}
+	return bytes.Join(rv, nil), nil
+}
+
+func unmarshalKeyBackup(data, passphrase []byte) (*keyBackup, error) {
+	headerLen := len(keyBackupMagic) + 1
+	if len(data) < headerLen || string(data[:len(keyBackupMagic)]) != keyBackupMagic {
+		return nil, errors.Errorf("not a DKG key backup")
+	}
+	if data[len(keyBackupMagic)] != keyBackupVersionNum {
+		return nil, errors.Errorf(
+			"don't know how to read version %d key backups", data[len(keyBackupMagic)],
+		)
+	}
+	keyContext, ct, err := readLenPrefixed(data[headerLen:])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read key backup context")
+	}
+	b, err := unmarshalKeyBackupContext(keyContext)
+	if err != nil {
+		return nil, err
+	}
+	authenticated := data[:len(data)-len(ct)]
+	share := &SecretShare{b.keyData.SecretShare.Idx, nil}
+	if err := share.DecryptWithContext(
+		passphrase, ct, authenticated, b.encryptionGroup,
+	); err != nil {
+		return nil, errors.Wrap(err, "could not decrypt key backup")
+	}
+	b.keyData.SecretShare = share
+	return b, nil
+}
+
+func unmarshalKeyBackupContext(data []byte) (*keyBackup, error) {
+	var b keyBackup
+	if len(data) < len(b.cfgDgst)+len(b.keyID) {
+		return nil, errors.Errorf("key backup context too short")
+	}
+	copy(b.cfgDgst[:], data)
+	data = data[len(b.cfgDgst):]
+	copy(b.keyID[:], data)
+	data = data[len(b.keyID):]
+	groupName, data, err := readLenPrefixed(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read encryption group")
+	}
+	var ok bool
+	b.encryptionGroup, ok = encryptionGroupRegistry[string(groupName)]
+	if !ok {
+		return nil, errors.Errorf("unknown encryption group %s in key backup", groupName)
+	}
+	translatorName, data, err := readLenPrefixed(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read translator")
+	}
+	b.translator, ok = translatorRegistry[string(translatorName)]
+	if !ok {
+		return nil, errors.Errorf("unknown translator %s in key backup", translatorName)
+	}
+	b.translationGroup, err = b.translator.TargetGroup(b.encryptionGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get translation group for key backup")
+	}
+	t, data, err := player_idx.RawUnmarshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read threshold")
+	}
+	pkB, data, err := readLenPrefixed(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read public key")
+	}
+	pk := b.translationGroup.Point()
+	if err := pk.UnmarshalBinary(pkB); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal public key")
+	}
+	idx, data, err := player_idx.Unmarshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read secret share index")
+	}
+	numShares, data, err := player_idx.RawUnmarshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read number of public shares")
+	}
+	shares := make([]kshare.PubShare, numShares)
+	for i := range shares {
+		var psB []byte
+		psB, data, err = readLenPrefixed(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read public share")
+		}
+		ps := b.translationGroup.Point()
+		if err := ps.UnmarshalBinary(psB); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal public share")
+		}
+		shares[i] = kshare.PubShare{i, ps}
+	}
+	if len(data) < 4 {
+		return nil, errors.Errorf("could not read number of key hashes")
+	}
+	numHashes, data := binary.BigEndian.Uint32(data), data[4:]
+	if uint64(len(data)) != uint64(numHashes)*hash.Size {
+		return nil, errors.Errorf("key backup has wrong length for %d hashes", numHashes)
+	}
+	b.hashes = make([]hash.Hash, numHashes)
+	for i := range b.hashes {
+		copy(b.hashes[i][:], data[i*hash.Size:])
+	}
+	b.keyData = &KeyData{pk, shares, &SecretShare{*idx, nil}, nil, nil, t, true}
+	return &b, nil
+}
+
+func (b *keyBackup) checkConsistency() error {
+	kd := b.keyData
+	if len(kd.Weights) > 0 || len(kd.ExtraShares) > 0 {
+		return errors.Errorf("key backups don't support weighted committees")
+	}
+	if !kd.SecretShare.Idx.AtMost(player_idx.Int(len(kd.Shares))) {
+		return errors.Errorf("key backup secret share index out of range")
+	}
+	if int(kd.T) >= len(kd.Shares) {
+		return errors.Errorf(
+			"key backup threshold %d too large for %d shares", kd.T, len(kd.Shares),
+		)
+	}
+	players, err := player_idx.PlayerIdxs(player_idx.Int(len(kd.Shares)))
+	if err != nil {
+		return errors.Wrap(err, "could not construct players for key backup")
+	}
+	t := int(kd.T)
+	for j := t; j < len(kd.Shares); j++ {
+		subset := append(append([]*player_idx.PlayerIdx{}, players[:t]...), players[j])
+		coeffs, err := player_idx.LagrangeCoefficients(b.translationGroup, subset)
+		if err != nil {
+			return errors.Wrap(err, "could not interpolate key backup public shares")
+		}
+		pk := b.translationGroup.Point().Null()
+		for i, c := range coeffs {
+			share := kd.Shares[j]
+			if i < t {
+				share = kd.Shares[i]
+			}
+			pk.Add(pk, b.translationGroup.Point().Mul(c, share.V))
+		}
+		if !pk.Equal(kd.PublicKey) {
+			return errors.Errorf(
+				"key backup public share %d is inconsistent with public key", j,
+			)
+		}
+	}
+	pubShare, err := b.translator.TranslateKey(kd.SecretShare.share)
+	if err != nil {
+		return errors.Wrap(err, "could not translate key backup secret share")
+	}
+	if !pubShare.Equal(kd.SecretShare.Idx.Index(kd.Shares).(kshare.PubShare).V) {
+		return errors.Errorf("key backup secret share does not match public share")
+	}
+	return nil
+}
+
+func (b *keyBackup) check(onchain *contract.KeyData) error {
+	if onchain.PublicKey == nil {
+		return errors.Errorf(
+			"no key reported onchain for key ID 0x%x and config digest %s",
+			b.keyID, b.cfgDgst,
+		)
+	}
+	if err := b.checkConsistency(); err != nil {
+		return err
+	}
+	return checkKeySnapshot(b.keyData, b.hashes, onchain, b.translator)
+}
+
+func (d *dkgReportingPluginFactory) exportKeyBackup(
+	ctx context.Context, passphrase []byte,
+) ([]byte, error) {
+	d.lock.RLock()
+	ck, ok := d.completedKeys[d.l.keyID]
+	d.lock.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("no completed key for key ID 0x%x", d.l.keyID)
+	}
+	if len(ck.keyData.Weights) > 0 {
+		return nil, errors.Errorf("key backups don't support weighted committees")
+	}
+	onchain, err := d.l.contract.KeyData(ctx, d.l.keyID, ck.cfgDgst)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get onchain key data for key backup")
+	}
+	translationGroup, err := ck.translator.TargetGroup(ck.encryptionGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get translation group for key backup")
+	}
+	b := &keyBackup{
+		ck.cfgDgst, d.l.keyID, ck.encryptionGroup, ck.translator, translationGroup,
+		ck.keyData, onchain.Hashes,
+	}
+	if err := b.check(&onchain); err != nil {
+		return nil, errors.Wrap(err, "refusing to export invalid key backup")
+	}
+	return b.marshal(passphrase)
+}
+
+func (d *dkgReportingPluginFactory) importKeyBackup(
+	ctx context.Context, backup, passphrase []byte,
+) (*KeyData, error) {
+	b, err := unmarshalKeyBackup(backup, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if b.keyID != d.l.keyID {
+		return nil, errors.Errorf(
+			"key backup is for key ID 0x%x, not 0x%x", b.keyID, d.l.keyID,
+		)
+	}
+	onchain, err := d.l.contract.KeyData(ctx, b.keyID, b.cfgDgst)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get onchain key data to check key backup")
+	}
+	if err := b.check(&onchain); err != nil {
+		return nil, errors.Wrap(err, "invalid key backup")
+	}
+	d.lock.Lock()
+	current := d.current
+	if ck, ok := d.completedKeys[b.keyID]; ok && ck.cfgDgst != b.cfgDgst {
+		d.lock.Unlock()
+		return nil, errors.Errorf(
+			"node already holds key 0x%x from config digest %s", b.keyID, ck.cfgDgst,
+		)
+	}
+	d.lock.Unlock()
+	if current != nil && len(current.weights) > 0 {
+		return nil, errors.Errorf("key backups don't support weighted committees")
+	}
+	if current != nil && current.cfgDgst == b.cfgDgst &&
+		!current.selfIdx.Equal(&b.keyData.SecretShare.Idx) {
+		return nil, errors.Errorf(
+			"key backup is for player %s, but this node is player %s",
+			b.keyData.SecretShare.Idx, current.selfIdx,
+		)
+	}
+	esk, err := d.l.keys.EncryptionKey(b.encryptionGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load encryption key to persist imported key")
+	}
+	err = writeKeySnapshot(ctx, d.l.shareDB, esk, b.cfgDgst, b.keyID, b.keyData, b.hashes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not persist imported key")
+	}
+	ck := completedKey{b.cfgDgst, b.keyData.Clone(), b.encryptionGroup, b.translator}
+	if err := d.persistCompletedKey(ctx, b.keyID, ck); err != nil {
+		return nil, errors.Wrap(err, "could not record imported key")
+	}
+	d.lock.Lock()
+	d.completedKeys[b.keyID] = ck
+	d.lock.Unlock()
+	d.l.keyConsumer.forDigest(b.cfgDgst).NewKey(b.keyID, b.keyData)
+	return b.keyData, nil
+}
+
+func exportStoredKeyBackup(
+	db dkg_types.DKGSharePersistence, keys key_store.KeyStore,
+	encryptionGroup anon.Suite, translator point_translation.PubKeyTranslation,
+	cfgDgst types.ConfigDigest, keyID contract.KeyID, passphrase []byte,
+) ([]byte, error) {
+	translationGroup, err := translator.TargetGroup(encryptionGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get translation group for key backup")
+	}
+	esk, err := keys.EncryptionKey(encryptionGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load encryption key for key backup")
+	}
+	kd, hashes, err := readKeySnapshot(
+		db, esk, encryptionGroup, translationGroup, cfgDgst, keyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if kd == nil {
+		return nil, errors.Errorf(
+			"no key snapshot stored for key ID 0x%x and config digest %s", keyID, cfgDgst,
+		)
+	}
+	b := &keyBackup{
+		cfgDgst, keyID, encryptionGroup, translator, translationGroup, kd, hashes,
+	}
+	if err := b.checkConsistency(); err != nil {
+		return nil, errors.Wrap(err, "refusing to export invalid key snapshot")
+	}
+	return b.marshal(passphrase)
+}
+
+func restoreStoredKeyBackup(
+	ctx context.Context, db dkg_types.DKGSharePersistence, keys key_store.KeyStore,
+	backup, passphrase []byte,
+) (types.ConfigDigest, contract.KeyID, error) {
+	b, err := unmarshalKeyBackup(backup, passphrase)
+	if err != nil {
+		return types.ConfigDigest{}, contract.KeyID{}, err
+	}
+	if err := b.checkConsistency(); err != nil {
+		return types.ConfigDigest{}, contract.KeyID{}, errors.Wrap(err, "invalid key backup")
+	}
+	if _, ok := db.(dkg_types.KeySnapshotPersistence); !ok {
+		return types.ConfigDigest{}, contract.KeyID{},
+			errors.Errorf("share persistence can't store key snapshots")
+	}
+	esk, err := keys.EncryptionKey(b.encryptionGroup)
+	if err != nil {
+		return types.ConfigDigest{}, contract.KeyID{},
+			errors.Wrap(err, "could not load encryption key for key backup")
+	}
+	err = writeKeySnapshot(ctx, db, esk, b.cfgDgst, b.keyID, b.keyData, b.hashes)
+	if err != nil {
+		return types.ConfigDigest{}, contract.KeyID{},
+			errors.Wrap(err, "could not write key snapshot from backup")
+	}
+	return b.cfgDgst, b.keyID, nil
+}
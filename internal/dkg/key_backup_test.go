@@ -0,0 +1,248 @@
+package dkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	"github.com/smartcontractkit/chainlink-vrf/internal/util"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+var backupPassphrase = []byte("backup passphrase")
+
+// testKeyBackup returns a backup of the second player's share of a key shared
+// among three players, and the onchain key data it matches.
+func testKeyBackup(t *testing.T) (*keyBackup, *contract.KeyData) {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	translator := translatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+	translationGroup, err := translator.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kd := newPreviousKey(t, 3, 1).keyData(2)
+	hashes := []hash.Hash{{1}, {2}}
+	b := &keyBackup{
+		cfgDgst:          types.ConfigDigest{1},
+		keyID:            contract.KeyID{2},
+		encryptionGroup:  g,
+		translator:       translator,
+		translationGroup: translationGroup,
+		keyData:          kd,
+		hashes:           hashes,
+	}
+	return b, &contract.KeyData{PublicKey: kd.PublicKey, Hashes: hashes}
+}
+
+func TestKeyBackupRoundTrip(t *testing.T) {
+	b, onchain := testKeyBackup(t)
+	m, err := b.marshal(backupPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := unmarshalKeyBackup(m, backupPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.check(onchain); err != nil {
+		t.Fatal(err)
+	}
+	if got.cfgDgst != b.cfgDgst || got.keyID != b.keyID ||
+		got.encryptionGroup.String() != b.encryptionGroup.String() ||
+		got.translator.Name() != b.translator.Name() {
+		t.Fatal("key backup context differs after round trip")
+	}
+	kd, gotKD := b.keyData, got.keyData
+	if !gotKD.SecretShare.Idx.Equal(&kd.SecretShare.Idx) ||
+		!gotKD.SecretShare.share.Equal(kd.SecretShare.share) || gotKD.T != kd.T {
+		t.Fatal("secret share differs after round trip")
+	}
+
+	if _, err := unmarshalKeyBackup(m, []byte("wrong passphrase")); err == nil {
+		t.Fatal("key backup decrypted with the wrong passphrase")
+	}
+	if _, err := unmarshalKeyBackup(m[:len(m)-1], backupPassphrase); err == nil {
+		t.Fatal("truncated key backup decrypted")
+	}
+}
+
+func TestKeyBackupAuthenticatesContext(t *testing.T) {
+	b, _ := testKeyBackup(t)
+	m, err := b.marshal(backupPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyContext, err := b.marshalContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The header is the magic, the version and the context's length prefix.
+	headerLen := len(keyBackupMagic) + 1 + 4
+	// Every byte of the header and the context is authenticated, but deriving
+	// the key for each is slow, so only tamper with one byte of each field.
+	for _, tc := range []struct {
+		name   string
+		offset int
+	}{
+		{"magic", 0},
+		{"version", len(keyBackupMagic)},
+		{"context length", headerLen - 1},
+		{"config digest", headerLen},
+		{"key ID", headerLen + len(b.cfgDgst)},
+		// The share set hashes end the context.
+		{"share set hash", headerLen + len(keyContext) - 1},
+	} {
+		tampered := append([]byte{}, m...)
+		tampered[tc.offset] ^= 1
+		if _, err := unmarshalKeyBackup(tampered, backupPassphrase); err == nil {
+			t.Fatalf("key backup with tampered %s decrypted", tc.name)
+		}
+	}
+}
+
+func TestKeyBackupRejectsInconsistentKeys(t *testing.T) {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	b, onchain := testKeyBackup(t)
+	shares := append(b.keyData.Shares[:0:0], b.keyData.Shares...)
+	shares[2].V = b.translationGroup.Point().Pick(g.RandomStream())
+	b.keyData.Shares = shares
+	if err := b.checkConsistency(); err == nil {
+		t.Fatal("accepted key backup with a public share inconsistent with the public key")
+	}
+
+	b, _ = testKeyBackup(t)
+	b.keyData.SecretShare.share = g.Scalar().Pick(g.RandomStream())
+	if err := b.checkConsistency(); err == nil {
+		t.Fatal("accepted key backup with a secret share not matching its public share")
+	}
+
+	b, _ = testKeyBackup(t)
+	onchain.Hashes = []hash.Hash{{3}}
+	if err := b.check(onchain); err == nil {
+		t.Fatal("accepted key backup whose share set hashes are not onchain")
+	}
+	if err := b.check(&contract.KeyData{}); err == nil {
+		t.Fatal("accepted key backup for a config digest with no onchain key")
+	}
+}
+
+// onchainKey reports the given key for every key ID and config digest. Its
+// other contract methods are not implemented.
+type onchainKey struct {
+	contract.DKG
+	kd contract.OnchainKeyData
+}
+
+func (k *onchainKey) GetKey(
+	context.Context, contract.KeyID, [32]byte,
+) (contract.OnchainKeyData, error) {
+	return k.kd, nil
+}
+
+func testOnchainContract(
+	t *testing.T, g kyber.Group, pk kyber.Point, hashes []hash.Hash,
+) contract.OnchainContract {
+	pkB, err := pk.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kd := contract.OnchainKeyData{PublicKey: pkB}
+	for _, h := range hashes {
+		kd.Hashes = append(kd.Hashes, h)
+	}
+	return contract.OnchainContract{DKG: &onchainKey{kd: kd}, KeyGroup: g}
+}
+
+func TestImportKeyBackupChecksKeyIDAndDigest(t *testing.T) {
+	ctx := context.Background()
+	b, onchain := testKeyBackup(t)
+	backup, err := b.marshal(backupPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &dkgReportingPluginFactory{
+		l: &localArgs{
+			keyID:    contract.KeyID{3},
+			contract: testOnchainContract(t, b.translationGroup, onchain.PublicKey, onchain.Hashes),
+			logger:   util.MakeLogger(),
+			shareDB:  persistence.NewMemorySharePersistence(),
+		},
+		completedKeys: newCompletedKeys(),
+	}
+	if _, err := f.importKeyBackup(ctx, backup, backupPassphrase); err == nil {
+		t.Fatal("imported key backup for another key ID")
+	}
+
+	f.l.keyID = b.keyID
+	f.completedKeys[b.keyID] = completedKey{
+		cfgDgst:         types.ConfigDigest{9},
+		keyData:         b.keyData.Clone(),
+		encryptionGroup: b.encryptionGroup,
+		translator:      b.translator,
+	}
+	if _, err := f.importKeyBackup(ctx, backup, backupPassphrase); err == nil {
+		t.Fatal("imported key backup over a key held from another config digest")
+	}
+
+	delete(f.completedKeys, b.keyID)
+	f.l.contract = testOnchainContract(
+		t, b.translationGroup, onchain.PublicKey, []hash.Hash{{3}},
+	)
+	if _, err := f.importKeyBackup(ctx, backup, backupPassphrase); err == nil {
+		t.Fatal("imported key backup which doesn't match the onchain key")
+	}
+}
+
+func TestStoredKeyBackupRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	b, _ := testKeyBackup(t)
+	backup, err := b.marshal(backupPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := b.encryptionGroup
+	keys := key_store.NewInProcessKeyStore(g.Scalar().Pick(g.RandomStream()), nil)
+
+	if _, _, err := restoreStoredKeyBackup(
+		ctx, appendOnlyShares{persistence.NewMemorySharePersistence()}, keys,
+		backup, backupPassphrase,
+	); err == nil {
+		t.Fatal("restored key backup to a store which can't hold key snapshots")
+	}
+
+	db := persistence.NewMemorySharePersistence()
+	cfgDgst, keyID, err := restoreStoredKeyBackup(ctx, db, keys, backup, backupPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfgDgst != b.cfgDgst || keyID != b.keyID {
+		t.Fatalf("restored key 0x%x from %s, expected 0x%x from %s",
+			keyID, cfgDgst, b.keyID, b.cfgDgst)
+	}
+	exported, err := exportStoredKeyBackup(
+		db, keys, b.encryptionGroup, b.translator, cfgDgst, keyID, backupPassphrase,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := unmarshalKeyBackup(exported, backupPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.keyData.SecretShare.share.Equal(b.keyData.SecretShare.share) {
+		t.Fatal("re-exported key backup has a different secret share")
+	}
+
+	if _, err := exportStoredKeyBackup(
+		db, keys, b.encryptionGroup, b.translator, types.ConfigDigest{9}, keyID,
+		backupPassphrase,
+	); err == nil {
+		t.Fatal("exported key backup for a config digest with no stored snapshot")
+	}
+}
@@ -0,0 +1,187 @@
+package dkg
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+)
+
+type KeyEventType int
+
+const (
+	NewKeyEvent KeyEventType = iota
+	KeyInvalidatedEvent
+)
+
+func (t KeyEventType) String() string {
+	switch t {
+	case NewKeyEvent:
+		return "new key"
+	case KeyInvalidatedEvent:
+		return "key invalidated"
+	default:
+		return fmt.Sprintf("unknown key event %d", int(t))
+	}
+}
+
+type KeyEvent struct {
+	Type         KeyEventType
+	KeyID        contract.KeyID
+	ConfigDigest types.ConfigDigest
+	Timestamp    time.Time
+
+	KeyData *KeyData
+}
+
+type KeyBroadcaster struct {
+	lock          sync.RWMutex
+	consumers     []KeyConsumer
+	subscriptions map[*keySubscription]bool
+}
+
+var _ KeyConsumer = (*KeyBroadcaster)(nil)
+
+func NewKeyBroadcaster(consumers ...KeyConsumer) *KeyBroadcaster {
+	return &KeyBroadcaster{
+		sync.RWMutex{},
+		append([]KeyConsumer{}, consumers...),
+		map[*keySubscription]bool{},
+	}
+}
+
+func keyBroadcaster(k KeyConsumer) *KeyBroadcaster {
+	if b, ok := k.(*KeyBroadcaster); ok {
+		return b
+	}
+	if k == nil {
+		return NewKeyBroadcaster()
+	}
+	return NewKeyBroadcaster(k)
+}
+
+func (b *KeyBroadcaster) AddConsumer(k KeyConsumer) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.consumers = append(b.consumers, k)
+}
+
+func (b *KeyBroadcaster) setConsumer(k KeyConsumer) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.consumers = []KeyConsumer{k}
+}
+
+func (b *KeyBroadcaster) Subscribe() (events <-chan KeyEvent, unsubscribe func()) {
+	s := &keySubscription{
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		events: make(chan KeyEvent),
+	}
+	b.lock.Lock()
+	b.subscriptions[s] = true
+	b.lock.Unlock()
+	go s.run()
+	return s.events, func() {
+		b.lock.Lock()
+		delete(b.subscriptions, s)
+		b.lock.Unlock()
+		s.close()
+	}
+}
+
+func (b *KeyBroadcaster) KeyInvalidated(keyID contract.KeyID) {
+	b.publish(KeyEvent{KeyInvalidatedEvent, keyID, types.ConfigDigest{}, time.Now(), nil})
+}
+
+func (b *KeyBroadcaster) NewKey(keyID contract.KeyID, kd *KeyData) {
+	b.publish(KeyEvent{NewKeyEvent, keyID, types.ConfigDigest{}, time.Now(), kd})
+}
+
+func (b *KeyBroadcaster) forDigest(cfgDgst types.ConfigDigest) KeyConsumer {
+	return &digestKeyConsumer{b, cfgDgst}
+}
+
+func (b *KeyBroadcaster) publish(e KeyEvent) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for _, k := range b.consumers {
+		switch e.Type {
+		case NewKeyEvent:
+			k.NewKey(e.KeyID, e.KeyData)
+		case KeyInvalidatedEvent:
+			k.KeyInvalidated(e.KeyID)
+		}
+	}
+	for s := range b.subscriptions {
+		se := e
+		if e.KeyData != nil {
+			se.KeyData = e.KeyData.Clone()
+		}
+		s.push(se)
+	}
+}
+
+type digestKeyConsumer struct {
+	b       *KeyBroadcaster
+	cfgDgst types.ConfigDigest
+}
+
+func (d *digestKeyConsumer) KeyInvalidated(keyID contract.KeyID) {
+	d.b.publish(KeyEvent{KeyInvalidatedEvent, keyID, d.cfgDgst, time.Now(), nil})
+}
+
+func (d *digestKeyConsumer) NewKey(keyID contract.KeyID, kd *KeyData) {
+	d.b.publish(KeyEvent{NewKeyEvent, keyID, d.cfgDgst, time.Now(), kd})
+}
+
+type keySubscription struct {
+	lock    sync.Mutex
+	pending []KeyEvent
+	closed  sync.Once
+
+	wake   chan struct{}
+	done   chan struct{}
+	events chan KeyEvent
+}
+
+func (s *keySubscription) push(e KeyEvent) {
+	s.lock.Lock()
+	s.pending = append(s.pending, e)
+	s.lock.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *keySubscription) run() {
+	defer close(s.events)
+	for {
+		s.lock.Lock()
+		if len(s.pending) == 0 {
+			s.lock.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		e := s.pending[0]
+		s.pending = s.pending[1:]
+		s.lock.Unlock()
+		select {
+		case s.events <- e:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *keySubscription) close() {
+	s.closed.Do(func() { close(s.done) })
+}
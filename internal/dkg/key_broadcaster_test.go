@@ -0,0 +1,106 @@
+package dkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+)
+
+// recordingConsumer records the key IDs of the events it's given.
+type recordingConsumer struct {
+	newKeys, invalidated []contract.KeyID
+}
+
+func (r *recordingConsumer) NewKey(keyID contract.KeyID, _ *KeyData) {
+	r.newKeys = append(r.newKeys, keyID)
+}
+
+func (r *recordingConsumer) KeyInvalidated(keyID contract.KeyID) {
+	r.invalidated = append(r.invalidated, keyID)
+}
+
+func receive(t *testing.T, events <-chan KeyEvent) (KeyEvent, bool) {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		return e, ok
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for key event")
+		return KeyEvent{}, false
+	}
+}
+
+func TestSlowSubscriberDoesNotBlockPublish(t *testing.T) {
+	const numEvents = 100
+	consumer := &recordingConsumer{}
+	b := NewKeyBroadcaster(consumer)
+	slow, unsubscribeSlow := b.Subscribe()
+	defer unsubscribeSlow()
+	events, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	kd := newPreviousKey(t, 3, 1).keyData(1)
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		k := b.forDigest(types.ConfigDigest{1})
+		for i := 0; i < numEvents; i++ {
+			k.NewKey(contract.KeyID{byte(i)}, kd)
+		}
+		k.KeyInvalidated(contract.KeyID{numEvents})
+	}()
+	// Neither subscriber is reading yet.
+	select {
+	case <-published:
+	case <-time.After(10 * time.Second):
+		t.Fatal("publishing blocked on subscribers which aren't reading")
+	}
+	if len(consumer.newKeys) != numEvents || len(consumer.invalidated) != 1 {
+		t.Fatalf("consumer given %d new keys and %d invalidations",
+			len(consumer.newKeys), len(consumer.invalidated))
+	}
+
+	for i := 0; i <= numEvents; i++ {
+		e, ok := receive(t, events)
+		if !ok {
+			t.Fatal("subscription closed while events were pending")
+		}
+		want := NewKeyEvent
+		if i == numEvents {
+			want = KeyInvalidatedEvent
+		}
+		if e.Type != want || e.KeyID != (contract.KeyID{byte(i)}) ||
+			e.ConfigDigest != (types.ConfigDigest{1}) {
+			t.Fatalf("event %d is %s for key 0x%x from %s", i, e.Type, e.KeyID, e.ConfigDigest)
+		}
+		if want == NewKeyEvent && (e.KeyData == kd || !e.KeyData.PublicKey.Equal(kd.PublicKey)) {
+			t.Fatalf("event %d does not carry a copy of the key data", i)
+		}
+	}
+	if e, ok := receive(t, slow); !ok || e.KeyID != (contract.KeyID{}) {
+		t.Fatalf("slow subscriber's first event is %+v", e)
+	}
+}
+
+func TestUnsubscribeClosesEvents(t *testing.T) {
+	b := NewKeyBroadcaster()
+	events, unsubscribe := b.Subscribe()
+	b.KeyInvalidated(contract.KeyID{1})
+	b.KeyInvalidated(contract.KeyID{2})
+	// Unsubscribing drops events not yet received.
+	unsubscribe()
+	for {
+		if _, ok := receive(t, events); !ok {
+			break
+		}
+	}
+	// Neither publishing nor unsubscribing again blocks or panics.
+	b.KeyInvalidated(contract.KeyID{3})
+	unsubscribe()
+	if len(b.subscriptions) != 0 {
+		t.Fatalf("%d subscriptions left after unsubscribing", len(b.subscriptions))
+	}
+}
@@ -20,7 +20,9 @@ type KeyData struct {
 	PublicKey   kyber.Point
 	Shares      []share.PubShare
 	SecretShare *SecretShare
+	ExtraShares []*SecretShare
 
+	Weights []player_idx.Int
 	T       player_idx.Int
 	Present bool
 }
@@ -40,10 +42,58 @@ func (kd KeyData) Clone() *KeyData {
 	if kd.SecretShare == nil {
 		panic("nil secret share")
 	}
+	var extraShares []*SecretShare
+	for _, s := range kd.ExtraShares {
+		extraShares = append(extraShares, s.Clone())
+	}
 	return &KeyData{
 		kd.PublicKey.Clone(),
 		shares, kd.SecretShare.Clone(),
+		extraShares,
+		append([]player_idx.Int(nil), kd.Weights...),
 		kd.T,
 		kd.Present,
 	}
 }
+
+// HeldShares returns all this player's secret shares, one for each unit of its
+// weight.
+func (kd *KeyData) HeldShares() []*SecretShare {
+	return append([]*SecretShare{kd.SecretShare}, kd.ExtraShares...)
+}
+
+// Weight returns the number of shares held by the player p.
+func (kd *KeyData) Weight(p *player_idx.PlayerIdx) int {
+	if len(kd.Weights) == 0 {
+		return 1
+	}
+	if !p.AtMost(player_idx.Int(len(kd.Weights))) {
+		return 0
+	}
+	return int(p.Index(kd.Weights).(player_idx.Int))
+}
+
+// PlayerShares returns the public shares held by the player p, in the order of
+// its secret shares.
+func (kd *KeyData) PlayerShares(p *player_idx.PlayerIdx) ([]share.PubShare, error) {
+	n := len(kd.Weights)
+	if n == 0 {
+		n = len(kd.Shares)
+	}
+	if !p.AtMost(player_idx.Int(n)) {
+		return nil, fmt.Errorf("player %s out of range of %d players", p, n)
+	}
+	held, err := heldShares(kd.Weights, n)
+	if err != nil {
+		return nil, err
+	}
+	idxs := p.Index(held).([]*player_idx.PlayerIdx)
+	if !idxs[len(idxs)-1].AtMost(player_idx.Int(len(kd.Shares))) {
+		return nil, fmt.Errorf("missing public shares of player %s", p)
+	}
+	rv := make([]share.PubShare, len(idxs))
+	for i, idx := range idxs {
+		rv[i] = idx.Index(kd.Shares).(share.PubShare)
+	}
+	return rv, nil
+}
@@ -0,0 +1,255 @@
+package dkg
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+)
+
+// requiredShareSets is counted in dealer weight, so that the dealers of a key
+// always include an honest one.
+func (d *dkg) requiredShareSets() int {
+	if d.mode == reshareKey {
+		return int(d.lastKeyData.T) + 1
+	}
+	return int(d.t) + 1
+}
+
+func (d *dkg) ownShareSet() (*pvss.ShareSet, error) {
+	switch d.mode {
+	case refreshKey:
+		return pvss.NewShareSetWithSecret(
+			d.cfgDgst, d.t, d.selfIdx, d.encryptionGroup, d.translator, d.shareEpks(),
+			d.encryptionGroup.Scalar().Zero(),
+		)
+	case reshareKey:
+		if d.selfIdx.Index(d.previousPlayers).(player_idx.Int) == 0 ||
+			d.lastKeyData.SecretShare == nil {
+			return nil, nil
+		}
+		return pvss.NewShareSetWithSecret(
+			d.cfgDgst, d.t, d.selfIdx, d.encryptionGroup, d.translator, d.shareEpks(),
+			d.lastKeyData.SecretShare.share,
+		)
+	default:
+		return pvss.NewShareSet(
+			d.cfgDgst, d.t, d.selfIdx, d.encryptionGroup, d.translator, d.shareEpks(),
+		)
+	}
+}
+
+func (d *dkg) checkSharedSecret(ss *pvss.ShareSet) error {
+	if ss.Threshold() != d.t {
+		return errors.Errorf(
+			"share set has threshold %d, expected %d", ss.Threshold(), d.t,
+		)
+	}
+	switch d.mode {
+	case refreshKey:
+		if !ss.SecretCommitment().Equal(d.encryptionGroup.Point().Null()) {
+			return errors.Errorf("share set does not share zero during key refresh")
+		}
+	case reshareKey:
+		previous, err := d.previousPlayer(ss)
+		if err != nil {
+			return err
+		}
+		if !ss.PublicKey().Equal(previous.Index(d.lastKeyData.Shares).(kshare.PubShare).V) {
+			return errors.Errorf(
+				"share set does not share the dealer's share of the previous key",
+			)
+		}
+	}
+	return nil
+}
+
+func (d *dkg) previousPlayer(ss *pvss.ShareSet) (*player_idx.PlayerIdx, error) {
+	dealer, err := ss.Dealer()
+	if err != nil {
+		return nil, errors.Wrap(err, "bad dealer on share set")
+	}
+	previousPlayers, err := player_idx.PlayerIdxs(player_idx.Int(len(d.lastKeyData.Shares)))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not construct previous players")
+	}
+	previousIdx := dealer.Index(d.previousPlayers).(player_idx.Int)
+	if previousIdx == 0 {
+		return nil, errors.Errorf("dealer %s held no share of the previous key", dealer)
+	}
+	return previousPlayers[previousIdx-1], nil
+}
+
+func (d *dkg) checkReportedKey(kd *contract.KeyData) (map[hash.Hash]kyber.Scalar, error) {
+	for _, h := range kd.Hashes {
+		if err := d.checkSharedSecret(d.shareSets[h].shareSet); err != nil {
+			return nil, errors.Wrapf(err, "bad share set for key hash 0x%x", h)
+		}
+	}
+	if d.mode == freshKey {
+		return nil, nil
+	}
+	if !kd.PublicKey.Equal(d.lastKeyData.PublicKey) {
+		return nil, errors.Errorf("reported key does not match previous key")
+	}
+	if d.mode != reshareKey {
+		return nil, nil
+	}
+	if len(kd.Hashes) < d.requiredShareSets() {
+		return nil, errors.Errorf(
+			"need %d share sets to reshare key, got %d",
+			d.requiredShareSets(), len(kd.Hashes),
+		)
+	}
+	dealers := make([]*player_idx.PlayerIdx, len(kd.Hashes))
+	for i, h := range kd.Hashes {
+		previous, err := d.previousPlayer(d.shareSets[h].shareSet)
+		if err != nil {
+			return nil, err
+		}
+		dealers[i] = previous
+	}
+	coeffs, err := player_idx.LagrangeCoefficients(d.encryptionGroup, dealers)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not weight reshared shares")
+	}
+	weights := make(map[hash.Hash]kyber.Scalar, len(kd.Hashes))
+	for i, h := range kd.Hashes {
+		weights[h] = coeffs[i]
+	}
+	return weights, nil
+}
+
+func (d *dkg) refreshShares(
+	zeroShare *kshare.PriShare, zeroShares []kyber.Point,
+) (*kshare.PriShare, []kyber.Point, error) {
+	old := d.lastKeyData
+	if len(zeroShares) != len(old.Shares) {
+		return nil, nil, errors.Errorf(
+			"number of public shares changed from %d to %d",
+			len(old.Shares), len(zeroShares),
+		)
+	}
+	share := d.selfIdx.PriShare(
+		d.encryptionGroup.Scalar().Add(old.SecretShare.share, zeroShare.V),
+	)
+	shares := make([]kyber.Point, len(zeroShares))
+	for i, s := range zeroShares {
+		shares[i] = s.Clone().Add(old.Shares[i].V, s)
+	}
+	return &share, shares, nil
+}
+
+func (a *NewDKGArgs) sanityCheckPreviousKey(n int) error {
+	switch a.mode {
+	case refreshKey:
+		if a.lastKeyData == nil {
+			return nil
+		}
+		if a.lastKeyData.T != a.t {
+			return errors.Errorf("threshold changed from %d to %d", a.lastKeyData.T, a.t)
+		}
+		if len(a.lastKeyData.Shares) != n {
+			return errors.Errorf(
+				"number of players changed from %d to %d", len(a.lastKeyData.Shares), n,
+			)
+		}
+		if a.lastKeyData.SecretShare == nil {
+			return errors.Errorf("missing secret share")
+		}
+		return a.sanityCheckSecretShare(a.selfIdx)
+	case reshareKey:
+		if len(a.previousPlayers) != n {
+			return errors.Errorf(
+				"need previous player index for each of %d players, got %d",
+				n, len(a.previousPlayers),
+			)
+		}
+		if int(a.previousThreshold) >= len(a.previousPublicShares) {
+			return errors.Errorf(
+				"previous threshold %d too large for %d previous players",
+				a.previousThreshold, len(a.previousPublicShares),
+			)
+		}
+		dealers := 0
+		for _, p := range a.previousPlayers {
+			if p != 0 {
+				dealers++
+			}
+		}
+		if dealers <= int(a.previousThreshold) {
+			return errors.Errorf(
+				"only %d previous players remain, need %d to reshare key",
+				dealers, a.previousThreshold+1,
+			)
+		}
+		if a.lastKeyData == nil {
+			return nil
+		}
+		if err := a.sanityCheckPreviousShares(); err != nil {
+			return err
+		}
+		if a.lastKeyData.SecretShare == nil {
+			return nil
+		}
+		previousIdx := a.selfIdx.Index(a.previousPlayers).(player_idx.Int)
+		if previousIdx == 0 {
+			return errors.Errorf("have a previous secret share, but no previous index")
+		}
+		previousPlayers, err := player_idx.PlayerIdxs(previousIdx)
+		if err != nil {
+			return errors.Wrap(err, "could not construct previous players")
+		}
+		return a.sanityCheckSecretShare(previousPlayers[previousIdx-1])
+	}
+	return nil
+}
+
+func (a *NewDKGArgs) sanityCheckSecretShare(idx *player_idx.PlayerIdx) error {
+	kd := a.lastKeyData
+	if !kd.SecretShare.Idx.Equal(idx) {
+		return errors.Errorf(
+			"secret share is for player %s, expected %s", kd.SecretShare.Idx, idx,
+		)
+	}
+	pubShare, err := a.translator.TranslateKey(kd.SecretShare.share)
+	if err != nil {
+		return errors.Wrap(err, "could not translate secret share")
+	}
+	if !pubShare.Equal(idx.Index(kd.Shares).(kshare.PubShare).V) {
+		return errors.Errorf("secret share does not match public share")
+	}
+	return nil
+}
+
+func (a *NewDKGArgs) sanityCheckPreviousShares() error {
+	kd := a.lastKeyData
+	players, err := player_idx.PlayerIdxs(player_idx.Int(len(kd.Shares)))
+	if err != nil {
+		return errors.Wrap(err, "could not construct previous players")
+	}
+	t := int(kd.T)
+	for j := t; j < len(players); j++ {
+		subset := append(append([]*player_idx.PlayerIdx{}, players[:t]...), players[j])
+		coeffs, err := player_idx.LagrangeCoefficients(a.encryptionGroup, subset)
+		if err != nil {
+			return errors.Wrap(err, "could not interpolate previous public shares")
+		}
+		acc := a.translationGroup.Point().Null()
+		for i, p := range subset {
+			share := p.Index(kd.Shares).(kshare.PubShare).V
+			acc = acc.Add(acc, a.translationGroup.Point().Mul(coeffs[i], share))
+		}
+		if !acc.Equal(kd.PublicKey) {
+			return errors.Errorf(
+				"previous public shares do not match previous key at threshold %d", t,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package dkg
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
+	"github.com/smartcontractkit/chainlink-vrf/internal/util"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+// previousKey is a key shared among n players, which a refresh or reshare
+// starts from.
+type previousKey struct {
+	secret    kyber.Scalar
+	shares    []*kshare.PriShare
+	pubShares []kshare.PubShare
+	publicKey kyber.Point
+	t         player_idx.Int
+}
+
+func newPreviousKey(t *testing.T, n, threshold int) *previousKey {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	translator := translatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+	poly := kshare.NewPriPoly(g, threshold+1, nil, g.RandomStream())
+	rv := &previousKey{secret: poly.Secret(), shares: poly.Shares(n), t: player_idx.Int(threshold)}
+	var err error
+	rv.publicKey, err = translator.TranslateKey(rv.secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range rv.shares {
+		pubShare, err := translator.TranslateKey(s.V)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv.pubShares = append(rv.pubShares, kshare.PubShare{I: s.I, V: pubShare})
+	}
+	return rv
+}
+
+// keyData returns the key data the player with the given 1-based index kept
+// for k.
+func (k *previousKey) keyData(idx player_idx.Int) *KeyData {
+	players, _ := player_idx.PlayerIdxs(idx)
+	return &KeyData{
+		PublicKey:   k.publicKey,
+		Shares:      k.pubShares,
+		SecretShare: &SecretShare{*players[idx-1], k.shares[idx-1].V},
+		T:           k.t,
+		Present:     true,
+	}
+}
+
+// modePlayers returns a DKG instance for each of n players dealing a key with
+// the given threshold in the given mode. lastKeyData gives each player's key
+// data for the previous key, and previousPlayers each player's index in the
+// previous committee, if any.
+func modePlayers(
+	t *testing.T, mode dkgMode, n, threshold int,
+	lastKeyData func(i int) *KeyData, previousPlayers []player_idx.Int,
+) []*dkg {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	translator := translatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+	translationGroup, err := translator.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players, err := player_idx.PlayerIdxs(player_idx.Int(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	esks := make([]key_store.EncryptionKey, n)
+	epks := make([]kyber.Point, n)
+	for i := range players {
+		esks[i] = testEncryptionKey(t)
+		epks[i] = esks[i].PublicKey()
+	}
+	ds := make([]*dkg, n)
+	for i, p := range players {
+		ds[i] = &dkg{
+			t:                player_idx.Int(threshold),
+			selfIdx:          p,
+			cfgDgst:          types.ConfigDigest{1},
+			keyID:            contract.KeyID{2},
+			shareSets:        newShareRecords(),
+			esk:              esks[i],
+			epks:             epks,
+			encryptionGroup:  g,
+			translationGroup: translationGroup,
+			translator:       translator,
+			mode:             mode,
+			lastKeyData:      lastKeyData(i),
+			previousPlayers:  previousPlayers,
+			logger:           util.MakeLogger(),
+		}
+	}
+	return ds
+}
+
+// deal has each dealer deal its share set for the mode to every player, and
+// returns the key data reporting those share sets.
+func deal(t *testing.T, dealers, ds []*dkg, publicKey kyber.Point) contract.KeyData {
+	kd := contract.KeyData{PublicKey: publicKey}
+	for i, dealer := range dealers {
+		ss, err := dealer.ownShareSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		kd.Hashes = append(kd.Hashes, addShareSet(t, ds, ss, hash.Hash{byte(i + 1)}))
+	}
+	return kd
+}
+
+func addShareSet(t *testing.T, ds []*dkg, ss *pvss.ShareSet, h hash.Hash) hash.Hash {
+	for _, d := range ds {
+		if err := d.shareSets.set(&shareRecord{shareSet: ss}, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return h
+}
+
+// recoverKey has each player recover its share of the reported key, and
+// returns those shares and the public shares the first player recovered.
+func recoverKey(
+	t *testing.T, ds []*dkg, kd contract.KeyData,
+) ([]*kshare.PriShare, []kshare.PubShare) {
+	var shares []*kshare.PriShare
+	var pubShares []kshare.PubShare
+	for _, d := range ds {
+		weights, err := d.checkReportedKey(&kd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blame := func(_ *shareRecord, _ player_idx.PlayerIdx, fault error) {
+			t.Fatalf("undecryptable share: %v", fault)
+		}
+		share, err := d.shareSets.recoverDistributedKeyShare(
+			d.esk, *d.selfIdx, &kd, d.encryptionGroup, d.cfgDgst, weights, blame,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points, err := d.shareSets.recoverPublicShares(&kd, weights)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.mode == refreshKey {
+			share, points, err = d.refreshShares(share, points)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		shares = append(shares, share)
+		if pubShares == nil {
+			for i, p := range points {
+				pubShares = append(pubShares, kshare.PubShare{I: i, V: p})
+			}
+		}
+	}
+	return shares, pubShares
+}
+
+// checkSharing fails unless the shares and public shares both interpolate to
+// the given key at the threshold, and each share matches its public share.
+func checkSharing(
+	t *testing.T, d *dkg, threshold int, shares []*kshare.PriShare,
+	pubShares []kshare.PubShare, secret kyber.Scalar, publicKey kyber.Point,
+) {
+	n := len(pubShares)
+	got, err := kshare.RecoverSecret(d.encryptionGroup, shares, threshold+1, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(secret) {
+		t.Fatal("shares interpolate to the wrong secret")
+	}
+	ptrs := make([]*kshare.PubShare, n)
+	for i := range pubShares {
+		ptrs[i] = &pubShares[i]
+	}
+	// Each threshold-sized window of public shares must give the key.
+	for start := 0; start+threshold+1 <= n; start++ {
+		window := ptrs[start : start+threshold+1]
+		commit, err := kshare.RecoverCommit(d.translationGroup, window, threshold+1, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !commit.Equal(publicKey) {
+			t.Fatalf("public shares %d to %d interpolate to the wrong key", start, start+threshold)
+		}
+	}
+	for _, s := range shares {
+		pubShare, err := d.translator.TranslateKey(s.V)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pubShare.Equal(pubShares[s.I].V) {
+			t.Fatalf("share %d does not match its public share", s.I)
+		}
+	}
+}
+
+func TestRefreshPreservesKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	const n, threshold = 5, 1
+	old := newPreviousKey(t, n, threshold)
+	ds := modePlayers(t, refreshKey, n, threshold, func(i int) *KeyData {
+		return old.keyData(player_idx.Int(i + 1))
+	}, nil)
+	kd := deal(t, ds[:threshold+1], ds, old.publicKey)
+
+	shares, pubShares := recoverKey(t, ds, kd)
+	checkSharing(t, ds[0], threshold, shares, pubShares, old.secret, old.publicKey)
+	checkSharing(t, ds[0], threshold, old.shares, old.pubShares, old.secret, old.publicKey)
+	for i, s := range shares {
+		if s.I != old.shares[i].I || s.V.Equal(old.shares[i].V) {
+			t.Fatalf("refresh did not change player %d's share", i+1)
+		}
+		if pubShares[i].V.Equal(old.pubShares[i].V) {
+			t.Fatalf("refresh did not change player %d's public share", i+1)
+		}
+	}
+
+	wrongKey := kd
+	wrongKey.PublicKey = old.publicKey.Clone().Add(old.publicKey, old.publicKey)
+	if _, err := ds[0].checkReportedKey(&wrongKey); err == nil {
+		t.Fatal("refresh accepted a report of a different key")
+	}
+}
+
+func TestRefreshRejectsNonZeroDealer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	const n, threshold = 5, 1
+	old := newPreviousKey(t, n, threshold)
+	ds := modePlayers(t, refreshKey, n, threshold, func(i int) *KeyData {
+		return old.keyData(player_idx.Int(i + 1))
+	}, nil)
+	dealer := ds[1]
+	ss, err := pvss.NewShareSet(
+		dealer.cfgDgst, dealer.t, dealer.selfIdx, dealer.encryptionGroup, dealer.translator,
+		dealer.shareEpks(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ds[0].checkSharedSecret(ss); err == nil {
+		t.Fatal("refresh accepted a share set for a non-zero secret")
+	}
+	kd := contract.KeyData{
+		PublicKey: old.publicKey, Hashes: []hash.Hash{addShareSet(t, ds, ss, hash.Hash{1})},
+	}
+	if _, err := ds[0].checkReportedKey(&kd); err == nil {
+		t.Fatal("refresh accepted a reported key dealt from a non-zero secret")
+	}
+}
+
+// resharePlayers returns a DKG instance for each of n players resharing old
+// with the given threshold. previousPlayers gives each player's index in the
+// old committee, or zero; those with an index hold their old share.
+func resharePlayers(
+	t *testing.T, old *previousKey, n, threshold int, previousPlayers []player_idx.Int,
+) []*dkg {
+	return modePlayers(t, reshareKey, n, threshold, func(i int) *KeyData {
+		if previousPlayers[i] == 0 {
+			return &KeyData{PublicKey: old.publicKey, Shares: old.pubShares, T: old.t}
+		}
+		return old.keyData(previousPlayers[i])
+	}, previousPlayers)
+}
+
+func TestResharePreservesKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	const oldN, oldThreshold, n, threshold = 4, 1, 5, 2
+	old := newPreviousKey(t, oldN, oldThreshold)
+	// Old players 4 and 2 deal, so the weights aren't those of the first
+	// players.
+	ds := resharePlayers(t, old, n, threshold, []player_idx.Int{4, 2, 3, 0, 0})
+	kd := deal(t, ds[:oldThreshold+1], ds, old.publicKey)
+
+	weights, err := ds[2].checkReportedKey(&kd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := ds[0].encryptionGroup
+	// The Lagrange coefficients at zero for old players 4 and 2.
+	minusOne := g.Scalar().Sub(g.Scalar().Zero(), g.Scalar().One())
+	if !weights[kd.Hashes[0]].Equal(minusOne) ||
+		!weights[kd.Hashes[1]].Equal(g.Scalar().SetInt64(2)) {
+		t.Fatal("reshared share sets have the wrong weights")
+	}
+
+	shares, pubShares := recoverKey(t, ds, kd)
+	if len(pubShares) != n {
+		t.Fatalf("reshared key has %d public shares, expected %d", len(pubShares), n)
+	}
+	checkSharing(t, ds[0], threshold, shares, pubShares, old.secret, old.publicKey)
+
+	if _, err := ds[2].checkReportedKey(&contract.KeyData{
+		PublicKey: old.publicKey, Hashes: kd.Hashes[:1],
+	}); err == nil {
+		t.Fatal("reshare accepted fewer share sets than the old threshold needs")
+	}
+}
+
+func TestReshareRejectsWrongOldShare(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	const oldN, oldThreshold, n, threshold = 4, 1, 5, 2
+	old := newPreviousKey(t, oldN, oldThreshold)
+	ds := resharePlayers(t, old, n, threshold, []player_idx.Int{4, 2, 3, 0, 0})
+	// The dealer claims old player 4's place, but deals old player 3's share.
+	ds[0].lastKeyData.SecretShare.share = old.shares[2].V
+	ss, err := ds[0].ownShareSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ds[1].checkSharedSecret(ss); err == nil {
+		t.Fatal("reshare accepted a share set dealt from the wrong old share")
+	}
+	kd := deal(t, ds[1:2], ds, old.publicKey)
+	kd.Hashes = append(kd.Hashes, addShareSet(t, ds, ss, hash.Hash{9}))
+	if _, err := ds[1].checkReportedKey(&kd); err == nil {
+		t.Fatal("reshare accepted a reported key dealt from the wrong old share")
+	}
+}
@@ -0,0 +1,373 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+)
+
+// Version 2 snapshots also hold the player weights and the extra secret shares
+// of a weighted player.
+var keySnapshotVersionNum, weightedKeySnapshotVersionNum uint8 = 1, 2
+
+const keySnapshotDomainSep = "chainlink-vrf DKG key snapshot"
+
+func marshalKeySnapshot(kd *KeyData, hashes []hash.Hash) ([]byte, error) {
+	pk, err := kd.PublicKey.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal public key")
+	}
+	secret, err := kd.SecretShare.share.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal secret share")
+	}
+	if len(kd.Shares) > int(player_idx.MaxPlayer) {
+		return nil, errors.Errorf("too many public shares to marshal")
+	}
+	version := keySnapshotVersionNum
+	if len(kd.Weights) > 0 {
+		version = weightedKeySnapshotVersionNum
+	}
+	rv := [][]byte{
+		{version},
+		player_idx.RawMarshal(kd.T),
+		lenPrefix(pk),
+		pk,
+		kd.SecretShare.Idx.Marshal(),
+		lenPrefix(secret),
+		secret,
+		player_idx.RawMarshal(player_idx.Int(len(kd.Shares))),
+	}
+	for _, s := range kd.Shares {
+		ps, err := s.V.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal public share")
+		}
+		rv = append(rv, lenPrefix(ps), ps)
+	}
+	if version == weightedKeySnapshotVersionNum {
+		rv = append(rv, player_idx.RawMarshal(player_idx.Int(len(kd.Weights))))
+		for _, w := range kd.Weights {
+			rv = append(rv, player_idx.RawMarshal(w))
+		}
+		rv = append(rv, player_idx.RawMarshal(player_idx.Int(len(kd.ExtraShares))))
+		for _, s := range kd.ExtraShares {
+			secret, err := s.share.MarshalBinary()
+			if err != nil {
+				return nil, errors.Wrap(err, "could not marshal extra secret share")
+			}
+			rv = append(rv, s.Idx.Marshal(), lenPrefix(secret), secret)
+		}
+	}
+	var numHashes [4]byte
+	binary.BigEndian.PutUint32(numHashes[:], uint32(len(hashes)))
+	rv = append(rv, numHashes[:])
+	for _, h := range hashes {
+		rv = append(rv, append([]byte{}, h[:]...))
+	}
+	return bytes.Join(rv, nil), nil
+}
+
+func unmarshalKeySnapshot(
+	data []byte, encryptionGroup, translationGroup kyber.Group,
+) (*KeyData, []hash.Hash, error) {
+	if len(data) < 1 ||
+		(data[0] != keySnapshotVersionNum && data[0] != weightedKeySnapshotVersionNum) {
+		return nil, nil, errors.Errorf("unknown key snapshot version")
+	}
+	version := data[0]
+	t, data, err := player_idx.RawUnmarshal(data[1:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read threshold")
+	}
+	pkB, data, err := readLenPrefixed(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read public key")
+	}
+	pk := translationGroup.Point()
+	if err := pk.UnmarshalBinary(pkB); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal public key")
+	}
+	idx, data, err := player_idx.Unmarshal(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read secret share index")
+	}
+	secretB, data, err := readLenPrefixed(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read secret share")
+	}
+	secret := encryptionGroup.Scalar()
+	if err := secret.UnmarshalBinary(secretB); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal secret share")
+	}
+	numShares, data, err := player_idx.RawUnmarshal(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read number of public shares")
+	}
+	shares := make([]kshare.PubShare, numShares)
+	for i := range shares {
+		var psB []byte
+		psB, data, err = readLenPrefixed(data)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not read public share")
+		}
+		ps := translationGroup.Point()
+		if err := ps.UnmarshalBinary(psB); err != nil {
+			return nil, nil, errors.Wrap(err, "could not unmarshal public share")
+		}
+		shares[i] = kshare.PubShare{i, ps}
+	}
+	var weights []player_idx.Int
+	var extraShares []*SecretShare
+	if version == weightedKeySnapshotVersionNum {
+		weights, extraShares, data, err = unmarshalWeightedShares(data, encryptionGroup)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(data) < 4 {
+		return nil, nil, errors.Errorf("could not read number of key hashes")
+	}
+	numHashes, data := binary.BigEndian.Uint32(data), data[4:]
+	if uint64(len(data)) != uint64(numHashes)*hash.Size {
+		return nil, nil, errors.Errorf("key snapshot has wrong length for %d hashes", numHashes)
+	}
+	hashes := make([]hash.Hash, numHashes)
+	for i := range hashes {
+		copy(hashes[i][:], data[i*hash.Size:])
+	}
+	kd := &KeyData{pk, shares, &SecretShare{*idx, secret}, extraShares, weights, t, true}
+	return kd, hashes, nil
+}
+
+func unmarshalWeightedShares(data []byte, encryptionGroup kyber.Group) (
+	weights []player_idx.Int, extraShares []*SecretShare, rem []byte, err error,
+) {
+	numWeights, data, err := player_idx.RawUnmarshal(data)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not read number of weights")
+	}
+	if numWeights == 0 {
+		return nil, nil, nil, errors.Errorf("weighted key snapshot has no weights")
+	}
+	weights = make([]player_idx.Int, numWeights)
+	for i := range weights {
+		weights[i], data, err = player_idx.RawUnmarshal(data)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "could not read weight")
+		}
+	}
+	numExtra, data, err := player_idx.RawUnmarshal(data)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not read number of extra secret shares")
+	}
+	extraShares = make([]*SecretShare, numExtra)
+	for i := range extraShares {
+		var idx *player_idx.PlayerIdx
+		idx, data, err = player_idx.Unmarshal(data)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "could not read extra secret share index")
+		}
+		var secretB []byte
+		secretB, data, err = readLenPrefixed(data)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "could not read extra secret share")
+		}
+		secret := encryptionGroup.Scalar()
+		if err := secret.UnmarshalBinary(secretB); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "could not unmarshal extra secret share")
+		}
+		extraShares[i] = &SecretShare{*idx, secret}
+	}
+	return weights, extraShares, data, nil
+}
+
+func snapshotCipher(esk key_store.EncryptionKey) (cipher.AEAD, error) {
+	k, err := esk.DeriveKey(key_store.KeySnapshotKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not derive key snapshot key")
+	}
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not construct block cipher for key snapshot")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not construct GCM cipher for key snapshot")
+	}
+	return gcm, nil
+}
+
+func snapshotAssociatedData(cfgDgst types.ConfigDigest, keyID contract.KeyID) []byte {
+	return bytes.Join([][]byte{[]byte(keySnapshotDomainSep), cfgDgst[:], keyID[:]}, nil)
+}
+
+func writeKeySnapshot(
+	ctx context.Context, db dkg_types.DKGSharePersistence, esk key_store.EncryptionKey,
+	cfgDgst types.ConfigDigest, keyID contract.KeyID, kd *KeyData, hashes []hash.Hash,
+) error {
+	sdb, ok := db.(dkg_types.KeySnapshotPersistence)
+	if !ok {
+		return nil
+	}
+	pt, err := marshalKeySnapshot(kd, hashes)
+	if err != nil {
+		return err
+	}
+	gcm, err := snapshotCipher(esk)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.Wrap(err, "could not sample nonce for key snapshot")
+	}
+	ct := gcm.Seal(nonce, nonce, pt, snapshotAssociatedData(cfgDgst, keyID))
+	return sdb.WriteKeySnapshot(ctx, cfgDgst, keyID, ct)
+}
+
+func readKeySnapshot(
+	db dkg_types.DKGSharePersistence, esk key_store.EncryptionKey,
+	encryptionGroup, translationGroup kyber.Group,
+	cfgDgst types.ConfigDigest, keyID contract.KeyID,
+) (*KeyData, []hash.Hash, error) {
+	sdb, ok := db.(dkg_types.KeySnapshotPersistence)
+	if !ok {
+		return nil, nil, nil
+	}
+	ct, err := sdb.ReadKeySnapshot(cfgDgst, keyID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read key snapshot")
+	}
+	if len(ct) == 0 {
+		return nil, nil, nil
+	}
+	gcm, err := snapshotCipher(esk)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ct) < gcm.NonceSize() {
+		return nil, nil, errors.Errorf("key snapshot too short")
+	}
+	nonce, ct := ct[:gcm.NonceSize()], ct[gcm.NonceSize():]
+	pt, err := gcm.Open(nil, nonce, ct, snapshotAssociatedData(cfgDgst, keyID))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not decrypt key snapshot")
+	}
+	return unmarshalKeySnapshot(pt, encryptionGroup, translationGroup)
+}
+
+func checkKeySnapshot(
+	kd *KeyData, hashes []hash.Hash, onchain *contract.KeyData,
+	translator point_translation.PubKeyTranslation,
+) error {
+	if !kd.PublicKey.Equal(onchain.PublicKey) {
+		return errors.Errorf("key snapshot does not match onchain key")
+	}
+	if len(hashes) != len(onchain.Hashes) {
+		return errors.Errorf(
+			"key snapshot has %d share set hashes, onchain key has %d",
+			len(hashes), len(onchain.Hashes),
+		)
+	}
+	for i, h := range hashes {
+		if h != onchain.Hashes[i] {
+			return errors.Errorf("key snapshot share set hash %s does not match onchain key", h)
+		}
+	}
+	for _, s := range kd.HeldShares() {
+		if !s.Idx.AtMost(player_idx.Int(len(kd.Shares))) {
+			return errors.Errorf("key snapshot secret share index out of range")
+		}
+		pubShare, err := translator.TranslateKey(s.share)
+		if err != nil {
+			return errors.Wrap(err, "could not translate key snapshot secret share")
+		}
+		if !pubShare.Equal(s.Idx.Index(kd.Shares).(kshare.PubShare).V) {
+			return errors.Errorf("key snapshot secret share does not match public share")
+		}
+	}
+	return nil
+}
+
+func (d *dkg) restoreKeySnapshot(ctx context.Context) bool {
+	kd, err := d.loadKeySnapshot(ctx)
+	if err != nil {
+		d.logger.Warn("ignoring key snapshot", commontypes.LogFields{"err": err})
+		return false
+	}
+	if kd == nil {
+		return false
+	}
+	d.keyConsumer.NewKey(d.keyID, kd)
+	d.keyData = kd
+	d.completed = true
+	d.finishAttemptLocked(AttemptCompleted, "restored from key snapshot")
+	d.dkgComplete(d, kd)
+	return true
+}
+
+func (d *dkg) loadKeySnapshot(ctx context.Context) (*KeyData, error) {
+	kd, hashes, err := readKeySnapshot(
+		d.db, d.esk, d.encryptionGroup, d.translationGroup, d.cfgDgst, d.keyID,
+	)
+	if err != nil || kd == nil {
+		return nil, err
+	}
+	onchain, err := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get onchain key to validate key snapshot")
+	}
+	if onchain.PublicKey == nil {
+		return nil, nil
+	}
+	if err := checkKeySnapshot(kd, hashes, &onchain, d.translator); err != nil {
+		return nil, err
+	}
+	own, err := d.ownShares()
+	if err != nil {
+		return nil, err
+	}
+	if !d.snapshotMatchesPosition(kd, own) {
+		return nil, errors.Errorf("key snapshot is for a different committee position")
+	}
+	return kd, nil
+}
+
+func (d *dkg) snapshotMatchesPosition(kd *KeyData, own []*player_idx.PlayerIdx) bool {
+	held := kd.HeldShares()
+	if kd.T != d.t || len(kd.Shares) != len(d.shareEpks()) || len(held) != len(own) ||
+		len(kd.Weights) != len(d.weights) {
+		return false
+	}
+	for i, w := range kd.Weights {
+		if w != d.weights[i] {
+			return false
+		}
+	}
+	for i, s := range held {
+		if !s.Idx.Equal(own[i]) {
+			return false
+		}
+	}
+	return true
+}
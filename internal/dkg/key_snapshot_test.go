@@ -0,0 +1,112 @@
+package dkg
+
+import (
+	"testing"
+
+	"go.dedis.ch/kyber/v3/group/edwards25519"
+	kshare "go.dedis.ch/kyber/v3/share"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+var snapshotGroup = edwards25519.NewBlakeSHA256Ed25519()
+
+// testKeyData returns the key data for the player at position self in a
+// committee with the given weights, and the onchain key it should match.
+func testKeyData(
+	t *testing.T, weights []player_idx.Int, self int,
+) (*KeyData, *contract.KeyData) {
+	n := len(weights)
+	if n == 0 {
+		n = 3
+	}
+	held, err := heldShares(weights, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, _ := shareCounts(weights, n, 0)
+	threshold := player_idx.Int(1)
+	poly := kshare.NewPriPoly(snapshotGroup, int(threshold)+1, nil, snapshotGroup.RandomStream())
+	commits := poly.Commit(nil)
+	pubShares := make([]kshare.PubShare, total)
+	for i, s := range commits.Shares(total) {
+		pubShares[i] = *s
+	}
+	priShares := poly.Shares(total)
+	var secrets []*SecretShare
+	for _, idx := range held[self] {
+		secrets = append(secrets, &SecretShare{*idx, idx.Index(priShares).(*kshare.PriShare).V})
+	}
+	kd := &KeyData{
+		commits.Commit(), pubShares, secrets[0], secrets[1:], weights, threshold, true,
+	}
+	onchain := &contract.KeyData{commits.Commit(), []hash.Hash{{1}, {2}}}
+	return kd, onchain
+}
+
+func TestKeySnapshotRoundTrip(t *testing.T) {
+	translator := point_translation.NewSameGroupTranslation(snapshotGroup)
+	for _, tc := range []struct {
+		name    string
+		weights []player_idx.Int
+		version uint8
+	}{
+		{"unweighted", nil, keySnapshotVersionNum},
+		{"weighted", []player_idx.Int{1, 3, 2}, weightedKeySnapshotVersionNum},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kd, onchain := testKeyData(t, tc.weights, 1)
+			m, err := marshalKeySnapshot(kd, onchain.Hashes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m[0] != tc.version {
+				t.Fatalf("snapshot has version %d, expected %d", m[0], tc.version)
+			}
+			got, hashes, err := unmarshalKeySnapshot(m, snapshotGroup, snapshotGroup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := checkKeySnapshot(got, hashes, onchain, translator); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Weights) != len(kd.Weights) || len(got.ExtraShares) != len(kd.ExtraShares) {
+				t.Fatalf("snapshot lost weights or extra shares: %+v", got)
+			}
+			for i, s := range kd.HeldShares() {
+				g := got.HeldShares()[i]
+				if !g.Idx.Equal(&s.Idx) || !g.share.Equal(s.share) {
+					t.Fatalf("secret share %d differs after round trip", i)
+				}
+			}
+			if _, _, err := unmarshalKeySnapshot(m[:len(m)-1], snapshotGroup, snapshotGroup); err == nil {
+				t.Fatal("accepted truncated snapshot")
+			}
+		})
+	}
+}
+
+func TestKeySnapshotRejectsMismatchedShares(t *testing.T) {
+	translator := point_translation.NewSameGroupTranslation(snapshotGroup)
+	kd, onchain := testKeyData(t, []player_idx.Int{2, 2}, 0)
+	kd.ExtraShares[0].share = snapshotGroup.Scalar().Pick(snapshotGroup.RandomStream())
+	if err := checkKeySnapshot(kd, onchain.Hashes, onchain, translator); err == nil {
+		t.Fatal("accepted snapshot with a wrong extra secret share")
+	}
+
+	kd, onchain = testKeyData(t, nil, 0)
+	if err := checkKeySnapshot(kd, onchain.Hashes[:1], onchain, translator); err == nil {
+		t.Fatal("accepted snapshot with missing share set hashes")
+	}
+	m, err := marshalKeySnapshot(kd, onchain.Hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m[0] = weightedKeySnapshotVersionNum + 1
+	if _, _, err := unmarshalKeySnapshot(m, snapshotGroup, snapshotGroup); err == nil {
+		t.Fatal("accepted snapshot with unknown version")
+	}
+}
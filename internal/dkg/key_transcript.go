@@ -0,0 +1,255 @@
+package dkg
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	kshare "go.dedis.ch/kyber/v3/share"
+)
+
+// keyTranscript aggregates the share sets of a reported key, for players which
+// are missing some of them. Its share hashes are not checked against the share
+// sets it aggregates, so a player which only has the transcript trusts that
+// it is honest: see agreedTranscript.
+type keyTranscript struct {
+	hashes []hash.Hash
+
+	transcript *pvss.Transcript
+}
+
+func (kt *keyTranscript) covers(hs []hash.Hash) bool {
+	if kt == nil || len(kt.hashes) != len(hs) {
+		return false
+	}
+	for i, h := range hs {
+		if kt.hashes[i] != h {
+			return false
+		}
+	}
+	return true
+}
+
+func (kt *keyTranscript) marshal() ([]byte, error) {
+	if len(kt.hashes) > maxRequestedHashes {
+		return nil, errors.Errorf("too many share record hashes to marshal with transcript")
+	}
+	t, err := kt.transcript.Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal transcript")
+	}
+	return append(marshalHashes(kt.hashes), t...), nil
+}
+
+func (d *dkg) unmarshalKeyTranscript(data []byte) (*keyTranscript, error) {
+	hs, data, err := unmarshalHashes(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read share record hashes of transcript")
+	}
+	t, rem, err := pvss.UnmarshalTranscript(
+		d.encryptionGroup, d.translationGroup, data, d.translator, d.shareEpks(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(rem) > 0 {
+		return nil, errors.Errorf("overage of %d bytes in marshalled transcript", len(rem))
+	}
+	if len(t.Dealers()) != len(hs) {
+		return nil, errors.Errorf(
+			"transcript aggregates %d share sets, but names %d share records",
+			len(t.Dealers()), len(hs),
+		)
+	}
+	return &keyTranscript{hs, t}, nil
+}
+
+func marshalTranscriptObservation(missing []hash.Hash, transcript []byte) []byte {
+	rv := append([]byte{transcriptTag}, marshalHashes(missing)...)
+	return append(rv, transcript...)
+}
+
+func unmarshalTranscriptObservation(
+	o []byte,
+) (missing []hash.Hash, transcript []byte, err error) {
+	if len(o) == 0 || o[0] != transcriptTag {
+		return nil, nil, errors.Errorf("not a transcript observation")
+	}
+	missing, transcript, err = unmarshalHashes(o[1:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read missing share records")
+	}
+	return missing, transcript, nil
+}
+
+func (d *dkg) aggregateShareRecords(hs []hash.Hash) (*keyTranscript, error) {
+	shareSets := make([]*pvss.ShareSet, len(hs))
+	for i, h := range hs {
+		r, ok := d.shareSets[h]
+		if !ok {
+			return nil, errors.Errorf("no share record for key hash %s", h)
+		}
+		shareSets[i] = r.shareSet
+	}
+	t, err := pvss.AggregateShareSets(shareSets...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not aggregate share records")
+	}
+	return &keyTranscript{append([]hash.Hash{}, hs...), t}, nil
+}
+
+func (d *dkg) processTranscripts(ctx context.Context, observations []*observation) {
+	kd, err := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
+	if err != nil || d.mode == reshareKey || d.transcript.covers(kd.Hashes) {
+		return
+	}
+	var kt *keyTranscript
+	if d.shareSets.allKeysPresent(kd.Hashes) {
+		if len(d.requestedHashes) == 0 {
+			return
+		}
+		kt, err = d.aggregateShareRecords(kd.Hashes)
+	} else {
+		kt, err = d.agreedTranscript(observations, kd.Hashes)
+	}
+	if err != nil {
+		d.logger.Warn("could not construct transcript for reported key",
+			commontypes.LogFields{"err": err},
+		)
+		return
+	}
+	if kt == nil {
+		return
+	}
+	d.transcript = kt
+	if err := writeTranscript(ctx, d.db, d.cfgDgst, d.keyID, kt); err != nil {
+		d.logger.Warn("could not persist transcript", commontypes.LogFields{"err": err})
+	}
+}
+
+// agreedTranscript returns the transcript which more oracles send than can be
+// faulty, so that at least one honest oracle aggregated it from the reported
+// share records. The transcript's own checks only show that it is a valid
+// sharing of the reported key, not that it aggregates those share records.
+func (d *dkg) agreedTranscript(
+	observations []*observation, hs []hash.Hash,
+) (*keyTranscript, error) {
+	senders := map[hash.Hash]map[player_idx.PlayerIdx]bool{}
+	for _, o := range observations {
+		if len(o.transcript) == 0 {
+			continue
+		}
+		h := hash.GetHash(o.transcript)
+		if senders[h] == nil {
+			senders[h] = map[player_idx.PlayerIdx]bool{}
+		}
+		senders[h][*o.sender] = true
+		if len(senders[h]) != d.faultyOracles+1 {
+			continue
+		}
+		kt, err := d.unmarshalKeyTranscript(o.transcript)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid transcript from %d peers", len(senders[h]))
+		}
+		if !kt.covers(hs) {
+			return nil, errors.Errorf(
+				"transcript from %d peers is for a different key", len(senders[h]),
+			)
+		}
+		return kt, nil
+	}
+	return nil, nil
+}
+
+func (d *dkg) checkTranscript(kd *contract.KeyData, t *pvss.Transcript) error {
+	if t.Threshold() != d.t {
+		return errors.Errorf("transcript has threshold %d, expected %d", t.Threshold(), d.t)
+	}
+	switch d.mode {
+	case freshKey:
+		if !t.PublicKey().Equal(kd.PublicKey) {
+			return errors.Errorf("transcript does not match reported key")
+		}
+	case refreshKey:
+		if !t.SecretCommitment().Equal(d.encryptionGroup.Point().Null()) {
+			return errors.Errorf("transcript does not share zero during key refresh")
+		}
+		if !kd.PublicKey.Equal(d.lastKeyData.PublicKey) {
+			return errors.Errorf("reported key does not match previous key")
+		}
+	default:
+		return errors.Errorf("can't recover key from transcript for %s", d.mode)
+	}
+	return nil
+}
+
+func (d *dkg) recoverKeyFromTranscript(ctx context.Context, kd *contract.KeyData) error {
+	t := d.transcript.transcript
+	if err := d.checkTranscript(kd, t); err != nil {
+		return errors.Wrapf(err, "reported key is invalid for %s", d.mode)
+	}
+	held, err := d.ownShares()
+	if err != nil {
+		return err
+	}
+	finalShares := make([]*kshare.PriShare, len(held))
+	for i, idx := range held {
+		finalShare, err := t.Decrypt(*idx, d.esk, d.encryptionGroup)
+		if err != nil {
+			return errors.Wrap(err, "could not recover distributed key from transcript")
+		}
+		finalShares[i] = &finalShare
+	}
+	shares := t.PublicShares()
+	if d.mode == refreshKey {
+		finalShares[0], shares, err = d.refreshShares(finalShares[0], shares)
+		if err != nil {
+			return errors.Wrap(err, "could not refresh key shares")
+		}
+	}
+	return d.completeKey(ctx, kd, finalShares, shares, "")
+}
+
+func writeTranscript(
+	ctx context.Context, db dkg_types.DKGSharePersistence,
+	cfgDgst types.ConfigDigest, keyID contract.KeyID, kt *keyTranscript,
+) error {
+	tdb, ok := db.(dkg_types.TranscriptPersistence)
+	if !ok {
+		return nil
+	}
+	m, err := kt.marshal()
+	if err != nil {
+		return err
+	}
+	return tdb.WriteTranscript(ctx, cfgDgst, keyID, m)
+}
+
+func (d *dkg) restoreTranscript() error {
+	tdb, ok := d.db.(dkg_types.TranscriptPersistence)
+	if !ok {
+		return nil
+	}
+	m, err := tdb.ReadTranscript(d.cfgDgst, d.keyID)
+	if err != nil {
+		return errors.Wrap(err, "could not read transcript")
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	kt, err := d.unmarshalKeyTranscript(m)
+	if err != nil {
+		return errors.Wrap(err, "could not unmarshal persisted transcript")
+	}
+	d.transcript = kt
+	return nil
+}
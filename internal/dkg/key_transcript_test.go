@@ -0,0 +1,36 @@
+package dkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+func TestAgreedTranscriptNeedsMoreSendersThanFaultyOracles(t *testing.T) {
+	d, _ := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Hour, 0)
+	d.faultyOracles = 1
+	players, err := player_idx.PlayerIdxs(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Not a valid transcript, so agreedTranscript fails once it tries to
+	// unmarshal it.
+	transcript := []byte("transcript")
+	hs := []hash.Hash{{1}}
+	observations := []*observation{
+		{sender: players[1], transcript: transcript},
+		{sender: players[1], transcript: transcript},
+		{sender: players[2], transcript: []byte("other transcript")},
+	}
+	kt, err := d.agreedTranscript(observations, hs)
+	if kt != nil || err != nil {
+		t.Fatal("transcript accepted from no more senders than can be faulty")
+	}
+	observations = append(observations, &observation{sender: players[2], transcript: transcript})
+	if _, err := d.agreedTranscript(observations, hs); err == nil {
+		t.Fatal("transcript from more senders than can be faulty not considered")
+	}
+}
@@ -0,0 +1,256 @@
+package dkg
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/util"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+)
+
+type AttemptOutcome uint8
+
+const (
+	AttemptRunning AttemptOutcome = iota
+	AttemptCompleted
+	AttemptTimedOut
+	AttemptAborted
+	AttemptFailed
+)
+
+func (o AttemptOutcome) String() string {
+	switch o {
+	case AttemptRunning:
+		return "running"
+	case AttemptCompleted:
+		return "completed"
+	case AttemptTimedOut:
+		return "timed out"
+	case AttemptAborted:
+		return "aborted"
+	case AttemptFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown outcome %d", uint8(o))
+	}
+}
+
+type Attempt struct {
+	ConfigDigest types.ConfigDigest
+	KeyID        contract.KeyID
+	Number       int
+	Started      time.Time
+	Deadline     time.Time
+	Outcome      AttemptOutcome
+	Reason       string
+}
+
+type lifecycle struct {
+	timeout    time.Duration
+	maxRetries int
+}
+
+const maxAttemptLogLength = 64
+
+type attemptLog struct {
+	lock     sync.RWMutex
+	attempts []Attempt
+}
+
+func newAttemptLog() *attemptLog {
+	return &attemptLog{sync.RWMutex{}, nil}
+}
+
+func (l *attemptLog) record(a Attempt) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for i := len(l.attempts) - 1; i >= 0; i-- {
+		if l.attempts[i].ConfigDigest == a.ConfigDigest && l.attempts[i].Number == a.Number {
+			l.attempts[i] = a
+			return
+		}
+	}
+	l.attempts = append(l.attempts, a)
+	if len(l.attempts) > maxAttemptLogLength {
+		l.attempts = append([]Attempt{}, l.attempts[len(l.attempts)-maxAttemptLogLength:]...)
+	}
+}
+
+func (l *attemptLog) next(cfgDgst types.ConfigDigest) int {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	rv := 1
+	for _, a := range l.attempts {
+		if a.ConfigDigest == cfgDgst && a.Number >= rv {
+			rv = a.Number + 1
+		}
+	}
+	return rv
+}
+
+func (l *attemptLog) list() []Attempt {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return append([]Attempt{}, l.attempts...)
+}
+
+func (d *dkg) startAttemptLocked(number int) {
+	now := time.Now()
+	var deadline time.Time
+	if d.lifecycle.timeout > 0 {
+		deadline = now.Add(d.lifecycle.timeout)
+	}
+	d.attempt = Attempt{d.cfgDgst, d.keyID, number, now, deadline, AttemptRunning, ""}
+	d.attempts.record(d.attempt)
+}
+
+func (d *dkg) finishAttemptLocked(outcome AttemptOutcome, reason string) {
+	if d.attempt.Outcome != AttemptRunning {
+		return
+	}
+	d.attempt.Outcome, d.attempt.Reason = outcome, reason
+	d.attempts.record(d.attempt)
+	fields := commontypes.LogFields{
+		"configDigest": d.cfgDgst, "keyID": d.keyID, "attempt": d.attempt.Number,
+		"outcome": outcome.String(), "reason": reason,
+	}
+	if outcome == AttemptCompleted {
+		d.logger.Info("DKG attempt finished", fields)
+	} else {
+		d.logger.Warn("DKG attempt finished", fields)
+	}
+}
+
+func (d *dkg) abort(reason string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.abortLocked(AttemptAborted, reason)
+}
+
+func (d *dkg) abortLocked(outcome AttemptOutcome, reason string) {
+	if d.completed || d.aborted {
+		return
+	}
+	d.finishAttemptLocked(outcome, reason)
+	d.aborted = true
+	d.cancelFunc()
+}
+
+func (d *dkg) watchDeadline() {
+	for {
+		d.lock.RLock()
+		deadline, running := d.attempt.Deadline, d.attempt.Outcome == AttemptRunning
+		ctx := d.ctx
+		d.lock.RUnlock()
+		if !running || deadline.IsZero() {
+			return
+		}
+		timer := time.NewTimer(time.Until(deadline))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if !d.expire() {
+			return
+		}
+	}
+}
+
+func (d *dkg) expire() (retrying bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.completed || d.aborted || d.attempt.Outcome != AttemptRunning {
+		return false
+	}
+	if d.keyReportedOnchain(d.ctx) {
+		d.clearDeadlineLocked()
+		return false
+	}
+	reason := fmt.Sprintf("no distributed key after %s", d.lifecycle.timeout)
+	if d.retries >= d.lifecycle.maxRetries {
+		d.abortLocked(AttemptTimedOut, reason)
+		return false
+	}
+	if _, ok := d.shareRecordDropper(); !ok {
+		d.abortLocked(AttemptTimedOut, reason+
+			", and share persistence can't drop the attempt's share records to retry")
+		return false
+	}
+	d.finishAttemptLocked(AttemptTimedOut, reason)
+	d.retries++
+	if err := d.retryLocked(); err != nil {
+		d.abortLocked(AttemptFailed, util.WrapError(err, "could not retry DKG").Error())
+		return false
+	}
+	return true
+}
+
+// retryLocked starts a fresh attempt. It drops every share record collected
+// for this config, including this node's own dealing, and deals a new share
+// set. Share records from the timed-out attempt which peers still broadcast
+// are accepted again, since they remain validly signed for this config.
+func (d *dkg) retryLocked() error {
+	drop, _ := d.shareRecordDropper()
+	if err := drop(d.ctx); err != nil {
+		return errors.Wrap(err, "could not drop share records of timed-out attempt")
+	}
+	d.shareSets = newShareRecords()
+	d.myShareRecord = nil
+	d.shareRecordBroadcast.Store(false)
+	d.validShareSets = 0
+	d.accused = map[player_idx.PlayerIdx]bool{}
+	d.complaints = map[player_idx.PlayerIdx][]byte{}
+	if d.bias != nil {
+		d.bias = newBiasResistantDeal(d.bias.pedersenBase)
+	}
+	d.startAttemptLocked(d.attempt.Number + 1)
+	return d.dealLocked(d.signingGroup)
+}
+
+// shareRecordDropper returns a function which deletes the share records
+// persisted for this config, if the share persistence supports that.
+func (d *dkg) shareRecordDropper() (func(context.Context) error, bool) {
+	switch db := d.db.(type) {
+	case dkg_types.ReplaceableDKGSharePersistence:
+		return func(ctx context.Context) error {
+			return db.ReplaceShareRecords(ctx, d.cfgDgst, d.keyID, nil)
+		}, true
+	case dkg_types.PrunableDKGSharePersistence:
+		return func(ctx context.Context) error {
+			return db.DeleteShareRecords(ctx, d.cfgDgst, d.keyID)
+		}, true
+	}
+	return nil, false
+}
+
+// Once the key is reported onchain, the share records it lists must be kept
+// until this node has recovered its share and can serve them to its peers, so
+// the attempt no longer times out.
+func (d *dkg) clearDeadlineLocked() {
+	d.attempt.Deadline = time.Time{}
+	d.attempts.record(d.attempt)
+}
+
+// resumeIfReportedLocked restarts a DKG which timed out before its peers
+// reported the key. Aborting cancelled its context, so it gets a new one. The
+// resumed attempt has no deadline, so there is no deadline to watch.
+func (d *dkg) resumeIfReportedLocked(ctx context.Context) {
+	if !d.aborted || d.completed || d.attempt.Outcome != AttemptTimedOut ||
+		!d.keyReportedOnchain(ctx) {
+		return
+	}
+	d.aborted = false
+	d.ctx, d.cancelFunc = context.WithCancel(context.Background())
+	d.startAttemptLocked(d.attempt.Number + 1)
+	d.clearDeadlineLocked()
+}
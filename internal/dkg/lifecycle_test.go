@@ -0,0 +1,204 @@
+package dkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"go.dedis.ch/kyber/v3"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	"github.com/smartcontractkit/chainlink-vrf/internal/util"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+// testContract reports the given key data for every key ID and config.
+type testContract struct{ kd contract.KeyData }
+
+func (c *testContract) KeyData(
+	context.Context, contract.KeyID, types.ConfigDigest,
+) (contract.KeyData, error) {
+	return c.kd, nil
+}
+
+func (c *testContract) report(g kyber.Group) {
+	c.kd = contract.KeyData{g.Point().Base(), []hash.Hash{{1}}}
+}
+
+// appendOnlyShares hides every method of its share persistence except those
+// of DKGSharePersistence.
+type appendOnlyShares struct{ dkg_types.DKGSharePersistence }
+
+// lifecyclePlayer returns the first of five players in a fresh-key DKG, with a
+// running first attempt which times out after the given delay.
+func lifecyclePlayer(
+	t *testing.T, db dkg_types.DKGSharePersistence, timeout time.Duration, maxRetries int,
+) (*dkg, *testContract) {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	translator := translatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+	translationGroup, err := translator.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players, err := player_idx.PlayerIdxs(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esk := testEncryptionKey(t)
+	epks := []kyber.Point{esk.PublicKey()}
+	for range players[1:] {
+		epks = append(epks, testEncryptionKey(t).PublicKey())
+	}
+	ssk, err := key_store.NewInProcessKeyStore(nil, SigningGroup.Scalar().Pick(SigningGroup.RandomStream())).
+		SigningKey(SigningGroup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spks := []kyber.Point{ssk.PublicKey()}
+	for range players[1:] {
+		spks = append(spks, SigningGroup.Point().Pick(SigningGroup.RandomStream()))
+	}
+	c := &testContract{}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	d := &dkg{
+		t:                1,
+		selfIdx:          players[0],
+		cfgDgst:          types.ConfigDigest{1},
+		keyID:            contract.KeyID{2},
+		shareSets:        newShareRecords(),
+		esk:              esk,
+		epks:             epks,
+		ssk:              ssk,
+		spks:             spks,
+		signingGroup:     SigningGroup,
+		encryptionGroup:  g,
+		translationGroup: translationGroup,
+		translator:       translator,
+		contract:         c,
+		lifecycle:        lifecycle{timeout, maxRetries},
+		attempts:         newAttemptLog(),
+		accused:          map[player_idx.PlayerIdx]bool{},
+		complaints:       map[player_idx.PlayerIdx][]byte{},
+		db:               db,
+		logger:           util.MakeLogger(),
+		ctx:              ctx,
+		cancelFunc:       cancel,
+	}
+	d.startAttemptLocked(d.attempts.next(d.cfgDgst))
+	return d, c
+}
+
+func TestDeadlineAbortsDKGWithoutRetries(t *testing.T) {
+	d, _ := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Millisecond, 0)
+	d.watchDeadline()
+	if !d.aborted || d.attempt.Outcome != AttemptTimedOut {
+		t.Fatalf("DKG not timed out at its deadline: %+v", d.attempt)
+	}
+	if d.ctx.Err() == nil {
+		t.Fatal("timing out did not cancel the DKG's context")
+	}
+}
+
+func TestDeadlineAbortsDKGWhichCannotDropShareRecords(t *testing.T) {
+	db := appendOnlyShares{persistence.NewMemorySharePersistence()}
+	d, _ := lifecyclePlayer(t, db, time.Millisecond, 1)
+	d.watchDeadline()
+	if !d.aborted || d.attempt.Outcome != AttemptTimedOut || d.retries != 0 {
+		t.Fatalf("DKG retried without dropping its share records: %+v", d.attempt)
+	}
+}
+
+func TestDeadlineClearedOnceKeyReported(t *testing.T) {
+	d, c := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Millisecond, 0)
+	c.report(d.encryptionGroup)
+	d.watchDeadline()
+	if d.aborted || d.attempt.Outcome != AttemptRunning || !d.attempt.Deadline.IsZero() {
+		t.Fatalf("DKG timed out after its key was reported: %+v", d.attempt)
+	}
+}
+
+func TestAbortedDKGIsNotResumed(t *testing.T) {
+	d, c := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), 0, 0)
+	d.abort("test")
+	if !d.aborted || d.attempt.Outcome != AttemptAborted || d.ctx.Err() == nil {
+		t.Fatalf("abort did not stop the DKG: %+v", d.attempt)
+	}
+	c.report(d.encryptionGroup)
+	d.resumeIfReportedLocked(context.Background())
+	if !d.aborted {
+		t.Fatal("resumed an explicitly aborted DKG")
+	}
+}
+
+func TestTimedOutDKGResumesOnceKeyReported(t *testing.T) {
+	d, c := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Hour, 0)
+	d.abortLocked(AttemptTimedOut, "test")
+	d.resumeIfReportedLocked(context.Background())
+	if !d.aborted {
+		t.Fatal("resumed a timed-out DKG whose key was never reported")
+	}
+	c.report(d.encryptionGroup)
+	d.resumeIfReportedLocked(context.Background())
+	if d.aborted || d.ctx.Err() != nil {
+		t.Fatal("resumed DKG is still stopped")
+	}
+	if d.attempt.Number != 2 || d.attempt.Outcome != AttemptRunning ||
+		!d.attempt.Deadline.IsZero() {
+		t.Fatalf("resumed DKG is not running a new attempt without deadline: %+v", d.attempt)
+	}
+}
+
+func TestRetryDealsNewShareSet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	db := persistence.NewMemorySharePersistence()
+	d, _ := lifecyclePlayer(t, db, time.Hour, 1)
+	ownHash := func() hash.Hash {
+		m, err := d.myShareRecord.marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash.GetHash(m)
+	}
+	if err := d.initializeShareSets(d.signingGroup); err != nil {
+		t.Fatal(err)
+	}
+	first := ownHash()
+	d.shareRecordBroadcast.Store(true)
+	d.accused[*d.selfIdx] = true
+
+	if !d.expire() {
+		t.Fatalf("DKG not retried: %+v", d.attempt)
+	}
+	if d.attempt.Number != 2 || d.attempt.Outcome != AttemptRunning {
+		t.Fatalf("retry did not start a new attempt: %+v", d.attempt)
+	}
+	if attempts := d.attempts.list(); attempts[0].Outcome != AttemptTimedOut {
+		t.Fatalf("first attempt not recorded as timed out: %+v", attempts[0])
+	}
+	second := ownHash()
+	if second == first {
+		t.Fatal("retry reused the timed-out attempt's share set")
+	}
+	if len(d.shareSets) != 1 || d.shareRecordBroadcast.Load() || len(d.accused) != 0 {
+		t.Fatal("retry kept state from the timed-out attempt")
+	}
+	persisted, err := db.ReadShareRecords(d.cfgDgst, d.keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persisted) != 1 || persisted[0].Hash != second {
+		t.Fatal("retry did not replace the persisted share records")
+	}
+
+	if d.expire() || !d.aborted || d.attempt.Outcome != AttemptTimedOut {
+		t.Fatalf("DKG retried more often than configured: %+v", d.attempt)
+	}
+}
@@ -0,0 +1,80 @@
+package dkg
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext/schnorr"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+// migrateShareRecords re-frames legacy share records in the stored records for
+// cfgDgst and keyID. A store which can replace its records has the legacy
+// records replaced; any other store keeps them alongside the migrated ones.
+func migrateShareRecords(
+	ctx context.Context, db dkg_types.DKGSharePersistence, signingGroup schnorr.Suite,
+	cfgDgst types.ConfigDigest, keyID contract.KeyID,
+) (migrated int, err error) {
+	records, err := db.ReadShareRecords(cfgDgst, keyID)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not read share records to migrate")
+	}
+	stored := map[hash.Hash]bool{}
+	for _, r := range records {
+		stored[hash.GetHash(r.MarshaledShareRecord)] = true
+	}
+	var kept, migratedRecords []dkg_types.PersistentShareSetRecord
+	legacy := 0
+	for _, r := range records {
+		m := r.MarshaledShareRecord
+		if envelope.IsEnveloped(m) {
+			kept = append(kept, r)
+			continue
+		}
+		legacy++
+		ssBytes, sig, rem, err := unmarshalLegacyShareRecord(signingGroup, m)
+		if err != nil {
+			return 0, errors.Wrapf(err, "could not parse stored share record from %s", r.Dealer)
+		}
+		if len(rem) > 0 {
+			return 0, errors.Errorf(
+				"overage of %d bytes in stored share record from %s", len(rem), r.Dealer,
+			)
+		}
+		msr, err := msrComponents(signingGroup, ssBytes, sig)
+		if err != nil {
+			return 0, errors.Wrapf(err, "could not migrate share record from %s", r.Dealer)
+		}
+		if shareRecordHash(msr) != shareRecordHash(m) {
+			return 0, errors.Errorf("migration changed identity of share record from %s", r.Dealer)
+		}
+		h := hash.GetHash(msr)
+		if stored[h] {
+			continue
+		}
+		stored[h] = true
+		migratedRecords = append(migratedRecords,
+			dkg_types.PersistentShareSetRecord{r.Dealer, msr, h},
+		)
+	}
+	if rdb, ok := db.(dkg_types.ReplaceableDKGSharePersistence); ok && legacy > 0 {
+		err := rdb.ReplaceShareRecords(ctx, cfgDgst, keyID, append(kept, migratedRecords...))
+		if err != nil {
+			return 0, errors.Wrap(err, "could not replace legacy share records")
+		}
+		return len(migratedRecords), nil
+	}
+	if len(migratedRecords) == 0 {
+		return 0, nil
+	}
+	if err := db.WriteShareRecords(ctx, cfgDgst, keyID, migratedRecords); err != nil {
+		return 0, errors.Wrap(err, "could not write migrated share records")
+	}
+	return len(migratedRecords), nil
+}
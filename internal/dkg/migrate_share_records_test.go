@@ -0,0 +1,124 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+// legacyShareRecord returns d's share record in the encoding which predates
+// enveloped share records.
+func legacyShareRecord(t *testing.T, d *dkg) []byte {
+	m, err := d.myShareRecord.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssBytes, sig, _, err := unmarshalEnvelopedShareRecord(d.signingGroup, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return legacyMSRComponents(ssBytes, sig)
+}
+
+func TestLegacyShareRecordDecodes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	d := complaintPlayer(t)
+	legacy := legacyShareRecord(t, d)
+	m, err := d.myShareRecord.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shareRecordHash(legacy) != shareRecordHash(m) {
+		t.Fatal("legacy and enveloped encodings of a share record have different hashes")
+	}
+	d.shareSets = newShareRecords()
+	r, err := unmarshalSignedShareRecord(d, legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.shareSet.Equal(d.myShareRecord.shareSet) {
+		t.Fatal("legacy share record decoded to a different share set")
+	}
+	if _, err := unmarshalSignedShareRecord(d, legacy[:len(legacy)-1]); err == nil {
+		t.Fatal("truncated legacy share record decoded")
+	}
+}
+
+func TestMigrateShareRecords(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	d := complaintPlayer(t)
+	legacy := legacyShareRecord(t, d)
+	m, err := d.myShareRecord.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		db   func() dkg_types.DKGSharePersistence
+		// keepsLegacy is whether the store still has the legacy record after
+		// migration.
+		keepsLegacy bool
+	}{
+		{"replaceable", func() dkg_types.DKGSharePersistence {
+			return persistence.NewMemorySharePersistence()
+		}, false},
+		{"append-only", func() dkg_types.DKGSharePersistence {
+			return appendOnlyShares{persistence.NewMemorySharePersistence()}
+		}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := tc.db()
+			err := db.WriteShareRecords(ctx, d.cfgDgst, d.keyID, []dkg_types.PersistentShareSetRecord{
+				{*d.selfIdx, legacy, hash.GetHash(legacy)},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			migrated, err := migrateShareRecords(ctx, db, d.signingGroup, d.cfgDgst, d.keyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if migrated != 1 {
+				t.Fatalf("migrated %d share records, expected 1", migrated)
+			}
+			records, err := db.ReadShareRecords(d.cfgDgst, d.keyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var enveloped, legacyLeft int
+			for _, r := range records {
+				if envelope.IsEnveloped(r.MarshaledShareRecord) {
+					enveloped++
+					if !bytes.Equal(r.MarshaledShareRecord, m) ||
+						r.Hash != hash.GetHash(m) || !r.Dealer.Equal(d.selfIdx) {
+						t.Fatal("migrated share record differs from the enveloped encoding")
+					}
+				} else {
+					legacyLeft++
+				}
+			}
+			if enveloped != 1 || (legacyLeft == 1) != tc.keepsLegacy {
+				t.Fatalf("store has %d enveloped and %d legacy records after migration",
+					enveloped, legacyLeft)
+			}
+
+			migrated, err = migrateShareRecords(ctx, db, d.signingGroup, d.cfgDgst, d.keyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if migrated != 0 {
+				t.Fatalf("second migration migrated %d share records", migrated)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
@@ -23,6 +24,14 @@ import (
 	"go.dedis.ch/kyber/v3/sign/anon"
 )
 
+// PluginConfig combines the offchain and onchain configs for a DKG.
+//
+// An offchain config with player weights (see WithWeights) only supports a
+// plain fresh key. SanityCheckConfigs and NewDKG reject a weighted config for
+// key refresh, key reshare or bias-resistant dealing. A weighted committee also
+// can't recover a lost share from its peers, or export or import key backups.
+// Key snapshots and completed keys do record the weights, so a player in a
+// weighted committee still restores its key after a restart.
 type PluginConfig struct {
 	offchainConfig offchainConfig
 	onchainConfig  onchainConfig
@@ -34,9 +43,13 @@ type offchainConfig struct {
 
 	encryptionGroup anon.Suite
 	translator      point_translation.PubKeyTranslation
-}
+	signingGroup    anon.Suite
+
+	previousPublicShares []kyber.Point
+	previousPlayers      []player_idx.Int
 
-type onchainConfig struct{ contract.KeyID }
+	weights []player_idx.Int
+}
 
 func (o *offchainConfig) MarshalBinary() ([]byte, error) {
 	if len(o.epks) > int(player_idx.MaxPlayer) || len(o.epks) > math.MaxInt32 {
@@ -69,20 +82,52 @@ func (o *offchainConfig) MarshalBinary() ([]byte, error) {
 		errMsg := "attempt to marshal DKG offchain config with nil group translator"
 		return nil, fmt.Errorf(errMsg)
 	}
+	signingGroup := o.signingGroup
+	if signingGroup == nil {
+		signingGroup = SigningGroup
+	}
+	if _, ok := signingGroupRegistry[signingGroup.String()]; !ok {
+		return nil, fmt.Errorf("unsupported signing group %s", signingGroup)
+	}
+
+	if len(o.previousPlayers) > 0 && len(o.previousPlayers) != len(o.epks) {
+		errMsg := "num previous players (%d) should match num public keys (%d)"
+		return nil, fmt.Errorf(errMsg, len(o.previousPlayers), len(o.epks))
+	}
+	previousShares := make([][]byte, 0, len(o.previousPublicShares))
+	for i, ps := range o.previousPublicShares {
+		psb, err := ps.MarshalBinary()
+		if err != nil {
+			errMsg := "could not marshal %dth previous public share %v"
+			return nil, util.WrapErrorf(err, errMsg, i, ps)
+		}
+		previousShares = append(previousShares, psb)
+	}
+	previousPlayers := make([]uint32, 0, len(o.previousPlayers))
+	for _, p := range o.previousPlayers {
+		previousPlayers = append(previousPlayers, uint32(p))
+	}
+	if err := checkWeights(o.weights, len(o.epks)); err != nil {
+		return nil, err
+	}
+	var weights []uint32
+	for _, w := range o.weights {
+		weights = append(weights, uint32(w))
+	}
 
 	return proto.Marshal(&protobuf.OffchainConfig{
-		EncryptionPKs:   epks,
-		SignaturePKs:    spks,
-		EncryptionGroup: o.encryptionGroup.String(),
-		Translator:      o.translator.Name(),
+		EncryptionPKs:        epks,
+		SignaturePKs:         spks,
+		EncryptionGroup:      o.encryptionGroup.String(),
+		Translator:           o.translator.Name(),
+		PreviousPublicShares: previousShares,
+		PreviousPlayers:      previousPlayers,
+		SigningGroup:         signingGroup.String(),
+		Weights:              weights,
 	})
 
 }
 
-func (o *onchainConfig) Marshal() []byte {
-	return append([]byte{}, o.KeyID[:]...)
-}
-
 func unmarshalBinaryOffchainConfig(
 	offchainBinaryConfig []byte,
 ) (*offchainConfig, error) {
@@ -106,6 +151,13 @@ func unmarshalBinaryOffchainConfig(
 		errMsg := "unrecognized encryption-group name, '%s'"
 		return nil, fmt.Errorf(errMsg, p.EncryptionGroup)
 	}
+	signingGroup := SigningGroup
+	if p.SigningGroup != "" {
+		signingGroup, ok = signingGroupRegistry[p.SigningGroup]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized signing-group name, '%s'", p.SigningGroup)
+		}
+	}
 	epks, spks := make([]kyber.Point, 0, nepk), make([]kyber.Point, 0, nspk)
 	for i, bepk := range p.EncryptionPKs {
 		epk := encGgroup.Point()
@@ -114,11 +166,11 @@ func unmarshalBinaryOffchainConfig(
 			return nil, util.WrapErrorf(err, errMsg, bepk, p.EncryptionGroup)
 		}
 		epks = append(epks, epk)
-		spk := SigningGroup.Point()
+		spk := signingGroup.Point()
 		bspk := p.SignaturePKs[i]
 		if err := spk.UnmarshalBinary(bspk); err != nil {
 			errMsg := "could not unmarshal signing key 0x%x in group %s"
-			return nil, util.WrapErrorf(err, errMsg, bspk, "ed25519")
+			return nil, util.WrapErrorf(err, errMsg, bspk, signingGroup)
 		}
 		spks = append(spks, spk)
 	}
@@ -126,20 +178,71 @@ func unmarshalBinaryOffchainConfig(
 	if !ok {
 		return nil, fmt.Errorf("unrecognized translator name: %s", p.Translator)
 	}
+	previousShares, previousPlayers, err := unmarshalPreviousKeyConfig(
+		p, encGgroup, translator,
+	)
+	if err != nil {
+		return nil, err
+	}
+	weights, err := unmarshalWeights(p.Weights, nepk)
+	if err != nil {
+		return nil, err
+	}
 	return &offchainConfig{
 		epks,
 		spks,
 		encGgroup,
 		translator,
+		signingGroup,
+		previousShares,
+		previousPlayers,
+		weights,
 	}, nil
 }
 
-func unmarshalBinaryOnchainConfig(onchainBinaryConfig []byte) (rv onchainConfig, err error) {
-	if len(onchainBinaryConfig) != len(contract.KeyID{}) {
-		return rv, fmt.Errorf("onchainConfig binary is wrong length")
+func unmarshalPreviousKeyConfig(
+	p *protobuf.OffchainConfig,
+	encryptionGroup anon.Suite,
+	translator point_translation.PubKeyTranslation,
+) ([]kyber.Point, []player_idx.Int, error) {
+	if len(p.PreviousPlayers) == 0 && len(p.PreviousPublicShares) == 0 {
+		return nil, nil, nil
+	}
+	if len(p.PreviousPlayers) != len(p.EncryptionPKs) {
+		errMsg := "num previous players (%d) should match num encryption PKs (%d)"
+		return nil, nil, fmt.Errorf(errMsg, len(p.PreviousPlayers), len(p.EncryptionPKs))
+	}
+	if len(p.PreviousPublicShares) > int(player_idx.MaxPlayer) {
+		return nil, nil, fmt.Errorf("too many previous public shares")
+	}
+	shareGroup, err := translator.TargetGroup(encryptionGroup)
+	if err != nil {
+		errMsg := "could not determine group of previous public shares"
+		return nil, nil, util.WrapError(err, errMsg)
+	}
+	shares := make([]kyber.Point, 0, len(p.PreviousPublicShares))
+	for _, bps := range p.PreviousPublicShares {
+		ps := shareGroup.Point()
+		if err := ps.UnmarshalBinary(bps); err != nil {
+			errMsg := "could not unmarshal previous public share 0x%x"
+			return nil, nil, util.WrapErrorf(err, errMsg, bps)
+		}
+		shares = append(shares, ps)
+	}
+	players := make([]player_idx.Int, 0, len(p.PreviousPlayers))
+	seen := make(map[uint32]bool)
+	for _, pp := range p.PreviousPlayers {
+		if pp > uint32(len(shares)) {
+			errMsg := "previous player %d out of range of %d previous public shares"
+			return nil, nil, fmt.Errorf(errMsg, pp, len(shares))
+		}
+		if pp != 0 && seen[pp] {
+			return nil, nil, fmt.Errorf("previous player %d listed twice", pp)
+		}
+		seen[pp] = true
+		players = append(players, player_idx.Int(pp))
 	}
-	copy(rv.KeyID[:], onchainBinaryConfig)
-	return rv, nil
+	return shares, players, nil
 }
 
 func unmarshalPluginConfig(offchainBinaryConfig, onchainBinaryConfig []byte) (*PluginConfig, error) {
@@ -160,19 +263,29 @@ type NewDKGArgs struct {
 	t                          player_idx.Int
 	selfIdx                    *player_idx.PlayerIdx
 	cfgDgst                    types.ConfigDigest
-	esk                        kyber.Scalar
+	esk                        key_store.EncryptionKey
 	epks                       []kyber.Point
-	ssk                        kyber.Scalar
+	ssk                        key_store.SigningKey
 	spks                       []kyber.Point
 	keyID                      contract.KeyID
 	keyConsumer                KeyConsumer
 	encryptionGroup            anon.Suite
 	translationGroup           kyber.Group
 	translator                 point_translation.PubKeyTranslation
+	configuredSigningGroup     anon.Suite
 	contract                   contract.OnchainContract
 	logger                     commontypes.Logger
 	randomness                 io.Reader
 	db                         dkg_types.DKGSharePersistence
+	mode                       dkgMode
+	previousDigest             types.ConfigDigest
+	previousThreshold          player_idx.Int
+	previousPublicShares       []kyber.Point
+	previousPlayers            []player_idx.Int
+	weights                    []player_idx.Int
+	biasResistant              bool
+	lastKeyData                *KeyData
+	faultyOracles              int
 	xxxTestingOnlySigningGroup anon.Suite
 }
 
@@ -193,34 +306,52 @@ func (p *PluginConfig) NewDKGArgs(
 		return nil, util.WrapError(err, "could not determine local player index")
 	}
 	selfIdx := players[oID]
-	return &NewDKGArgs{
+	args := &NewDKGArgs{
 		t,
 		selfIdx,
 		d,
-		l.esk,
+		nil,
 		oc.epks,
-		l.ssk,
+		nil,
 		oc.spks,
 		p.onchainConfig.KeyID,
-		l.keyConsumer,
+		l.keyConsumer.forDigest(d),
 		oc.encryptionGroup,
 		translationGroup,
 		oc.translator,
+		oc.signingGroup,
 		l.contract,
 		l.logger,
 		l.randomness,
 		l.shareDB,
+		p.onchainConfig.mode,
+		p.onchainConfig.previousDigest,
+		p.onchainConfig.previousThreshold,
+		oc.previousPublicShares,
+		oc.previousPlayers,
+		oc.weights,
+		p.onchainConfig.biasResistant,
 		nil,
-	}, nil
+		0,
+		nil,
+	}
+	args.esk, err = l.keys.EncryptionKey(args.encryptionGroup)
+	if err != nil {
+		return nil, util.WrapError(err, "could not load encryption key")
+	}
+	args.ssk, err = l.keys.SigningKey(args.signingGroup())
+	if err != nil {
+		return nil, util.WrapError(err, "could not load signing key")
+	}
+	return args, nil
 }
 
 type localArgs struct {
-	esk         kyber.Scalar
-	ssk         kyber.Scalar
+	keys        key_store.KeyStore
 	keyID       contract.KeyID
 	contract    contract.OnchainContract
 	logger      commontypes.Logger
-	keyConsumer KeyConsumer
+	keyConsumer *KeyBroadcaster
 	randomness  io.Reader
 	shareDB     dkg_types.DKGSharePersistence
 }
@@ -247,10 +378,18 @@ func (o *offchainConfig) String() string {
   spks: %s,
   encryptionGroup: %s,
   translator: %s,
+  signingGroup: %s,
+  previousPublicShares: %v,
+  previousPlayers: %v,
+  weights: %v,
 }`,
 		strings.Join(epks, ", "),
 		strings.Join(spks, ", "),
 		o.encryptionGroup,
 		o.translator,
+		o.signingGroup,
+		o.previousPublicShares,
+		o.previousPlayers,
+		o.weights,
 	)
 }
@@ -0,0 +1,121 @@
+package dkg
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/protobuf"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+func testConfigKeys(signingGroup anon.Suite) (epks, spks []kyber.Point) {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	for i := 0; i < 4; i++ {
+		epks = append(epks, g.Point().Pick(g.RandomStream()))
+		spks = append(spks, signingGroup.Point().Pick(signingGroup.RandomStream()))
+	}
+	return epks, spks
+}
+
+func TestOffchainConfigSigningGroupRoundTrip(t *testing.T) {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	translator := translatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+	for _, tc := range []struct {
+		name       string
+		configured anon.Suite
+		want       anon.Suite
+	}{
+		{"default", nil, SigningGroup},
+		{"ed25519", SigningGroup, SigningGroup},
+		{"secp256k1", secp256k1Suite, secp256k1Suite},
+	} {
+		epks, spks := testConfigKeys(tc.want)
+		m, err := OffchainConfigWithSigningGroup(epks, spks, g, translator, tc.configured)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		got, err := unmarshalBinaryOffchainConfig(m)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got.signingGroup.String() != tc.want.String() {
+			t.Fatalf("%s: signing group %s after round trip, expected %s",
+				tc.name, got.signingGroup, tc.want)
+		}
+		for i, spk := range spks {
+			if !got.spks[i].Equal(spk) {
+				t.Fatalf("%s: signing key %d changed in round trip", tc.name, i)
+			}
+		}
+		// The DKG loads its signing key from, and verifies share records in,
+		// the configured group.
+		keys := key_store.NewInProcessKeyStore(
+			g.Scalar().Pick(g.RandomStream()), tc.want.Scalar().Pick(tc.want.RandomStream()),
+		)
+		p := &PluginConfig{offchainConfig: *got}
+		args, err := p.NewDKGArgs([32]byte{}, &localArgs{keys: keys}, 0, 4, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if args.signingGroup().String() != tc.want.String() {
+			t.Fatalf("%s: DKG args use signing group %s, expected %s",
+				tc.name, args.signingGroup(), tc.want)
+		}
+	}
+}
+
+func TestOffchainConfigSigningGroupErrors(t *testing.T) {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	translator := translatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+	epks, spks := testConfigKeys(secp256k1Suite)
+	if _, err := OffchainConfigWithSigningGroup(epks, spks, g, translator, g); err == nil {
+		t.Fatal("marshaled offchain config with an unsupported signing group")
+	}
+
+	marshal := func(signingGroup string, spks []kyber.Point) []byte {
+		p := &protobuf.OffchainConfig{
+			EncryptionGroup: g.String(),
+			Translator:      translator.Name(),
+			SigningGroup:    signingGroup,
+		}
+		for i := range epks {
+			epk, err := epks[i].MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			spk, err := spks[i].MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.EncryptionPKs = append(p.EncryptionPKs, epk)
+			p.SignaturePKs = append(p.SignaturePKs, spk)
+		}
+		m, err := proto.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	if _, err := unmarshalBinaryOffchainConfig(marshal("no such group", spks)); err == nil {
+		t.Fatal("unmarshaled offchain config with an unknown signing group")
+	}
+	// secp256k1 keys don't unmarshal as ed25519 keys.
+	if _, err := unmarshalBinaryOffchainConfig(marshal(SigningGroup.String(), spks)); err == nil {
+		t.Fatal("unmarshaled secp256k1 signing keys as ed25519 keys")
+	}
+
+	// Configs from before the signing group was configurable leave it unset,
+	// and use ed25519.
+	_, edSpks := testConfigKeys(SigningGroup)
+	got, err := unmarshalBinaryOffchainConfig(marshal("", edSpks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.signingGroup.String() != SigningGroup.String() {
+		t.Fatalf("config without a signing group uses %s", got.signingGroup)
+	}
+}
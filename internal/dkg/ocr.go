@@ -9,56 +9,45 @@ import (
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 )
 
-func (d *dkg) Query(context.Context, types.ReportTimestamp,
+func (d *dkg) Query(ctx context.Context, _ types.ReportTimestamp,
 ) (types.Query, error) {
-	return nil, nil
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	if d.aborted || !d.keyReportedOnchain(ctx) {
+		return nil, nil
+	}
+	return d.dataAvailabilityQuery(ctx), nil
 }
 
 func (d *dkg) Observation(
-	ctx context.Context, _ types.ReportTimestamp, _ types.Query,
+	ctx context.Context, _ types.ReportTimestamp, q types.Query,
 ) (o types.Observation, err error) {
 	d.lock.RLock()
 	defer d.lock.RUnlock()
-	var respondingShareRecord *shareRecord
-	if !d.keyReportedOnchain(ctx) {
-		respondingShareRecord = d.myShareRecord
-	} else {
-
-		keyData, err2 := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
-		if err2 != nil {
-
-			return nil, errors.Wrap(err2, "digest marked as complete, but key data is unavailable")
-		}
-		respondingShareRecordHash, err2 := d.shareSets.getRandom(keyData.Hashes, d.randomness)
-		if err2 != nil {
-
-			d.logger.Error(
-				"could not choose random hash to send for data-availability phase",
-				commontypes.LogFields{
-					"required hashes":     keyData.Hashes,
-					"existing share sets": d.shareSets,
-				},
-			)
-			return types.Observation{}, nil
+	if d.aborted {
+		return nil, nil
+	}
+	if d.keyReportedOnchain(ctx) {
+		return d.dataAvailabilityObservation(ctx, q)
+	}
+	if d.bias != nil {
+		return d.biasResistantObservation()
+	}
+	if d.myShareRecord == nil && len(d.complaints) == 0 {
+		return nil, nil
+	}
+	if d.myShareRecord != nil {
+		o, err = d.myShareRecord.marshal()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not construct observation")
 		}
-		var ok bool
-		respondingShareRecord, ok = d.shareSets[respondingShareRecordHash]
-		if !ok {
-			d.logger.Error(
-				"could not choose random share set to send. No record for the hash.",
-				commontypes.LogFields{
-					"randomly chosen hash": respondingShareRecordHash,
-				},
-			)
-			return types.Observation{}, nil
+		if _, err := unmarshalSignedShareRecord(d, o); err != nil {
+			panic(err)
 		}
 	}
-	o, err = respondingShareRecord.marshal()
-	if err != nil {
-		return nil, errors.Wrap(err, "could not construct observation")
-	}
-	if _, err := unmarshalSignedShareRecord(d, o); err != nil {
-		panic(err)
+	o = marshalObservation(o, d.pendingComplaints(len(o)))
+	if d.myShareRecord != nil {
+		d.shareRecordBroadcast.Store(true)
 	}
 	return o, nil
 }
@@ -69,6 +58,10 @@ func (d *dkg) Report(
 ) (shouldReport bool, report types.Report, err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
+	d.resumeIfReportedLocked(ctx)
+	if d.aborted {
+		return false, nil, nil
+	}
 
 	v, err := d.newValidShareRecords(ctx)
 	if err != nil {
@@ -76,22 +69,47 @@ func (d *dkg) Report(
 			err, "could not create record for valid shares",
 		)
 	}
+	observations := make([]*observation, 0, len(shares))
 	for _, aobs := range shares {
-		senderField := commontypes.LogFields{"sender": aobs.Observer}
+		o := v.parseObservation(aobs)
+		if o == nil {
+			continue
+		}
+		v.processComplaints(o)
+		observations = append(observations, o)
+	}
+	for _, o := range observations {
+		senderField := commontypes.LogFields{"sender": o.sender}
 		d.logger.Debug("processing share set", senderField)
 
-		v.processShareSet(aobs)
+		v.processShareSet(o)
 	}
-	if d.keyReportedOnchain(ctx) {
+	d.validShareSets = v.validShareCount
+	reported := d.keyReportedOnchain(ctx)
+	if reported {
+		v.processDataAvailabilityRequests(observations)
+		d.processShareRecoveryRequests(observations)
+		d.processTranscripts(ctx, observations)
+	}
+	if d.completed {
+		return false, nil, nil
+	}
+	if reported && d.recovery != nil {
+		return false, nil, d.progressShareRecovery(ctx, observations)
+	}
+	if reported {
 
 		return false, nil, d.recoverDistributedKeyShare(ctx)
 	}
 
+	if d.bias != nil {
+		return d.biasResistantReport(v, observations)
+	}
 	if !v.enoughShareSets() {
 		d.logger.Warn(
 			"need quorum of unique share sets to construct secure distributed key",
 			commontypes.LogFields{
-				"required": d.t + 1,
+				"required": d.requiredShareSets(),
 				"received": v.validShareCount,
 				"players":  v.players,
 			},
@@ -108,14 +126,33 @@ func (d *dkg) Report(
 }
 
 func (d *dkg) ShouldAcceptFinalizedReport(
-	_ context.Context, _ types.ReportTimestamp, _ types.Report) (bool, error) {
+	_ context.Context, _ types.ReportTimestamp, r types.Report) (bool, error) {
 
-	return true, nil
+	if d.bias == nil {
+		return true, nil
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	phase, payload, err := d.unmarshalPhaseReport(r)
+	if err != nil {
+		return true, nil
+	}
+	if err := d.acceptPhaseReport(phase, payload); err != nil {
+		d.logger.Warn("could not process DKG phase report", commontypes.LogFields{
+			"err": err, "phase": phase,
+		})
+	}
+	return false, nil
 }
 
 func (d *dkg) ShouldTransmitAcceptedReport(
 	c context.Context, t types.ReportTimestamp, r types.Report) (bool, error) {
 
+	if d.bias != nil {
+		if _, _, err := d.unmarshalPhaseReport(r); err == nil {
+			return false, nil
+		}
+	}
 	return !d.keyReportedOnchain(c), nil
 }
 
@@ -124,7 +161,9 @@ func (d *dkg) Start() error {
 }
 
 func (d *dkg) Close() error {
-
+	d.abort("plugin closed")
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	d.cancelFunc()
 	return nil
 }
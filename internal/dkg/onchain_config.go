@@ -0,0 +1,171 @@
+package dkg
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+)
+
+type dkgMode uint8
+
+const (
+	freshKey dkgMode = iota
+	refreshKey
+	reshareKey
+)
+
+func (m dkgMode) String() string {
+	switch m {
+	case freshKey:
+		return "fresh key"
+	case refreshKey:
+		return "key refresh"
+	case reshareKey:
+		return "key reshare"
+	default:
+		return fmt.Sprintf("unknown DKG mode %d", uint8(m))
+	}
+}
+
+type onchainConfig struct {
+	contract.KeyID
+
+	mode              dkgMode
+	previousDigest    types.ConfigDigest
+	previousThreshold player_idx.Int
+
+	threshold player_idx.Int
+
+	biasResistant bool
+}
+
+const (
+	modeTag byte = iota + 1
+	previousDigestTag
+	previousThresholdTag
+	thresholdTag
+	biasResistantTag
+)
+
+func (o *onchainConfig) Marshal() []byte {
+	rv := append([]byte{}, o.KeyID[:]...)
+	if o.mode != freshKey {
+		rv = append(rv, modeTag, 1, byte(o.mode))
+		rv = append(rv, previousDigestTag, byte(len(o.previousDigest)))
+		rv = append(rv, o.previousDigest[:]...)
+	}
+	if o.mode == reshareKey {
+		t := player_idx.RawMarshal(o.previousThreshold)
+		rv = append(rv, previousThresholdTag, byte(len(t)))
+		rv = append(rv, t...)
+	}
+	if o.threshold != 0 {
+		t := player_idx.RawMarshal(o.threshold)
+		rv = append(rv, thresholdTag, byte(len(t)))
+		rv = append(rv, t...)
+	}
+	if o.biasResistant {
+		rv = append(rv, biasResistantTag, 1, 1)
+	}
+	return rv
+}
+
+func (o *onchainConfig) thresholdFor(f player_idx.Int) player_idx.Int {
+	if o.threshold != 0 {
+		return o.threshold
+	}
+	return f
+}
+
+func checkThreshold(n, f, t int) error {
+	if t < f {
+		return fmt.Errorf(
+			"threshold %d is below fault tolerance %d, so faulty oracles could "+
+				"reconstruct the key", t, f,
+		)
+	}
+	if t >= n-f {
+		return fmt.Errorf(
+			"threshold %d needs %d shares, but only %d of %d shares are "+
+				"guaranteed to be held by honest oracles", t, t+1, n-f, n,
+		)
+	}
+	return nil
+}
+
+func unmarshalBinaryOnchainConfig(onchainBinaryConfig []byte) (rv onchainConfig, err error) {
+	if len(onchainBinaryConfig) < len(contract.KeyID{}) {
+		return rv, fmt.Errorf("onchainConfig binary is wrong length")
+	}
+	copy(rv.KeyID[:], onchainBinaryConfig)
+	data := onchainBinaryConfig[len(contract.KeyID{}):]
+	seen := map[byte]bool{}
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return rv, fmt.Errorf("truncated onchainConfig field header")
+		}
+		tag, fieldLen := data[0], int(data[1])
+		if len(data[2:]) < fieldLen {
+			return rv, fmt.Errorf("truncated onchainConfig field %d", tag)
+		}
+		value := data[2 : 2+fieldLen]
+		data = data[2+fieldLen:]
+		if seen[tag] {
+			return rv, fmt.Errorf("duplicate onchainConfig field %d", tag)
+		}
+		seen[tag] = true
+		switch tag {
+		case modeTag:
+			if len(value) != 1 {
+				return rv, fmt.Errorf("onchainConfig mode field is wrong length")
+			}
+			rv.mode = dkgMode(value[0])
+		case previousDigestTag:
+			if len(value) != len(rv.previousDigest) {
+				return rv, fmt.Errorf("onchainConfig previous digest is wrong length")
+			}
+			copy(rv.previousDigest[:], value)
+		case previousThresholdTag:
+			t, rem, err := player_idx.RawUnmarshal(value)
+			if err != nil || len(rem) > 0 {
+				return rv, fmt.Errorf("onchainConfig previous threshold is malformed")
+			}
+			rv.previousThreshold = t
+		case thresholdTag:
+			t, rem, err := player_idx.RawUnmarshal(value)
+			if err != nil || len(rem) > 0 || t == 0 {
+				return rv, fmt.Errorf("onchainConfig threshold is malformed")
+			}
+			rv.threshold = t
+		case biasResistantTag:
+			if len(value) != 1 || value[0] != 1 {
+				return rv, fmt.Errorf("onchainConfig bias-resistance flag is malformed")
+			}
+			rv.biasResistant = true
+		default:
+			return rv, fmt.Errorf("unknown onchainConfig field %d", tag)
+		}
+	}
+	switch rv.mode {
+	case freshKey:
+		if seen[previousDigestTag] {
+			return rv, fmt.Errorf("previous config digest given for fresh key")
+		}
+	case refreshKey, reshareKey:
+		if rv.previousDigest == (types.ConfigDigest{}) {
+			return rv, fmt.Errorf("%s requires the previous config digest", rv.mode)
+		}
+		if rv.biasResistant {
+			return rv, fmt.Errorf("bias-resistant dealing is only for a fresh key")
+		}
+		if seen[previousThresholdTag] != (rv.mode == reshareKey) {
+			return rv, fmt.Errorf("previous threshold is only given for key reshare")
+		}
+	default:
+		return rv, fmt.Errorf("unknown DKG mode %d", uint8(rv.mode))
+	}
+	return rv, nil
+}
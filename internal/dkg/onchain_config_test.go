@@ -0,0 +1,47 @@
+package dkg
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+)
+
+func TestWithBiasResistance(t *testing.T) {
+	keyID := contract.KeyID{1}
+	fresh, err := OnchainConfig(keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	biasResistant, err := WithBiasResistance(fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := unmarshalBinaryOnchainConfig(biasResistant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.biasResistant || o.mode != freshKey || o.KeyID != keyID {
+		t.Fatalf("bias-resistance flag changed config: %+v", o)
+	}
+	if o, err := unmarshalBinaryOnchainConfig(fresh); err != nil || o.biasResistant {
+		t.Fatal("fresh key config is bias-resistant by default")
+	}
+
+	refresh, err := RefreshOnchainConfig(keyID, types.ConfigDigest{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WithBiasResistance(refresh); err == nil {
+		t.Fatal("bias-resistant dealing accepted for key refresh")
+	}
+	forged := append(append([]byte{}, refresh...), biasResistantTag, 1, 1)
+	if _, err := unmarshalBinaryOnchainConfig(forged); err == nil {
+		t.Fatal("decoded bias-resistant key refresh config")
+	}
+	malformed := append(append([]byte{}, fresh...), biasResistantTag, 1, 0)
+	if _, err := unmarshalBinaryOnchainConfig(malformed); err == nil {
+		t.Fatal("decoded malformed bias-resistance flag")
+	}
+}
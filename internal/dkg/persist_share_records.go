@@ -7,7 +7,6 @@ import (
 	"github.com/smartcontractkit/libocr/commontypes"
 
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
-	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
 	"github.com/smartcontractkit/chainlink-vrf/internal/util"
 	"github.com/smartcontractkit/chainlink-vrf/types"
 	"github.com/smartcontractkit/chainlink-vrf/types/hash"
@@ -57,42 +56,62 @@ func (d *dkg) initializeShareSets(signingGroup anon.Suite) error {
 			errMsg := "could not unmarshal persisted share record"
 			return util.WrapError(err, errMsg)
 		}
-		if err := d.shareSets.set(share, mHash); err != nil {
+		if err := d.shareSets.set(share, shareRecordHash(m)); err != nil {
 			errMsg := "could not record persisted share from %s"
 			return util.WrapErrorf(err, errMsg, storedShare.Dealer)
 		}
-		if storedShare.Dealer.Equal(d.selfIdx) {
+		if storedShare.Dealer.Equal(d.selfIdx) && d.bias == nil {
 			myShareRecovered = true
 			d.myShareRecord = share
 		}
 	}
-	if !myShareRecovered {
+	if err := d.restoreTranscript(); err != nil {
+		d.logger.Warn("ignoring persisted transcript", commontypes.LogFields{"err": err})
+	}
+	if myShareRecovered {
+		return nil
+	}
+	return d.dealLocked(signingGroup)
+}
 
-		shareSet, err := pvss.NewShareSet(
-			d.cfgDgst, d.t, d.selfIdx, d.encryptionGroup, d.translator, d.epks,
-		)
+// dealLocked creates and persists this node's own share record, or its hiding
+// share record for bias-resistant dealing.
+func (d *dkg) dealLocked(signingGroup anon.Suite) (err error) {
+	if d.bias != nil {
+		d.bias.myRecord, err = d.newHidingShareRecord()
 		if err != nil {
-			return util.WrapError(err, "could not create own share set")
-		}
-		msr, err := newShareRecord(signingGroup, shareSet, d.ssk, d.cfgDgst)
-		if err != nil {
-			return util.WrapError(err, "could not create own share record")
-		}
-		d.myShareRecord = msr
-		psr, err := d.myShareRecord.PersistentRecord()
-		if err != nil {
-			errMsg := "could not construct own persistent share record"
-			return util.WrapError(err, errMsg)
-		}
-		lpsr := []types.PersistentShareSetRecord{psr}
-		err = d.db.WriteShareRecords(context.Background(), d.cfgDgst, d.keyID, lpsr)
-		if err != nil {
-			return util.WrapError(err, "could not write own share record")
-		}
-		err = d.shareSets.set(d.myShareRecord, hash.Zero)
-		if err != nil {
-			return util.WrapError(err, "could not set own share record")
+			return util.WrapError(err, "could not create own hiding share record")
 		}
+		return nil
+	}
+	shareSet, err := d.ownShareSet()
+	if err != nil {
+		return util.WrapError(err, "could not create own share set")
+	}
+	if shareSet == nil {
+		d.logger.Info("not dealing a share set", commontypes.LogFields{
+			"mode": d.mode.String(),
+		})
+		return nil
+	}
+	msr, err := newShareRecord(signingGroup, shareSet, d.ssk, d.cfgDgst)
+	if err != nil {
+		return util.WrapError(err, "could not create own share record")
+	}
+	d.myShareRecord = msr
+	psr, err := d.myShareRecord.PersistentRecord()
+	if err != nil {
+		errMsg := "could not construct own persistent share record"
+		return util.WrapError(err, errMsg)
+	}
+	lpsr := []types.PersistentShareSetRecord{psr}
+	err = d.db.WriteShareRecords(context.Background(), d.cfgDgst, d.keyID, lpsr)
+	if err != nil {
+		return util.WrapError(err, "could not write own share record")
+	}
+	err = d.shareSets.set(d.myShareRecord, hash.Zero)
+	if err != nil {
+		return util.WrapError(err, "could not set own share record")
 	}
 	return nil
 }
@@ -102,7 +121,9 @@ func (v *validShareRecords) persistShares(
 	marshaledShareSet []byte,
 	h hash.Hash,
 ) {
-	psr := types.PersistentShareSetRecord{reportedDealer, marshaledShareSet, h}
+	psr := types.PersistentShareSetRecord{
+		reportedDealer, marshaledShareSet, hash.GetHash(marshaledShareSet),
+	}
 	lpsr := []types.PersistentShareSetRecord{psr}
 	v.d.lock.Lock()
 	defer v.d.lock.Unlock()
@@ -0,0 +1,452 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+
+	ocr_types "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+// EncryptedSharePersistence encrypts everything it stores in an underlying
+// share persistence. The value returned by NewEncryptedSharePersistence also
+// implements whichever of types.PrunableDKGSharePersistence,
+// types.ReplaceableDKGSharePersistence, types.KeySnapshotPersistence,
+// types.TranscriptPersistence and types.CompletedKeyPersistence the underlying
+// store implements, and no others.
+type EncryptedSharePersistence interface {
+	types.DKGSharePersistence
+
+	// AllowUnencryptedReads lets the store read records written before it
+	// encrypted them.
+	AllowUnencryptedReads() EncryptedSharePersistence
+
+	// Rewrap re-encrypts everything stored for the config digest and key ID,
+	// and the key ID's completed key record, under the current wrapping key,
+	// replacing the old ciphertexts so that the keys they were sealed under can
+	// be retired.
+	Rewrap(ctx context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte) error
+}
+
+type encryptedSharePersistence struct {
+	db   types.DKGSharePersistence
+	keys KeySource
+
+	allowUnencrypted bool
+	// view is the value handed out for this store, which exposes only the
+	// optional interfaces db implements.
+	view EncryptedSharePersistence
+}
+
+func NewEncryptedSharePersistence(
+	db types.DKGSharePersistence, keys KeySource,
+) EncryptedSharePersistence {
+	e := &encryptedSharePersistence{db, keys, false, nil}
+	e.view = e.withCapabilitiesOf(db)
+	return e.view
+}
+
+func (e *encryptedSharePersistence) AllowUnencryptedReads() EncryptedSharePersistence {
+	e.allowUnencrypted = true
+	return e.view
+}
+
+const (
+	unencryptedRecordVersionNum uint8 = 0
+	// Version 1 records have no wrapping-key salt in their headers.
+	encryptedRecordVersionNum uint8 = 1
+	saltedRecordVersionNum    uint8 = 2
+)
+
+const nonceLen = 12
+
+const maxSaltLen = 255
+
+func (e *encryptedSharePersistence) WriteShareRecords(
+	ctx context.Context,
+	cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte,
+	shareRecords []types.PersistentShareSetRecord,
+) error {
+	encrypted, err := e.sealRecords(cfgDgst, keyID, shareRecords)
+	if err != nil {
+		return err
+	}
+	return e.db.WriteShareRecords(ctx, cfgDgst, keyID, encrypted)
+}
+
+func (e *encryptedSharePersistence) replaceShareRecords(
+	ctx context.Context,
+	cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte,
+	shareRecords []types.PersistentShareSetRecord,
+) error {
+	db, ok := e.db.(types.ReplaceableDKGSharePersistence)
+	if !ok {
+		return errors.Errorf("underlying share persistence can't replace share records")
+	}
+	encrypted, err := e.sealRecords(cfgDgst, keyID, shareRecords)
+	if err != nil {
+		return err
+	}
+	return db.ReplaceShareRecords(ctx, cfgDgst, keyID, encrypted)
+}
+
+func (e *encryptedSharePersistence) sealRecords(
+	cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte,
+	shareRecords []types.PersistentShareSetRecord,
+) ([]types.PersistentShareSetRecord, error) {
+	wrappingKey, header, gcm, err := e.currentKey()
+	if err != nil {
+		return nil, err
+	}
+	encrypted := make([]types.PersistentShareSetRecord, len(shareRecords))
+	for i, r := range shareRecords {
+		ct := seal(
+			gcm, wrappingKey, header, cfgDgst, keyID, r.Dealer.Marshal(),
+			r.MarshaledShareRecord,
+		)
+		encrypted[i] = types.PersistentShareSetRecord{r.Dealer, ct, hash.GetHash(ct)}
+	}
+	return encrypted, nil
+}
+
+func (e *encryptedSharePersistence) ReadShareRecords(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]types.PersistentShareSetRecord, error) {
+	encrypted, err := e.db.ReadShareRecords(cfgDgst, keyID)
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]types.PersistentShareSetRecord, len(encrypted))
+	for i, r := range encrypted {
+		pt, err := e.open(cfgDgst, keyID, r.Dealer.Marshal(), r.MarshaledShareRecord)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decrypt share record from %s", r.Dealer)
+		}
+		rv[i] = types.PersistentShareSetRecord{r.Dealer, pt, hash.GetHash(pt)}
+	}
+	return rv, nil
+}
+
+func (e *encryptedSharePersistence) Rewrap(
+	ctx context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) error {
+	records, err := e.ReadShareRecords(cfgDgst, keyID)
+	if err != nil {
+		return errors.Wrap(err, "could not read share records to rewrap")
+	}
+	if len(records) > 0 {
+		if err := e.replaceShareRecords(ctx, cfgDgst, keyID, records); err != nil {
+			return errors.Wrap(err, "could not write rewrapped share records")
+		}
+	}
+	snapshot, err := e.readKeySnapshot(cfgDgst, keyID)
+	if err != nil {
+		return errors.Wrap(err, "could not read key snapshot to rewrap")
+	}
+	if len(snapshot) > 0 {
+		if err := e.writeKeySnapshot(ctx, cfgDgst, keyID, snapshot); err != nil {
+			return errors.Wrap(err, "could not write rewrapped key snapshot")
+		}
+	}
+	transcript, err := e.readTranscript(cfgDgst, keyID)
+	if err != nil {
+		return errors.Wrap(err, "could not read transcript to rewrap")
+	}
+	if len(transcript) > 0 {
+		if err := e.writeTranscript(ctx, cfgDgst, keyID, transcript); err != nil {
+			return errors.Wrap(err, "could not write rewrapped transcript")
+		}
+	}
+	return e.rewrapCompletedKey(ctx, keyID)
+}
+
+func (e *encryptedSharePersistence) rewrapCompletedKey(
+	ctx context.Context, keyID [32]byte,
+) error {
+	db, ok := e.db.(types.CompletedKeyPersistence)
+	if !ok {
+		return nil
+	}
+	cts, err := db.CompletedKeys()
+	ct, ok := cts[keyID]
+	if !ok {
+		return errors.Wrap(err, "could not read completed keys to rewrap")
+	}
+	record, err := e.open(ocr_types.ConfigDigest{}, keyID, completedKeyLabel, ct)
+	if err != nil {
+		return errors.Wrap(err, "could not decrypt completed key to rewrap")
+	}
+	if err := e.writeCompletedKey(ctx, keyID, record); err != nil {
+		return errors.Wrap(err, "could not write rewrapped completed key")
+	}
+	return nil
+}
+
+func (e *encryptedSharePersistence) configDigests(
+	keyID [32]byte,
+) ([]ocr_types.ConfigDigest, error) {
+	db, ok := e.db.(types.PrunableDKGSharePersistence)
+	if !ok {
+		return nil, errors.Errorf("underlying share persistence can't list config digests")
+	}
+	return db.ConfigDigests(keyID)
+}
+
+func (e *encryptedSharePersistence) deleteShareRecords(
+	ctx context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) error {
+	db, ok := e.db.(types.PrunableDKGSharePersistence)
+	if !ok {
+		return errors.Errorf("underlying share persistence can't delete share records")
+	}
+	return db.DeleteShareRecords(ctx, cfgDgst, keyID)
+}
+
+var snapshotLabel = []byte("key snapshot")
+
+func (e *encryptedSharePersistence) writeKeySnapshot(
+	ctx context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, snapshot []byte,
+) error {
+	db, ok := e.db.(types.KeySnapshotPersistence)
+	if !ok {
+		return errors.Errorf("underlying share persistence can't store key snapshots")
+	}
+	wrappingKey, header, gcm, err := e.currentKey()
+	if err != nil {
+		return err
+	}
+	ct := seal(gcm, wrappingKey, header, cfgDgst, keyID, snapshotLabel, snapshot)
+	return db.WriteKeySnapshot(ctx, cfgDgst, keyID, ct)
+}
+
+func (e *encryptedSharePersistence) readKeySnapshot(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]byte, error) {
+	db, ok := e.db.(types.KeySnapshotPersistence)
+	if !ok {
+		return nil, nil
+	}
+	ct, err := db.ReadKeySnapshot(cfgDgst, keyID)
+	if err != nil || len(ct) == 0 {
+		return nil, err
+	}
+	pt, err := e.open(cfgDgst, keyID, snapshotLabel, ct)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt key snapshot")
+	}
+	return pt, nil
+}
+
+var transcriptLabel = []byte("transcript")
+
+func (e *encryptedSharePersistence) writeTranscript(
+	ctx context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, transcript []byte,
+) error {
+	db, ok := e.db.(types.TranscriptPersistence)
+	if !ok {
+		return errors.Errorf("underlying share persistence can't store transcripts")
+	}
+	wrappingKey, header, gcm, err := e.currentKey()
+	if err != nil {
+		return err
+	}
+	ct := seal(gcm, wrappingKey, header, cfgDgst, keyID, transcriptLabel, transcript)
+	return db.WriteTranscript(ctx, cfgDgst, keyID, ct)
+}
+
+func (e *encryptedSharePersistence) readTranscript(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]byte, error) {
+	db, ok := e.db.(types.TranscriptPersistence)
+	if !ok {
+		return nil, nil
+	}
+	ct, err := db.ReadTranscript(cfgDgst, keyID)
+	if err != nil || len(ct) == 0 {
+		return nil, err
+	}
+	pt, err := e.open(cfgDgst, keyID, transcriptLabel, ct)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt transcript")
+	}
+	return pt, nil
+}
+
+var completedKeyLabel = []byte("completed key")
+
+func (e *encryptedSharePersistence) writeCompletedKey(
+	ctx context.Context, keyID [32]byte, record []byte,
+) error {
+	db, ok := e.db.(types.CompletedKeyPersistence)
+	if !ok {
+		return errors.Errorf("underlying share persistence can't store completed keys")
+	}
+	wrappingKey, header, gcm, err := e.currentKey()
+	if err != nil {
+		return err
+	}
+	ct := seal(
+		gcm, wrappingKey, header, ocr_types.ConfigDigest{}, keyID,
+		completedKeyLabel, record,
+	)
+	return db.WriteCompletedKey(ctx, keyID, ct)
+}
+
+func (e *encryptedSharePersistence) completedKeys() (map[[32]byte][]byte, error) {
+	db, ok := e.db.(types.CompletedKeyPersistence)
+	if !ok {
+		return nil, nil
+	}
+	cts, err := db.CompletedKeys()
+	rv := make(map[[32]byte][]byte, len(cts))
+	failed := 0
+	for keyID, ct := range cts {
+		pt, err2 := e.open(ocr_types.ConfigDigest{}, keyID, completedKeyLabel, ct)
+		if err2 != nil {
+			failed++
+			err = errors.Wrapf(err2, "could not decrypt completed key 0x%x", keyID)
+			continue
+		}
+		rv[keyID] = pt
+	}
+	if failed > 1 {
+		err = errors.Wrapf(err, "could not decrypt %d completed keys", failed)
+	}
+	return rv, err
+}
+
+// currentKey returns the current wrapping key, an AES-GCM cipher under it, and
+// the header, up to the nonce, of records sealed under it: the version, the
+// wrapping key ID, and the length-prefixed salt.
+func (e *encryptedSharePersistence) currentKey() (
+	wrappingKey, header []byte, gcm cipher.AEAD, err error,
+) {
+	wrappingKeyID, salt, wrappingKey, err := e.keys.CurrentKey()
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not get current wrapping key")
+	}
+	if len(salt) > maxSaltLen {
+		return nil, nil, nil, errors.Errorf(
+			"wrapping key salt must be at most %d bytes, got %d", maxSaltLen, len(salt),
+		)
+	}
+	gcm, err = newGCM(wrappingKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	header = binary.BigEndian.AppendUint32([]byte{saltedRecordVersionNum}, wrappingKeyID)
+	header = append(append(header, uint8(len(salt))), salt...)
+	return wrappingKey, header, gcm, nil
+}
+
+func seal(
+	gcm cipher.AEAD, wrappingKey, header []byte,
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte, label, pt []byte,
+) []byte {
+	ad := associatedData(header, cfgDgst, keyID, label)
+	// The associated data ends in a variable-length label, so it's
+	// length-prefixed to keep it from running into the plaintext.
+	var adLen [8]byte
+	binary.BigEndian.PutUint64(adLen[:], uint64(len(ad)))
+	mac := hmac.New(sha256.New, nonceKey(wrappingKey))
+	_, _ = mac.Write(adLen[:])
+	_, _ = mac.Write(ad)
+	_, _ = mac.Write(pt)
+	nonce := mac.Sum(nil)[:nonceLen]
+	return gcm.Seal(append(append([]byte{}, header...), nonce...), nonce, pt, ad)
+}
+
+var nonceKeyLabel = []byte("chainlink-vrf share record nonce key")
+
+// nonceKey derives the key for the synthetic nonces from the wrapping key, so
+// that the wrapping key itself is only ever used with AES-GCM.
+func nonceKey(wrappingKey []byte) []byte {
+	mac := hmac.New(sha256.New, wrappingKey)
+	_, _ = mac.Write(nonceKeyLabel)
+	return mac.Sum(nil)
+}
+
+func (e *encryptedSharePersistence) open(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte, label, ct []byte,
+) ([]byte, error) {
+	if len(ct) == 0 {
+		return nil, errors.Errorf("empty share record")
+	}
+	if ct[0] == unencryptedRecordVersionNum && e.allowUnencrypted {
+		return ct, nil
+	}
+	header, salt, err := recordHeader(ct)
+	if err != nil {
+		return nil, err
+	}
+	if len(ct) < len(header)+nonceLen {
+		return nil, errors.Errorf("encrypted share record too short, %d bytes", len(ct))
+	}
+	wrappingKeyID := binary.BigEndian.Uint32(ct[1:5])
+	wrappingKey, err := e.keys.Key(wrappingKeyID, salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get wrapping key")
+	}
+	gcm, err := newGCM(wrappingKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := ct[len(header) : len(header)+nonceLen]
+	ad := associatedData(header, cfgDgst, keyID, label)
+	pt, err := gcm.Open(nil, nonce, ct[len(header)+nonceLen:], ad)
+	if err != nil {
+		return nil, errors.Wrap(err, "authentication failed")
+	}
+	return pt, nil
+}
+
+// recordHeader returns the header of the encrypted record ct up to its nonce,
+// and the salt of the wrapping key it was sealed under.
+func recordHeader(ct []byte) (header, salt []byte, err error) {
+	switch ct[0] {
+	case encryptedRecordVersionNum:
+		if len(ct) < 5 {
+			return nil, nil, errors.Errorf("encrypted share record too short, %d bytes", len(ct))
+		}
+		return ct[:5], nil, nil
+	case saltedRecordVersionNum:
+		if len(ct) < 6 || len(ct) < 6+int(ct[5]) {
+			return nil, nil, errors.Errorf("encrypted share record too short, %d bytes", len(ct))
+		}
+		return ct[:6+int(ct[5])], ct[6 : 6+int(ct[5])], nil
+	default:
+		return nil, nil, errors.Errorf(
+			"don't know how to decrypt version %d share records", ct[0],
+		)
+	}
+}
+
+func associatedData(
+	header []byte, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, label []byte,
+) []byte {
+	return bytes.Join([][]byte{header, cfgDgst[:], keyID[:], label}, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not construct block cipher for share record")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not construct GCM cipher for share record")
+	}
+	return gcm, nil
+}
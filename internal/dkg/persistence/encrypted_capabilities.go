@@ -0,0 +1,323 @@
+package persistence
+
+import (
+	"context"
+
+	ocr_types "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/types"
+)
+
+// The DKG decides what it can do with a share persistence by asserting its
+// optional interfaces, so the encrypted store must implement exactly the ones
+// its underlying store does. Each capability below exposes one of them, and
+// withCapabilitiesOf combines those which apply.
+
+type prunable struct{ e *encryptedSharePersistence }
+
+func (p prunable) ConfigDigests(keyID [32]byte) ([]ocr_types.ConfigDigest, error) {
+	return p.e.configDigests(keyID)
+}
+
+func (p prunable) DeleteShareRecords(
+	ctx context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) error {
+	return p.e.deleteShareRecords(ctx, cfgDgst, keyID)
+}
+
+type replaceable struct{ e *encryptedSharePersistence }
+
+func (r replaceable) ReplaceShareRecords(
+	ctx context.Context,
+	cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte,
+	shareRecords []types.PersistentShareSetRecord,
+) error {
+	return r.e.replaceShareRecords(ctx, cfgDgst, keyID, shareRecords)
+}
+
+type snapshots struct{ e *encryptedSharePersistence }
+
+func (s snapshots) WriteKeySnapshot(
+	ctx context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, snapshot []byte,
+) error {
+	return s.e.writeKeySnapshot(ctx, cfgDgst, keyID, snapshot)
+}
+
+func (s snapshots) ReadKeySnapshot(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]byte, error) {
+	return s.e.readKeySnapshot(cfgDgst, keyID)
+}
+
+type transcripts struct{ e *encryptedSharePersistence }
+
+func (t transcripts) WriteTranscript(
+	ctx context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, transcript []byte,
+) error {
+	return t.e.writeTranscript(ctx, cfgDgst, keyID, transcript)
+}
+
+func (t transcripts) ReadTranscript(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]byte, error) {
+	return t.e.readTranscript(cfgDgst, keyID)
+}
+
+type completedKeys struct{ e *encryptedSharePersistence }
+
+func (c completedKeys) WriteCompletedKey(
+	ctx context.Context, keyID [32]byte, record []byte,
+) error {
+	return c.e.writeCompletedKey(ctx, keyID, record)
+}
+
+func (c completedKeys) CompletedKeys() (map[[32]byte][]byte, error) {
+	return c.e.completedKeys()
+}
+
+const (
+	isPrunable = 1 << iota
+	isReplaceable
+	hasSnapshots
+	hasTranscripts
+	hasCompletedKeys
+)
+
+func (e *encryptedSharePersistence) withCapabilitiesOf(
+	db types.DKGSharePersistence,
+) EncryptedSharePersistence {
+	caps := 0
+	if _, ok := db.(types.PrunableDKGSharePersistence); ok {
+		caps |= isPrunable
+	}
+	if _, ok := db.(types.ReplaceableDKGSharePersistence); ok {
+		caps |= isReplaceable
+	}
+	if _, ok := db.(types.KeySnapshotPersistence); ok {
+		caps |= hasSnapshots
+	}
+	if _, ok := db.(types.TranscriptPersistence); ok {
+		caps |= hasTranscripts
+	}
+	if _, ok := db.(types.CompletedKeyPersistence); ok {
+		caps |= hasCompletedKeys
+	}
+	type (
+		base = *encryptedSharePersistence
+		p    = prunable
+		r    = replaceable
+		s    = snapshots
+		t    = transcripts
+		c    = completedKeys
+	)
+	pv, rv, sv, tv, cv := p{e}, r{e}, s{e}, t{e}, c{e}
+	switch caps {
+	case 0:
+		return e
+	case isPrunable:
+		return struct {
+			base
+			p
+		}{e, pv}
+	case isReplaceable:
+		return struct {
+			base
+			r
+		}{e, rv}
+	case isPrunable | isReplaceable:
+		return struct {
+			base
+			p
+			r
+		}{e, pv, rv}
+	case hasSnapshots:
+		return struct {
+			base
+			s
+		}{e, sv}
+	case hasSnapshots | isPrunable:
+		return struct {
+			base
+			p
+			s
+		}{e, pv, sv}
+	case hasSnapshots | isReplaceable:
+		return struct {
+			base
+			r
+			s
+		}{e, rv, sv}
+	case hasSnapshots | isPrunable | isReplaceable:
+		return struct {
+			base
+			p
+			r
+			s
+		}{e, pv, rv, sv}
+	case hasTranscripts:
+		return struct {
+			base
+			t
+		}{e, tv}
+	case hasTranscripts | isPrunable:
+		return struct {
+			base
+			p
+			t
+		}{e, pv, tv}
+	case hasTranscripts | isReplaceable:
+		return struct {
+			base
+			r
+			t
+		}{e, rv, tv}
+	case hasTranscripts | isPrunable | isReplaceable:
+		return struct {
+			base
+			p
+			r
+			t
+		}{e, pv, rv, tv}
+	case hasTranscripts | hasSnapshots:
+		return struct {
+			base
+			s
+			t
+		}{e, sv, tv}
+	case hasTranscripts | hasSnapshots | isPrunable:
+		return struct {
+			base
+			p
+			s
+			t
+		}{e, pv, sv, tv}
+	case hasTranscripts | hasSnapshots | isReplaceable:
+		return struct {
+			base
+			r
+			s
+			t
+		}{e, rv, sv, tv}
+	case hasTranscripts | hasSnapshots | isPrunable | isReplaceable:
+		return struct {
+			base
+			p
+			r
+			s
+			t
+		}{e, pv, rv, sv, tv}
+	case hasCompletedKeys:
+		return struct {
+			base
+			c
+		}{e, cv}
+	case hasCompletedKeys | isPrunable:
+		return struct {
+			base
+			p
+			c
+		}{e, pv, cv}
+	case hasCompletedKeys | isReplaceable:
+		return struct {
+			base
+			r
+			c
+		}{e, rv, cv}
+	case hasCompletedKeys | isPrunable | isReplaceable:
+		return struct {
+			base
+			p
+			r
+			c
+		}{e, pv, rv, cv}
+	case hasCompletedKeys | hasSnapshots:
+		return struct {
+			base
+			s
+			c
+		}{e, sv, cv}
+	case hasCompletedKeys | hasSnapshots | isPrunable:
+		return struct {
+			base
+			p
+			s
+			c
+		}{e, pv, sv, cv}
+	case hasCompletedKeys | hasSnapshots | isReplaceable:
+		return struct {
+			base
+			r
+			s
+			c
+		}{e, rv, sv, cv}
+	case hasCompletedKeys | hasSnapshots | isPrunable | isReplaceable:
+		return struct {
+			base
+			p
+			r
+			s
+			c
+		}{e, pv, rv, sv, cv}
+	case hasCompletedKeys | hasTranscripts:
+		return struct {
+			base
+			t
+			c
+		}{e, tv, cv}
+	case hasCompletedKeys | hasTranscripts | isPrunable:
+		return struct {
+			base
+			p
+			t
+			c
+		}{e, pv, tv, cv}
+	case hasCompletedKeys | hasTranscripts | isReplaceable:
+		return struct {
+			base
+			r
+			t
+			c
+		}{e, rv, tv, cv}
+	case hasCompletedKeys | hasTranscripts | isPrunable | isReplaceable:
+		return struct {
+			base
+			p
+			r
+			t
+			c
+		}{e, pv, rv, tv, cv}
+	case hasCompletedKeys | hasTranscripts | hasSnapshots:
+		return struct {
+			base
+			s
+			t
+			c
+		}{e, sv, tv, cv}
+	case hasCompletedKeys | hasTranscripts | hasSnapshots | isPrunable:
+		return struct {
+			base
+			p
+			s
+			t
+			c
+		}{e, pv, sv, tv, cv}
+	case hasCompletedKeys | hasTranscripts | hasSnapshots | isReplaceable:
+		return struct {
+			base
+			r
+			s
+			t
+			c
+		}{e, rv, sv, tv, cv}
+	default:
+		return struct {
+			base
+			p
+			r
+			s
+			t
+			c
+		}{e, pv, rv, sv, tv, cv}
+	}
+}
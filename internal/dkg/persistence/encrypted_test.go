@@ -0,0 +1,284 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	ocr_types "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/types"
+)
+
+func wrappingKeys(t *testing.T, current uint32, ids ...uint32) KeySource {
+	keys := make(map[uint32][]byte, len(ids))
+	for _, id := range ids {
+		keys[id] = bytes.Repeat([]byte{byte(id)}, wrappingKeyLen)
+	}
+	ks, err := NewStaticKeySource(current, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+// encryptedStore is what NewEncryptedSharePersistence returns for an
+// underlying store with every optional capability.
+type encryptedStore interface {
+	EncryptedSharePersistence
+	types.PrunableDKGSharePersistence
+	types.ReplaceableDKGSharePersistence
+	types.KeySnapshotPersistence
+	types.TranscriptPersistence
+	types.CompletedKeyPersistence
+}
+
+func newEncryptedStore(
+	t *testing.T, db types.DKGSharePersistence, keys KeySource,
+) encryptedStore {
+	e, ok := NewEncryptedSharePersistence(db, keys).(encryptedStore)
+	if !ok {
+		t.Fatal("encrypted store lacks a capability of its underlying store")
+	}
+	return e
+}
+
+func TestRewrapRetiresOldWrappingKey(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	players, err := player_idx.PlayerIdxs(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewFileSharePersistence(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := newEncryptedStore(t, db, wrappingKeys(t, 1, 1))
+	records := []types.PersistentShareSetRecord{
+		{Dealer: *players[0], MarshaledShareRecord: []byte("record")},
+	}
+	if err := old.WriteShareRecords(ctx, cfgDgst, keyID, records); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.WriteKeySnapshot(ctx, cfgDgst, keyID, []byte("snapshot")); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.WriteTranscript(ctx, cfgDgst, keyID, []byte("transcript")); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.WriteCompletedKey(ctx, keyID, []byte("completed")); err != nil {
+		t.Fatal(err)
+	}
+
+	rotating := NewEncryptedSharePersistence(db, wrappingKeys(t, 2, 1, 2))
+	if err := rotating.Rewrap(ctx, cfgDgst, keyID); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = NewFileSharePersistence(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired := newEncryptedStore(t, db, wrappingKeys(t, 2, 2))
+	rs, err := retired.ReadShareRecords(cfgDgst, keyID)
+	if err != nil || len(rs) != 1 || string(rs[0].MarshaledShareRecord) != "record" {
+		t.Fatalf("share records not rewrapped: %v %v", rs, err)
+	}
+	snapshot, err := retired.ReadKeySnapshot(cfgDgst, keyID)
+	if err != nil || string(snapshot) != "snapshot" {
+		t.Fatalf("key snapshot not rewrapped: %q %v", snapshot, err)
+	}
+	transcript, err := retired.ReadTranscript(cfgDgst, keyID)
+	if err != nil || string(transcript) != "transcript" {
+		t.Fatalf("transcript not rewrapped: %q %v", transcript, err)
+	}
+	completed, err := retired.CompletedKeys()
+	if err != nil || string(completed[keyID]) != "completed" {
+		t.Fatalf("completed key not rewrapped: %q %v", completed, err)
+	}
+}
+
+func TestCompletedKeysSkipsUnreadableRecords(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemorySharePersistence()
+	sealedUnderOld, sealedUnderNew := [32]byte{1}, [32]byte{2}
+	err := newEncryptedStore(t, db, wrappingKeys(t, 1, 1)).
+		WriteCompletedKey(ctx, sealedUnderOld, []byte("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired := newEncryptedStore(t, db, wrappingKeys(t, 2, 2))
+	if err := retired.WriteCompletedKey(ctx, sealedUnderNew, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	completed, err := retired.CompletedKeys()
+	if err == nil {
+		t.Fatal("no error for completed key sealed under a retired wrapping key")
+	}
+	if len(completed) != 1 || string(completed[sealedUnderNew]) != "new" {
+		t.Fatalf("readable completed keys not returned: %q", completed)
+	}
+}
+
+func TestEncryptedStoreExposesOnlyUnderlyingCapabilities(t *testing.T) {
+	mem := NewMemorySharePersistence()
+	for _, tc := range []struct {
+		name string
+		db   types.DKGSharePersistence
+		// prunable, replaceable, snapshots, transcripts, completedKeys
+		want [5]bool
+	}{
+		{"all", mem, [5]bool{true, true, true, true, true}},
+		{"none", struct{ types.DKGSharePersistence }{mem}, [5]bool{}},
+		{"prunable", struct {
+			types.PrunableDKGSharePersistence
+		}{mem}, [5]bool{true, false, false, false, false}},
+		{"snapshots and completed keys", struct {
+			types.DKGSharePersistence
+			types.KeySnapshotPersistence
+			types.CompletedKeyPersistence
+		}{mem, mem, mem}, [5]bool{false, false, true, false, true}},
+		{"all but replaceable", struct {
+			types.PrunableDKGSharePersistence
+			types.KeySnapshotPersistence
+			types.TranscriptPersistence
+			types.CompletedKeyPersistence
+		}{mem, mem, mem, mem}, [5]bool{true, false, true, true, true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEncryptedSharePersistence(tc.db, wrappingKeys(t, 1, 1))
+			var got [5]bool
+			_, got[0] = e.(types.PrunableDKGSharePersistence)
+			_, got[1] = e.(types.ReplaceableDKGSharePersistence)
+			_, got[2] = e.(types.KeySnapshotPersistence)
+			_, got[3] = e.(types.TranscriptPersistence)
+			_, got[4] = e.(types.CompletedKeyPersistence)
+			if got != tc.want {
+				t.Fatalf("encrypted store has capabilities %v, expected %v", got, tc.want)
+			}
+			if e.AllowUnencryptedReads() != e {
+				t.Fatal("allowing unencrypted reads changed the store's capabilities")
+			}
+		})
+	}
+}
+
+func TestPassphraseKeysAreSaltedPerStore(t *testing.T) {
+	ctx := context.Background()
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	passphrases := map[uint32][]byte{7: []byte("passphrase")}
+	sources := make([]KeySource, 2)
+	salts := make([][]byte, 2)
+	keys := make([][]byte, 2)
+	for i := range sources {
+		var err error
+		sources[i], err = NewPassphraseKeySource(7, passphrases, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var id uint32
+		id, salts[i], keys[i], err = sources[i].CurrentKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 7 || len(salts[i]) != passphraseSaltLen {
+			t.Fatalf("current key has ID %d and %d-byte salt", id, len(salts[i]))
+		}
+	}
+	if bytes.Equal(salts[0], salts[1]) || bytes.Equal(keys[0], keys[1]) {
+		t.Fatal("key sources for the same passphrase share a salt")
+	}
+
+	db := NewMemorySharePersistence()
+	if err := newEncryptedStore(t, db, sources[0]).
+		WriteKeySnapshot(ctx, cfgDgst, keyID, []byte("snapshot")); err != nil {
+		t.Fatal(err)
+	}
+	ct, err := db.ReadKeySnapshot(cfgDgst, keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, salt, err := recordHeader(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header[0] != saltedRecordVersionNum || binary.BigEndian.Uint32(header[1:5]) != 7 ||
+		!bytes.Equal(salt, salts[0]) {
+		t.Fatalf("record header %x doesn't carry the key ID and salt", header)
+	}
+	// A store with a fresh salt can still read records sealed under the old one.
+	snapshot, err := newEncryptedStore(t, db, sources[1]).ReadKeySnapshot(cfgDgst, keyID)
+	if err != nil || string(snapshot) != "snapshot" {
+		t.Fatalf("could not read record sealed under another salt: %q %v", snapshot, err)
+	}
+	ct = append([]byte{}, ct...)
+	ct[6] ^= 1
+	if err := db.WriteKeySnapshot(ctx, cfgDgst, keyID, ct); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newEncryptedStore(t, db, sources[1]).ReadKeySnapshot(cfgDgst, keyID); err == nil {
+		t.Fatal("record read after its salt was changed")
+	}
+
+	static := wrappingKeys(t, 1, 1)
+	if _, err := static.Key(1, salts[0]); err == nil {
+		t.Fatal("static wrapping key accepted a salt")
+	}
+}
+
+func TestReadsUnsaltedRecords(t *testing.T) {
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	passphrase := []byte("passphrase")
+	// Sealed as records were before wrapping keys were salted.
+	idBin := []byte{0, 0, 0, 7}
+	wrappingKey := pbkdf2.Key(
+		passphrase, append([]byte(passphraseSaltPrefix), idBin...), 1, wrappingKeyLen,
+		sha256.New,
+	)
+	gcm, err := newGCM(wrappingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := append([]byte{encryptedRecordVersionNum}, idBin...)
+	nonce := bytes.Repeat([]byte{3}, nonceLen)
+	ct := gcm.Seal(
+		append(append([]byte{}, header...), nonce...), nonce, []byte("transcript"),
+		bytes.Join([][]byte{header, cfgDgst[:], keyID[:], transcriptLabel}, nil),
+	)
+
+	db := NewMemorySharePersistence()
+	if err := db.WriteTranscript(context.Background(), cfgDgst, keyID, ct); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewPassphraseKeySource(7, map[uint32][]byte{7: passphrase}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transcript, err := newEncryptedStore(t, db, keys).ReadTranscript(cfgDgst, keyID)
+	if err != nil || string(transcript) != "transcript" {
+		t.Fatalf("could not read unsalted record: %q %v", transcript, err)
+	}
+}
+
+func TestSealNonceSeparatesLabelFromPlaintext(t *testing.T) {
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	e := &encryptedSharePersistence{NewMemorySharePersistence(), wrappingKeys(t, 1, 1), false, nil}
+	wrappingKey, header, gcm, err := e.currentKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := func(label, pt string) []byte {
+		ct := seal(gcm, wrappingKey, header, cfgDgst, keyID, []byte(label), []byte(pt))
+		return ct[len(header) : len(header)+nonceLen]
+	}
+	if bytes.Equal(nonce("ab", "c"), nonce("a", "bc")) {
+		t.Fatal("records whose labels and plaintexts concatenate alike share a nonce")
+	}
+}
@@ -0,0 +1,498 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	ocr_types "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+type FileSharePersistence struct {
+	dir string
+
+	lock  sync.Mutex
+	files map[recordsKey]*recordFile
+}
+
+var _ types.PrunableDKGSharePersistence = (*FileSharePersistence)(nil)
+var _ types.ReplaceableDKGSharePersistence = (*FileSharePersistence)(nil)
+var _ types.KeySnapshotPersistence = (*FileSharePersistence)(nil)
+var _ types.TranscriptPersistence = (*FileSharePersistence)(nil)
+var _ types.CompletedKeyPersistence = (*FileSharePersistence)(nil)
+
+type recordFile struct {
+	validLen int64
+	records  []types.PersistentShareSetRecord
+}
+
+const (
+	fileMagic            = "vrfdkgsr"
+	fileFormatVersionNum = 1
+	fileHeaderLength     = len(fileMagic) + 1
+	recordFileSuffix     = ".records"
+
+	snapshotMagic      = "vrfdkgks"
+	snapshotFileSuffix = ".snapshot"
+
+	transcriptMagic      = "vrfdkgtr"
+	transcriptFileSuffix = ".transcript"
+
+	completedKeyMagic    = "vrfdkgck"
+	completedKeyFileName = "completed.key"
+
+	maxFrameLength = 16 << 20
+)
+
+func NewFileSharePersistence(dir string) (*FileSharePersistence, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "could not create share record directory")
+	}
+	return &FileSharePersistence{dir, sync.Mutex{}, map[recordsKey]*recordFile{}}, nil
+}
+
+func (f *FileSharePersistence) WriteShareRecords(
+	_ context.Context,
+	cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte,
+	shareRecords []types.PersistentShareSetRecord,
+) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	k := recordsKey{cfgDgst, keyID}
+	rf, err := f.load(k)
+	if err != nil {
+		return err
+	}
+	frames, added, err := newFrames(rf.records, shareRecords)
+	if err != nil {
+		return err
+	}
+	if len(frames) == 0 {
+		return nil
+	}
+	if rf.validLen == 0 {
+		if err := f.replaceFile(k, fileHeader()); err != nil {
+			return err
+		}
+		rf.validLen = int64(fileHeaderLength)
+	}
+	fh, err := os.OpenFile(f.path(k), os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "could not open share record file")
+	}
+	defer fh.Close()
+	if err := fh.Truncate(rf.validLen); err != nil {
+		return errors.Wrap(err, "could not drop torn share record")
+	}
+	data := bytes.Join(frames, nil)
+	if _, err := fh.WriteAt(data, rf.validLen); err != nil {
+		return errors.Wrap(err, "could not append share records")
+	}
+	if err := fh.Sync(); err != nil {
+		return errors.Wrap(err, "could not sync share record file")
+	}
+	rf.validLen += int64(len(data))
+	rf.records = append(rf.records, added...)
+	return nil
+}
+
+func (f *FileSharePersistence) ReplaceShareRecords(
+	_ context.Context,
+	cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte,
+	shareRecords []types.PersistentShareSetRecord,
+) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	k := recordsKey{cfgDgst, keyID}
+	frames, added, err := newFrames(nil, shareRecords)
+	if err != nil {
+		return err
+	}
+	data := bytes.Join(append([][]byte{fileHeader()}, frames...), nil)
+	if err := f.replaceFile(k, data); err != nil {
+		return err
+	}
+	f.files[k] = &recordFile{int64(len(data)), added}
+	return nil
+}
+
+func fileHeader() []byte {
+	return append([]byte(fileMagic), fileFormatVersionNum)
+}
+
+func newFrames(
+	stored, shareRecords []types.PersistentShareSetRecord,
+) (frames [][]byte, added []types.PersistentShareSetRecord, err error) {
+	for _, r := range shareRecords {
+		h, err := recordHash(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		if containsHash(stored, h) || containsHash(added, h) {
+			continue
+		}
+		frames = append(frames, frame(&r.Dealer, r.MarshaledShareRecord))
+		added = append(added, types.PersistentShareSetRecord{
+			r.Dealer, append([]byte{}, r.MarshaledShareRecord...), h,
+		})
+	}
+	return frames, added, nil
+}
+
+func (f *FileSharePersistence) ReadShareRecords(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]types.PersistentShareSetRecord, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	rf, err := f.load(recordsKey{cfgDgst, keyID})
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]types.PersistentShareSetRecord, len(rf.records))
+	for i, r := range rf.records {
+		rv[i] = types.PersistentShareSetRecord{
+			r.Dealer, append([]byte{}, r.MarshaledShareRecord...), r.Hash,
+		}
+	}
+	return rv, nil
+}
+
+func (f *FileSharePersistence) ConfigDigests(
+	keyID [32]byte,
+) ([]ocr_types.ConfigDigest, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, hex.EncodeToString(keyID[:])))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list share record files")
+	}
+	var rv []ocr_types.ConfigDigest
+	seen := map[ocr_types.ConfigDigest]bool{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			continue
+		}
+		var stem string
+		switch {
+		case strings.HasSuffix(name, recordFileSuffix):
+			stem = strings.TrimSuffix(name, recordFileSuffix)
+		case strings.HasSuffix(name, snapshotFileSuffix):
+			stem = strings.TrimSuffix(name, snapshotFileSuffix)
+		case strings.HasSuffix(name, transcriptFileSuffix):
+			stem = strings.TrimSuffix(name, transcriptFileSuffix)
+		default:
+			continue
+		}
+		b, err := hex.DecodeString(stem)
+		if err != nil || len(b) != len(ocr_types.ConfigDigest{}) {
+			continue
+		}
+		var d ocr_types.ConfigDigest
+		copy(d[:], b)
+		if !seen[d] {
+			seen[d] = true
+			rv = append(rv, d)
+		}
+	}
+	sortDigests(rv)
+	return rv, nil
+}
+
+func (f *FileSharePersistence) DeleteShareRecords(
+	_ context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	k := recordsKey{cfgDgst, keyID}
+	delete(f.files, k)
+	if err := os.Remove(f.path(k)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "could not delete share record file")
+	}
+	if err := os.Remove(f.snapshotPath(k)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "could not delete key snapshot file")
+	}
+	if err := os.Remove(f.transcriptPath(k)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "could not delete transcript file")
+	}
+	return syncDir(filepath.Dir(f.path(k)))
+}
+
+func (f *FileSharePersistence) WriteKeySnapshot(
+	_ context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, snapshot []byte,
+) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p := f.snapshotPath(recordsKey{cfgDgst, keyID})
+	return f.writeChecksummedFile(p, snapshotMagic, snapshot, "key snapshot")
+}
+
+func (f *FileSharePersistence) ReadKeySnapshot(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p := f.snapshotPath(recordsKey{cfgDgst, keyID})
+	return readChecksummedFile(p, snapshotMagic, "key snapshot")
+}
+
+func (f *FileSharePersistence) WriteTranscript(
+	_ context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, transcript []byte,
+) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p := f.transcriptPath(recordsKey{cfgDgst, keyID})
+	return f.writeChecksummedFile(p, transcriptMagic, transcript, "transcript")
+}
+
+func (f *FileSharePersistence) ReadTranscript(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p := f.transcriptPath(recordsKey{cfgDgst, keyID})
+	return readChecksummedFile(p, transcriptMagic, "transcript")
+}
+
+func (f *FileSharePersistence) WriteCompletedKey(
+	_ context.Context, keyID [32]byte, record []byte,
+) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p := f.completedKeyPath(keyID)
+	return f.writeChecksummedFile(p, completedKeyMagic, record, "completed key")
+}
+
+func (f *FileSharePersistence) CompletedKeys() (map[[32]byte][]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list key directories")
+	}
+	rv := map[[32]byte][]byte{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		b, err := hex.DecodeString(e.Name())
+		if err != nil || len(b) != 32 {
+			continue
+		}
+		var keyID [32]byte
+		copy(keyID[:], b)
+		record, err2 := readChecksummedFile(
+			f.completedKeyPath(keyID), completedKeyMagic, "completed key",
+		)
+		if err2 != nil {
+			err = errors.Wrapf(err2, "could not read completed key 0x%x", keyID)
+			continue
+		}
+		if record != nil {
+			rv[keyID] = record
+		}
+	}
+	return rv, err
+}
+
+func (f *FileSharePersistence) writeChecksummedFile(
+	p, magic string, contents []byte, what string,
+) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return errors.Wrap(err, "could not create share record directory")
+	}
+	if err := syncDir(f.dir); err != nil {
+		return err
+	}
+	checksum := hash.GetHash(contents)
+	data := bytes.Join(
+		[][]byte{[]byte(magic), {fileFormatVersionNum}, contents, checksum[:]}, nil,
+	)
+	tmp := p + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return errors.Wrapf(err, "could not replace %s file", what)
+	}
+	return syncDir(filepath.Dir(p))
+}
+
+func readChecksummedFile(p, magic, what string) ([]byte, error) {
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s file", what)
+	}
+	if len(data) < fileHeaderLength+hash.Size || string(data[:len(magic)]) != magic {
+		return nil, errors.Errorf("%s is not a %s file", p, what)
+	}
+	if v := data[len(magic)]; v != fileFormatVersionNum {
+		return nil, errors.Errorf("don't know how to read version %d %s files", v, what)
+	}
+	contents := data[fileHeaderLength : len(data)-hash.Size]
+	var checksum hash.Hash
+	copy(checksum[:], data[len(data)-hash.Size:])
+	if hash.GetHash(contents) != checksum {
+		return nil, errors.Errorf("checksum mismatch on %s %s", what, p)
+	}
+	return contents, nil
+}
+
+func (f *FileSharePersistence) path(k recordsKey) string {
+	return filepath.Join(
+		f.dir, hex.EncodeToString(k.keyID[:]),
+		hex.EncodeToString(k.cfgDgst[:])+recordFileSuffix,
+	)
+}
+
+func (f *FileSharePersistence) snapshotPath(k recordsKey) string {
+	return filepath.Join(
+		f.dir, hex.EncodeToString(k.keyID[:]),
+		hex.EncodeToString(k.cfgDgst[:])+snapshotFileSuffix,
+	)
+}
+
+func (f *FileSharePersistence) transcriptPath(k recordsKey) string {
+	return filepath.Join(
+		f.dir, hex.EncodeToString(k.keyID[:]),
+		hex.EncodeToString(k.cfgDgst[:])+transcriptFileSuffix,
+	)
+}
+
+func (f *FileSharePersistence) completedKeyPath(keyID [32]byte) string {
+	return filepath.Join(f.dir, hex.EncodeToString(keyID[:]), completedKeyFileName)
+}
+
+func (f *FileSharePersistence) replaceFile(k recordsKey, data []byte) error {
+	p := f.path(k)
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return errors.Wrap(err, "could not create share record directory")
+	}
+	if err := syncDir(f.dir); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return errors.Wrap(err, "could not replace share record file")
+	}
+	return syncDir(filepath.Dir(p))
+}
+
+func (f *FileSharePersistence) load(k recordsKey) (*recordFile, error) {
+	if rf, ok := f.files[k]; ok {
+		return rf, nil
+	}
+	rf := &recordFile{}
+	data, err := os.ReadFile(f.path(k))
+	if os.IsNotExist(err) {
+		f.files[k] = rf
+		return rf, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read share record file")
+	}
+	if len(data) < fileHeaderLength || string(data[:len(fileMagic)]) != fileMagic {
+		return nil, errors.Errorf("%s is not a share record file", f.path(k))
+	}
+	if v := data[len(fileMagic)]; v != fileFormatVersionNum {
+		return nil, errors.Errorf("don't know how to read version %d share record files", v)
+	}
+	rf.validLen = int64(fileHeaderLength)
+	rest := data[fileHeaderLength:]
+	for len(rest) > 0 {
+		r, n, err := readFrame(rest)
+		if err == io.ErrUnexpectedEOF || (err != nil && n == len(rest)) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "corrupt share record at offset %d of %s", rf.validLen, f.path(k),
+			)
+		}
+		rf.records = append(rf.records, *r)
+		rf.validLen += int64(n)
+		rest = rest[n:]
+	}
+	f.files[k] = rf
+	return rf, nil
+}
+
+func frame(dealer *player_idx.PlayerIdx, record []byte) []byte {
+	body := append(dealer.Marshal(), record...)
+	checksum := hash.GetHash(body)
+	return bytes.Join([][]byte{lenPrefix(body), body, checksum[:]}, nil)
+}
+
+func readFrame(data []byte) (*types.PersistentShareSetRecord, int, error) {
+	if len(data) < 4 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	bodyLen := binary.BigEndian.Uint32(data)
+	if bodyLen > maxFrameLength {
+		return nil, 0, errors.Errorf("share record frame too long, %d bytes", bodyLen)
+	}
+	n := 4 + int(bodyLen) + hash.Size
+	if len(data) < n {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	body := data[4 : 4+bodyLen]
+	var checksum hash.Hash
+	copy(checksum[:], data[4+bodyLen:n])
+	if hash.GetHash(body) != checksum {
+		return nil, n, errors.Errorf("checksum mismatch on share record frame")
+	}
+	dealer, record, err := player_idx.Unmarshal(body)
+	if err != nil {
+		return nil, n, errors.Wrap(err, "could not read dealer of share record")
+	}
+	record = append([]byte{}, record...)
+	return &types.PersistentShareSetRecord{*dealer, record, hash.GetHash(record)}, n, nil
+}
+
+func lenPrefix(b []byte) []byte {
+	rv := make([]byte, 4)
+	binary.BigEndian.PutUint32(rv, uint32(len(b)))
+	return rv
+}
+
+func writeFileSync(path string, data []byte) error {
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "could not create share record file")
+	}
+	defer fh.Close()
+	if _, err := fh.Write(data); err != nil {
+		return errors.Wrap(err, "could not write share record file")
+	}
+	return errors.Wrap(fh.Sync(), "could not sync share record file")
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return errors.Wrap(err, "could not open share record directory")
+	}
+	defer d.Close()
+	return errors.Wrap(d.Sync(), "could not sync share record directory")
+}
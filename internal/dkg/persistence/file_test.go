@@ -0,0 +1,221 @@
+package persistence
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocr_types "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+func newFileStore(t *testing.T, dir string) *FileSharePersistence {
+	db, err := NewFileSharePersistence(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestFileSharePersistence(t *testing.T) {
+	testSharePersistence(t, newFileStore(t, t.TempDir()))
+}
+
+func TestFileSharePersistenceReopens(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	db := newFileStore(t, dir)
+	if err := db.WriteShareRecords(ctx, cfgDgst, keyID, testRecords(t, "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WriteKeySnapshot(ctx, cfgDgst, keyID, []byte("snapshot")); err != nil {
+		t.Fatal(err)
+	}
+	db = newFileStore(t, dir)
+	checkRecords(t, db, cfgDgst, keyID, "a", "b")
+	snapshot, err := db.ReadKeySnapshot(cfgDgst, keyID)
+	if err != nil || string(snapshot) != "snapshot" {
+		t.Fatalf("key snapshot not persisted: %q %v", snapshot, err)
+	}
+}
+
+func TestFileSharePersistenceDropsTornTail(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	records := testRecords(t, "a", "b", "c")
+	db := newFileStore(t, dir)
+	if err := db.WriteShareRecords(ctx, cfgDgst, keyID, records[:2]); err != nil {
+		t.Fatal(err)
+	}
+	p := db.path(recordsKey{cfgDgst, keyID})
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A crash part way through appending the third record.
+	third := frame(&records[2].Dealer, records[2].MarshaledShareRecord)
+	if err := os.WriteFile(p, append(data, third[:len(third)-1]...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db = newFileStore(t, dir)
+	checkRecords(t, db, cfgDgst, keyID, "a", "b")
+
+	if err := db.WriteShareRecords(ctx, cfgDgst, keyID, records[2:]); err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, newFileStore(t, dir), cfgDgst, keyID, "a", "b", "c")
+}
+
+func TestFileSharePersistenceChecksums(t *testing.T) {
+	ctx := context.Background()
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	records := testRecords(t, "a", "b")
+
+	t.Run("corrupt record before others", func(t *testing.T) {
+		dir := t.TempDir()
+		db := newFileStore(t, dir)
+		if err := db.WriteShareRecords(ctx, cfgDgst, keyID, records); err != nil {
+			t.Fatal(err)
+		}
+		p := db.path(recordsKey{cfgDgst, keyID})
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The first frame's body is its dealer, then its record.
+		data[fileHeaderLength+4+1] ^= 1
+		if err := os.WriteFile(p, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := newFileStore(t, dir).ReadShareRecords(cfgDgst, keyID); err == nil {
+			t.Fatal("read share records past a corrupt record")
+		}
+	})
+
+	t.Run("corrupt final record", func(t *testing.T) {
+		dir := t.TempDir()
+		db := newFileStore(t, dir)
+		if err := db.WriteShareRecords(ctx, cfgDgst, keyID, records); err != nil {
+			t.Fatal(err)
+		}
+		p := db.path(recordsKey{cfgDgst, keyID})
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[len(data)-1] ^= 1
+		if err := os.WriteFile(p, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		// Indistinguishable from a torn write, so the record is dropped.
+		checkRecords(t, newFileStore(t, dir), cfgDgst, keyID, "a")
+	})
+
+	t.Run("corrupt snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		db := newFileStore(t, dir)
+		if err := db.WriteKeySnapshot(ctx, cfgDgst, keyID, []byte("snapshot")); err != nil {
+			t.Fatal(err)
+		}
+		p := db.snapshotPath(recordsKey{cfgDgst, keyID})
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[fileHeaderLength] ^= 1
+		if err := os.WriteFile(p, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.ReadKeySnapshot(cfgDgst, keyID); err == nil {
+			t.Fatal("read key snapshot with a checksum mismatch")
+		}
+	})
+
+	t.Run("wrong magic", func(t *testing.T) {
+		dir := t.TempDir()
+		db := newFileStore(t, dir)
+		if err := db.WriteShareRecords(ctx, cfgDgst, keyID, records); err != nil {
+			t.Fatal(err)
+		}
+		p := db.path(recordsKey{cfgDgst, keyID})
+		if err := os.WriteFile(p, []byte("not a share record file"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := newFileStore(t, dir).ReadShareRecords(cfgDgst, keyID); err == nil {
+			t.Fatal("read share records from a file with the wrong magic")
+		}
+	})
+}
+
+func TestFileSharePersistenceReplaceIgnoresLeftoverTmpFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	db := newFileStore(t, dir)
+	if err := db.WriteShareRecords(ctx, cfgDgst, keyID, testRecords(t, "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	// A crash part way through an earlier replacement leaves its temporary
+	// file behind, and the share records as they were.
+	p := db.path(recordsKey{cfgDgst, keyID})
+	if err := os.WriteFile(p+".tmp", []byte("torn replacement"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db = newFileStore(t, dir)
+	checkRecords(t, db, cfgDgst, keyID, "a", "b")
+	if digests, err := db.ConfigDigests(keyID); err != nil || len(digests) != 1 {
+		t.Fatalf("temporary file listed as a config digest: %v %v", digests, err)
+	}
+
+	if err := db.ReplaceShareRecords(ctx, cfgDgst, keyID, testRecords(t, "c")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("replacement left its temporary file: %v", err)
+	}
+	checkRecords(t, db, cfgDgst, keyID, "c")
+	checkRecords(t, newFileStore(t, dir), cfgDgst, keyID, "c")
+}
+
+func TestFileSharePersistenceConfigDigestsSkipsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	keyID := [32]byte{2}
+	keyDir := filepath.Join(dir, hex.EncodeToString(keyID[:]))
+	cfgDgst := ocr_types.ConfigDigest{7}
+	stem := hex.EncodeToString(cfgDgst[:])
+	for _, name := range []string{
+		stem + recordFileSuffix,
+		stem + snapshotFileSuffix,
+		hex.EncodeToString([]byte{1, 2}) + recordFileSuffix,
+		"not hex" + recordFileSuffix,
+		stem + ".unknown",
+		completedKeyFileName,
+	} {
+		if err := os.MkdirAll(keyDir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(keyDir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := ocr_types.ConfigDigest{8}
+	if err := os.Mkdir(
+		filepath.Join(keyDir, hex.EncodeToString(other[:])+recordFileSuffix), 0o700,
+	); err != nil {
+		t.Fatal(err)
+	}
+	digests, err := newFileStore(t, dir).ConfigDigests(keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digests) != 1 || digests[0] != cfgDgst {
+		t.Fatalf("listed config digests %v, expected only %v", digests, cfgDgst)
+	}
+	digests, err = newFileStore(t, dir).ConfigDigests([32]byte{3})
+	if err != nil || digests != nil {
+		t.Fatalf("listed config digests %v for a key with no directory: %v", digests, err)
+	}
+}
@@ -0,0 +1,148 @@
+package persistence
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// KeySource provides the keys which EncryptedSharePersistence wraps records
+// under. A key is identified by its key ID and a salt, both of which are
+// stored in the header of each record sealed under it. An empty salt
+// identifies keys from before salts were stored.
+type KeySource interface {
+	CurrentKey() (keyID uint32, salt, key []byte, err error)
+
+	Key(keyID uint32, salt []byte) ([]byte, error)
+}
+
+const wrappingKeyLen = 32
+
+type staticKeySource struct {
+	current uint32
+	keys    map[uint32][]byte
+}
+
+var _ KeySource = (*staticKeySource)(nil)
+
+func NewStaticKeySource(current uint32, keys map[uint32][]byte) (KeySource, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, errors.Errorf("no key given for current key ID %d", current)
+	}
+	rv := &staticKeySource{current, make(map[uint32][]byte, len(keys))}
+	for id, k := range keys {
+		if len(k) != wrappingKeyLen {
+			return nil, errors.Errorf(
+				"wrapping key %d must be %d bytes, got %d", id, wrappingKeyLen, len(k),
+			)
+		}
+		rv.keys[id] = append([]byte{}, k...)
+	}
+	return rv, nil
+}
+
+func (s *staticKeySource) CurrentKey() (uint32, []byte, []byte, error) {
+	k, err := s.Key(s.current, nil)
+	return s.current, nil, k, err
+}
+
+// Key returns the wrapping key with the given ID. Static keys are used as
+// given, so they are never salted.
+func (s *staticKeySource) Key(keyID uint32, salt []byte) ([]byte, error) {
+	if len(salt) > 0 {
+		return nil, errors.Errorf("static wrapping key %d can't be salted", keyID)
+	}
+	k, ok := s.keys[keyID]
+	if !ok {
+		return nil, errors.Errorf("unknown wrapping key ID %d", keyID)
+	}
+	return k, nil
+}
+
+var defaultPBKDF2NumberOfIterations = 150_000
+
+const passphraseSaltPrefix = "chainlink-vrf DKG share persistence wrapping key"
+
+const passphraseSaltLen = 16
+
+type passphraseKeySource struct {
+	current     uint32
+	passphrases map[uint32][]byte
+	iterations  int
+	// salt is drawn afresh for each key source, so that keys derived from the
+	// same passphrase differ across stores.
+	salt []byte
+
+	lock    sync.Mutex
+	derived map[derivedKeyID][]byte
+}
+
+type derivedKeyID struct {
+	keyID uint32
+	salt  string
+}
+
+var _ KeySource = (*passphraseKeySource)(nil)
+
+func NewPassphraseKeySource(
+	current uint32, passphrases map[uint32][]byte, itersTestingOnly ...int,
+) (KeySource, error) {
+	if len(itersTestingOnly) > 1 {
+		return nil, errors.Errorf("at most one derived-key iteration value allowed")
+	}
+	if _, ok := passphrases[current]; !ok {
+		return nil, errors.Errorf("no passphrase given for current key ID %d", current)
+	}
+	iter := defaultPBKDF2NumberOfIterations
+	if len(itersTestingOnly) > 0 {
+		iter = itersTestingOnly[0]
+	}
+	salt := make([]byte, passphraseSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "could not draw wrapping key salt")
+	}
+	rv := &passphraseKeySource{
+		current,
+		make(map[uint32][]byte, len(passphrases)),
+		iter,
+		salt,
+		sync.Mutex{},
+		map[derivedKeyID][]byte{},
+	}
+	for id, p := range passphrases {
+		if len(p) == 0 {
+			return nil, errors.Errorf("empty passphrase for key ID %d", id)
+		}
+		rv.passphrases[id] = append([]byte{}, p...)
+	}
+	return rv, nil
+}
+
+func (s *passphraseKeySource) CurrentKey() (uint32, []byte, []byte, error) {
+	k, err := s.Key(s.current, s.salt)
+	return s.current, s.salt, k, err
+}
+
+func (s *passphraseKeySource) Key(keyID uint32, salt []byte) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	id := derivedKeyID{keyID, string(salt)}
+	if k, ok := s.derived[id]; ok {
+		return k, nil
+	}
+	p, ok := s.passphrases[keyID]
+	if !ok {
+		return nil, errors.Errorf("unknown wrapping key ID %d", keyID)
+	}
+	var idBin [4]byte
+	binary.BigEndian.PutUint32(idBin[:], keyID)
+	fullSalt := append(append([]byte(passphraseSaltPrefix), idBin[:]...), salt...)
+	k := pbkdf2.Key(p, fullSalt, s.iterations, wrappingKeyLen, sha256.New)
+	s.derived[id] = k
+	return k, nil
+}
@@ -0,0 +1,232 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	ocr_types "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+type recordsKey struct {
+	cfgDgst ocr_types.ConfigDigest
+	keyID   [32]byte
+}
+
+type MemorySharePersistence struct {
+	lock      sync.RWMutex
+	records   map[recordsKey][]types.PersistentShareSetRecord
+	snapshots map[recordsKey][]byte
+
+	transcripts map[recordsKey][]byte
+	completed   map[[32]byte][]byte
+}
+
+var _ types.PrunableDKGSharePersistence = (*MemorySharePersistence)(nil)
+var _ types.ReplaceableDKGSharePersistence = (*MemorySharePersistence)(nil)
+var _ types.KeySnapshotPersistence = (*MemorySharePersistence)(nil)
+var _ types.TranscriptPersistence = (*MemorySharePersistence)(nil)
+var _ types.CompletedKeyPersistence = (*MemorySharePersistence)(nil)
+
+func NewMemorySharePersistence() *MemorySharePersistence {
+	return &MemorySharePersistence{
+		sync.RWMutex{},
+		map[recordsKey][]types.PersistentShareSetRecord{},
+		map[recordsKey][]byte{},
+		map[recordsKey][]byte{},
+		map[[32]byte][]byte{},
+	}
+}
+
+func (m *MemorySharePersistence) WriteShareRecords(
+	_ context.Context,
+	cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte,
+	shareRecords []types.PersistentShareSetRecord,
+) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	k := recordsKey{cfgDgst, keyID}
+	for _, r := range shareRecords {
+		h, err := recordHash(r)
+		if err != nil {
+			return err
+		}
+		if containsHash(m.records[k], h) {
+			continue
+		}
+		m.records[k] = append(m.records[k], types.PersistentShareSetRecord{
+			r.Dealer, append([]byte{}, r.MarshaledShareRecord...), h,
+		})
+	}
+	return nil
+}
+
+func (m *MemorySharePersistence) ReplaceShareRecords(
+	_ context.Context,
+	cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte,
+	shareRecords []types.PersistentShareSetRecord,
+) error {
+	var replaced []types.PersistentShareSetRecord
+	for _, r := range shareRecords {
+		h, err := recordHash(r)
+		if err != nil {
+			return err
+		}
+		if containsHash(replaced, h) {
+			continue
+		}
+		replaced = append(replaced, types.PersistentShareSetRecord{
+			r.Dealer, append([]byte{}, r.MarshaledShareRecord...), h,
+		})
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.records[recordsKey{cfgDgst, keyID}] = replaced
+	return nil
+}
+
+func (m *MemorySharePersistence) ReadShareRecords(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]types.PersistentShareSetRecord, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	stored := m.records[recordsKey{cfgDgst, keyID}]
+	rv := make([]types.PersistentShareSetRecord, len(stored))
+	for i, r := range stored {
+		rv[i] = types.PersistentShareSetRecord{
+			r.Dealer, append([]byte{}, r.MarshaledShareRecord...), r.Hash,
+		}
+	}
+	return rv, nil
+}
+
+func (m *MemorySharePersistence) ConfigDigests(
+	keyID [32]byte,
+) ([]ocr_types.ConfigDigest, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	seen := map[ocr_types.ConfigDigest]bool{}
+	for k := range m.records {
+		if k.keyID == keyID {
+			seen[k.cfgDgst] = true
+		}
+	}
+	for k := range m.snapshots {
+		if k.keyID == keyID {
+			seen[k.cfgDgst] = true
+		}
+	}
+	for k := range m.transcripts {
+		if k.keyID == keyID {
+			seen[k.cfgDgst] = true
+		}
+	}
+	rv := make([]ocr_types.ConfigDigest, 0, len(seen))
+	for d := range seen {
+		rv = append(rv, d)
+	}
+	sortDigests(rv)
+	return rv, nil
+}
+
+func (m *MemorySharePersistence) DeleteShareRecords(
+	_ context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.records, recordsKey{cfgDgst, keyID})
+	delete(m.snapshots, recordsKey{cfgDgst, keyID})
+	delete(m.transcripts, recordsKey{cfgDgst, keyID})
+	return nil
+}
+
+func (m *MemorySharePersistence) WriteKeySnapshot(
+	_ context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, snapshot []byte,
+) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.snapshots[recordsKey{cfgDgst, keyID}] = append([]byte{}, snapshot...)
+	return nil
+}
+
+func (m *MemorySharePersistence) ReadKeySnapshot(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	s, ok := m.snapshots[recordsKey{cfgDgst, keyID}]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, s...), nil
+}
+
+func (m *MemorySharePersistence) WriteTranscript(
+	_ context.Context, cfgDgst ocr_types.ConfigDigest, keyID [32]byte, transcript []byte,
+) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.transcripts[recordsKey{cfgDgst, keyID}] = append([]byte{}, transcript...)
+	return nil
+}
+
+func (m *MemorySharePersistence) ReadTranscript(
+	cfgDgst ocr_types.ConfigDigest, keyID [32]byte,
+) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	t, ok := m.transcripts[recordsKey{cfgDgst, keyID}]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, t...), nil
+}
+
+func recordHash(r types.PersistentShareSetRecord) (hash.Hash, error) {
+	h := hash.GetHash(r.MarshaledShareRecord)
+	if r.Hash != hash.Zero && r.Hash != h {
+		return hash.Zero, errors.Errorf("hash mismatch on record from %s", r.Dealer)
+	}
+	return h, nil
+}
+
+func containsHash(records []types.PersistentShareSetRecord, h hash.Hash) bool {
+	for _, r := range records {
+		if r.Hash == h {
+			return true
+		}
+	}
+	return false
+}
+
+func sortDigests(ds []ocr_types.ConfigDigest) {
+	sort.Slice(ds, func(i, j int) bool {
+		return string(ds[i][:]) < string(ds[j][:])
+	})
+}
+
+func (m *MemorySharePersistence) WriteCompletedKey(
+	_ context.Context, keyID [32]byte, record []byte,
+) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.completed[keyID] = append([]byte{}, record...)
+	return nil
+}
+
+func (m *MemorySharePersistence) CompletedKeys() (map[[32]byte][]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	rv := make(map[[32]byte][]byte, len(m.completed))
+	for keyID, r := range m.completed {
+		rv[keyID] = append([]byte{}, r...)
+	}
+	return rv, nil
+}
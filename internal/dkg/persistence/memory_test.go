@@ -0,0 +1,162 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+
+	ocr_types "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+// sharePersistence has every optional capability of a share persistence.
+type sharePersistence interface {
+	types.PrunableDKGSharePersistence
+	types.ReplaceableDKGSharePersistence
+	types.KeySnapshotPersistence
+	types.TranscriptPersistence
+	types.CompletedKeyPersistence
+}
+
+func testRecords(t *testing.T, contents ...string) []types.PersistentShareSetRecord {
+	players, err := player_idx.PlayerIdxs(player_idx.Int(len(contents)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := make([]types.PersistentShareSetRecord, len(contents))
+	for i, c := range contents {
+		rv[i] = types.PersistentShareSetRecord{
+			Dealer: *players[i], MarshaledShareRecord: []byte(c), Hash: hash.GetHash([]byte(c)),
+		}
+	}
+	return rv
+}
+
+func checkRecords(
+	t *testing.T, db types.DKGSharePersistence, cfgDgst ocr_types.ConfigDigest,
+	keyID [32]byte, want ...string,
+) {
+	t.Helper()
+	got, err := db.ReadShareRecords(cfgDgst, keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d share records, expected %d", len(got), len(want))
+	}
+	for i, r := range got {
+		if string(r.MarshaledShareRecord) != want[i] ||
+			r.Hash != hash.GetHash([]byte(want[i])) {
+			t.Fatalf("share record %d is %q, expected %q", i, r.MarshaledShareRecord, want[i])
+		}
+	}
+}
+
+// testSharePersistence checks the behavior every share persistence in this
+// package shares.
+func testSharePersistence(t *testing.T, db sharePersistence) {
+	ctx := context.Background()
+	cfgDgst, keyID := ocr_types.ConfigDigest{3}, [32]byte{2}
+	records := testRecords(t, "a", "b", "c")
+
+	if err := db.WriteShareRecords(ctx, cfgDgst, keyID, records[:2]); err != nil {
+		t.Fatal(err)
+	}
+	// Records already stored are skipped.
+	if err := db.WriteShareRecords(ctx, cfgDgst, keyID, records[1:]); err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, db, cfgDgst, keyID, "a", "b", "c")
+	checkRecords(t, db, ocr_types.ConfigDigest{4}, keyID)
+
+	bad := records[0]
+	bad.MarshaledShareRecord = []byte("not a")
+	err := db.WriteShareRecords(ctx, cfgDgst, keyID, []types.PersistentShareSetRecord{bad})
+	if err == nil {
+		t.Fatal("stored share record with the wrong hash")
+	}
+
+	if err := db.ReplaceShareRecords(ctx, cfgDgst, keyID, records[2:]); err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, db, cfgDgst, keyID, "c")
+
+	err = db.WriteKeySnapshot(ctx, ocr_types.ConfigDigest{2}, keyID, []byte("snapshot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.WriteTranscript(ctx, ocr_types.ConfigDigest{1}, keyID, []byte("transcript"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WriteCompletedKey(ctx, keyID, []byte("completed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WriteShareRecords(ctx, cfgDgst, [32]byte{9}, records); err != nil {
+		t.Fatal(err)
+	}
+	digests, err := db.ConfigDigests(keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ocr_types.ConfigDigest{{1}, {2}, {3}}
+	if len(digests) != len(want) {
+		t.Fatalf("listed config digests %v, expected %v", digests, want)
+	}
+	for i := range want {
+		if digests[i] != want[i] {
+			t.Fatalf("listed config digests %v, expected %v", digests, want)
+		}
+	}
+
+	for _, d := range want {
+		if err := db.DeleteShareRecords(ctx, d, keyID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Deleting what isn't there is not an error.
+	if err := db.DeleteShareRecords(ctx, ocr_types.ConfigDigest{8}, keyID); err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, db, cfgDgst, keyID)
+	if digests, err := db.ConfigDigests(keyID); err != nil || len(digests) != 0 {
+		t.Fatalf("config digests %v left after deletion: %v", digests, err)
+	}
+	snapshot, err := db.ReadKeySnapshot(ocr_types.ConfigDigest{2}, keyID)
+	if err != nil || snapshot != nil {
+		t.Fatalf("key snapshot %q left after deletion: %v", snapshot, err)
+	}
+	transcript, err := db.ReadTranscript(ocr_types.ConfigDigest{1}, keyID)
+	if err != nil || transcript != nil {
+		t.Fatalf("transcript %q left after deletion: %v", transcript, err)
+	}
+	checkRecords(t, db, cfgDgst, [32]byte{9}, "a", "b", "c")
+	completed, err := db.CompletedKeys()
+	if err != nil || string(completed[keyID]) != "completed" {
+		t.Fatalf("completed key not kept after deleting share records: %q %v", completed, err)
+	}
+}
+
+func TestMemorySharePersistence(t *testing.T) {
+	testSharePersistence(t, NewMemorySharePersistence())
+}
+
+func TestMemorySharePersistenceCopiesRecords(t *testing.T) {
+	ctx := context.Background()
+	cfgDgst, keyID := ocr_types.ConfigDigest{1}, [32]byte{2}
+	db := NewMemorySharePersistence()
+	records := testRecords(t, "a")
+	records[0].Hash = hash.Zero
+	if err := db.WriteShareRecords(ctx, cfgDgst, keyID, records); err != nil {
+		t.Fatal(err)
+	}
+	records[0].MarshaledShareRecord[0] = 'x'
+	got, err := db.ReadShareRecords(cfgDgst, keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got[0].MarshaledShareRecord[0] = 'y'
+	checkRecords(t, db, cfgDgst, keyID, "a")
+}
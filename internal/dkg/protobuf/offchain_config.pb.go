@@ -19,10 +19,14 @@ type OffchainConfig struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	EncryptionPKs   [][]byte `protobuf:"bytes,2,rep,name=encryptionPKs,proto3" json:"encryptionPKs,omitempty"`
-	SignaturePKs    [][]byte `protobuf:"bytes,3,rep,name=signaturePKs,proto3" json:"signaturePKs,omitempty"`
-	EncryptionGroup string   `protobuf:"bytes,4,opt,name=encryptionGroup,proto3" json:"encryptionGroup,omitempty"`
-	Translator      string   `protobuf:"bytes,5,opt,name=translator,proto3" json:"translator,omitempty"`
+	EncryptionPKs        [][]byte `protobuf:"bytes,2,rep,name=encryptionPKs,proto3" json:"encryptionPKs,omitempty"`
+	SignaturePKs         [][]byte `protobuf:"bytes,3,rep,name=signaturePKs,proto3" json:"signaturePKs,omitempty"`
+	EncryptionGroup      string   `protobuf:"bytes,4,opt,name=encryptionGroup,proto3" json:"encryptionGroup,omitempty"`
+	Translator           string   `protobuf:"bytes,5,opt,name=translator,proto3" json:"translator,omitempty"`
+	PreviousPublicShares [][]byte `protobuf:"bytes,6,rep,name=previousPublicShares,proto3" json:"previousPublicShares,omitempty"`
+	PreviousPlayers      []uint32 `protobuf:"varint,7,rep,packed,name=previousPlayers,proto3" json:"previousPlayers,omitempty"`
+	SigningGroup         string   `protobuf:"bytes,8,opt,name=signingGroup,proto3" json:"signingGroup,omitempty"`
+	Weights              []uint32 `protobuf:"varint,9,rep,packed,name=weights,proto3" json:"weights,omitempty"`
 }
 
 func (x *OffchainConfig) Reset() {
@@ -84,12 +88,40 @@ func (x *OffchainConfig) GetTranslator() string {
 	return ""
 }
 
+func (x *OffchainConfig) GetPreviousPublicShares() [][]byte {
+	if x != nil {
+		return x.PreviousPublicShares
+	}
+	return nil
+}
+
+func (x *OffchainConfig) GetPreviousPlayers() []uint32 {
+	if x != nil {
+		return x.PreviousPlayers
+	}
+	return nil
+}
+
+func (x *OffchainConfig) GetSigningGroup() string {
+	if x != nil {
+		return x.SigningGroup
+	}
+	return ""
+}
+
+func (x *OffchainConfig) GetWeights() []uint32 {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
 var File_offchain_config_proto protoreflect.FileDescriptor
 
 var file_offchain_config_proto_rawDesc = []byte{
 	0x0a, 0x15, 0x6f, 0x66, 0x66, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x22, 0xa4,
-	0x01, 0x0a, 0x0e, 0x6f, 0x66, 0x66, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x22, 0xc0,
+	0x02, 0x0a, 0x0e, 0x6f, 0x66, 0x66, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69,
 	0x67, 0x12, 0x24, 0x0a, 0x0d, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x50,
 	0x4b, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0d, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70,
 	0x74, 0x69, 0x6f, 0x6e, 0x50, 0x4b, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x73, 0x69, 0x67, 0x6e, 0x61,
@@ -99,8 +131,18 @@ var file_offchain_config_proto_rawDesc = []byte{
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e,
 	0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61,
 	0x74, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x72, 0x61, 0x6e, 0x73,
-	0x6c, 0x61, 0x74, 0x6f, 0x72, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6c, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x32, 0x0a, 0x14, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75,
+	0x73, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x53, 0x68, 0x61, 0x72, 0x65, 0x73, 0x18, 0x06, 0x20,
+	0x03, 0x28, 0x0c, 0x52, 0x14, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x50, 0x75, 0x62,
+	0x6c, 0x69, 0x63, 0x53, 0x68, 0x61, 0x72, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x70, 0x72, 0x65,
+	0x76, 0x69, 0x6f, 0x75, 0x73, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x07, 0x20, 0x03,
+	0x28, 0x0d, 0x52, 0x0f, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x50, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x73, 0x69, 0x67, 0x6e, 0x69, 0x6e, 0x67, 0x47, 0x72,
+	0x6f, 0x75, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x73, 0x69, 0x67, 0x6e, 0x69,
+	0x6e, 0x67, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x07, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x73, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
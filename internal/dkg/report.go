@@ -9,6 +9,7 @@ import (
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
 	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
@@ -18,7 +19,7 @@ import (
 var _ = (&dkg{}).Report
 
 func (d *dkg) recoverShareRecord(report []byte) (r *shareRecord, h hash.Hash, err error) {
-	h = hash.GetHash(report)
+	h = shareRecordHash(report)
 	r, present := d.shareSets[h]
 	if !present {
 		r, err = unmarshalSignedShareRecord(d, report)
@@ -33,6 +34,10 @@ func (d *dkg) recoverShareRecord(report []byte) (r *shareRecord, h hash.Hash, er
 type validShareRecords struct {
 	includedDealers map[player_idx.PlayerIdx]bool
 
+	// excluded lists the dealers shown to be faulty by complaints in this round's
+	// observations, so that every honest node excludes the same dealers.
+	excluded map[player_idx.PlayerIdx]bool
+
 	includedHashes hash.Hashes
 
 	validShareCount int
@@ -52,6 +57,7 @@ func (d *dkg) newValidShareRecords(
 		return nil, errors.Wrap(err, "could not construct player list")
 	}
 	return &validShareRecords{
+		map[player_idx.PlayerIdx]bool{},
 		map[player_idx.PlayerIdx]bool{},
 		hash.MakeHashes(),
 		0,
@@ -72,7 +78,23 @@ func (v *validShareRecords) validateShareRecord(
 			err, "bad dealer on prospective share record for report",
 		)
 	}
+	if r.sig.sig == nil && !v.d.keyReportedOnchain(v.context) {
+		return nil, errors.Errorf(
+			"excluding unsigned share record for dealer %s from report", reportedDealer,
+		)
+	}
+	if err := v.d.checkSharedSecret(r.shareSet); err != nil {
+		if evidence, merr := r.marshal(); merr == nil {
+			v.d.accuse(reportedDealer, InvalidShareSet, err, evidence)
+		}
+		return nil, errors.Wrapf(err, "bad share set from dealer %d", reportedDealer)
+	}
 	if !v.d.keyReportedOnchain(v.context) {
+		if v.excluded[*reportedDealer] {
+			return nil, errors.Errorf(
+				"excluding share set from accused dealer %s", reportedDealer,
+			)
+		}
 		if v.includedDealers[*reportedDealer] {
 			return nil, errors.Errorf(
 				"excluding share set from dealer %d, which already has a share set "+
@@ -87,6 +109,11 @@ func (v *validShareRecords) validateShareRecord(
 				sender, reportedDealer,
 			)
 		}
+		if v.d.bias != nil {
+			if err := v.d.bias.checkRevelation(r.shareSet, reportedDealer); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return reportedDealer, nil
 }
@@ -103,22 +130,103 @@ func (v *validShareRecords) storeValidShareSet(
 	_ = v.aggregatePublicKey.Add(agg, shareSet.PublicKey())
 	v.includedHashes.Add(*h)
 	go v.persistShares(reportedDealer, marshaledShareSet, *h)
-	v.validShareCount++
+	v.validShareCount += v.d.weight(&reportedDealer)
 }
 
-func (v *validShareRecords) processShareSet(aobs types.AttributedObservation) {
+func (v *validShareRecords) parseObservation(aobs types.AttributedObservation) *observation {
 	if int(aobs.Observer) >= len(v.players) {
 		v.d.logger.Debug("observer index out of range", commontypes.LogFields{
 			"observer index": aobs.Observer, "max index": len(v.players) - 1,
 		})
-		return
+		return nil
 	}
 	sender := v.players[aobs.Observer]
+	o, recovery, err := unwrapShareRecovery(aobs.Observation)
+	if err == nil {
+		var obs *observation
+		obs, err = parseInnerObservation(sender, o)
+		if err == nil {
+			obs.recovery = recovery
+			return obs
+		}
+	}
+	v.d.logger.Warn("could not parse observation", commontypes.LogFields{
+		"err": err, "sender": sender,
+	})
+	return nil
+}
+
+func parseInnerObservation(sender *player_idx.PlayerIdx, o []byte) (*observation, error) {
+	if len(o) > 0 && o[0] == dataAvailabilityTag {
+		missing, records, err := unmarshalDataAvailabilityObservation(o)
+		if err != nil {
+			return nil, err
+		}
+		return &observation{sender, records, nil, missing, nil, nil, nil}, nil
+	}
+	if len(o) > 0 && o[0] == transcriptTag {
+		missing, transcript, err := unmarshalTranscriptObservation(o)
+		if err != nil {
+			return nil, err
+		}
+		return &observation{sender, nil, nil, missing, transcript, nil, nil}, nil
+	}
+	var hiding *hidingObservation
+	if len(o) > 0 && o[0] == hidingTag {
+		var err error
+		o, hiding, err = unmarshalHidingObservation(o)
+		if err != nil {
+			return nil, err
+		}
+	}
+	record, complaints, err := unmarshalObservation(o)
+	if err != nil {
+		return nil, err
+	}
+	var records [][]byte
+	if len(record) > 0 {
+		records = [][]byte{record}
+	}
+	return &observation{sender, records, complaints, nil, nil, nil, hiding}, nil
+}
+
+func (v *validShareRecords) processComplaints(o *observation) {
+	for _, c := range o.complaints {
+		if v.excluded[*c.dealer] {
+			continue
+		}
+		kind, fault, err := v.d.checkEvidence(c.dealer, c.evidence)
+		if err != nil {
+			v.d.logger.Warn("rejecting unfounded complaint", commontypes.LogFields{
+				"err": err, "accuser": o.sender, "dealer": c.dealer,
+			})
+			continue
+		}
+		v.excluded[*c.dealer] = true
+		v.d.blame(c.dealer, o.sender, kind, fault, c.evidence)
+	}
+}
 
-	r, h, err := v.d.recoverShareRecord(aobs.Observation)
+func (v *validShareRecords) processShareSet(o *observation) {
+	sender := o.sender
+	if len(o.records) == 0 {
+		v.d.logger.Debug("no share set in observation", commontypes.LogFields{
+			"sender": sender,
+		})
+		return
+	}
+	for _, record := range o.records {
+		v.processShareRecord(sender, record)
+	}
+}
+
+func (v *validShareRecords) processShareRecord(sender *player_idx.PlayerIdx, record []byte) {
+	_, seen := v.d.shareSets[shareRecordHash(record)]
+	r, h, err := v.d.recoverShareRecord(record)
 	if err != nil {
 		v.d.logger.Warn("excluding invalid share set from report",
 			commontypes.LogFields{"err": err, "sender": sender})
+		v.d.accuseSigner(record, err)
 		return
 	}
 
@@ -127,16 +235,23 @@ func (v *validShareRecords) processShareSet(aobs types.AttributedObservation) {
 		v.d.logger.Warn("invalid share set", commontypes.LogFields{"err": err})
 		return
 	}
-	v.storeValidShareSet(aobs.Observation, *reportedDealer, r.shareSet, &h)
+	if !seen && r.sig.sig != nil && !v.d.keyReportedOnchain(v.context) {
+		v.d.checkOwnShares(r, reportedDealer)
+	}
+	v.storeValidShareSet(record, *reportedDealer, r.shareSet, &h)
 }
 
 func (v *validShareRecords) enoughShareSets() bool {
-	return v.validShareCount > int(v.d.t)
+	return v.validShareCount >= v.d.requiredShareSets()
 }
 
 func (v *validShareRecords) report() (rv []byte, err error) {
 
-	kd := &contract.KeyData{v.aggregatePublicKey, v.includedHashes}
+	publicKey := v.aggregatePublicKey
+	if v.d.mode != freshKey {
+		publicKey = v.d.lastKeyData.PublicKey
+	}
+	kd := &contract.KeyData{publicKey, v.includedHashes}
 	kb, err := kd.MarshalBinary(v.d.keyID)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not marshal key for onchain report")
@@ -145,9 +260,12 @@ func (v *validShareRecords) report() (rv []byte, err error) {
 }
 
 func unmarshalSignedShareRecord(d *dkg, report []byte) (*shareRecord, error) {
+	if e, rem, err := envelope.Unmarshal(report, envelope.ReconstructedShareRecord); err == nil {
+		return d.unmarshalReconstructedShareRecord(e, rem)
+	}
 	r, rem, err := unmarshalShareRecord(
-		SigningGroup, d.encryptionGroup, d.translationGroup,
-		report, d.translator, d.cfgDgst, d.epks, d.spks,
+		d.signingGroup, d.encryptionGroup, d.translationGroup,
+		report, d.translator, d.cfgDgst, d.shareEpks(), d.spks,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not unmarshal unknown share record")
@@ -4,19 +4,37 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
 	"github.com/smartcontractkit/chainlink-vrf/internal/util"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	kshare "go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/sign/anon"
 )
 
 type dkgReportingPluginFactory struct {
 	l    *localArgs
 	lock sync.RWMutex
 
-	dkgInProgress bool
+	lifecycle lifecycle
+
+	completedKeys completedKeys
+	faultyDealers *faultyDealers
+	attempts      *attemptLog
+	configOrder   configOrder
+
+	current *dkg
 
 	testmode          bool
 	xxxDKGTestingOnly *dkg
@@ -24,18 +42,58 @@ type dkgReportingPluginFactory struct {
 
 var _ types.ReportingPluginFactory = (*dkgReportingPluginFactory)(nil)
 
+type completedKey struct {
+	cfgDgst         types.ConfigDigest
+	keyData         *KeyData
+	encryptionGroup anon.Suite
+	translator      point_translation.PubKeyTranslation
+}
+
+type completedKeys map[contract.KeyID]completedKey
+
+func newCompletedKeys() completedKeys {
+	return map[contract.KeyID]completedKey{}
+}
+
+// configOrder numbers config digests in the order the factory was given them,
+// so that a DKG which finishes late can't displace the key of a newer config.
+type configOrder map[types.ConfigDigest]uint64
+
+func (o configOrder) note(cfgDgst types.ConfigDigest) {
+	if _, ok := o[cfgDgst]; !ok {
+		o[cfgDgst] = uint64(len(o)) + 1
+	}
+}
+
+// before reports whether a was configured before b. Digests from before the
+// process started have no number, and come before every digest given since.
+func (o configOrder) before(a, b types.ConfigDigest) bool {
+	return o[a] < o[b]
+}
+
 func (d *dkgReportingPluginFactory) NewReportingPlugin(
 	c types.ReportingPluginConfig,
-) (types.ReportingPlugin, types.ReportingPluginInfo, error) {
+) (_ types.ReportingPlugin, _ types.ReportingPluginInfo, err error) {
+	d.lock.Lock()
+	previous := d.current
+	d.current = nil
+	d.lock.Unlock()
+	if previous != nil {
+		previous.abort(fmt.Sprintf("superseded by config digest %s", c.ConfigDigest))
+	}
 	d.lock.Lock()
 	defer d.lock.Unlock()
+	constructed := false
+	defer func() {
+		if err != nil && !constructed {
+			now := time.Now()
+			d.attempts.record(Attempt{
+				c.ConfigDigest, d.l.keyID, d.attempts.next(c.ConfigDigest), now, now,
+				AttemptFailed, err.Error(),
+			})
+		}
+	}()
 	emptyInfo := types.ReportingPluginInfo{}
-	if d.dkgInProgress {
-		return nil, emptyInfo, fmt.Errorf(
-			"attempt to initiate DKG round while an earlier DKG round is in progress",
-		)
-	}
-	d.dkgInProgress = true
 	a, err := unmarshalPluginConfig(c.OffchainConfig, c.OnchainConfig)
 	if err != nil {
 		return nil, emptyInfo,
@@ -45,37 +103,72 @@ func (d *dkgReportingPluginFactory) NewReportingPlugin(
 		return nil, emptyInfo,
 			fmt.Errorf("too many players: %d > %d", c.N, player_idx.MaxPlayer)
 	}
+	total, faulty := shareCounts(a.offchainConfig.weights, c.N, c.F)
 	args, err := a.NewDKGArgs(
-		c.ConfigDigest, d.l, c.OracleID, player_idx.Int(c.N), player_idx.Int(c.F),
+		c.ConfigDigest, d.l, c.OracleID, player_idx.Int(c.N),
+		a.onchainConfig.thresholdFor(player_idx.Int(faulty)),
 	)
 	if err != nil {
 		return nil, emptyInfo, util.WrapError(err, "could not construct DKG args")
 	}
+	args.faultyOracles = c.F
+	args.lastKeyData, err = d.previousKey(args)
+	if err != nil {
+		return nil, emptyInfo, util.WrapError(err, "could not load previous key")
+	}
+	if args.mode == refreshKey && a.onchainConfig.threshold == 0 && args.lastKeyData != nil {
+		args.t = args.lastKeyData.T
+	}
+	if err := checkThreshold(total, faulty, int(args.t)); err != nil {
+		return nil, emptyInfo, util.WrapError(err, "unsafe DKG threshold")
+	}
+	args.keyConsumer.KeyInvalidated(args.keyID)
 	d.l.logger.Debug("constructing share set", commontypes.LogFields{})
+	constructed = true
 	dkg, err := d.NewDKG(args)
 	if err != nil {
 		return nil, emptyInfo, util.WrapError(err, "while creating reporting plugin")
 	}
 	d.l.logger.Debug("finished constructing share set", commontypes.LogFields{})
+	d.current = dkg
 	if d.testmode {
 		d.xxxDKGTestingOnly = dkg
 	}
-	dkg.keyConsumer.KeyInvalidated(dkg.keyID)
 	return dkg, types.ReportingPluginInfo{
 		Name: fmt.Sprintf("dkg instance %v", dkg.selfIdx),
 		Limits: types.ReportingPluginLimits{
-			MaxQueryLength:       1000,
-			MaxObservationLength: 1_000_000,
-			MaxReportLength:      10_000,
+			MaxQueryLength:       maxQueryLength,
+			MaxObservationLength: maxObservationLength,
+			MaxReportLength:      maxReportLength(c.N),
 		},
 		UniqueReports: true,
 	}, nil
 }
 
+// maxReportLength bounds the onchain key report, which lists the hash of each
+// share record contributing to the key.
+func maxReportLength(n int) int {
+	return 10_000 + hash.Size*n
+}
+
 func (d *dkgReportingPluginFactory) NewDKG(a *NewDKGArgs) (*dkg, error) {
-	if err := a.SanityCheckArgs(); err != nil {
+	if err := a.sanityCheckArgs(true); err != nil {
 		return nil, util.WrapError(err, "could not construct new DKG")
 	}
+	d.configOrder.note(a.cfgDgst)
+	recovering := !a.encryptionKeyMatches()
+	if recovering && len(a.weights) > 0 {
+		return nil, errors.Errorf(
+			"secret encryption key does not match public encryption key, " +
+				"and key shares can't be recovered in a weighted committee",
+		)
+	}
+	if recovering && !a.keyReportedOnchain(context.Background()) {
+		return nil, errors.Errorf(
+			"secret encryption key does not match public encryption key, " +
+				"and there is no reported key to recover a share of",
+		)
+	}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	factory := &dkg{
@@ -87,30 +180,73 @@ func (d *dkgReportingPluginFactory) NewDKG(a *NewDKGArgs) (*dkg, error) {
 		a.keyConsumer,
 		newShareRecords(),
 		nil,
+		atomic.Bool{},
+		0,
 		a.esk,
 		a.epks,
 		a.ssk,
 		a.spks,
+		a.weights,
+		a.signingGroup(),
 		a.encryptionGroup,
 		a.translationGroup,
 		a.translator,
-		nil,
+		a.mode,
+		a.lastKeyData,
+		a.previousPlayers,
 		a.contract,
 		false,
-		d.markCompleted,
+		nil,
+		d.recordCompleted,
+		d.lifecycle,
+		Attempt{},
+		d.attempts,
+		0,
+		false,
+		map[player_idx.PlayerIdx]bool{},
+		map[player_idx.PlayerIdx][]byte{},
+		d.faultyDealers,
+		nil,
+		nil,
+		a.faultyOracles,
+		nil,
+		map[player_idx.PlayerIdx]*shareRecoveryResponse{},
+		a.newBiasResistantDeal(),
 		a.db,
 		a.logger,
 		a.randomness,
 		ctx,
 		cancelFunc,
 	}
-	if err := factory.initializeShareSets(a.signingGroup()); err != nil {
-		return nil, util.WrapError(err, "could not initialize share sets")
+	defer func() { factory.dkgComplete = d.markCompleted }()
+	factory.startAttemptLocked(d.attempts.next(a.cfgDgst))
+	if recovering {
+		factory.logger.Warn(
+			"encryption key does not match config; recovering key share from peers",
+			commontypes.LogFields{},
+		)
+		factory.recovery = newShareRecovery(a.esk.PublicKey())
+	}
+	if !recovering && factory.restoreKeySnapshot(ctx) {
+		go func() {
+			if err := factory.initializeShareSets(factory.signingGroup); err != nil {
+				factory.logger.Warn("could not initialize share sets", commontypes.LogFields{
+					"err": err,
+				})
+			}
+		}()
+		return factory, nil
+	}
+	if err := factory.initializeShareSets(factory.signingGroup); err != nil {
+		err = util.WrapError(err, "could not initialize share sets")
+		factory.abortLocked(AttemptFailed, err.Error())
+		return nil, err
 	}
 
 	res := make(chan error, 1)
 	go func(ctx context.Context) {
-		if factory.keyReportedOnchain(ctx) {
+		if !recovering && factory.keyReportedOnchain(ctx) &&
+			!factory.awaitingShareRecords(ctx) {
 			err := factory.recoverDistributedKeyShare(ctx)
 			if err != nil {
 				errMsg := "could not reconstruct shares for an available distributed key"
@@ -122,17 +258,149 @@ func (d *dkgReportingPluginFactory) NewDKG(a *NewDKGArgs) (*dkg, error) {
 	}(ctx)
 	err := <-res
 	if err != nil {
+		factory.abortLocked(AttemptFailed, err.Error())
 		return nil, err
 	}
+	go factory.watchDeadline()
 	return factory, nil
 }
 
-func (d *dkgReportingPluginFactory) markCompleted() {
+func (d *dkgReportingPluginFactory) markCompleted(k *dkg, kd *KeyData) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
-	d.dkgInProgress = false
+	d.recordCompleted(k, kd)
+}
+
+// recordCompleted keeps the key of the newest config to complete for each key
+// ID. It persists the record before returning, so that the record on disk is
+// always that of the newest key. The caller must hold d.lock.
+func (d *dkgReportingPluginFactory) recordCompleted(k *dkg, kd *KeyData) {
+	if last, ok := d.completedKeys[k.keyID]; ok && last.cfgDgst != k.cfgDgst &&
+		!d.configOrder.before(last.cfgDgst, k.cfgDgst) {
+		d.l.logger.Warn("not recording key superseded by a newer config", commontypes.LogFields{
+			"keyID": k.keyID, "configDigest": k.cfgDgst, "newer": last.cfgDgst,
+		})
+		return
+	}
+	ck := completedKey{k.cfgDgst, kd.Clone(), k.encryptionGroup, k.translator}
+	d.completedKeys[k.keyID] = ck
+	if err := d.persistCompletedKey(context.Background(), k.keyID, ck); err != nil {
+		d.l.logger.Warn("could not persist completed key", commontypes.LogFields{
+			"err": err, "keyID": k.keyID,
+		})
+	}
+	d.pruneSupersededShareRecords(k.keyID, k.cfgDgst)
+}
+
+// pruneSupersededShareRecords deletes what is stored for the configs of the
+// key ID from before cfgDgst. Records of cfgDgst and of any newer config, whose
+// DKG may still be running, are kept. The caller must hold d.lock.
+func (d *dkgReportingPluginFactory) pruneSupersededShareRecords(
+	keyID contract.KeyID, cfgDgst types.ConfigDigest,
+) {
+	db, ok := d.l.shareDB.(dkg_types.PrunableDKGSharePersistence)
+	if !ok {
+		return
+	}
+	digests, err := db.ConfigDigests(keyID)
+	if err != nil {
+		d.l.logger.Warn("could not list persisted share records", commontypes.LogFields{
+			"err": err, "keyID": keyID,
+		})
+		return
+	}
+	for _, digest := range digests {
+		if !d.configOrder.before(digest, cfgDgst) {
+			continue
+		}
+		err := db.DeleteShareRecords(context.Background(), digest, keyID)
+		if err != nil {
+			d.l.logger.Warn("could not prune superseded share records", commontypes.LogFields{
+				"err": err, "keyID": keyID, "configDigest": digest,
+			})
+		}
+	}
 }
 
 func (d *dkgReportingPluginFactory) SetKeyConsumer(k KeyConsumer) {
-	d.l.keyConsumer = k
+	d.l.keyConsumer.setConsumer(k)
+}
+
+func (d *dkgReportingPluginFactory) previousKey(a *NewDKGArgs) (*KeyData, error) {
+	last, ok := d.completedKeys[a.keyID]
+	haveLast := ok && last.cfgDgst == a.previousDigest
+	switch a.mode {
+	case refreshKey:
+		if haveLast {
+			return last.keyData, nil
+		}
+		kd, err := d.snapshotKey(a)
+		if err != nil {
+			return nil, util.WrapError(err, "could not load key snapshot to refresh")
+		}
+		if kd == nil {
+			return nil, fmt.Errorf(
+				"no key from config digest %s available for refresh", a.previousDigest,
+			)
+		}
+		return kd, nil
+	case reshareKey:
+		kd, err := d.l.contract.KeyData(context.Background(), a.keyID, a.previousDigest)
+		if err != nil {
+			return nil, util.WrapError(err, "could not retrieve key to reshare")
+		}
+		if kd.PublicKey == nil {
+			return nil, fmt.Errorf(
+				"no key from config digest %s reported onchain", a.previousDigest,
+			)
+		}
+		shares := make([]kshare.PubShare, len(a.previousPublicShares))
+		for i, ps := range a.previousPublicShares {
+			shares[i] = kshare.PubShare{i, ps}
+		}
+		var secretShare *SecretShare
+		if haveLast {
+			secretShare = last.keyData.SecretShare
+		} else if snapshot, err := d.snapshotKey(a); err != nil {
+			return nil, util.WrapError(err, "could not load key snapshot to reshare")
+		} else if snapshot != nil {
+			secretShare = snapshot.SecretShare
+		} else {
+			d.l.logger.Info("no share of key to reshare; will not deal", commontypes.LogFields{
+				"previous digest": a.previousDigest,
+			})
+		}
+		return &KeyData{
+			kd.PublicKey, shares, secretShare, nil, nil, a.previousThreshold, true,
+		}, nil
+	}
+	return nil, nil
+}
+
+func (d *dkgReportingPluginFactory) snapshotKey(a *NewDKGArgs) (*KeyData, error) {
+	kd, hashes, err := readKeySnapshot(
+		d.l.shareDB, a.esk, a.encryptionGroup, a.translationGroup,
+		a.previousDigest, a.keyID,
+	)
+	if err != nil || kd == nil {
+		return nil, err
+	}
+	onchain, err := d.l.contract.KeyData(context.Background(), a.keyID, a.previousDigest)
+	if err != nil {
+		return nil, util.WrapError(err, "could not get onchain key to validate key snapshot")
+	}
+	if err := checkKeySnapshot(kd, hashes, &onchain, a.translator); err != nil {
+		return nil, err
+	}
+	return kd, nil
+}
+
+func (d *dkgReportingPluginFactory) status(ctx context.Context) (*Status, error) {
+	d.lock.RLock()
+	current := d.current
+	d.lock.RUnlock()
+	if current == nil {
+		return &Status{}, nil
+	}
+	return current.status(ctx)
 }
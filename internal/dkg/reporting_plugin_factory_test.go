@@ -0,0 +1,92 @@
+package dkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	"github.com/smartcontractkit/chainlink-vrf/internal/util"
+	dkg_types "github.com/smartcontractkit/chainlink-vrf/types"
+)
+
+func TestRecordCompletedKeepsNewestConfig(t *testing.T) {
+	db := persistence.NewMemorySharePersistence()
+	f := &dkgReportingPluginFactory{
+		l:             &localArgs{logger: util.MakeLogger(), shareDB: db},
+		completedKeys: newCompletedKeys(),
+		configOrder:   configOrder{},
+	}
+	keyID := contract.KeyID{1}
+	older, newer := types.ConfigDigest{2}, types.ConfigDigest{1}
+	f.configOrder.note(older)
+	f.configOrder.note(newer)
+	completed := func(cfgDgst types.ConfigDigest) {
+		kd, _ := testKeyData(t, nil, 0)
+		f.recordCompleted(&dkg{
+			cfgDgst:         cfgDgst,
+			keyID:           keyID,
+			encryptionGroup: encryptionGroupRegistry["Secp256k1"],
+			translator:      translatorRegistry["translator from Secp256k1 to Secp256k1"],
+		}, kd)
+	}
+	persisted := func() types.ConfigDigest {
+		records, err := db.CompletedKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ck, err := unmarshalCompletedKey(records[keyID])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ck.cfgDgst
+	}
+
+	completed(newer)
+	if persisted() != newer {
+		t.Fatal("completed key not persisted when recorded")
+	}
+	completed(older)
+	if f.completedKeys[keyID].cfgDgst != newer || persisted() != newer {
+		t.Fatal("key of an older config displaced the key of a newer one")
+	}
+}
+
+func TestPruneKeepsRecordsOfNewerConfigs(t *testing.T) {
+	ctx := context.Background()
+	db := persistence.NewMemorySharePersistence()
+	f := &dkgReportingPluginFactory{
+		l:             &localArgs{logger: util.MakeLogger(), shareDB: db},
+		completedKeys: newCompletedKeys(),
+		configOrder:   configOrder{},
+	}
+	keyID := contract.KeyID{1}
+	beforeRestart := types.ConfigDigest{9}
+	older, completed, running := types.ConfigDigest{3}, types.ConfigDigest{2}, types.ConfigDigest{1}
+	players, err := player_idx.PlayerIdxs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []types.ConfigDigest{beforeRestart, older, completed, running} {
+		f.configOrder.note(d)
+		err := db.WriteShareRecords(ctx, d, keyID, []dkg_types.PersistentShareSetRecord{
+			{Dealer: *players[0], MarshaledShareRecord: []byte("record")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	delete(f.configOrder, beforeRestart)
+
+	f.pruneSupersededShareRecords(keyID, completed)
+	digests, err := db.ConfigDigests(keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digests) != 2 || digests[0] != running || digests[1] != completed {
+		t.Fatalf("expected only records of completed and running configs, got %v", digests)
+	}
+}
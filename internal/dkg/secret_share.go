@@ -30,7 +30,7 @@ func (s *SecretShare) Mul(p kyber.Point) kyber.Point {
 
 var defaultPBKDF2NumberOfIterations uint32 = 150_000
 
-var encryptionVersionNum uint8
+var encryptionVersionNum, contextEncryptionVersionNum uint8 = 0, 1
 
 const saltLen, nonceLen = 32, 12
 
@@ -38,6 +38,21 @@ const preambleLength = 1 + saltLen + 4 + nonceLen
 
 func (s *SecretShare) Encrypt(
 	passphrase []byte, itersTestingOnly ...uint32,
+) ([]byte, error) {
+	return s.encrypt(passphrase, nil, itersTestingOnly...)
+}
+
+func (s *SecretShare) EncryptWithContext(
+	passphrase, context []byte, itersTestingOnly ...uint32,
+) ([]byte, error) {
+	if context == nil {
+		context = []byte{}
+	}
+	return s.encrypt(passphrase, context, itersTestingOnly...)
+}
+
+func (s *SecretShare) encrypt(
+	passphrase, context []byte, itersTestingOnly ...uint32,
 ) ([]byte, error) {
 	if len(itersTestingOnly) > 1 {
 		return nil, errors.Errorf("at most one derived-key iteration value allowed")
@@ -64,8 +79,12 @@ func (s *SecretShare) Encrypt(
 		return nil, errors.Wrap(err, "could not serialize secret share for "+
 			"encryption")
 	}
+	versionNum := encryptionVersionNum
+	if context != nil {
+		versionNum = contextEncryptionVersionNum
+	}
 	preamble := bytes.Join(
-		[][]byte{{encryptionVersionNum}, salt[:], iterBin[:], nonce[:]}, nil,
+		[][]byte{{versionNum}, salt[:], iterBin[:], nonce[:]}, nil,
 	)
 	if len(preamble) != preambleLength {
 		panic("wrong length for preamble")
@@ -74,16 +93,38 @@ func (s *SecretShare) Encrypt(
 	if err != nil {
 		return nil, err
 	}
-	return gcm.Seal(preamble, nonce[:], plaintext, nil), nil
+	return gcm.Seal(preamble, nonce[:], plaintext, associatedData(preamble, context)), nil
 }
 
 func (s *SecretShare) Decrypt(
 	passphrase, ciphertext []byte, shareGroup kyber.Group,
 ) error {
+	return s.decrypt(passphrase, ciphertext, nil, shareGroup)
+}
+
+func (s *SecretShare) DecryptWithContext(
+	passphrase, ciphertext, context []byte, shareGroup kyber.Group,
+) error {
+	if context == nil {
+		context = []byte{}
+	}
+	return s.decrypt(passphrase, ciphertext, context, shareGroup)
+}
+
+func (s *SecretShare) decrypt(
+	passphrase, ciphertext, context []byte, shareGroup kyber.Group,
+) error {
+	if len(ciphertext) < preambleLength {
+		return errors.Errorf("secret share ciphertext too short")
+	}
 	cursor := 0
 	versionNum := ciphertext[0]
 	cursor++
-	if versionNum != encryptionVersionNum {
+	expectedVersionNum := encryptionVersionNum
+	if context != nil {
+		expectedVersionNum = contextEncryptionVersionNum
+	}
+	if versionNum != expectedVersionNum {
 		return errors.Errorf(
 			"don't know how to decrypt version %d ciphertexts", versionNum,
 		)
@@ -111,7 +152,8 @@ func (s *SecretShare) Decrypt(
 	if err != nil {
 		return err
 	}
-	plaintext, err := gcm.Open(nil, nonce[:], ciphertext[preambleLength:], nil)
+	ad := associatedData(ciphertext[:preambleLength], context)
+	plaintext, err := gcm.Open(nil, nonce[:], ciphertext[preambleLength:], ad)
 	if err != nil {
 		return errors.Wrap(err, "could not decrypt secret share")
 	}
@@ -122,6 +164,13 @@ func (s *SecretShare) Decrypt(
 	)
 }
 
+func associatedData(preamble, context []byte) []byte {
+	if context == nil {
+		return nil
+	}
+	return append(append([]byte{}, preamble...), context...)
+}
+
 func (s SecretShare) Equal(os SecretShare) bool {
 	return s.Idx.Equal(&os.Idx) && s.share.Equal(os.share)
 }
@@ -8,9 +8,13 @@ import (
 
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext/schnorr"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
 	"github.com/smartcontractkit/chainlink-vrf/internal/pvss"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
 
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/sign/anon"
@@ -22,15 +26,17 @@ type shareRecord struct {
 	marshaledShareRecord []byte
 
 	sig signature
+
+	sigSuite schnorr.Suite
 }
 
 type signature struct{ sig []byte }
 
 func newShareRecord(
-	suite schnorr.Suite, shareSet *pvss.ShareSet, sk kyber.Scalar,
+	suite schnorr.Suite, shareSet *pvss.ShareSet, sk key_store.SigningKey,
 	domainSep types.ConfigDigest,
 ) (*shareRecord, error) {
-	rv := &shareRecord{shareSet: shareSet}
+	rv := &shareRecord{shareSet: shareSet, sigSuite: suite}
 
 	if err := rv.sign(suite, domainSep, sk); err != nil {
 		return nil, errors.Wrapf(err, "could not sign new share record")
@@ -50,87 +56,160 @@ func (r *shareRecord) marshal() ([]byte, error) {
 		if len(ss) > shareLenBound {
 			return nil, errors.Wrap(err, "could not marshal share record: marshalled share set too long")
 		}
-		msr = msrComponents(ss, r.sig.sig)
+		if r.sig.sig == nil {
+			msr, err = envelope.New(envelope.ReconstructedShareRecord, nil, ss).Marshal()
+		} else {
+			msr, err = msrComponents(r.sigSuite, ss, r.sig.sig)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal share record")
+		}
 		r.marshaledShareRecord = msr
 	}
 	return msr, nil
 }
 
-func msrComponents(ssBytes, sig []byte) []byte {
+func msrComponents(sigSuite schnorr.Suite, ssBytes, sig []byte) ([]byte, error) {
+	return envelope.New(
+		envelope.ShareRecord, []kyber.Group{sigSuite}, ssBytes, sig,
+	).Marshal()
+}
+
+func legacyMSRComponents(ssBytes, sig []byte) []byte {
 	ssLenData := make([]byte, 4)
 	binary.BigEndian.PutUint32(ssLenData, uint32(len(ssBytes)))
 	return bytes.Join([][]byte{ssLenData, ssBytes, sig}, nil)
 }
 
+func shareRecordHash(msr []byte) hash.Hash {
+	if envelope.IsEnveloped(msr) {
+		e, rem, err := envelope.Unmarshal(msr, envelope.ShareRecord)
+		if err == nil && len(rem) == 0 && len(e.Fields) == 2 {
+			return hash.GetHash(legacyMSRComponents(e.Fields[0], e.Fields[1]))
+		}
+	}
+	return hash.GetHash(msr)
+}
+
 func unmarshalShareRecord(
 	sigSuite schnorr.Suite, g anon.Suite, translationGroup kyber.Group,
 	data []byte, translation point_translation.PubKeyTranslation,
 	cfgDgst types.ConfigDigest, pks []kyber.Point, spks []kyber.Point,
 ) (*shareRecord, []byte, error) {
-	if len(data) < 4 {
-		return nil, nil, errors.Errorf("marshalled share record too short, %d bytes", len(data))
+	_, ssBytes, sig, rem, err := verifyShareRecordSignature(sigSuite, data, cfgDgst, spks)
+	if err != nil {
+		return nil, nil, err
 	}
-	if len(data) > shareLenBound {
-		return nil, nil, errors.Errorf("marshalled share record too long, %d bytes", len(data))
+
+	shareSet, _, err := pvss.UnmarshalShareSet(
+		g, translationGroup, ssBytes, translation, cfgDgst, pks,
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal share record")
 	}
-	ssLenData, data := data[:4], data[4:]
-	ssLen := binary.BigEndian.Uint32(ssLenData)
-	if int(ssLen) > len(data)+1 {
-		return nil, nil, errors.Errorf(
-			"marshalled share record too short, %d bytes, need %d bytes",
-			len(data), ssLen,
+
+	msr := append([]byte{}, data[:len(data)-len(rem)]...)
+	return &shareRecord{shareSet, msr, signature{sig}, sigSuite}, rem, nil
+}
+
+func verifyShareRecordSignature(
+	sigSuite schnorr.Suite, data []byte, cfgDgst types.ConfigDigest, spks []kyber.Point,
+) (dealer *player_idx.PlayerIdx, ssBytes, sig, rem []byte, err error) {
+	if len(data) > shareLenBound {
+		return nil, nil, nil, nil, errors.Errorf(
+			"marshalled share record too long, %d bytes", len(data),
 		)
 	}
-	if ssLen > shareLenBound {
-		return nil, nil, errors.Errorf("marshalled share record too long, %d bytes", len(data))
+	if envelope.IsEnveloped(data) {
+		ssBytes, sig, data, err = unmarshalEnvelopedShareRecord(sigSuite, data)
+	} else {
+		ssBytes, sig, data, err = unmarshalLegacyShareRecord(sigSuite, data)
+	}
+	if err != nil {
+		return nil, nil, nil, nil, err
 	}
-	ssBytes, data := data[:ssLen], data[ssLen:]
 
-	dealer, err := pvss.UnmarshalDealer(ssBytes)
+	dealer, err = pvss.UnmarshalDealer(ssBytes)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "could not get signer identity for share record")
+		return nil, nil, nil, nil, errors.Wrap(
+			err, "could not get signer identity for share record",
+		)
 	}
-	if !dealer.AtMost(uint8(len(spks))) {
-		return nil, nil, errors.Errorf("dealer out of range")
+	if !dealer.AtMost(player_idx.Int(len(spks))) {
+		return nil, nil, nil, nil, errors.Errorf("dealer out of range")
 	}
 
-	sig, data := data[:64], data[64:]
 	dealerPK := dealer.Index(spks).(kyber.Point)
 
 	msg := append(cfgDgst[:], ssBytes...)
 	err = schnorr.Verify(sigSuite, dealerPK, msg, sig)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "invalid signature on marshalled share set")
+		return nil, nil, nil, nil, errors.Wrap(err, "invalid signature on marshalled share set")
 	}
+	return dealer, ssBytes, sig, data, nil
+}
 
-	shareSet, _, err := pvss.UnmarshalShareSet(
-		g, translationGroup, ssBytes, translation, cfgDgst, pks,
-	)
+func unmarshalEnvelopedShareRecord(
+	sigSuite schnorr.Suite, data []byte,
+) (ssBytes, sig, rem []byte, err error) {
+	e, rem, err := envelope.Unmarshal(data, envelope.ShareRecord)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "could not unmarshal share record")
+		return nil, nil, nil, errors.Wrap(err, "could not unmarshal share record")
+	}
+	if err := e.CheckGroups(sigSuite); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not unmarshal share record")
+	}
+	if len(e.Fields) != 2 {
+		return nil, nil, nil, errors.Errorf(
+			"share record version %d has %d fields, need 2", e.Version, len(e.Fields),
+		)
 	}
+	ssBytes, sig = e.Fields[0], e.Fields[1]
+	if sigLen := sigSuite.PointLen() + sigSuite.ScalarLen(); len(sig) != sigLen {
+		return nil, nil, nil, errors.Errorf(
+			"share record signature is %d bytes, need %d bytes", len(sig), sigLen,
+		)
+	}
+	return ssBytes, sig, rem, nil
+}
 
-	msr := msrComponents(ssBytes, sig)
-	return &shareRecord{shareSet, msr, signature{sig}}, data, nil
+func unmarshalLegacyShareRecord(
+	sigSuite schnorr.Suite, data []byte,
+) (ssBytes, sig, rem []byte, err error) {
+	if len(data) < 4 {
+		return nil, nil, nil, errors.Errorf(
+			"marshalled share record too short, %d bytes", len(data),
+		)
+	}
+	ssLenData, data := data[:4], data[4:]
+	ssLen := binary.BigEndian.Uint32(ssLenData)
+	sigLen := sigSuite.PointLen() + sigSuite.ScalarLen()
+	if int(ssLen)+sigLen > len(data) {
+		return nil, nil, nil, errors.Errorf(
+			"marshalled share record too short, %d bytes, need %d bytes",
+			len(data), int(ssLen)+sigLen,
+		)
+	}
+	ssBytes, data = data[:ssLen], data[ssLen:]
+	sig, data = data[:sigLen], data[sigLen:]
+	return ssBytes, sig, data, nil
 }
 
 func (r *shareRecord) sign(suite schnorr.Suite,
 	domainSep types.ConfigDigest,
-	sk kyber.Scalar) error {
+	sk key_store.SigningKey) error {
 	ss, err := r.shareSet.Marshal()
 	if err != nil {
 		return errors.Wrap(err, "could not marshal share set for signing")
 	}
 	msg := append(domainSep[:], ss...)
-	r.sig.sig, err = schnorr.Sign(suite, sk, msg)
+	r.sig.sig, err = sk.Sign(msg)
 	if err != nil {
 		return errors.Wrap(err, "could sign share set")
 	}
 
-	pk := suite.Point().Mul(sk, nil)
-
-	if err := schnorr.Verify(suite, pk, msg, r.sig.sig); err != nil {
-		panic("failed to verify own signature: " + err.Error())
+	if err := schnorr.Verify(suite, sk.PublicKey(), msg, r.sig.sig); err != nil {
+		return errors.Wrap(err, "signing key produced an invalid signature")
 	}
 	return nil
 }
@@ -1,14 +1,11 @@
 package dkg
 
 import (
-	"crypto/rand"
-	"io"
-	"math/big"
-
 	"github.com/pkg/errors"
 
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
 	"github.com/smartcontractkit/chainlink-vrf/types/hash"
@@ -30,31 +27,12 @@ func (rs shareRecords) set(r *shareRecord, h hash.Hash) error {
 		if err != nil {
 			return errors.Wrap(err, "could not marshal share record to get content address")
 		}
-		h = hash.GetHash(m)
+		h = shareRecordHash(m)
 	}
 	rs[h] = r
 	return nil
 }
 
-func (rs shareRecords) getRandom(givenHashes []hash.Hash, randomness io.Reader) (hash.Hash, error) {
-	extantHashes := make([]hash.Hash, 0, len(givenHashes))
-	for _, h := range givenHashes {
-		if _, ok := rs[h]; ok {
-			extantHashes = append(extantHashes, h)
-		}
-	}
-	if len(extantHashes) == 0 {
-		return hash.Hash{}, errors.Errorf(
-			"don't know any of the share records in the chosen key",
-		)
-	}
-	hIdx, err := rand.Int(randomness, big.NewInt(int64(len(extantHashes))))
-	if err != nil {
-		return hash.Hash{}, errors.Wrap(err, "could not choose random hash")
-	}
-	return extantHashes[hIdx.Uint64()], nil
-}
-
 func (rs shareRecords) allKeysPresent(hs []hash.Hash) bool {
 	for _, h := range hs {
 		if _, ok := rs[h]; !ok {
@@ -65,11 +43,13 @@ func (rs shareRecords) allKeysPresent(hs []hash.Hash) bool {
 }
 
 func (rs shareRecords) recoverDistributedKeyShare(
-	encryptionSecretKey kyber.Scalar,
+	encryptionSecretKey key_store.EncryptionKey,
 	receiver player_idx.PlayerIdx,
 	keyData *contract.KeyData,
 	keyGroup anon.Suite,
 	domainSep types.ConfigDigest,
+	weights map[hash.Hash]kyber.Scalar,
+	blame func(*shareRecord, player_idx.PlayerIdx, error),
 ) (*kshare.PriShare, error) {
 	acc := keyGroup.Scalar().Zero()
 	for _, h := range keyData.Hashes {
@@ -81,8 +61,12 @@ func (rs shareRecords) recoverDistributedKeyShare(
 			receiver, encryptionSecretKey, keyGroup, domainSep,
 		)
 		if err != nil {
+			blame(publicShare, receiver, err)
 			return nil, errors.Wrapf(err, "could not decrypt share for key hash 0x%x", h)
 		}
+		if w, ok := weights[h]; ok {
+			recvShare.V = keyGroup.Scalar().Mul(w, recvShare.V)
+		}
 		acc = acc.Clone().Add(acc, recvShare.V)
 	}
 	rv := receiver.PriShare(acc)
@@ -90,7 +74,7 @@ func (rs shareRecords) recoverDistributedKeyShare(
 }
 
 func (rs shareRecords) recoverPublicShares(
-	keyData *contract.KeyData,
+	keyData *contract.KeyData, weights map[hash.Hash]kyber.Scalar,
 ) ([]kyber.Point, error) {
 	var partialShares [][]kyber.Point
 	if len(keyData.Hashes) == 0 {
@@ -102,7 +86,13 @@ func (rs shareRecords) recoverPublicShares(
 			return nil, errors.Errorf("no share found for hash %s", h)
 		}
 
-		partialShares = append(partialShares, sr.shareSet.PublicShares())
+		ps := sr.shareSet.PublicShares()
+		if w, ok := weights[h]; ok {
+			for i, p := range ps {
+				ps[i] = p.Clone().Mul(w, p)
+			}
+		}
+		partialShares = append(partialShares, ps)
 	}
 	rv := make([]kyber.Point, len(partialShares[0]))
 	for _, ps := range partialShares {
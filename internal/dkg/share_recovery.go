@@ -0,0 +1,682 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+)
+
+const (
+	shareRecoveryTag byte = 3
+
+	shareRecoveryRounds = 3
+)
+
+type shareRecoveryRequest struct {
+	recipient *player_idx.PlayerIdx
+	epk       kyber.Point
+	helpers   []*player_idx.PlayerIdx
+	session   hash.Hash
+}
+
+type shareRecoveryContribution struct {
+	recipient  *player_idx.PlayerIdx
+	session    hash.Hash
+	commitment kyber.Point
+	translated kyber.Point
+	masks      []kyber.Point
+	cipherText *ciphertext.CipherText
+}
+
+type shareRecoveryMessages struct {
+	request       []byte
+	contributions [][]byte
+}
+
+type shareRecovery struct {
+	epk          kyber.Point
+	publicShares []kyber.Point
+
+	request       *shareRecoveryRequest
+	marshaled     []byte
+	rounds        int
+	contributions map[player_idx.PlayerIdx]*shareRecoveryContribution
+	subShares     map[player_idx.PlayerIdx]kyber.Scalar
+
+	seen     map[player_idx.PlayerIdx]bool
+	excluded map[player_idx.PlayerIdx]bool
+}
+
+type shareRecoveryResponse struct {
+	session      hash.Hash
+	contribution []byte
+}
+
+func newShareRecovery(epk kyber.Point) *shareRecovery {
+	return &shareRecovery{
+		epk:      epk,
+		seen:     map[player_idx.PlayerIdx]bool{},
+		excluded: map[player_idx.PlayerIdx]bool{},
+	}
+}
+
+func (d *dkg) newShareRecoveryRequest(
+	recipient *player_idx.PlayerIdx, epk kyber.Point, helpers []*player_idx.PlayerIdx,
+) (*shareRecoveryRequest, error) {
+	if err := d.checkShareRecoveryHelpers(recipient, helpers); err != nil {
+		return nil, err
+	}
+	epkB, err := epk.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal recovery encryption key")
+	}
+	components := [][]byte{
+		[]byte("share recovery session"), d.cfgDgst[:], d.keyID[:], recipient.Marshal(), epkB,
+	}
+	for _, h := range helpers {
+		components = append(components, h.Marshal())
+	}
+	session := hash.GetHash(bytes.Join(components, nil))
+	return &shareRecoveryRequest{recipient, epk, helpers, session}, nil
+}
+
+func (d *dkg) checkShareRecoveryHelpers(
+	recipient *player_idx.PlayerIdx, helpers []*player_idx.PlayerIdx,
+) error {
+	if len(d.weights) > 0 {
+		// Helpers would each need to reshare all of their shares, and the
+		// recipient to recover more than one.
+		return errors.Errorf("weighted committees don't support peer share recovery")
+	}
+	if len(helpers) != int(d.t)+1 {
+		return errors.Errorf(
+			"share recovery needs %d helpers, got %d", int(d.t)+1, len(helpers),
+		)
+	}
+	seen := map[player_idx.PlayerIdx]bool{}
+	for _, h := range helpers {
+		if _, err := h.Check(); err != nil {
+			return errors.Wrap(err, "bad share recovery helper")
+		}
+		if !h.AtMost(player_idx.Int(len(d.epks))) {
+			return errors.Errorf("share recovery helper %s out of range", h)
+		}
+		if h.Equal(recipient) {
+			return errors.Errorf("recovering player %s listed as its own helper", h)
+		}
+		if seen[*h] {
+			return errors.Errorf("share recovery helper %s listed twice", h)
+		}
+		seen[*h] = true
+	}
+	return nil
+}
+
+func (r *shareRecoveryRequest) marshal() ([]byte, error) {
+	epkB, err := r.epk.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal recovery encryption key")
+	}
+	rv := append(lenPrefix(epkB), epkB...)
+	rv = append(rv, player_idx.RawMarshal(player_idx.Int(len(r.helpers)))...)
+	for _, h := range r.helpers {
+		rv = append(rv, h.Marshal()...)
+	}
+	return rv, nil
+}
+
+func (d *dkg) unmarshalShareRecoveryRequest(
+	recipient *player_idx.PlayerIdx, data []byte,
+) (*shareRecoveryRequest, error) {
+	epkB, data, err := readLenPrefixed(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read recovery encryption key")
+	}
+	epk := d.encryptionGroup.Point()
+	if err := epk.UnmarshalBinary(epkB); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal recovery encryption key")
+	}
+	numHelpers, data, err := player_idx.RawUnmarshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "share recovery request missing helper count")
+	}
+	helpers := make([]*player_idx.PlayerIdx, numHelpers)
+	for i := range helpers {
+		helpers[i], data, err = player_idx.Unmarshal(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read share recovery helper")
+		}
+	}
+	if len(data) > 0 {
+		return nil, errors.Errorf("overage of %d bytes in share recovery request", len(data))
+	}
+	return d.newShareRecoveryRequest(recipient, epk, helpers)
+}
+
+func (r *shareRecoveryRequest) helperPosition(p *player_idx.PlayerIdx) int {
+	for i, h := range r.helpers {
+		if h.Equal(p) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *shareRecoveryRequest) domainSep(helper *player_idx.PlayerIdx) []byte {
+	return bytes.Join(
+		[][]byte{[]byte("share recovery contribution"), r.session[:], helper.Marshal()}, nil,
+	)
+}
+
+func (c *shareRecoveryContribution) maskFor(pos, other int) kyber.Point {
+	if other > pos {
+		return c.masks[other-1]
+	}
+	return c.masks[other]
+}
+
+func (c *shareRecoveryContribution) marshal() ([]byte, error) {
+	points := append([]kyber.Point{c.commitment, c.translated}, c.masks...)
+	rv := append(c.recipient.Marshal(), c.session[:]...)
+	rv = append(rv, player_idx.RawMarshal(player_idx.Int(len(c.masks)))...)
+	for _, p := range points {
+		pB, err := p.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal share recovery commitment")
+		}
+		rv = append(append(rv, lenPrefix(pB)...), pB...)
+	}
+	ct, err := c.cipherText.Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal share recovery ciphertext")
+	}
+	return append(append(rv, lenPrefix(ct)...), ct...), nil
+}
+
+func (d *dkg) unmarshalShareRecoveryContribution(
+	data []byte,
+) (c *shareRecoveryContribution, err error) {
+	c = &shareRecoveryContribution{}
+	c.recipient, data, err = player_idx.Unmarshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read share recovery recipient")
+	}
+	if len(data) < hash.Size {
+		return nil, errors.Errorf("share recovery contribution truncated")
+	}
+	copy(c.session[:], data)
+	numMasks, data, err := player_idx.RawUnmarshal(data[hash.Size:])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read share recovery mask count")
+	}
+	points := make([]kyber.Point, int(numMasks)+2)
+	for i := range points {
+		var pB []byte
+		pB, data, err = readLenPrefixed(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read share recovery commitment")
+		}
+		points[i] = d.translationGroup.Point()
+		if i == 0 {
+			points[i] = d.encryptionGroup.Point()
+		}
+		if err := points[i].UnmarshalBinary(pB); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal share recovery commitment")
+		}
+	}
+	c.commitment, c.translated, c.masks = points[0], points[1], points[2:]
+	ct, data, err := readLenPrefixed(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read share recovery ciphertext")
+	}
+	if len(data) > 0 {
+		return nil, errors.Errorf(
+			"overage of %d bytes in share recovery contribution", len(data),
+		)
+	}
+	c.cipherText, err = ciphertext.Unmarshal(d.encryptionGroup, bytes.NewReader(ct))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal share recovery ciphertext")
+	}
+	return c, nil
+}
+
+func (m *shareRecoveryMessages) wrap(o []byte) []byte {
+	if m == nil || (len(m.request) == 0 && len(m.contributions) == 0) {
+		return o
+	}
+	rv := append([]byte{shareRecoveryTag}, lenPrefix(o)...)
+	rv = append(append(rv, o...), lenPrefix(m.request)...)
+	rv = append(append(rv, m.request...), player_idx.RawMarshal(player_idx.Int(len(m.contributions)))...)
+	for _, c := range m.contributions {
+		rv = append(append(rv, lenPrefix(c)...), c...)
+	}
+	return rv
+}
+
+func (m *shareRecoveryMessages) size() int {
+	if m == nil || (len(m.request) == 0 && len(m.contributions) == 0) {
+		return 0
+	}
+	rv := 1 + 4 + 4 + len(m.request) + player_idx.MaxMarshalLen
+	for _, c := range m.contributions {
+		rv += 4 + len(c)
+	}
+	return rv
+}
+
+func unwrapShareRecovery(o []byte) (inner []byte, m *shareRecoveryMessages, err error) {
+	if len(o) == 0 || o[0] != shareRecoveryTag {
+		return o, nil, nil
+	}
+	m = &shareRecoveryMessages{}
+	inner, o, err = readLenPrefixed(o[1:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read observation wrapped in share recovery")
+	}
+	m.request, o, err = readLenPrefixed(o)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read share recovery request")
+	}
+	numContributions, o, err := player_idx.RawUnmarshal(o)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "missing share recovery contribution count")
+	}
+	m.contributions = make([][]byte, numContributions)
+	for i := range m.contributions {
+		m.contributions[i], o, err = readLenPrefixed(o)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not read share recovery contribution")
+		}
+	}
+	if len(o) > 0 {
+		return nil, nil, errors.Errorf("overage of %d bytes in observation", len(o))
+	}
+	return inner, m, nil
+}
+
+func (d *dkg) shareRecoveryMessages() *shareRecoveryMessages {
+	m := &shareRecoveryMessages{}
+	if d.recovery != nil {
+		m.request = d.recovery.marshaled
+	}
+	players := make([]player_idx.PlayerIdx, 0, len(d.recoveryResponses))
+	for p := range d.recoveryResponses {
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].Less(&players[j])
+	})
+	for _, p := range players {
+		m.contributions = append(m.contributions, d.recoveryResponses[p].contribution)
+	}
+	return m
+}
+
+func (d *dkg) processShareRecoveryRequests(observations []*observation) {
+	for _, o := range observations {
+		if o.sender.Equal(d.selfIdx) {
+			continue
+		}
+		if o.recovery == nil || len(o.recovery.request) == 0 {
+			delete(d.recoveryResponses, *o.sender)
+			continue
+		}
+		if !d.completed || d.keyData == nil {
+			continue
+		}
+		r, err := d.unmarshalShareRecoveryRequest(o.sender, o.recovery.request)
+		if err != nil {
+			d.logger.Warn("ignoring invalid share recovery request", commontypes.LogFields{
+				"err": err, "recipient": o.sender,
+			})
+			continue
+		}
+		if r.helperPosition(d.selfIdx) < 0 {
+			delete(d.recoveryResponses, *o.sender)
+			continue
+		}
+		if resp, ok := d.recoveryResponses[*o.sender]; ok && resp.session == r.session {
+			continue
+		}
+		c, err := d.shareRecoveryContribution(r)
+		if err != nil {
+			d.logger.Error("could not construct share recovery contribution",
+				commontypes.LogFields{"err": err, "recipient": o.sender},
+			)
+			continue
+		}
+		d.logger.Info("sending share recovery contribution", commontypes.LogFields{
+			"recipient": o.sender, "session": r.session,
+		})
+		d.recoveryResponses[*o.sender] = &shareRecoveryResponse{r.session, c}
+	}
+}
+
+func (d *dkg) shareRecoveryContribution(r *shareRecoveryRequest) ([]byte, error) {
+	pos := r.helperPosition(d.selfIdx)
+	g := d.encryptionGroup
+	coeffs, err := player_idx.LagrangeCoefficientsAt(g, r.helpers, r.recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute share recovery coefficients")
+	}
+	subShare := g.Scalar().Mul(coeffs[pos], d.keyData.SecretShare.share)
+	masks := make([]kyber.Point, 0, len(r.helpers)-1)
+	for other := range r.helpers {
+		if other == pos {
+			continue
+		}
+		mask, err := d.shareRecoveryMask(r, pos, other)
+		if err != nil {
+			return nil, err
+		}
+		if other > pos {
+			subShare = g.Scalar().Add(subShare, mask)
+		} else {
+			subShare = g.Scalar().Sub(subShare, mask)
+		}
+		maskPK, err := d.translator.TranslateKey(mask)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not translate share recovery mask")
+		}
+		masks = append(masks, maskPK)
+	}
+	translated, err := d.translator.TranslateKey(subShare)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not translate share recovery contribution")
+	}
+	ct, _, err := ciphertext.Encrypt(
+		r.domainSep(d.selfIdx), g, kshare.NewPriPoly(g, 1, subShare, g.RandomStream()),
+		r.recipient, r.epk,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encrypt share recovery contribution")
+	}
+	c := &shareRecoveryContribution{
+		r.recipient, r.session, g.Point().Mul(subShare, nil), translated, masks, ct,
+	}
+	return c.marshal()
+}
+
+func (d *dkg) shareRecoveryMask(
+	r *shareRecoveryRequest, pos, other int,
+) (kyber.Scalar, error) {
+	g := d.encryptionGroup
+	peer := r.helpers[other]
+	dh, err := d.esk.SharedSecrets([]kyber.Point{peer.Index(d.epks).(kyber.Point)})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute share recovery mask key")
+	}
+	dhB, err := dh[0].MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal share recovery mask key")
+	}
+	lo, hi := r.helpers[pos], peer
+	if other < pos {
+		lo, hi = hi, lo
+	}
+	seed := bytes.Join(
+		[][]byte{[]byte("share recovery mask"), r.session[:], lo.Marshal(), hi.Marshal(), dhB},
+		nil,
+	)
+	return g.Scalar().Pick(g.XOF(seed)), nil
+}
+
+func (d *dkg) progressShareRecovery(
+	ctx context.Context, observations []*observation,
+) error {
+	s := d.recovery
+	kd, err := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
+	if err != nil {
+		return errors.Wrap(err, "could not get key data while recovering key share")
+	}
+	if len(d.missingShareRecords(kd.Hashes)) > 0 {
+		return nil
+	}
+	if s.publicShares == nil {
+		s.publicShares, err = d.reportedPublicShares(&kd)
+		if err != nil {
+			return errors.Wrap(err, "could not compute public shares for share recovery")
+		}
+	}
+	s.seen = map[player_idx.PlayerIdx]bool{}
+	for _, o := range observations {
+		s.seen[*o.sender] = true
+	}
+	if s.request != nil {
+		for _, o := range observations {
+			if o.recovery == nil {
+				continue
+			}
+			for _, cB := range o.recovery.contributions {
+				d.receiveShareRecoveryContribution(o.sender, cB)
+			}
+		}
+		if len(s.subShares) == len(s.request.helpers) {
+			return d.finishShareRecovery(ctx, &kd)
+		}
+	}
+	if s.request == nil || s.rounds >= shareRecoveryRounds {
+		if s.request != nil {
+			for _, h := range s.request.helpers {
+				if _, ok := s.subShares[*h]; !ok {
+					s.excluded[*h] = true
+				}
+			}
+		}
+		return d.requestShareRecovery()
+	}
+	s.rounds++
+	return nil
+}
+
+func (d *dkg) reportedPublicShares(kd *contract.KeyData) ([]kyber.Point, error) {
+	var shares []kyber.Point
+	if !d.shareSets.allKeysPresent(kd.Hashes) && d.transcript.covers(kd.Hashes) {
+		if err := d.checkTranscript(kd, d.transcript.transcript); err != nil {
+			return nil, errors.Wrapf(err, "reported key is invalid for %s", d.mode)
+		}
+		shares = d.transcript.transcript.PublicShares()
+	} else {
+		weights, err := d.checkReportedKey(kd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reported key is invalid for %s", d.mode)
+		}
+		shares, err = d.shareSets.recoverPublicShares(kd, weights)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get public shares")
+		}
+	}
+	if d.mode == refreshKey {
+		if d.lastKeyData == nil || len(d.lastKeyData.Shares) != len(shares) {
+			return nil, errors.Errorf("public shares of the refreshed key are unavailable")
+		}
+		for i, s := range shares {
+			shares[i] = s.Clone().Add(d.lastKeyData.Shares[i].V, s)
+		}
+	}
+	return shares, nil
+}
+
+func (d *dkg) requestShareRecovery() error {
+	s := d.recovery
+	helpers, err := s.chooseHelpers(d.selfIdx, player_idx.Int(len(d.epks)), d.t)
+	if err != nil {
+		return err
+	}
+	r, err := d.newShareRecoveryRequest(d.selfIdx, s.epk, helpers)
+	if err != nil {
+		return errors.Wrap(err, "could not construct share recovery request")
+	}
+	s.marshaled, err = r.marshal()
+	if err != nil {
+		return err
+	}
+	s.request, s.rounds = r, 0
+	s.contributions = map[player_idx.PlayerIdx]*shareRecoveryContribution{}
+	s.subShares = map[player_idx.PlayerIdx]kyber.Scalar{}
+	d.logger.Info("requesting share recovery", commontypes.LogFields{
+		"helpers": helpers, "session": r.session,
+	})
+	return nil
+}
+
+func (s *shareRecovery) chooseHelpers(
+	self *player_idx.PlayerIdx, n, t player_idx.Int,
+) ([]*player_idx.PlayerIdx, error) {
+	players, err := player_idx.PlayerIdxs(n)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list players for share recovery")
+	}
+	var seen, unseen []*player_idx.PlayerIdx
+	for _, p := range players {
+		if p.Equal(self) || s.excluded[*p] {
+			continue
+		}
+		if s.seen[*p] {
+			seen = append(seen, p)
+		} else {
+			unseen = append(unseen, p)
+		}
+	}
+	candidates := append(seen, unseen...)
+	if len(candidates) < int(t)+1 {
+		if len(s.excluded) == 0 {
+			return nil, errors.Errorf("not enough players to recover share")
+		}
+		s.excluded = map[player_idx.PlayerIdx]bool{}
+		return s.chooseHelpers(self, n, t)
+	}
+	helpers := candidates[:int(t)+1]
+	sort.Slice(helpers, func(i, j int) bool {
+		return helpers[i].Less(helpers[j])
+	})
+	return helpers, nil
+}
+
+func (d *dkg) receiveShareRecoveryContribution(sender *player_idx.PlayerIdx, cB []byte) {
+	s := d.recovery
+	r := s.request
+	pos := r.helperPosition(sender)
+	if pos < 0 {
+		return
+	}
+	if _, ok := s.subShares[*sender]; ok {
+		return
+	}
+	c, err := d.unmarshalShareRecoveryContribution(cB)
+	if err != nil || !c.recipient.Equal(d.selfIdx) || c.session != r.session {
+		return
+	}
+	subShare, err := d.verifyShareRecoveryContribution(r, pos, c)
+	if err == nil {
+		err = s.checkMasks(r, pos, c)
+	}
+	if err != nil {
+		d.logger.Warn("rejecting share recovery contribution", commontypes.LogFields{
+			"err": err, "helper": sender,
+		})
+		s.excluded[*sender] = true
+		s.rounds = shareRecoveryRounds
+		return
+	}
+	s.contributions[*sender] = c
+	s.subShares[*sender] = subShare
+}
+
+func (d *dkg) verifyShareRecoveryContribution(
+	r *shareRecoveryRequest, pos int, c *shareRecoveryContribution,
+) (kyber.Scalar, error) {
+	helper := r.helpers[pos]
+	if len(c.masks) != len(r.helpers)-1 {
+		return nil, errors.Errorf(
+			"expected %d masks, got %d", len(r.helpers)-1, len(c.masks),
+		)
+	}
+	err := d.translator.VerifyTranslation(c.commitment, c.translated)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad translation of contribution commitment")
+	}
+	coeffs, err := player_idx.LagrangeCoefficientsAt(d.encryptionGroup, r.helpers, r.recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute share recovery coefficients")
+	}
+	publicShare := helper.Index(d.recovery.publicShares).(kyber.Point)
+	expected := d.translationGroup.Point().Mul(coeffs[pos], publicShare)
+	for other := range r.helpers {
+		if other == pos {
+			continue
+		}
+		if other > pos {
+			expected = d.translationGroup.Point().Add(expected, c.maskFor(pos, other))
+		} else {
+			expected = d.translationGroup.Point().Sub(expected, c.maskFor(pos, other))
+		}
+	}
+	if !expected.Equal(c.translated) {
+		return nil, errors.Errorf("contribution does not match helper's public share")
+	}
+	subShare, err := c.cipherText.Decrypt(
+		d.esk, d.encryptionGroup, r.domainSep(helper), c.commitment, *r.recipient,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt share recovery contribution")
+	}
+	return subShare, nil
+}
+
+func (s *shareRecovery) checkMasks(
+	r *shareRecoveryRequest, pos int, c *shareRecoveryContribution,
+) error {
+	for other, h := range r.helpers {
+		c2, ok := s.contributions[*h]
+		if other == pos || !ok {
+			continue
+		}
+		if !c.maskFor(pos, other).Equal(c2.maskFor(other, pos)) {
+			s.excluded[*h] = true
+			return errors.Errorf("mask disagrees with helper %s", h)
+		}
+	}
+	return nil
+}
+
+func (d *dkg) finishShareRecovery(ctx context.Context, kd *contract.KeyData) error {
+	s := d.recovery
+	secretShare := d.encryptionGroup.Scalar().Zero()
+	for _, subShare := range s.subShares {
+		secretShare = d.encryptionGroup.Scalar().Add(secretShare, subShare)
+	}
+	publicShare, err := d.translator.TranslateKey(secretShare)
+	if err != nil {
+		return errors.Wrap(err, "could not translate recovered share")
+	}
+	if !publicShare.Equal(d.selfIdx.Index(s.publicShares).(kyber.Point)) {
+		s.rounds = shareRecoveryRounds
+		return errors.Errorf("recovered share does not match public share")
+	}
+	d.logger.Info("recovered key share from peers", commontypes.LogFields{
+		"session": s.request.session,
+	})
+	recovered := d.selfIdx.PriShare(secretShare)
+	err = d.completeKey(
+		ctx, kd, []*kshare.PriShare{&recovered}, s.publicShares, "recovered share from peers",
+	)
+	if err != nil {
+		return err
+	}
+	d.recovery = nil
+	return nil
+}
@@ -0,0 +1,222 @@
+package dkg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/internal/util"
+)
+
+// recoveryPlayers returns a DKG instance for each of n players holding shares
+// of a key with threshold t, and the public shares of that key.
+func recoveryPlayers(t *testing.T, n, threshold int) ([]*dkg, []kyber.Point) {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	translator := translatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+	translationGroup, err := translator.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players, err := player_idx.PlayerIdxs(player_idx.Int(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	poly := kshare.NewPriPoly(g, threshold+1, nil, g.RandomStream())
+	priShares := poly.Shares(n)
+	publicShares := make([]kyber.Point, n)
+	epks := make([]kyber.Point, n)
+	esks := make([]key_store.EncryptionKey, n)
+	for i, p := range players {
+		publicShares[i], err = translator.TranslateKey(p.Index(priShares).(*kshare.PriShare).V)
+		if err != nil {
+			t.Fatal(err)
+		}
+		esks[i] = testEncryptionKey(t)
+		epks[i] = esks[i].PublicKey()
+	}
+	ds := make([]*dkg, n)
+	for i, p := range players {
+		share := p.Index(priShares).(*kshare.PriShare).V
+		ds[i] = &dkg{
+			t:                 player_idx.Int(threshold),
+			selfIdx:           p,
+			cfgDgst:           types.ConfigDigest{1},
+			keyID:             contract.KeyID{2},
+			esk:               esks[i],
+			epks:              epks,
+			encryptionGroup:   g,
+			translationGroup:  translationGroup,
+			translator:        translator,
+			completed:         true,
+			keyData:           &KeyData{SecretShare: &SecretShare{*p, share}},
+			recoveryResponses: map[player_idx.PlayerIdx]*shareRecoveryResponse{},
+			logger:            util.MakeLogger(),
+		}
+	}
+	return ds, publicShares
+}
+
+func testEncryptionKey(t *testing.T) key_store.EncryptionKey {
+	g := encryptionGroupRegistry["AltBN-128 G₁"]
+	k, err := key_store.NewInProcessKeyStore(g.Scalar().Pick(g.RandomStream()), nil).
+		EncryptionKey(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// startRecovery has the last player, with a fresh encryption key, request
+// recovery of its share.
+func startRecovery(
+	t *testing.T, ds []*dkg, publicShares []kyber.Point,
+) (*dkg, kyber.Scalar) {
+	recipient := ds[len(ds)-1]
+	lost := recipient.keyData.SecretShare.share
+	recipient.keyData, recipient.completed = nil, false
+	recipient.esk = testEncryptionKey(t)
+	recipient.recovery = newShareRecovery(recipient.esk.PublicKey())
+	recipient.recovery.publicShares = publicShares
+	if err := recipient.requestShareRecovery(); err != nil {
+		t.Fatal(err)
+	}
+	return recipient, lost
+}
+
+// contribute returns the contribution each helper would broadcast in response
+// to the recipient's request.
+func contribute(t *testing.T, ds []*dkg, recipient *dkg) map[player_idx.PlayerIdx][]byte {
+	rv := map[player_idx.PlayerIdx][]byte{}
+	for _, d := range ds {
+		obs := []*observation{{
+			sender:   recipient.selfIdx,
+			recovery: &shareRecoveryMessages{request: recipient.recovery.marshaled},
+		}}
+		d.processShareRecoveryRequests(obs)
+		if resp, ok := d.recoveryResponses[*recipient.selfIdx]; ok {
+			rv[*d.selfIdx] = resp.contribution
+		}
+	}
+	return rv
+}
+
+func TestShareRecovery(t *testing.T) {
+	ds, publicShares := recoveryPlayers(t, 4, 1)
+	recipient, lost := startRecovery(t, ds, publicShares)
+	contributions := contribute(t, ds, recipient)
+	if len(contributions) != 2 {
+		t.Fatalf("expected contributions from 2 helpers, got %d", len(contributions))
+	}
+	for helper, c := range contributions {
+		helper := helper
+		recipient.receiveShareRecoveryContribution(&helper, c)
+	}
+	recovered := recipient.encryptionGroup.Scalar().Zero()
+	for _, s := range recipient.recovery.subShares {
+		recovered = recovered.Add(recovered, s)
+	}
+	if len(recipient.recovery.subShares) != 2 || !recovered.Equal(lost) {
+		t.Fatal("contributions do not sum to the lost share")
+	}
+}
+
+func TestShareRecoveryExcludesBadHelper(t *testing.T) {
+	ds, publicShares := recoveryPlayers(t, 4, 1)
+	recipient, _ := startRecovery(t, ds, publicShares)
+	cheat := recipient.recovery.request.helpers[0]
+	for _, d := range ds {
+		if d.selfIdx.Equal(cheat) {
+			s := d.keyData.SecretShare
+			s.share = d.encryptionGroup.Scalar().Add(s.share, d.encryptionGroup.Scalar().One())
+		}
+	}
+	for helper, c := range contribute(t, ds, recipient) {
+		helper := helper
+		recipient.receiveShareRecoveryContribution(&helper, c)
+	}
+	s := recipient.recovery
+	if _, ok := s.subShares[*cheat]; ok || !s.excluded[*cheat] {
+		t.Fatal("contribution from a wrong share was not rejected")
+	}
+	if len(s.subShares) != 1 {
+		t.Fatal("honest helper's contribution was not accepted")
+	}
+	if err := recipient.requestShareRecovery(); err != nil {
+		t.Fatal(err)
+	}
+	if recipient.recovery.request.helperPosition(cheat) >= 0 {
+		t.Fatal("excluded helper asked again")
+	}
+}
+
+func TestShareRecoveryRequestChecksHelpers(t *testing.T) {
+	ds, _ := recoveryPlayers(t, 4, 1)
+	d := ds[0]
+	players, err := player_idx.PlayerIdxs(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	epk := testEncryptionKey(t).PublicKey()
+	for _, tc := range []struct {
+		name    string
+		helpers []*player_idx.PlayerIdx
+	}{
+		{"too few", players[1:2]},
+		{"recipient", players[0:2]},
+		{"duplicate", []*player_idx.PlayerIdx{players[1], players[1]}},
+		{"out of range", []*player_idx.PlayerIdx{players[1], players[4]}},
+	} {
+		if _, err := d.newShareRecoveryRequest(players[0], epk, tc.helpers); err == nil {
+			t.Errorf("accepted share recovery request with %s helpers", tc.name)
+		}
+	}
+
+	r, err := d.newShareRecoveryRequest(players[0], epk, players[1:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := r.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := d.unmarshalShareRecoveryRequest(players[0], m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.session != r.session {
+		t.Fatal("share recovery request changed in round trip")
+	}
+	if _, err := d.unmarshalShareRecoveryRequest(players[0], append(m, 0)); err == nil {
+		t.Fatal("accepted share recovery request with trailing bytes")
+	}
+}
+
+func TestShareRecoveryMessagesWrap(t *testing.T) {
+	inner := []byte("observation")
+	m := &shareRecoveryMessages{[]byte("request"), [][]byte{[]byte("a"), []byte("bc")}}
+	wrapped := m.wrap(inner)
+	if len(wrapped) > len(inner)+m.size() {
+		t.Fatalf("wrapped observation is %d bytes, size promised at most %d",
+			len(wrapped), len(inner)+m.size())
+	}
+	gotInner, got, err := unwrapShareRecovery(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotInner, inner) || !bytes.Equal(got.request, m.request) ||
+		len(got.contributions) != 2 || !bytes.Equal(got.contributions[1], []byte("bc")) {
+		t.Fatalf("share recovery messages changed in round trip: %q %+v", gotInner, got)
+	}
+	if !bytes.Equal((&shareRecoveryMessages{}).wrap(inner), inner) {
+		t.Fatal("empty share recovery messages changed observation")
+	}
+	if _, _, err := unwrapShareRecovery(wrapped[:len(wrapped)-1]); err == nil {
+		t.Fatal("accepted truncated share recovery messages")
+	}
+}
@@ -0,0 +1,93 @@
+package dkg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+type Phase uint8
+
+const (
+	Idle Phase = iota
+	AwaitingQuorum
+	RecoveringShare
+	Completed
+	Aborted
+)
+
+func (p Phase) String() string {
+	switch p {
+	case Idle:
+		return "idle"
+	case AwaitingQuorum:
+		return "awaiting quorum of share sets"
+	case RecoveringShare:
+		return "key onchain, recovering local share"
+	case Completed:
+		return "completed"
+	case Aborted:
+		return "aborted"
+	default:
+		return fmt.Sprintf("unknown phase %d", uint8(p))
+	}
+}
+
+type Status struct {
+	ConfigDigest types.ConfigDigest
+	KeyID        contract.KeyID
+	Phase        Phase
+
+	Dealing              bool
+	ShareRecordBroadcast bool
+
+	ValidShareSets    int
+	RequiredShareSets int
+	KnownShareSets    int
+
+	KeyOnchain    bool
+	MissingHashes []hash.Hash
+	Completed     bool
+
+	Attempt Attempt
+}
+
+func (d *dkg) status(ctx context.Context) (*Status, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	rv := &Status{
+		d.cfgDgst,
+		d.keyID,
+		AwaitingQuorum,
+		d.myShareRecord != nil,
+		d.shareRecordBroadcast.Load(),
+		d.validShareSets,
+		d.requiredShareSets(),
+		len(d.shareSets),
+		false,
+		nil,
+		d.completed,
+		d.attempt,
+	}
+	kd, err := d.contract.KeyData(ctx, d.keyID, d.cfgDgst)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get onchain key data for status")
+	}
+	rv.KeyOnchain = kd.PublicKey != nil && len(kd.Hashes) > 0
+	if rv.KeyOnchain {
+		rv.Phase = RecoveringShare
+		rv.MissingHashes = d.missingShareRecords(kd.Hashes)
+	}
+	if d.completed {
+		rv.Phase = Completed
+	} else if d.aborted {
+		rv.Phase = Aborted
+	}
+	return rv, nil
+}
@@ -0,0 +1,80 @@
+package dkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/persistence"
+	"github.com/smartcontractkit/chainlink-vrf/types/hash"
+)
+
+func checkPhase(t *testing.T, d *dkg, want Phase) *Status {
+	t.Helper()
+	s, err := d.status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Phase != want {
+		t.Fatalf("DKG in phase %q, expected %q", s.Phase, want)
+	}
+	return s
+}
+
+func TestStatusPhases(t *testing.T) {
+	d, c := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Hour, 0)
+	s := checkPhase(t, d, AwaitingQuorum)
+	if s.ConfigDigest != d.cfgDgst || s.KeyID != d.keyID || s.KeyOnchain ||
+		s.RequiredShareSets != 2 || s.Dealing || s.Completed ||
+		s.Attempt.Outcome != AttemptRunning {
+		t.Fatalf("unexpected status before key reported: %+v", s)
+	}
+
+	c.report(d.translationGroup)
+	s = checkPhase(t, d, RecoveringShare)
+	if !s.KeyOnchain || len(s.MissingHashes) != 1 || s.MissingHashes[0] != (hash.Hash{1}) {
+		t.Fatalf("unexpected status once key reported: %+v", s)
+	}
+
+	// As recovering the key would.
+	d.lock.Lock()
+	d.completed = true
+	d.finishAttemptLocked(AttemptCompleted, "key recovered")
+	d.lock.Unlock()
+	s = checkPhase(t, d, Completed)
+	if !s.Completed || s.Attempt.Outcome != AttemptCompleted {
+		t.Fatalf("unexpected status once completed: %+v", s)
+	}
+	// A completed DKG can't be aborted.
+	d.abort("too late")
+	checkPhase(t, d, Completed)
+}
+
+func TestStatusPhasesAborted(t *testing.T) {
+	d, c := lifecyclePlayer(t, persistence.NewMemorySharePersistence(), time.Hour, 0)
+	d.abort("operator request")
+	checkPhase(t, d, Aborted)
+	// Aborting takes precedence over a key reported afterwards.
+	c.report(d.translationGroup)
+	s := checkPhase(t, d, Aborted)
+	if !s.KeyOnchain || s.Attempt.Outcome != AttemptAborted {
+		t.Fatalf("unexpected status of aborted DKG: %+v", s)
+	}
+}
+
+func TestFactoryStatusIdle(t *testing.T) {
+	f := &dkgReportingPluginFactory{completedKeys: newCompletedKeys()}
+	s, err := PluginStatus(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Phase != Idle || s.Phase.String() != "idle" {
+		t.Fatalf("factory with no DKG reported phase %q", s.Phase)
+	}
+	if _, err := PluginStatus(context.Background(), nil); err == nil {
+		t.Fatal("reported status of a plugin factory which isn't for DKG")
+	}
+	if got := Phase(9).String(); got != "unknown phase 9" {
+		t.Fatalf("unknown phase described as %q", got)
+	}
+}
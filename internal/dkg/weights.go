@@ -0,0 +1,147 @@
+package dkg
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+
+	"go.dedis.ch/kyber/v3"
+)
+
+// A weighted committee gives the player at position i weights[i] key shares,
+// all encrypted to its one encryption key. Its shares follow those of the
+// players before it, so that share indices run from 1 to the total weight.
+// Nil weights give every player the one share at its own index.
+
+func checkWeights(weights []player_idx.Int, n int) error {
+	if len(weights) == 0 {
+		return nil
+	}
+	if len(weights) != n {
+		return errors.Errorf("got %d weights for %d players", len(weights), n)
+	}
+	total := 0
+	for i, w := range weights {
+		if w == 0 {
+			return errors.Errorf("player %d has zero weight", i)
+		}
+		total += int(w)
+	}
+	if total > int(player_idx.MaxPlayer) {
+		return errors.Errorf(
+			"total weight %d exceeds maximum of %d shares", total, player_idx.MaxPlayer,
+		)
+	}
+	return nil
+}
+
+// checkWeightedMode rejects the DKG modes which assume one share per player.
+// Refresh and reshare deal from a previous key's single shares, and
+// bias-resistant dealing reveals and reconstructs single shares.
+func checkWeightedMode(weights []player_idx.Int, mode dkgMode, biasResistant bool) error {
+	if len(weights) == 0 {
+		return nil
+	}
+	if mode != freshKey {
+		return errors.Errorf("weighted committees don't support %s", mode)
+	}
+	if biasResistant {
+		return errors.Errorf("weighted committees don't support bias-resistant dealing")
+	}
+	return nil
+}
+
+func unmarshalWeights(ws []uint32, n int) ([]player_idx.Int, error) {
+	if len(ws) == 0 {
+		return nil, nil
+	}
+	weights := make([]player_idx.Int, len(ws))
+	for i, w := range ws {
+		if w > uint32(player_idx.MaxPlayer) {
+			return nil, errors.Errorf("weight %d of player %d out of range", w, i)
+		}
+		weights[i] = player_idx.Int(w)
+	}
+	if err := checkWeights(weights, n); err != nil {
+		return nil, errors.Wrap(err, "invalid player weights")
+	}
+	return weights, nil
+}
+
+func weightAt(weights []player_idx.Int, i int) int {
+	if len(weights) == 0 {
+		return 1
+	}
+	return int(weights[i])
+}
+
+// shareCounts returns the total number of shares among n players, and the
+// most shares that f faulty players can hold between them. These take the
+// place of n and f when choosing and checking the threshold.
+func shareCounts(weights []player_idx.Int, n, f int) (total, faulty int) {
+	if len(weights) == 0 {
+		return n, f
+	}
+	sorted := make([]int, len(weights))
+	for i, w := range weights {
+		sorted[i] = int(w)
+		total += sorted[i]
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	for i := 0; i < f && i < len(sorted); i++ {
+		faulty += sorted[i]
+	}
+	return total, faulty
+}
+
+// heldShares returns the indices of the key shares held by each of n players.
+func heldShares(weights []player_idx.Int, n int) ([][]*player_idx.PlayerIdx, error) {
+	total, _ := shareCounts(weights, n, 0)
+	shares, err := player_idx.PlayerIdxs(player_idx.Int(total))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not construct share indices")
+	}
+	rv := make([][]*player_idx.PlayerIdx, n)
+	next := 0
+	for i := range rv {
+		w := weightAt(weights, i)
+		rv[i] = shares[next : next+w]
+		next += w
+	}
+	return rv, nil
+}
+
+// shareKeys lists the encryption key for each key share.
+func shareKeys(epks []kyber.Point, weights []player_idx.Int) []kyber.Point {
+	if len(weights) == 0 {
+		return epks
+	}
+	rv := make([]kyber.Point, 0, len(epks))
+	for i, epk := range epks {
+		for j := 0; j < int(weights[i]); j++ {
+			rv = append(rv, epk)
+		}
+	}
+	return rv
+}
+
+func (d *dkg) shareEpks() []kyber.Point {
+	return shareKeys(d.epks, d.weights)
+}
+
+func (d *dkg) weight(p *player_idx.PlayerIdx) int {
+	if len(d.weights) == 0 {
+		return 1
+	}
+	return int(p.Index(d.weights).(player_idx.Int))
+}
+
+func (d *dkg) ownShares() ([]*player_idx.PlayerIdx, error) {
+	shares, err := heldShares(d.weights, len(d.epks))
+	if err != nil {
+		return nil, err
+	}
+	return d.selfIdx.Index(shares).([]*player_idx.PlayerIdx), nil
+}
@@ -0,0 +1,51 @@
+package dkg
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+)
+
+func TestWeightedCommitteesOnlySupportFreshKeys(t *testing.T) {
+	weights := []player_idx.Int{2, 1, 1, 1}
+	if err := checkWeightedMode(weights, freshKey, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name          string
+		mode          dkgMode
+		biasResistant bool
+	}{
+		{"key refresh", refreshKey, false},
+		{"key reshare", reshareKey, false},
+		{"bias-resistant dealing", freshKey, true},
+	} {
+		if checkWeightedMode(weights, tc.mode, tc.biasResistant) == nil {
+			t.Errorf("weighted committee accepted %s", tc.name)
+		}
+		if err := checkWeightedMode(nil, tc.mode, tc.biasResistant); err != nil {
+			t.Errorf("unweighted committee rejected %s: %s", tc.name, err)
+		}
+	}
+}
+
+func TestWeightedCommitteesRejectShareRecovery(t *testing.T) {
+	ds, _ := recoveryPlayers(t, 4, 1)
+	d := ds[0]
+	d.weights = []player_idx.Int{1, 1, 1, 1}
+	players, err := player_idx.PlayerIdxs(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	epk := testEncryptionKey(t).PublicKey()
+	if _, err := d.newShareRecoveryRequest(players[0], epk, players[1:3]); err == nil {
+		t.Fatal("weighted committee accepted a share recovery request")
+	}
+}
+
+func TestWeightedCommitteesRejectKeyBackups(t *testing.T) {
+	b := &keyBackup{keyData: &KeyData{Weights: []player_idx.Int{2, 1, 1, 1}}}
+	if b.checkConsistency() == nil {
+		t.Fatal("accepted key backup of a weighted committee")
+	}
+}
@@ -1,8 +1,11 @@
 package pvss
 
 import (
+	"github.com/pkg/errors"
+
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
 
@@ -15,7 +18,18 @@ func NewShareSet(domainSep types.ConfigDigest, threshold player_idx.Int,
 	dealer *player_idx.PlayerIdx, group anon.Suite,
 	translation point_translation.PubKeyTranslation, pks []kyber.Point,
 ) (*ShareSet, error) {
-	return newShareSet(domainSep, threshold, dealer, group, translation, pks)
+	return newShareSet(domainSep, threshold, dealer, group, translation, pks, nil)
+}
+
+func NewShareSetWithSecret(domainSep types.ConfigDigest, threshold player_idx.Int,
+	dealer *player_idx.PlayerIdx, group anon.Suite,
+	translation point_translation.PubKeyTranslation, pks []kyber.Point,
+	secret kyber.Scalar,
+) (*ShareSet, error) {
+	if secret == nil {
+		return nil, errors.Errorf("no secret given for share set")
+	}
+	return newShareSet(domainSep, threshold, dealer, group, translation, pks, secret)
 }
 
 func (s *ShareSet) Marshal() (m []byte, err error) {
@@ -36,7 +50,7 @@ func (s *ShareSet) Verify(group anon.Suite, domainSep types.ConfigDigest, pks []
 
 func (s *ShareSet) Decrypt(
 	playerIdx player_idx.PlayerIdx,
-	sk kyber.Scalar,
+	sk key_store.EncryptionKey,
 	keyGroup anon.Suite,
 	domainSep types.ConfigDigest,
 ) (kshare.PriShare, error) {
@@ -46,6 +60,24 @@ func (s *ShareSet) Decrypt(
 	)
 }
 
+// ProveDecryption returns the shared secrets which decrypt the receiver's
+// share, with a proof that sk computed them.
+func (s *ShareSet) ProveDecryption(
+	receiver player_idx.PlayerIdx, sk key_store.EncryptionKey, domainSep types.ConfigDigest,
+) (blindingTerms []kyber.Point, proof []byte, err error) {
+	return s.proveDecryption(receiver, sk, domainSep)
+}
+
+// CheckDecryption decrypts the receiver's share with shared secrets from
+// ProveDecryption. It returns an error if their proof fails, and otherwise a
+// fault if the share doesn't decrypt to one matching the dealer's commitments.
+func (s *ShareSet) CheckDecryption(
+	receiver player_idx.PlayerIdx, domainSep types.ConfigDigest,
+	blindingTerms []kyber.Point, proof []byte,
+) (fault, err error) {
+	return s.checkDecryption(receiver, domainSep, blindingTerms, proof)
+}
+
 func (s *ShareSet) Dealer() (*player_idx.PlayerIdx, error) {
 	return s.dealer.Check()
 }
@@ -59,6 +91,147 @@ func (s *ShareSet) PublicKey() kyber.Point {
 	return s.pvssKey
 }
 
+func (s *ShareSet) SecretCommitment() kyber.Point {
+	return s.coeffCommitments.Commit()
+}
+
+func (s *ShareSet) Threshold() player_idx.Int {
+	_, commits := s.coeffCommitments.Info()
+	return player_idx.Int(len(commits) - 1)
+}
+
 func (s *ShareSet) PublicShares() []kyber.Point {
 	return s.publicShares()
 }
+
+func AggregateShareSets(shareSets ...*ShareSet) (*Transcript, error) {
+	return aggregateShareSets(shareSets)
+}
+
+func (t *Transcript) Marshal() ([]byte, error) {
+	return t.marshal()
+}
+
+func UnmarshalTranscript(
+	g anon.Suite, translationGroup kyber.Group, data []byte,
+	translation point_translation.PubKeyTranslation, pks []kyber.Point,
+) (t *Transcript, rem []byte, err error) {
+	return unmarshalTranscript(g, translationGroup, data, translation, pks)
+}
+
+func (t *Transcript) Verify(pks []kyber.Point) error {
+	return t.verify(pks)
+}
+
+func (t *Transcript) Decrypt(
+	playerIdx player_idx.PlayerIdx, sk key_store.EncryptionKey, keyGroup anon.Suite,
+) (kshare.PriShare, error) {
+	return t.decrypt(playerIdx, sk, keyGroup)
+}
+
+func (t *Transcript) Dealers() []*player_idx.PlayerIdx {
+	return t.dealers
+}
+
+func (t *Transcript) PublicKey() kyber.Point {
+	return t.pvssKey
+}
+
+func (t *Transcript) SecretCommitment() kyber.Point {
+	return t.coeffCommitments.Commit()
+}
+
+func (t *Transcript) Threshold() player_idx.Int {
+	_, commits := t.coeffCommitments.Info()
+	return player_idx.Int(len(commits) - 1)
+}
+
+func (t *Transcript) PublicShares() []kyber.Point {
+	return t.publicShares()
+}
+
+func NewHidingShareSet(domainSep types.ConfigDigest, threshold player_idx.Int,
+	dealer *player_idx.PlayerIdx, group anon.Suite,
+	translation point_translation.PubKeyTranslation, pks []kyber.Point,
+	pedersenBase kyber.Point,
+) (*HidingShareSet, error) {
+	return newHidingShareSet(
+		domainSep, threshold, dealer, group, translation, pks, pedersenBase,
+	)
+}
+
+func (s *HidingShareSet) Marshal() ([]byte, error) {
+	return s.marshal()
+}
+
+func UnmarshalHidingShareSet(
+	g anon.Suite, data []byte, translation point_translation.PubKeyTranslation,
+	pedersenBase kyber.Point, domainSep types.ConfigDigest, pks []kyber.Point,
+) (ss *HidingShareSet, rem []byte, err error) {
+	return unmarshalHidingShareSet(g, data, translation, pedersenBase, domainSep, pks)
+}
+
+func (s *HidingShareSet) Verify(
+	group anon.Suite, domainSep types.ConfigDigest, pks []kyber.Point,
+) error {
+	return s.verify(group, domainSep, pks)
+}
+
+func (s *HidingShareSet) Dealer() (*player_idx.PlayerIdx, error) {
+	return s.dealer.Check()
+}
+
+func UnmarshalHidingDealer(data []byte) (*player_idx.PlayerIdx, error) {
+	return unmarshalHidingDealer(data)
+}
+
+func (s *HidingShareSet) Threshold() player_idx.Int {
+	_, commits := s.hidingCommitments.Info()
+	return player_idx.Int(len(commits) - 1)
+}
+
+func (s *HidingShareSet) Equal(s2 *HidingShareSet) bool {
+	return s.equal(s2)
+}
+
+func (s *HidingShareSet) Reveal(pks []kyber.Point) (*ShareSet, error) {
+	return s.reveal(pks)
+}
+
+func (s *HidingShareSet) Opens(ss *ShareSet) bool {
+	return s.opens(ss)
+}
+
+func (s *HidingShareSet) Disclose(
+	playerIdx player_idx.PlayerIdx, sk key_store.EncryptionKey,
+) (*Disclosure, error) {
+	return s.disclose(playerIdx, sk)
+}
+
+func (s *HidingShareSet) VerifyDisclosure(d *Disclosure) error {
+	return s.verifyDisclosure(d)
+}
+
+func (s *HidingShareSet) Reconstruct(
+	domainSep types.ConfigDigest, pks []kyber.Point, disclosures []*Disclosure,
+) (*ShareSet, error) {
+	return s.reconstruct(domainSep, pks, disclosures)
+}
+
+func (s *ShareSet) HidingCommitments() *kshare.PubPoly {
+	return s.hidingCommitments
+}
+
+func (d *Disclosure) Marshal() ([]byte, error) {
+	return d.marshal()
+}
+
+func UnmarshalDisclosure(
+	g kyber.Group, receiver *player_idx.PlayerIdx, data []byte,
+) (*Disclosure, error) {
+	return unmarshalDisclosure(g, receiver, data)
+}
+
+func (d *Disclosure) Receiver() *player_idx.PlayerIdx {
+	return d.receiver
+}
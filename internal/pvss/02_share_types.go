@@ -6,6 +6,7 @@ import (
 
 	"go.dedis.ch/kyber/v3"
 	kshare "go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/sign/anon"
 )
 
 type ShareSet struct {
@@ -13,11 +14,15 @@ type ShareSet struct {
 
 	coeffCommitments *kshare.PubPoly
 
+	hidingCommitments *kshare.PubPoly
+
 	pvssKey kyber.Point
 
 	shares []*share
 
 	translation point_translation.PubKeyTranslation
 
+	group anon.Suite
+
 	xXXToxicWaste *kshare.PriPoly
 }
@@ -0,0 +1,63 @@
+package pvss
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+
+	"github.com/smartcontractkit/chainlink-vrf/altbn_128"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+)
+
+const benchmarkPlayers, benchmarkThreshold = 5, 2
+
+var benchmarkGroup = (&altbn_128.PairingSuite{}).G1().(anon.Suite)
+
+var benchmarkTranslation = point_translation.TranslatorRegistry["translator from AltBN-128 G₁ to AltBN-128 G₂"]
+
+func benchmarkKeys(b *testing.B) ([]*player_idx.PlayerIdx, []kyber.Point) {
+	players, err := player_idx.PlayerIdxs(benchmarkPlayers)
+	if err != nil {
+		b.Fatal(err)
+	}
+	pks := make([]kyber.Point, benchmarkPlayers)
+	for i := range pks {
+		pks[i] = benchmarkGroup.Point().Pick(benchmarkGroup.RandomStream())
+	}
+	return players, pks
+}
+
+func BenchmarkNewShareSet(b *testing.B) {
+	players, pks := benchmarkKeys(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := NewShareSet(
+			types.ConfigDigest{}, benchmarkThreshold, players[0], benchmarkGroup,
+			benchmarkTranslation, pks,
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifyShareSet(b *testing.B) {
+	players, pks := benchmarkKeys(b)
+	s, err := NewShareSet(
+		types.ConfigDigest{}, benchmarkThreshold, players[0], benchmarkGroup,
+		benchmarkTranslation, pks,
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.Verify(benchmarkGroup, types.ConfigDigest{}, pks); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
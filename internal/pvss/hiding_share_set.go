@@ -0,0 +1,286 @@
+package pvss
+
+import (
+	"bytes"
+	"crypto/cipher"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+// HidingShareSet is the first phase of a bias-resistant deal. It commits to the
+// dealer's secret polynomial f with Pedersen commitments a_k·G+b_k·H, which
+// reveal nothing about f, and encrypts each player's f(i) and blinding
+// evaluation b(i). Once the set of dealers is fixed, the dealer reveals the
+// Feldman commitments to f in a ShareSet which reuses the encryptions of f(i).
+type HidingShareSet struct {
+	dealer *player_idx.PlayerIdx
+
+	hidingCommitments *kshare.PubPoly
+
+	shares []*ciphertext.HidingCipherText
+
+	translation point_translation.PubKeyTranslation
+
+	group anon.Suite
+
+	pedersenBase kyber.Point
+
+	// secretPoly is only known to the dealer, and only kept in memory.
+	secretPoly *kshare.PriPoly
+}
+
+// Disclosure is a player's opening of its share of a hiding share set, which
+// it publishes when the dealer fails to reveal it.
+type Disclosure struct {
+	receiver *player_idx.PlayerIdx
+
+	secret, blinding kyber.Scalar
+}
+
+func newHidingShareSet(domainSep types.ConfigDigest, threshold player_idx.Int,
+	dealerIdx *player_idx.PlayerIdx, group anon.Suite,
+	translation point_translation.PubKeyTranslation, pks []kyber.Point,
+	pedersenBase kyber.Point,
+) (*HidingShareSet, error) {
+	if len(pks) < MinPlayers {
+		return nil, errors.Errorf("%d is not enough players", len(pks))
+	}
+	if int(threshold) >= len(pks) {
+		return nil, errors.Errorf(
+			"threshold %d cannot exceed number of players %d", threshold, len(pks),
+		)
+	}
+	players, err := player_idx.PlayerIdxs(player_idx.Int(len(pks)))
+	if err != nil {
+		return nil, err
+	}
+	secretPoly := kshare.NewPriPoly(group, int(threshold)+1, nil, group.RandomStream())
+	blindingPoly := kshare.NewPriPoly(group, int(threshold)+1, nil, group.RandomStream())
+	blindingCoeffs := blindingPoly.Coefficients()
+	commits := make([]kyber.Point, len(blindingCoeffs))
+	for k, a := range secretPoly.Coefficients() {
+		commits[k] = ciphertext.PedersenCommitment(group, pedersenBase, a, blindingCoeffs[k])
+	}
+	rv := &HidingShareSet{
+		dealerIdx, kshare.NewPubPoly(group, group.Point().Base(), commits),
+		make([]*ciphertext.HidingCipherText, len(pks)), translation, group, pedersenBase,
+		secretPoly,
+	}
+	edomain, err := shareSetDomainSep(domainSep, rv.hidingCommitments, dealerIdx)
+	if err != nil {
+		return nil, err
+	}
+	for shareIdx, receiver := range players {
+		pk := receiver.Index(pks).(kyber.Point)
+		rv.shares[shareIdx], err = ciphertext.EncryptHiding(
+			receiverDomainSep(edomain, receiver), group, secretPoly, blindingPoly, receiver,
+			pk, pedersenBase,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while constructing hiding share set")
+		}
+	}
+	return rv, nil
+}
+
+func (s *HidingShareSet) verify(
+	group anon.Suite, domainSep types.ConfigDigest, pks []kyber.Point,
+) error {
+	if _, commits := s.hidingCommitments.Info(); len(commits) < 1 {
+		return errors.Errorf("need at least one hiding commitment in a valid share set")
+	}
+	if len(pks) > int(player_idx.MaxPlayer) {
+		return errors.Errorf("Can't handle %d players; %d is max", len(pks), player_idx.MaxPlayer)
+	}
+	if len(s.shares) != len(pks) {
+		return errors.Errorf(
+			"hiding share set has %d shares, expected %d", len(s.shares), len(pks),
+		)
+	}
+	players, err := player_idx.PlayerIdxs(player_idx.Int(len(pks)))
+	if err != nil {
+		return errors.Wrap(err, "could not get list of player indices")
+	}
+	edomain, err := shareSetDomainSep(domainSep, s.hidingCommitments, s.dealer)
+	if err != nil {
+		return errors.Wrap(err, "failed to construct domain separator for PVSS proofs")
+	}
+	batch := ciphertext.NewBatchVerifier(group)
+	for shareIdx, sh := range s.shares {
+		p := players[shareIdx]
+		err := batch.AddHiding(
+			sh, receiverDomainSep(edomain, p), p.Index(pks).(kyber.Point), s.pedersenBase,
+			p.EvalPoint(s.hidingCommitments),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "could not verify hiding share for player %s", p)
+		}
+	}
+	return errors.Wrap(batch.Verify(), "could not verify every share in hiding share set")
+}
+
+// reveal returns the share set which opens s, with Feldman commitments to the
+// secret polynomial. Only the dealer of s can construct it.
+func (s *HidingShareSet) reveal(pks []kyber.Point) (*ShareSet, error) {
+	if s.secretPoly == nil {
+		return nil, errors.Errorf("secret polynomial for hiding share set is unknown")
+	}
+	players, err := player_idx.PlayerIdxs(player_idx.Int(len(s.shares)))
+	if err != nil {
+		return nil, err
+	}
+	pvssKey, err := s.translation.TranslateKey(s.secretPoly.Secret())
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not translate dealer's additive share")
+	}
+	rv := &ShareSet{
+		s.dealer, s.secretPoly.Commit(nil), s.hidingCommitments, pvssKey,
+		make([]*share, len(s.shares)), s.translation, s.group, nil,
+	}
+	for shareIdx, receiver := range players {
+		subKeyTranslation, err := s.translation.TranslateKey(receiver.Eval(s.secretPoly))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not translate pub key for secret share")
+		}
+		rv.shares[shareIdx] = &share{
+			s.shares[shareIdx].SecretCipherText(), receiver.Index(pks).(kyber.Point),
+			subKeyTranslation, rv,
+		}
+	}
+	return rv, nil
+}
+
+// opens reports whether ss is the revelation of s.
+func (s *HidingShareSet) opens(ss *ShareSet) bool {
+	if ss == nil || !s.dealer.Equal(ss.dealer) ||
+		!equalHidingCommitments(s.hidingCommitments, ss.hidingCommitments) ||
+		len(s.shares) != len(ss.shares) {
+		return false
+	}
+	for i, sh := range s.shares {
+		if !sh.SecretCipherText().Equal(ss.shares[i].cipherText) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *HidingShareSet) disclose(
+	receiver player_idx.PlayerIdx, sk key_store.EncryptionKey,
+) (*Disclosure, error) {
+	secret, blinding, err := receiver.Index(s.shares).(*ciphertext.HidingCipherText).Open(
+		sk, s.group, s.pedersenBase, receiver.EvalPoint(s.hidingCommitments),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open hiding share")
+	}
+	return &Disclosure{&receiver, secret, blinding}, nil
+}
+
+func (s *HidingShareSet) verifyDisclosure(d *Disclosure) error {
+	if d == nil || d.receiver.NonZero() != nil ||
+		!d.receiver.AtMost(player_idx.Int(len(s.shares))) {
+		return errors.Errorf("disclosure is for an unknown player")
+	}
+	commitment := ciphertext.PedersenCommitment(s.group, s.pedersenBase, d.secret, d.blinding)
+	if !commitment.Equal(d.receiver.EvalPoint(s.hidingCommitments)) {
+		return errors.Errorf("disclosure from player %s does not open its hiding share", d.receiver)
+	}
+	return nil
+}
+
+// reconstruct recovers the dealer's secret from the disclosures, and deals it
+// again. The new deal uses randomness derived from s, so every player
+// reconstructs the same share set. The dealer's contribution to the key is
+// public at this point, so this leaks nothing more.
+func (s *HidingShareSet) reconstruct(
+	domainSep types.ConfigDigest, pks []kyber.Point, disclosures []*Disclosure,
+) (*ShareSet, error) {
+	_, commits := s.hidingCommitments.Info()
+	threshold := len(commits) - 1
+	var receivers []*player_idx.PlayerIdx
+	var opened []*Disclosure
+	seen := make(map[player_idx.PlayerIdx]bool, len(disclosures))
+	for _, d := range disclosures {
+		if err := s.verifyDisclosure(d); err != nil {
+			return nil, err
+		}
+		if !seen[*d.receiver] && len(opened) <= threshold {
+			seen[*d.receiver] = true
+			receivers = append(receivers, d.receiver)
+			opened = append(opened, d)
+		}
+	}
+	if len(opened) <= threshold {
+		return nil, errors.Errorf(
+			"need %d disclosures to reconstruct share set, got %d",
+			threshold+1, len(opened),
+		)
+	}
+	coeffs, err := player_idx.LagrangeCoefficients(s.group, receivers)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not interpolate disclosures")
+	}
+	secret, blinding := s.group.Scalar().Zero(), s.group.Scalar().Zero()
+	for i, d := range opened {
+		secret.Add(secret, s.group.Scalar().Mul(coeffs[i], d.secret))
+		blinding.Add(blinding, s.group.Scalar().Mul(coeffs[i], d.blinding))
+	}
+	commitment := ciphertext.PedersenCommitment(s.group, s.pedersenBase, secret, blinding)
+	if !commitment.Equal(s.hidingCommitments.Commit()) {
+		return nil, errors.Errorf("recovered secret does not match hiding commitments")
+	}
+	seed, err := shareSetDomainSep(domainSep, s.hidingCommitments, s.dealer)
+	if err != nil {
+		return nil, err
+	}
+	seeded := &seededSuite{s.group, s.group.XOF(seed)}
+	secretPoly := kshare.NewPriPoly(seeded, threshold+1, secret, seeded.RandomStream())
+	rv, err := dealShareSet(
+		domainSep, s.dealer, seeded, s.translation, pks, secretPoly, s.hidingCommitments,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not deal reconstructed share set")
+	}
+	rv.group = s.group
+	return rv, nil
+}
+
+func (s *HidingShareSet) equal(s2 *HidingShareSet) bool {
+	if s == nil || s2 == nil || !s.dealer.Equal(s2.dealer) ||
+		!equalHidingCommitments(s.hidingCommitments, s2.hidingCommitments) ||
+		len(s.shares) != len(s2.shares) {
+		return false
+	}
+	for i, sh := range s.shares {
+		if !sh.Equal(s2.shares[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// seededSuite draws all its randomness from a fixed stream.
+type seededSuite struct {
+	anon.Suite
+	stream cipher.Stream
+}
+
+func (s *seededSuite) RandomStream() cipher.Stream {
+	return s.stream
+}
+
+func receiverDomainSep(domainSep []byte, receiver *player_idx.PlayerIdx) []byte {
+	return bytes.Join([][]byte{domainSep, receiver.Marshal()}, nil)
+}
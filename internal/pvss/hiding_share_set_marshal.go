@@ -0,0 +1,127 @@
+package pvss
+
+import (
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+)
+
+const hidingShareSetHeaderFields = 2
+
+func (s *HidingShareSet) marshal() ([]byte, error) {
+	if s == nil {
+		return nil, errors.Errorf("attempt to marshal non-existent hiding share set")
+	}
+	if s.dealer == nil {
+		return nil, errors.Errorf("can't marshal hiding share set with no dealer specified")
+	}
+	if len(s.shares) > int(player_idx.MaxPlayer) {
+		return nil, errors.Errorf("too many shares to marshal")
+	}
+	rv := make([][]byte, hidingShareSetHeaderFields, hidingShareSetHeaderFields+len(s.shares))
+	rv[0] = s.dealer.Marshal()
+	var err error
+	rv[1], err = (&pubPoly{s.hidingCommitments}).marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal hiding commitments")
+	}
+	for _, sh := range s.shares {
+		shB, err := sh.Marshal()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal share in hiding share set")
+		}
+		rv = append(rv, shB)
+	}
+	return envelope.New(envelope.HidingShareSet, []kyber.Group{s.group}, rv...).Marshal()
+}
+
+func unmarshalHidingShareSet(
+	g anon.Suite, data []byte, translation point_translation.PubKeyTranslation,
+	pedersenBase kyber.Point, domainSep types.ConfigDigest, pks []kyber.Point,
+) (*HidingShareSet, []byte, error) {
+	e, rem, err := envelope.Unmarshal(data, envelope.HidingShareSet)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal hiding share set")
+	}
+	if err := e.CheckGroups(g); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal hiding share set")
+	}
+	if err := e.CheckNumFields(hidingShareSetHeaderFields); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal hiding share set")
+	}
+	sharesB := e.Fields[hidingShareSetHeaderFields:]
+	if len(sharesB) > int(player_idx.MaxPlayer) {
+		return nil, nil, errors.Errorf("too many shares in marshalled hiding share set")
+	}
+	dealer, err := unmarshalDealerField(e.Fields[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	hidingCommitments, pubPolyRem, err := unmarshalPubPoly(g, e.Fields[1])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal hiding commitments")
+	}
+	if len(pubPolyRem) > 0 {
+		return nil, nil, errors.Errorf("overage in hiding commitments for hiding share set")
+	}
+	ss := &HidingShareSet{
+		dealer, hidingCommitments.PubPoly, make([]*ciphertext.HidingCipherText, len(sharesB)),
+		translation, g, pedersenBase, nil,
+	}
+	for i, shB := range sharesB {
+		if ss.shares[i], err = ciphertext.UnmarshalHiding(g, shB); err != nil {
+			return nil, nil, errors.Wrap(err, "could not unmarshal shares in hiding share set")
+		}
+	}
+	if err := ss.verify(g, domainSep, pks); err != nil {
+		return nil, nil, errors.Wrap(err, "unmarshaled to invalid hiding share set")
+	}
+	return ss, rem, nil
+}
+
+func (d *Disclosure) marshal() ([]byte, error) {
+	secret, err := d.secret.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal disclosed secret share")
+	}
+	blinding, err := d.blinding.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal disclosed blinding share")
+	}
+	return append(secret, blinding...), nil
+}
+
+func unmarshalDisclosure(
+	g kyber.Group, receiver *player_idx.PlayerIdx, data []byte,
+) (*Disclosure, error) {
+	if len(data) != 2*g.ScalarLen() {
+		return nil, errors.Errorf(
+			"disclosure is %d bytes, need %d", len(data), 2*g.ScalarLen(),
+		)
+	}
+	rv := &Disclosure{receiver, g.Scalar(), g.Scalar()}
+	if err := rv.secret.UnmarshalBinary(data[:g.ScalarLen()]); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal disclosed secret share")
+	}
+	if err := rv.blinding.UnmarshalBinary(data[g.ScalarLen():]); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal disclosed blinding share")
+	}
+	return rv, nil
+}
+
+func unmarshalHidingDealer(data []byte) (*player_idx.PlayerIdx, error) {
+	e, _, err := envelope.Unmarshal(data, envelope.HidingShareSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal hiding share set dealer")
+	}
+	if err := e.CheckNumFields(hidingShareSetHeaderFields); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal hiding share set dealer")
+	}
+	return unmarshalDealerField(e.Fields[0])
+}
@@ -0,0 +1,125 @@
+package pvss
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"go.dedis.ch/kyber/v3"
+
+	"github.com/smartcontractkit/chainlink-vrf/altbn_128"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+)
+
+func TestHidingShareSet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	g := benchmarkGroup
+	translationGroup, err := benchmarkTranslation.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players, err := player_idx.PlayerIdxs(benchmarkPlayers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esks := make([]key_store.EncryptionKey, benchmarkPlayers)
+	pks := make([]kyber.Point, benchmarkPlayers)
+	for i := range esks {
+		esks[i], err = key_store.NewInProcessKeyStore(g.Scalar().Pick(g.RandomStream()), nil).
+			EncryptionKey(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pks[i] = esks[i].PublicKey()
+	}
+	var seed [32]byte
+	copy(seed[:], "hiding share set test")
+	h := altbn_128.NewHashProof(seed).HashPoint
+	domainSep := types.ConfigDigest{1}
+	dealer := players[1]
+
+	hidden, err := NewHidingShareSet(
+		domainSep, benchmarkThreshold, dealer, g, benchmarkTranslation, pks, h,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := hidden.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := UnmarshalHidingShareSet(g, m, benchmarkTranslation, h, domainSep, pks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(hidden) {
+		t.Fatal("hiding share set differs after round trip")
+	}
+	_, _, err = UnmarshalHidingShareSet(
+		g, m, benchmarkTranslation, h, types.ConfigDigest{2}, pks,
+	)
+	if err == nil {
+		t.Fatal("hiding share set verified under the wrong config digest")
+	}
+
+	revealed, err := hidden.Reveal(pks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm, err := revealed.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revealed, _, err = UnmarshalShareSet(
+		g, translationGroup, rm, benchmarkTranslation, domainSep, pks,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Opens(revealed) {
+		t.Fatal("hiding share set does not open to its revealed share set")
+	}
+
+	// A dealer which withholds its reveal has its share set reconstructed from
+	// the receivers' disclosures.
+	var disclosures []*Disclosure
+	for i, p := range players[:benchmarkThreshold+1] {
+		d, err := got.Disclose(*p, esks[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		dm, err := d.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err = UnmarshalDisclosure(g, p, dm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := got.VerifyDisclosure(d); err != nil {
+			t.Fatal(err)
+		}
+		disclosures = append(disclosures, d)
+	}
+	if _, err := got.Reconstruct(domainSep, pks, disclosures[:benchmarkThreshold]); err == nil {
+		t.Fatal("reconstructed share set from too few disclosures")
+	}
+	reconstructed, err := got.Reconstruct(domainSep, pks, disclosures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reconstructed.PublicKey().Equal(revealed.PublicKey()) {
+		t.Fatal("reconstructed share set deals a different key")
+	}
+	last := len(players) - 1
+	s, err := reconstructed.Decrypt(*players[last], esks[last], g, domainSep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Point().Mul(s.V, nil).Equal(players[last].EvalPoint(reconstructed.coeffCommitments)) {
+		t.Fatal("reconstructed share set deals a share off its commitments")
+	}
+}
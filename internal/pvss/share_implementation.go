@@ -1,11 +1,10 @@
 package pvss
 
 import (
-	"bytes"
-
 	"github.com/pkg/errors"
 
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 
 	"go.dedis.ch/kyber/v3"
@@ -47,7 +46,7 @@ func newShare(domainSep []byte, group anon.Suite,
 }
 
 func (s *share) decrypt(
-	sk kyber.Scalar,
+	sk key_store.EncryptionKey,
 	keyGroup anon.Suite,
 	domainSep []byte,
 	sharePublicCommitment kyber.Point,
@@ -66,17 +65,24 @@ func (s *share) decrypt(
 func (s *share) verify(
 	group anon.Suite, domainSep []byte, receiver *player_idx.PlayerIdx,
 ) error {
+	sharePublicCommitment, err := s.verifyTranslation(receiver)
+	if err != nil {
+		return err
+	}
+	return s.cipherText.Verify(group, domainSep, s.encryptionKey, sharePublicCommitment)
+}
 
+func (s *share) verifyTranslation(receiver *player_idx.PlayerIdx) (kyber.Point, error) {
 	sharePublicCommitment := receiver.EvalPoint(s.shareSet.coeffCommitments)
 	err := s.shareSet.translation.VerifyTranslation(sharePublicCommitment, s.subKeyTranslation)
 	if err != nil {
-		return errors.Wrapf(err, "bad translation of share public key")
+		return nil, errors.Wrapf(err, "bad translation of share public key")
 	}
-	return s.cipherText.Verify(group, domainSep, s.encryptionKey, sharePublicCommitment)
+	return sharePublicCommitment, nil
 }
 
 func (s *share) domainSep(domainSep []byte, receiver *player_idx.PlayerIdx) []byte {
-	return bytes.Join([][]byte{domainSep, receiver.Marshal()}, nil)
+	return receiverDomainSep(domainSep, receiver)
 }
 
 func (s *share) equal(s2 *share) bool {
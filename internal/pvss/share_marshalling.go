@@ -2,7 +2,6 @@ package pvss
 
 import (
 	"bytes"
-	"encoding/binary"
 	"math"
 
 	"github.com/pkg/errors"
@@ -12,28 +11,28 @@ import (
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
 )
 
-func (s *share) marshal() ([]byte, error) {
-	rv := make([][]byte, 3)
+const shareFields = 3
+
+func (s *share) marshal() ([][]byte, error) {
+	rv := make([][]byte, shareFields)
 	cursor := 0
+	var err error
 
-	cm, err := s.cipherText.Marshal()
+	rv[cursor], err = s.cipherText.Marshal()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not marshal ciphertext of share")
 	}
-	if len(cm) > math.MaxUint16 {
+	if len(rv[cursor]) > math.MaxUint16 {
 		return nil, errors.Errorf("marshaled ciphertext too long")
 	}
-	ctLen := make([]byte, 2)
-	binary.BigEndian.PutUint16(ctLen, uint16(len(cm)))
-	rv[cursor] = append(ctLen, cm...)
 	cursor++
 
-	if rv[cursor], err = marshalKyberPointWithLen(s.encryptionKey); err != nil {
+	if rv[cursor], err = s.encryptionKey.MarshalBinary(); err != nil {
 		return nil, errors.Wrap(err, "could not marshal encryptionKey")
 	}
 	cursor++
 
-	if rv[cursor], err = marshalKyberPointWithLen(s.subKeyTranslation); err != nil {
+	if rv[cursor], err = s.subKeyTranslation.MarshalBinary(); err != nil {
 		return nil, errors.Wrap(err, "could not marshal subKeyTranslation")
 	}
 	cursor++
@@ -42,21 +41,37 @@ func (s *share) marshal() ([]byte, error) {
 		panic(errors.Errorf("marshal fields out of alignment"))
 	}
 
-	return bytes.Join(rv, nil), nil
+	return rv, nil
 }
 
-func marshalKyberPointWithLen(p kyber.Point) ([]byte, error) {
-	pm, err := p.MarshalBinary()
+func unmarshalShare(
+	group anon.Suite, translationGroup kyber.Group, fields [][]byte, ss *ShareSet,
+) (*share, error) {
+	if len(fields) != shareFields {
+		return nil, errors.Errorf("share has %d fields, need %d", len(fields), shareFields)
+	}
+	if len(fields[0]) > math.MaxUint16 {
+		return nil, errors.Errorf("marshaled ciphertext too long")
+	}
+	cipherText, err := ciphertext.Unmarshal(group, bytes.NewBuffer(fields[0]))
 	if err != nil {
-		return nil, errors.Wrap(err, "could not marshal key of share")
+		return nil, errors.Wrap(err, "could not unmarshal ciphertext of share")
+	}
+
+	encryptionKey := group.Point()
+	if err := encryptionKey.UnmarshalBinary(fields[1]); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal encryptionKey of share")
 	}
-	if len(pm) > math.MaxUint8 {
-		return nil, errors.Errorf("marshalled point too long")
+
+	subKeyTranslation := translationGroup.Point()
+	if err := subKeyTranslation.UnmarshalBinary(fields[2]); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal subKeyTranslation of share")
 	}
-	return bytes.Join([][]byte{{uint8(len(pm))}, pm}, nil), nil
+
+	return &share{cipherText, encryptionKey, subKeyTranslation, ss}, nil
 }
 
-func unmarshal(
+func unmarshalLegacy(
 	group anon.Suite, translationGroup kyber.Group, data []byte, ss *ShareSet,
 ) (*share, []byte, error) {
 
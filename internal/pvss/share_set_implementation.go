@@ -7,6 +7,8 @@ import (
 
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
 	"github.com/smartcontractkit/chainlink-vrf/internal/util"
@@ -22,7 +24,7 @@ var _ = NewShareSet
 
 func newShareSet(domainSep types.ConfigDigest, threshold player_idx.Int, dealerIdx *player_idx.PlayerIdx,
 	group anon.Suite, translation point_translation.PubKeyTranslation,
-	pks []kyber.Point, toxicWasteNeverUseThisParam ...interface{},
+	pks []kyber.Point, secret kyber.Scalar, toxicWasteNeverUseThisParam ...interface{},
 ) (*ShareSet, error) {
 	if len(pks) < MinPlayers {
 		return nil, errors.Errorf("%d is not enough players", len(pks))
@@ -32,25 +34,41 @@ func newShareSet(domainSep types.ConfigDigest, threshold player_idx.Int, dealerI
 			"threshold %d cannot exceed number of players %d", threshold, len(pks),
 		)
 	}
+	secretPoly := kshare.NewPriPoly(group, int(threshold)+1, secret, group.RandomStream())
+	rv, err := dealShareSet(
+		domainSep, dealerIdx, group, translation, pks, secretPoly, nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(toxicWasteNeverUseThisParam) == 1 && toxicWasteNeverUseThisParam[0] ==
+		"⚠⚠⚠☣☢️☠ Yes, please inject me with that yummy plutonium-salt solution. "+
+			"I long for the sweet release of a lingering death by radiation "+
+			"sickness ☠☢️☣️⚠⚠⚠" {
+		rv.xXXToxicWaste = secretPoly
+	}
+	return rv, nil
+}
+
+// dealShareSet encrypts the shares of secretPoly to pks. If hidingCommitments
+// is non-nil, the share proofs are bound to them rather than to the Feldman
+// commitments.
+func dealShareSet(domainSep types.ConfigDigest, dealerIdx *player_idx.PlayerIdx,
+	group anon.Suite, translation point_translation.PubKeyTranslation,
+	pks []kyber.Point, secretPoly *kshare.PriPoly, hidingCommitments *kshare.PubPoly,
+) (*ShareSet, error) {
 	players, err := player_idx.PlayerIdxs(player_idx.Int(len(pks)))
 	if err != nil {
 		return nil, err
 	}
-	secretPoly := kshare.NewPriPoly(group, int(threshold)+1, nil, group.RandomStream())
 	coeffCommits := secretPoly.Commit(nil)
 	pvssKey, err := translation.TranslateKey(secretPoly.Secret())
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not translate dealer's additive share")
 	}
 	rv := &ShareSet{
-		dealerIdx, coeffCommits, pvssKey, make([]*share, len(pks)), translation, nil,
-	}
-
-	if len(toxicWasteNeverUseThisParam) == 1 && toxicWasteNeverUseThisParam[0] ==
-		"⚠⚠⚠☣☢️☠ Yes, please inject me with that yummy plutonium-salt solution. "+
-			"I long for the sweet release of a lingering death by radiation "+
-			"sickness ☠☢️☣️⚠⚠⚠" {
-		rv.xXXToxicWaste = secretPoly
+		dealerIdx, coeffCommits, hidingCommitments, pvssKey, make([]*share, len(pks)),
+		translation, group, nil,
 	}
 	edomain, err := rv.domainSep(domainSep)
 	if err != nil {
@@ -71,9 +89,19 @@ func newShareSet(domainSep types.ConfigDigest, threshold player_idx.Int, dealerI
 var _ = (&ShareSet{}).Verify
 
 func (s *ShareSet) verify(group anon.Suite, domainSep types.ConfigDigest, pks []kyber.Point) error {
-	if _, commits := s.coeffCommitments.Info(); len(commits) < 1 {
+	_, commits := s.coeffCommitments.Info()
+	if len(commits) < 1 {
 		return errors.Errorf("need at least one coefficient commitment in a valid share")
 	}
+	if s.hidingCommitments != nil {
+		_, hidingCommits := s.hidingCommitments.Info()
+		if len(hidingCommits) != len(commits) {
+			return errors.Errorf(
+				"share set has %d hiding commitments, expected %d",
+				len(hidingCommits), len(commits),
+			)
+		}
+	}
 	if err := s.translation.VerifyTranslation(s.coeffCommitments.Commit(), s.pvssKey); err != nil {
 		return errors.Wrapf(err, "bad translation of additive key share in share set")
 	}
@@ -89,17 +117,43 @@ func (s *ShareSet) verify(group anon.Suite, domainSep types.ConfigDigest, pks []
 	if err != nil {
 		return util.WrapError(err, "could not get list of player indices in ShareSet.verify")
 	}
+	if len(s.shares) != numPlayers {
+		return errors.Errorf(
+			"share set has %d shares, expected %d", len(s.shares), numPlayers,
+		)
+	}
+	batch := ciphertext.NewBatchVerifier(group)
 	for shareIdx, share := range s.shares {
 		p := players[shareIdx]
-		if err := share.verify(group, append(edomain, p.Marshal()...), p); err != nil {
-			return errors.Wrapf(err, "could not verify every share in share set")
+		if !share.encryptionKey.Equal(p.Index(pks).(kyber.Point)) {
+			return errors.Errorf("share for player %s not encrypted to its key", p)
+		}
+		sharePublicCommitment, err := share.verifyTranslation(p)
+		if err != nil {
+			return errors.Wrapf(err, "could not verify share for player %s", p)
+		}
+		err = batch.Add(
+			share.cipherText, share.domainSep(edomain, p), share.encryptionKey,
+			sharePublicCommitment,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "could not verify share for player %s", p)
 		}
 	}
-	return nil
+	return errors.Wrapf(batch.Verify(), "could not verify every share in share set")
 }
 
 func (s *ShareSet) domainSep(domainSep types.ConfigDigest) ([]byte, error) {
-	_, commits := s.coeffCommitments.Info()
+	if s.hidingCommitments != nil {
+		return shareSetDomainSep(domainSep, s.hidingCommitments, s.dealer)
+	}
+	return shareSetDomainSep(domainSep, s.coeffCommitments, s.dealer)
+}
+
+func shareSetDomainSep(
+	domainSep types.ConfigDigest, commitments *kshare.PubPoly, dealer *player_idx.PlayerIdx,
+) ([]byte, error) {
+	_, commits := commitments.Info()
 	components := make([][]byte, len(commits)+2)
 	components[0] = domainSep[:]
 	offset := 1
@@ -113,7 +167,7 @@ func (s *ShareSet) domainSep(domainSep types.ConfigDigest) ([]byte, error) {
 		}
 	}
 
-	components[len(components)-1] = s.dealer.Marshal()
+	components[len(components)-1] = dealer.Marshal()
 	return bytes.Join(components, nil), nil
 }
 
@@ -128,6 +182,7 @@ func (s *ShareSet) Equal(s2 *ShareSet) bool {
 	_, s2commits := s2.coeffCommitments.Info()
 	if len(scommits) == len(s2commits) &&
 		s.coeffCommitments.Equal(s2.coeffCommitments) &&
+		equalHidingCommitments(s.hidingCommitments, s2.hidingCommitments) &&
 		s.pvssKey.Equal(s2.pvssKey) &&
 		(len(s.shares) == len(s2.shares)) {
 		for i, sh := range s.shares {
@@ -140,10 +195,19 @@ func (s *ShareSet) Equal(s2 *ShareSet) bool {
 	return false
 }
 
+func equalHidingCommitments(p, p2 *kshare.PubPoly) bool {
+	if p == nil || p2 == nil {
+		return p == p2
+	}
+	_, commits := p.Info()
+	_, commits2 := p2.Info()
+	return len(commits) == len(commits2) && p.Equal(p2)
+}
+
 var _ = (*ShareSet)(nil).Decrypt
 
 func (s *ShareSet) decrypt(
-	playerIdx player_idx.PlayerIdx, sk kyber.Scalar,
+	playerIdx player_idx.PlayerIdx, sk key_store.EncryptionKey,
 	keyGroup anon.Suite, domainSep types.ConfigDigest, sharePublicCommitment kyber.Point,
 ) (kshare.PriShare, error) {
 	playerShare := playerIdx.Index(s.shares).(*share)
@@ -156,6 +220,50 @@ func (s *ShareSet) decrypt(
 	)
 }
 
+func (s *ShareSet) proveDecryption(
+	receiver player_idx.PlayerIdx, sk key_store.EncryptionKey, domainSep types.ConfigDigest,
+) (blindingTerms []kyber.Point, proof []byte, err error) {
+	playerShare, cipherTextDomainSep, err := s.receiverShare(receiver, domainSep)
+	if err != nil {
+		return nil, nil, err
+	}
+	return playerShare.cipherText.ProveDecryption(sk, cipherTextDomainSep)
+}
+
+func (s *ShareSet) checkDecryption(
+	receiver player_idx.PlayerIdx, domainSep types.ConfigDigest,
+	blindingTerms []kyber.Point, proof []byte,
+) (fault, err error) {
+	playerShare, cipherTextDomainSep, err := s.receiverShare(receiver, domainSep)
+	if err != nil {
+		return nil, err
+	}
+	plaintextShare, fault, err := playerShare.cipherText.DecryptWithProof(
+		s.group, cipherTextDomainSep, blindingTerms, proof,
+	)
+	if err != nil || fault != nil {
+		return fault, err
+	}
+	if !s.group.Point().Mul(plaintextShare, nil).Equal(receiver.EvalPoint(s.coeffCommitments)) {
+		return errors.Errorf("share for player %s does not match dealer's commitments", receiver), nil
+	}
+	return nil, nil
+}
+
+func (s *ShareSet) receiverShare(
+	receiver player_idx.PlayerIdx, domainSep types.ConfigDigest,
+) (playerShare *share, cipherTextDomainSep []byte, err error) {
+	if receiver.NonZero() != nil || !receiver.AtMost(player_idx.Int(len(s.shares))) {
+		return nil, nil, errors.Errorf("no share for player %s", receiver)
+	}
+	playerShare = receiver.Index(s.shares).(*share)
+	edomain, err := s.domainSep(domainSep)
+	if err != nil {
+		return nil, nil, err
+	}
+	return playerShare, playerShare.domainSep(edomain, &receiver), nil
+}
+
 var _ = (*ShareSet)(nil).PublicShares
 
 func (s *ShareSet) publicShares() (rv []kyber.Point) {
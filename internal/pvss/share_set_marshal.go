@@ -1,13 +1,13 @@
 package pvss
 
 import (
-	"bytes"
-
 	"github.com/pkg/errors"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
 	"go.dedis.ch/kyber/v3/sign/anon"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
 	"github.com/smartcontractkit/chainlink-vrf/internal/util"
@@ -15,11 +15,20 @@ import (
 
 var _ = (&ShareSet{}).Marshal
 
+const shareSetHeaderFields = 3
+
 func (s *ShareSet) marshal() (m []byte, err error) {
 	if s == nil {
 		return nil, errors.Errorf("attempt to marshal non-existent share set")
 	}
-	rv := make([][]byte, 4+len(s.shares))
+	if s.group == nil {
+		return nil, errors.Errorf("can't marshal share set with no group specified")
+	}
+	translationGroup, err := s.translation.TargetGroup(s.group)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get group of translated PVSS public key")
+	}
+	rv := make([][]byte, shareSetHeaderFields, shareSetHeaderFields+shareFields*len(s.shares))
 	cursor := 0
 
 	if s.dealer == nil {
@@ -32,9 +41,16 @@ func (s *ShareSet) marshal() (m []byte, err error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "could not marshal coefficient commitments")
 	}
+	if s.hidingCommitments != nil {
+		hidingCommitments, err := (&pubPoly{s.hidingCommitments}).marshal()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal hiding commitments")
+		}
+		rv[cursor] = append(rv[cursor], hidingCommitments...)
+	}
 	cursor++
 
-	rv[cursor], err = marshalKyberPointWithLen(s.pvssKey)
+	rv[cursor], err = s.pvssKey.MarshalBinary()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not marshal translated PVSS public key")
 	}
@@ -43,24 +59,24 @@ func (s *ShareSet) marshal() (m []byte, err error) {
 	if len(s.shares) > int(player_idx.MaxPlayer) {
 		return nil, errors.Errorf("too many shares to marshal")
 	}
-	rv[cursor] = player_idx.RawMarshal(player_idx.Int(len(s.shares)))
-	cursor++
-
 	for _, sh := range s.shares {
-		rv[cursor], err = sh.marshal()
+		fields, err := sh.marshal()
 		if err != nil {
 			return nil, errors.Wrap(err, "could not marshal share in share-set")
 		}
-		cursor++
+		rv = append(rv, fields...)
+		cursor += len(fields)
 	}
 
-	if cursor != len(rv) {
+	if cursor != cap(rv) {
 		return nil, errors.Errorf(
 			"ShareSet marshal fields out of registration: cursor: %d, fields: %d",
-			cursor, len(rv),
+			cursor, cap(rv),
 		)
 	}
-	return bytes.Join(rv, nil), nil
+	return envelope.New(
+		envelope.ShareSet, []kyber.Group{s.group, translationGroup}, rv...,
+	).Marshal()
 }
 
 var _ = UnmarshalShareSet
@@ -70,8 +86,93 @@ func unmarshalShareSet(
 	translation point_translation.PubKeyTranslation, domainSep types.ConfigDigest,
 	pks []kyber.Point,
 ) (ss *ShareSet, rem []byte, err error) {
+	if envelope.IsEnveloped(data) {
+		ss, rem, err = unmarshalEnvelopedShareSet(g, translationGroup, data, translation)
+	} else {
+		ss, rem, err = unmarshalLegacyShareSet(g, translationGroup, data, translation)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ss.verify(g, domainSep, pks); err != nil {
+		return nil, nil, errors.Wrap(err, "unmarshaled to invalid share set")
+	}
+	return ss, rem, nil
+}
+
+func unmarshalEnvelopedShareSet(
+	g anon.Suite, translationGroup kyber.Group, data []byte,
+	translation point_translation.PubKeyTranslation,
+) (ss *ShareSet, rem []byte, err error) {
+	e, rem, err := envelope.Unmarshal(data, envelope.ShareSet)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal share set")
+	}
+	if err := e.CheckGroups(g, translationGroup); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal share set")
+	}
+	if err := e.CheckNumFields(shareSetHeaderFields); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal share set")
+	}
+	shareFieldsB := e.Fields[shareSetHeaderFields:]
+	if len(shareFieldsB)%shareFields != 0 {
+		return nil, nil, errors.Errorf(
+			"share set has %d share fields, which is not a multiple of %d",
+			len(shareFieldsB), shareFields,
+		)
+	}
+	numShares := len(shareFieldsB) / shareFields
+	if numShares > int(player_idx.MaxPlayer) {
+		return nil, nil, errors.Errorf("too many shares in marshalled share set")
+	}
+
+	dealer, err := unmarshalDealerField(e.Fields[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	coeffCommitments, pubPolyRem, err := unmarshalPubPoly(g, e.Fields[1])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal coefficient commitments for share set")
+	}
+	var hidingCommitments *kshare.PubPoly
+	if len(pubPolyRem) > 0 {
+		hiding, hidingRem, err := unmarshalPubPoly(g, pubPolyRem)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not unmarshal hiding commitments for share set")
+		}
+		if len(hidingRem) > 0 {
+			return nil, nil, errors.Errorf("overage in coefficient commitments for share set")
+		}
+		hidingCommitments = hiding.PubPoly
+	}
+
+	pvssKey := translationGroup.Point()
+	if err2 := pvssKey.UnmarshalBinary(e.Fields[2]); err2 != nil {
+		return nil, nil, errors.Wrap(err2, "could not read translated PVSS public key")
+	}
+
+	ss = &ShareSet{
+		dealer, coeffCommitments.PubPoly, hidingCommitments, pvssKey,
+		make([]*share, numShares), translation, g, nil,
+	}
+	for i := range ss.shares {
+		ss.shares[i], err = unmarshalShare(
+			g, translationGroup, shareFieldsB[i*shareFields:(i+1)*shareFields], ss,
+		)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not unmarshal shares in share set")
+		}
+	}
+	return ss, rem, nil
+}
+
+func unmarshalLegacyShareSet(
+	g anon.Suite, translationGroup kyber.Group, data []byte,
+	translation point_translation.PubKeyTranslation,
+) (ss *ShareSet, rem []byte, err error) {
 
-	dealer, data, err := unmarshalDealer(data)
+	dealer, data, err := unmarshalLegacyDealer(data)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -96,23 +197,49 @@ func unmarshalShareSet(
 
 	shareSpots := make([]*share, numShares)
 	ss = &ShareSet{
-		dealer, coeffCommitments.PubPoly, pvssKey, shareSpots, translation, nil,
+		dealer, coeffCommitments.PubPoly, nil, pvssKey, shareSpots, translation, g, nil,
 	}
 	for i := range ss.shares {
-		ss.shares[i], data, err = unmarshal(g, translationGroup, data, ss)
+		ss.shares[i], data, err = unmarshalLegacy(g, translationGroup, data, ss)
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "could not unmarshal shares in share set")
 		}
 	}
-	if err := ss.verify(g, domainSep, pks); err != nil {
-		return nil, nil, errors.Wrap(err, "unmarshaled to invalid share set")
-	}
 	return ss, data, nil
 }
 
 var _ = UnmarshalDealer
 
 func unmarshalDealer(data []byte) (*player_idx.PlayerIdx, []byte, error) {
+	if !envelope.IsEnveloped(data) {
+		return unmarshalLegacyDealer(data)
+	}
+	e, rem, err := envelope.Unmarshal(data, envelope.ShareSet)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal share set dealer")
+	}
+	if err := e.CheckNumFields(shareSetHeaderFields); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal share set dealer")
+	}
+	dealer, err := unmarshalDealerField(e.Fields[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return dealer, rem, nil
+}
+
+func unmarshalDealerField(data []byte) (*player_idx.PlayerIdx, error) {
+	dealer, rem, err := unmarshalLegacyDealer(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rem) > 0 {
+		return nil, errors.Errorf("overage in share set dealer")
+	}
+	return dealer, nil
+}
+
+func unmarshalLegacyDealer(data []byte) (*player_idx.PlayerIdx, []byte, error) {
 	dealer, data, err := player_idx.Unmarshal(data)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "could not unmarshal share set dealer")
@@ -0,0 +1,213 @@
+package pvss
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"go.dedis.ch/kyber/v3"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+	"github.com/smartcontractkit/chainlink-vrf/internal/signatures/secp256k1"
+)
+
+func TestCheckDecryption(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	g := benchmarkGroup
+	players, err := player_idx.PlayerIdxs(benchmarkPlayers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every share is encrypted to the same key, so that a share's ciphertext
+	// can be swapped for another's and still be decrypted.
+	esk, err := key_store.NewInProcessKeyStore(g.Scalar().Pick(g.RandomStream()), nil).
+		EncryptionKey(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pks := make([]kyber.Point, benchmarkPlayers)
+	for i := range pks {
+		pks[i] = esk.PublicKey()
+	}
+	domainSep := types.ConfigDigest{1}
+	s, err := NewShareSet(domainSep, benchmarkThreshold, players[0], g, benchmarkTranslation, pks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver := *players[1]
+
+	blindingTerms, proof, err := s.ProveDecryption(receiver, esk, domainSep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fault, err := s.CheckDecryption(receiver, domainSep, blindingTerms, proof)
+	if err != nil || fault != nil {
+		t.Fatalf("valid decryption rejected: fault %v, err %v", fault, err)
+	}
+	_, err = s.CheckDecryption(receiver, types.ConfigDigest{2}, blindingTerms, proof)
+	if err == nil {
+		t.Fatal("decryption proof verified under the wrong config digest")
+	}
+	_, err = s.CheckDecryption(*players[2], domainSep, blindingTerms, proof)
+	if err == nil {
+		t.Fatal("decryption proof verified for the wrong receiver's share")
+	}
+	forged := append([]kyber.Point{g.Point().Pick(g.RandomStream())}, blindingTerms[1:]...)
+	if _, err := s.CheckDecryption(receiver, domainSep, forged, proof); err == nil {
+		t.Fatal("decryption proof verified for a forged shared secret")
+	}
+	if _, err := s.CheckDecryption(player_idx.PlayerIdx{}, domainSep, nil, nil); err == nil {
+		t.Fatal("decryption checked for a player outside the share set")
+	}
+
+	s.shares[1].cipherText, s.shares[2].cipherText = s.shares[2].cipherText, s.shares[1].cipherText
+	blindingTerms, proof, err = s.ProveDecryption(receiver, esk, domainSep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fault, err = s.CheckDecryption(receiver, domainSep, blindingTerms, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fault == nil {
+		t.Fatal("share which decrypts to another player's share accepted")
+	}
+}
+
+// marshalLegacyShareSet encodes s as share sets were encoded before they were
+// enveloped.
+func marshalLegacyShareSet(t *testing.T, s *ShareSet) []byte {
+	rv := s.dealer.Marshal()
+	coeffs, err := (&pubPoly{s.coeffCommitments}).marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv = append(rv, coeffs...)
+	pvssKey, err := s.pvssKey.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv = append(append(rv, byte(len(pvssKey))), pvssKey...)
+	rv = append(rv, player_idx.RawMarshal(player_idx.Int(len(s.shares)))...)
+	for _, sh := range s.shares {
+		fields, err := sh.marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, encryptionKey, subKeyTranslation := fields[0], fields[1], fields[2]
+		rv = append(rv, byte(len(ct)>>8), byte(len(ct)))
+		rv = append(rv, ct...)
+		rv = append(append(rv, byte(len(encryptionKey))), encryptionKey...)
+		rv = append(append(rv, byte(len(subKeyTranslation))), subKeyTranslation...)
+	}
+	return rv
+}
+
+func TestLegacyShareSetDecodes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	g := benchmarkGroup
+	translationGroup, err := benchmarkTranslation.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players, err := player_idx.PlayerIdxs(benchmarkPlayers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pks := make([]kyber.Point, benchmarkPlayers)
+	for i := range pks {
+		pks[i] = g.Point().Pick(g.RandomStream())
+	}
+	domainSep := types.ConfigDigest{1}
+	s, err := NewShareSet(domainSep, benchmarkThreshold, players[2], g, benchmarkTranslation, pks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := marshalLegacyShareSet(t, s)
+	got, rem, err := UnmarshalShareSet(g, translationGroup, legacy, benchmarkTranslation, domainSep, pks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rem) > 0 || !got.Equal(s) {
+		t.Fatal("legacy share set decoded to a different share set")
+	}
+	dealer, err := UnmarshalDealer(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dealer.Equal(players[2]) {
+		t.Fatalf("legacy share set has dealer %s, expected %s", dealer, players[2])
+	}
+	_, _, err = UnmarshalShareSet(
+		g, translationGroup, legacy[:len(legacy)-1], benchmarkTranslation, domainSep, pks,
+	)
+	if err == nil {
+		t.Fatal("truncated legacy share set decoded")
+	}
+}
+
+func TestSecp256k1ShareSet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	const n, threshold = 7, 3
+	g := secp256k1.NewBlakeKeccackSecp256k1()
+	translation := point_translation.TranslatorRegistry["translator from Secp256k1 to Secp256k1"]
+	translationGroup, err := translation.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players, err := player_idx.PlayerIdxs(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esks := make([]key_store.EncryptionKey, n)
+	pks := make([]kyber.Point, n)
+	for i := range pks {
+		esks[i], err = key_store.NewInProcessKeyStore(g.Scalar().Pick(g.RandomStream()), nil).
+			EncryptionKey(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pks[i] = esks[i].PublicKey()
+	}
+	domainSep := types.ConfigDigest{1}
+	s, err := NewShareSet(domainSep, threshold, players[3], g, translation, pks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(g, domainSep, pks); err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, rem, err := UnmarshalShareSet(g, translationGroup, m, translation, domainSep, pks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rem) > 0 || !got.Equal(s) {
+		t.Fatal("secp256k1 share set changed in round trip")
+	}
+
+	publicShares := got.PublicShares()
+	for i, receiver := range players {
+		share, err := got.Decrypt(*receiver, esks[i], g, domainSep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g.Point().Mul(share.V, nil).Equal(publicShares[i]) {
+			t.Fatalf("player %d's decrypted share does not match its public share", i)
+		}
+	}
+	if err := got.Verify(g, types.ConfigDigest{2}, pks); err == nil {
+		t.Fatal("secp256k1 share set verified under the wrong config digest")
+	}
+}
@@ -0,0 +1,204 @@
+package pvss
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+
+	"go.dedis.ch/kyber/v3"
+	kshare "go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/sign/anon"
+)
+
+// Transcript is the sum of several verified share sets. Its commitments and
+// translated public shares can be checked against each other, but the
+// per-dealer proofs that each ciphertext encrypts the committed share do not
+// survive aggregation: a receiver instead checks its decrypted share against
+// the summed commitments.
+type Transcript struct {
+	dealers []*player_idx.PlayerIdx
+
+	coeffCommitments *kshare.PubPoly
+
+	pvssKey kyber.Point
+
+	shares []*aggregatedShare
+
+	translation point_translation.PubKeyTranslation
+
+	group anon.Suite
+}
+
+type aggregatedShare struct {
+	cipherText *ciphertext.AggregatedCipherText
+
+	subKeyTranslation kyber.Point
+}
+
+func aggregateShareSets(shareSets []*ShareSet) (*Transcript, error) {
+	if len(shareSets) == 0 {
+		return nil, errors.Errorf("can't aggregate zero share sets")
+	}
+	first := shareSets[0]
+	_, firstCommits := first.coeffCommitments.Info()
+	rv := &Transcript{
+		make([]*player_idx.PlayerIdx, len(shareSets)), first.coeffCommitments,
+		first.pvssKey.Clone(), make([]*aggregatedShare, len(first.shares)),
+		first.translation, first.group,
+	}
+	seen := make(map[player_idx.PlayerIdx]bool, len(shareSets))
+	for i, ss := range shareSets {
+		dealer, err := ss.Dealer()
+		if err != nil {
+			return nil, errors.Wrap(err, "bad dealer on share set to aggregate")
+		}
+		if seen[*dealer] {
+			return nil, errors.Errorf("two share sets from dealer %s", dealer)
+		}
+		seen[*dealer] = true
+		rv.dealers[i] = dealer
+		if i == 0 {
+			continue
+		}
+		if _, commits := ss.coeffCommitments.Info(); len(commits) != len(firstCommits) {
+			return nil, errors.Errorf(
+				"share set from dealer %s has threshold %d, expected %d",
+				dealer, len(commits)-1, len(firstCommits)-1,
+			)
+		}
+		if len(ss.shares) != len(first.shares) {
+			return nil, errors.Errorf(
+				"share set from dealer %s has %d shares, expected %d",
+				dealer, len(ss.shares), len(first.shares),
+			)
+		}
+		rv.coeffCommitments, err = rv.coeffCommitments.Add(ss.coeffCommitments)
+		if err != nil {
+			return nil, errors.Wrapf(err,
+				"could not add coefficient commitments from dealer %s", dealer,
+			)
+		}
+		rv.pvssKey = rv.pvssKey.Clone().Add(rv.pvssKey, ss.pvssKey)
+	}
+	for shareIdx := range rv.shares {
+		cts := make([]*ciphertext.CipherText, len(shareSets))
+		subKeyTranslation := first.shares[shareIdx].subKeyTranslation.Clone().Null()
+		for i, ss := range shareSets {
+			sh := ss.shares[shareIdx]
+			cts[i] = sh.cipherText
+			subKeyTranslation = subKeyTranslation.Clone().Add(
+				subKeyTranslation, sh.subKeyTranslation,
+			)
+		}
+		ct, err := ciphertext.Aggregate(cts...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not aggregate share %d", shareIdx)
+		}
+		rv.shares[shareIdx] = &aggregatedShare{ct, subKeyTranslation}
+	}
+	return rv, nil
+}
+
+func (t *Transcript) verify(pks []kyber.Point) error {
+	if _, commits := t.coeffCommitments.Info(); len(commits) < 1 {
+		return errors.Errorf("need at least one coefficient commitment in a valid transcript")
+	}
+	if len(t.dealers) == 0 {
+		return errors.Errorf("transcript has no dealers")
+	}
+	seen := make(map[player_idx.PlayerIdx]bool, len(t.dealers))
+	for _, dealer := range t.dealers {
+		if seen[*dealer] {
+			return errors.Errorf("transcript lists dealer %s twice", dealer)
+		}
+		seen[*dealer] = true
+	}
+	if err := t.translation.VerifyTranslation(t.coeffCommitments.Commit(), t.pvssKey); err != nil {
+		return errors.Wrapf(err, "bad translation of aggregate key in transcript")
+	}
+	numPlayers := len(pks)
+	if numPlayers > int(player_idx.MaxPlayer) {
+		return errors.Errorf("Can't handle %d players; %d is max", len(pks), player_idx.MaxPlayer)
+	}
+	if len(t.shares) != numPlayers {
+		return errors.Errorf(
+			"transcript has %d shares, expected %d", len(t.shares), numPlayers,
+		)
+	}
+	players, err := player_idx.PlayerIdxs(player_idx.Int(numPlayers))
+	if err != nil {
+		return errors.Wrap(err, "could not get list of player indices in Transcript.verify")
+	}
+	for shareIdx, sh := range t.shares {
+		p := players[shareIdx]
+		if !sh.cipherText.Receiver().Equal(p) {
+			return errors.Errorf("share %d of transcript is for player %s", shareIdx, p)
+		}
+		if !sh.cipherText.EncryptionKey().Equal(p.Index(pks).(kyber.Point)) {
+			return errors.Errorf("share for player %s not encrypted to its key", p)
+		}
+		if sh.cipherText.NumSummands() != len(t.dealers) {
+			return errors.Errorf(
+				"share for player %s aggregates %d ciphertexts, transcript has %d dealers",
+				p, sh.cipherText.NumSummands(), len(t.dealers),
+			)
+		}
+		err := t.translation.VerifyTranslation(p.EvalPoint(t.coeffCommitments), sh.subKeyTranslation)
+		if err != nil {
+			return errors.Wrapf(err, "bad translation of public share for player %s", p)
+		}
+	}
+	return nil
+}
+
+func (t *Transcript) decrypt(
+	playerIdx player_idx.PlayerIdx, sk key_store.EncryptionKey, keyGroup anon.Suite,
+) (kshare.PriShare, error) {
+	playerShare := playerIdx.Index(t.shares).(*aggregatedShare)
+	plaintextShare, err := playerShare.cipherText.Decrypt(
+		sk, keyGroup, playerIdx.EvalPoint(t.coeffCommitments),
+	)
+	if err != nil {
+		return kshare.PriShare{}, errors.Wrap(err, "could not decrypt aggregated share")
+	}
+	return playerIdx.PriShare(plaintextShare), nil
+}
+
+func (t *Transcript) publicShares() []kyber.Point {
+	rv := make([]kyber.Point, len(t.shares))
+	for i, sh := range t.shares {
+		rv[i] = sh.subKeyTranslation
+	}
+	return rv
+}
+
+func (t *Transcript) Equal(t2 *Transcript) bool {
+	if t == nil || t2 == nil {
+		return false
+	}
+	if len(t.dealers) != len(t2.dealers) || len(t.shares) != len(t2.shares) {
+		return false
+	}
+	for i, dealer := range t.dealers {
+		if !dealer.Equal(t2.dealers[i]) {
+			return false
+		}
+	}
+	_, tcommits := t.coeffCommitments.Info()
+	_, t2commits := t2.coeffCommitments.Info()
+	if len(tcommits) != len(t2commits) ||
+		!t.coeffCommitments.Equal(t2.coeffCommitments) ||
+		!t.pvssKey.Equal(t2.pvssKey) {
+		return false
+	}
+	for i, sh := range t.shares {
+		if !sh.cipherText.Equal(t2.shares[i].cipherText) ||
+			!sh.subKeyTranslation.Equal(t2.shares[i].subKeyTranslation) {
+			return false
+		}
+	}
+	return true
+}
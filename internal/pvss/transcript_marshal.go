@@ -0,0 +1,141 @@
+package pvss
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/anon"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/common/envelope"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/ciphertext"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/point_translation"
+)
+
+const (
+	transcriptHeaderFields = 3
+	aggregatedShareFields  = 2
+)
+
+func (t *Transcript) marshal() ([]byte, error) {
+	if t == nil {
+		return nil, errors.Errorf("attempt to marshal non-existent transcript")
+	}
+	translationGroup, err := t.translation.TargetGroup(t.group)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get group of translated PVSS public key")
+	}
+	if len(t.dealers) > int(player_idx.MaxPlayer) || len(t.shares) > int(player_idx.MaxPlayer) {
+		return nil, errors.Errorf("transcript too large to marshal")
+	}
+	rv := make([][]byte, transcriptHeaderFields,
+		transcriptHeaderFields+aggregatedShareFields*len(t.shares),
+	)
+
+	dealers := make([][]byte, len(t.dealers))
+	for i, dealer := range t.dealers {
+		dealers[i] = dealer.Marshal()
+	}
+	rv[0] = bytes.Join(dealers, nil)
+
+	rv[1], err = (&pubPoly{t.coeffCommitments}).marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal coefficient commitments")
+	}
+
+	rv[2], err = t.pvssKey.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal translated PVSS public key")
+	}
+
+	for _, sh := range t.shares {
+		ct, err := sh.cipherText.Marshal()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal aggregated ciphertext")
+		}
+		subKeyTranslation, err := sh.subKeyTranslation.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal subKeyTranslation")
+		}
+		rv = append(rv, ct, subKeyTranslation)
+	}
+	return envelope.New(
+		envelope.Transcript, []kyber.Group{t.group, translationGroup}, rv...,
+	).Marshal()
+}
+
+func unmarshalTranscript(
+	g anon.Suite, translationGroup kyber.Group, data []byte,
+	translation point_translation.PubKeyTranslation, pks []kyber.Point,
+) (t *Transcript, rem []byte, err error) {
+	e, rem, err := envelope.Unmarshal(data, envelope.Transcript)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal transcript")
+	}
+	if err := e.CheckGroups(g, translationGroup); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal transcript")
+	}
+	if err := e.CheckNumFields(transcriptHeaderFields); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal transcript")
+	}
+	shareFieldsB := e.Fields[transcriptHeaderFields:]
+	if len(shareFieldsB)%aggregatedShareFields != 0 {
+		return nil, nil, errors.Errorf(
+			"transcript has %d share fields, which is not a multiple of %d",
+			len(shareFieldsB), aggregatedShareFields,
+		)
+	}
+	numShares := len(shareFieldsB) / aggregatedShareFields
+	if numShares > int(player_idx.MaxPlayer) {
+		return nil, nil, errors.Errorf("too many shares in marshalled transcript")
+	}
+
+	t = &Transcript{
+		shares: make([]*aggregatedShare, numShares), translation: translation, group: g,
+	}
+	for dealersB := e.Fields[0]; len(dealersB) > 0; {
+		var dealer *player_idx.PlayerIdx
+		dealer, dealersB, err = unmarshalLegacyDealer(dealersB)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not unmarshal transcript dealers")
+		}
+		t.dealers = append(t.dealers, dealer)
+		if len(t.dealers) > len(pks) {
+			return nil, nil, errors.Errorf(
+				"transcript has more dealers than its %d players", len(pks),
+			)
+		}
+	}
+
+	coeffCommitments, pubPolyRem, err := unmarshalPubPoly(g, e.Fields[1])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal coefficient commitments for transcript")
+	}
+	if len(pubPolyRem) > 0 {
+		return nil, nil, errors.Errorf("overage in coefficient commitments for transcript")
+	}
+	t.coeffCommitments = coeffCommitments.PubPoly
+
+	t.pvssKey = translationGroup.Point()
+	if err := t.pvssKey.UnmarshalBinary(e.Fields[2]); err != nil {
+		return nil, nil, errors.Wrap(err, "could not read translated PVSS public key")
+	}
+
+	for i := range t.shares {
+		fields := shareFieldsB[i*aggregatedShareFields : (i+1)*aggregatedShareFields]
+		ct, err := ciphertext.UnmarshalAggregated(g, fields[0], len(t.dealers))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not unmarshal shares in transcript")
+		}
+		subKeyTranslation := translationGroup.Point()
+		if err := subKeyTranslation.UnmarshalBinary(fields[1]); err != nil {
+			return nil, nil, errors.Wrap(err, "could not unmarshal subKeyTranslation of share")
+		}
+		t.shares[i] = &aggregatedShare{ct, subKeyTranslation}
+	}
+	if err := t.verify(pks); err != nil {
+		return nil, nil, errors.Wrap(err, "unmarshaled to invalid transcript")
+	}
+	return t, rem, nil
+}
@@ -0,0 +1,91 @@
+package pvss
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"go.dedis.ch/kyber/v3"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
+)
+
+func TestTranscript(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dealing share sets is slow")
+	}
+	g := benchmarkGroup
+	translationGroup, err := benchmarkTranslation.TargetGroup(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players, err := player_idx.PlayerIdxs(benchmarkPlayers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esks := make([]key_store.EncryptionKey, benchmarkPlayers)
+	pks := make([]kyber.Point, benchmarkPlayers)
+	for i := range esks {
+		esks[i], err = key_store.NewInProcessKeyStore(g.Scalar().Pick(g.RandomStream()), nil).
+			EncryptionKey(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pks[i] = esks[i].PublicKey()
+	}
+	domainSep := types.ConfigDigest{1}
+	var shareSets []*ShareSet
+	for _, dealer := range players[:2] {
+		s, err := NewShareSet(
+			domainSep, benchmarkThreshold, dealer, g, benchmarkTranslation, pks,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shareSets = append(shareSets, s)
+	}
+
+	transcript, err := AggregateShareSets(shareSets...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := transcript.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, rem, err := UnmarshalTranscript(g, translationGroup, m, benchmarkTranslation, pks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rem) != 0 || !got.Equal(transcript) {
+		t.Fatal("transcript differs after round trip")
+	}
+	for i, p := range players[:2] {
+		share, err := got.Decrypt(*p, esks[i], g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := g.Scalar().Zero()
+		for _, s := range shareSets {
+			dealt, err := s.Decrypt(*p, esks[i], g, domainSep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum.Add(sum, dealt.V)
+		}
+		if !share.V.Equal(sum) {
+			t.Fatalf("player %d's transcript share is not the sum of its dealt shares", i)
+		}
+	}
+
+	if _, err := AggregateShareSets(shareSets[0], shareSets[0]); err == nil {
+		t.Fatal("aggregated two share sets from the same dealer")
+	}
+	tampered := append([]byte{}, m...)
+	tampered[len(tampered)-5] ^= 1
+	_, _, err = UnmarshalTranscript(g, translationGroup, tampered, benchmarkTranslation, pks)
+	if err == nil {
+		t.Fatal("accepted tampered transcript")
+	}
+}
@@ -49,7 +49,7 @@ func (kt *KeyTransceiver) KeyLookup(p contract.KeyID) dkg.KeyData {
 		if kt.kd != nil {
 			return *kt.kd.Clone()
 		}
-		return dkg.KeyData{nil, nil, nil, 0, false}
+		return dkg.KeyData{nil, nil, nil, nil, nil, 0, false}
 	}
 
 	panic("key consumer is asking for unknown key ID")
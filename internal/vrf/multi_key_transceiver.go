@@ -0,0 +1,93 @@
+package vrf
+
+import (
+	"sync"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg"
+	"github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
+)
+
+type MultiKeyTransceiver struct {
+	kds    map[contract.KeyID]*dkg.KeyData
+	logger commontypes.Logger
+	mu     sync.RWMutex
+}
+
+var _ dkg.KeyConsumer = (*MultiKeyTransceiver)(nil)
+var _ KeyProvider = (*MultiKeyTransceiver)(nil)
+
+func NewMultiKeyTransceiver(
+	logger commontypes.Logger, keyIDs ...contract.KeyID,
+) *MultiKeyTransceiver {
+	kt := &MultiKeyTransceiver{
+		map[contract.KeyID]*dkg.KeyData{}, logger, sync.RWMutex{},
+	}
+	for _, keyID := range keyIDs {
+		kt.AddKeyID(keyID)
+	}
+	return kt
+}
+
+func (kt *MultiKeyTransceiver) AddKeyID(keyID contract.KeyID) {
+
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+
+	if _, ok := kt.kds[keyID]; !ok {
+		kt.kds[keyID] = nil
+	}
+}
+
+func (kt *MultiKeyTransceiver) KeyInvalidated(kID contract.KeyID) {
+
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+
+	if _, ok := kt.kds[kID]; ok {
+		kt.kds[kID] = nil
+	}
+}
+
+func (kt *MultiKeyTransceiver) NewKey(kID contract.KeyID, kd *dkg.KeyData) {
+
+	kt.mu.Lock()
+	defer kt.mu.Unlock()
+
+	if _, ok := kt.kds[kID]; !ok {
+		kt.logger.Warn("ignoring new key for unregistered key ID", commontypes.LogFields{
+			"keyID": kID,
+		})
+		return
+	}
+	kt.kds[kID] = kd.Clone()
+}
+
+// KeyLookup returns the key data for p, or key data which is not Present if
+// the key has not been generated or p was never registered.
+func (kt *MultiKeyTransceiver) KeyLookup(p contract.KeyID) dkg.KeyData {
+
+	kt.mu.RLock()
+	defer kt.mu.RUnlock()
+
+	kd, ok := kt.kds[p]
+	if !ok {
+		kt.logger.Error("key consumer is asking for unknown key ID", commontypes.LogFields{
+			"keyID": p,
+		})
+	}
+	if kd != nil {
+		return *kd.Clone()
+	}
+	return dkg.KeyData{nil, nil, nil, nil, nil, 0, false}
+}
+
+func (kt *MultiKeyTransceiver) KeyGenerated(kID contract.KeyID) bool {
+
+	kt.mu.RLock()
+	defer kt.mu.RUnlock()
+
+	kd := kt.kds[kID]
+	return (kd != nil) && kd.Present
+}
@@ -100,7 +100,7 @@ func (s *sigRequest) Observation(
 			}
 			s.blockProofs[b] = blockProof
 		}
-		proofBytes, err3 := s.blockProofs[b].MarshalBinary()
+		proofBytes, err3 := marshalPartialSigs(s.blockProofs[b])
 		if err3 != nil {
 			s.logger.Warn(failedMarshalVRFProof, commontypes.LogFields{
 				"oracleID": s.i, "error": err3,
@@ -112,7 +112,7 @@ func (s *sigRequest) Observation(
 			Height:      b.Height,
 			Delay:       b.ConfirmationDelay,
 			Blockhash:   append([]byte{}, b.Hash[:]...),
-			Sig:         &protobuf.Signature{Sig: proofBytes[:]},
+			Sig:         &protobuf.Signature{Sig: proofBytes},
 			ShouldStore: b.ShouldStore,
 		})
 	}
@@ -233,8 +233,27 @@ func (s *sigRequest) Report(
 	_ types.Query,
 	obs []types.AttributedObservation,
 ) (bool, types.Report, error) {
-	if len(obs) < 2*int(s.t)+1 {
-		err := fmt.Errorf("got %d observations, need %d", len(obs), 2*int(s.t)+1)
+	kd := s.keyProvider.KeyLookup(s.keyID)
+	required := 2*int(s.f) + 1
+	if len(obs) < required {
+		err := fmt.Errorf("got %d observations, need %d", len(obs), required)
+		return false, nil, err
+	}
+	players, err := player_idx.PlayerIdxs(s.n)
+	if err != nil {
+		errMsg := "could not construct players for tracking shares"
+		return false, nil, errors.Wrap(err, errMsg)
+	}
+	weight := 0
+	for _, o := range obs {
+		if int(o.Observer) < len(players) {
+			weight += kd.Weight(players[o.Observer])
+		}
+	}
+	if weight <= int(kd.T) {
+		err := fmt.Errorf(
+			"got observations holding %d shares, need %d", weight, int(kd.T)+1,
+		)
 		return false, nil, err
 	}
 	if err := s.ocrsSynced(ctx); err != nil {
@@ -248,14 +267,8 @@ func (s *sigRequest) Report(
 	callbacksByBlock := make(map[heightDelay]map[common.Hash]struct{})
 
 	vrfContributions := make(
-		map[vrf_types.Block]map[commontypes.OracleID]kshare.PubShare,
+		map[vrf_types.Block]map[commontypes.OracleID][]kshare.PubShare,
 	)
-	kd := s.keyProvider.KeyLookup(s.keyID)
-	players, err := player_idx.PlayerIdxs(s.n)
-	if err != nil {
-		errMsg := "could not construct players for tracking shares"
-		return false, nil, errors.Wrap(err, errMsg)
-	}
 	juelsPerFeeCoinObs := make([]*big.Int, 0, len(obs))
 	reasonableGasPriceObs := make([]*big.Int, 0, len(obs))
 
@@ -265,7 +278,7 @@ func (s *sigRequest) Report(
 	}
 	recentBlockHashes := make(map[heightHash]int, 256*len(obs))
 	for _, o := range obs {
-		if s.n <= uint8(o.Observer) {
+		if int(s.n) <= int(o.Observer) {
 			s.logger.Error(
 				outOfRangeObserver,
 				commontypes.LogFields{"n": s.n, "oracleID": o.Observer},
@@ -352,13 +365,13 @@ func (s *sigRequest) Report(
 		for _, chs := range chashes {
 			ch := common.HexToHash(chs)
 
-			if callbackCounts[ch] > uint64(s.t) {
+			if callbackCounts[ch] > uint64(s.f) {
 				ccallbacks = append(ccallbacks, callbacks[ch])
 			} else {
 				s.logger.Warn(
 					notEnoughAppearancesCallback,
 					commontypes.LogFields{
-						"callback hash": ch, "t": s.t, "count": callbackCounts[ch],
+						"callback hash": ch, "f": s.f, "count": callbackCounts[ch],
 					},
 				)
 			}
@@ -390,7 +403,7 @@ func (s *sigRequest) Report(
 	var zeroHash common.Hash
 	for hh, c := range recentBlockHashes {
 
-		if c > int(s.t) {
+		if c > int(s.f) {
 			if (mostRecentBlockHash.hash == zeroHash) ||
 				(hh.height > mostRecentBlockHash.height) ||
 				((hh.height == mostRecentBlockHash.height) &&
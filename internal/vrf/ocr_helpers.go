@@ -12,7 +12,6 @@ import (
 
 	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/player_idx"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg"
-	"github.com/smartcontractkit/chainlink-vrf/internal/util"
 	"github.com/smartcontractkit/chainlink-vrf/internal/vrf/protobuf"
 	vrf_types "github.com/smartcontractkit/chainlink-vrf/types"
 
@@ -155,13 +154,20 @@ func (s *sigRequest) storeCallbacksByBlocks(
 
 func (s *sigRequest) parseAndStoreVRFProofs(
 	proofs []*protobuf.VRFResponse,
-	vrfContributions map[vrf_types.Block]map[commontypes.OracleID]kshare.PubShare,
+	vrfContributions map[vrf_types.Block]map[commontypes.OracleID][]kshare.PubShare,
 	observer commontypes.OracleID,
 	player *player_idx.PlayerIdx,
 	kd dkg.KeyData,
 ) {
 
-	pubShare := player.Index(kd.Shares).(kshare.PubShare)
+	pubShares, err := kd.PlayerShares(player)
+	if err != nil {
+		s.logger.Warn(wrongShare, commontypes.LogFields{
+			"oracleID": observer, "error": err,
+		})
+		return
+	}
+	pointLen := s.pairing.G1().PointLen()
 
 	seenBlocks := make(map[heightDelay]struct{}, len(proofs))
 	for _, output := range proofs {
@@ -193,75 +199,82 @@ func (s *sigRequest) parseAndStoreVRFProofs(
 			continue
 		}
 		seenBlocks[hd] = struct{}{}
-		contribution := s.pairing.G1().Point()
-		if err := contribution.UnmarshalBinary(output.Sig.Sig); err != nil {
+		if output.Sig == nil || len(output.Sig.Sig) != pointLen*len(pubShares) {
 			s.logger.Warn(failedReadContributionMsg, commontypes.LogFields{
-				"oracleID": observer, "error": err,
-				"contribution": fmt.Sprintf("0x%x", output.Sig.Sig),
+				"oracleID": observer, "error": "wrong length for player's weight",
+				"contribution": fmt.Sprintf("0x%x", output.Sig.GetSig()),
+				"weight":       len(pubShares),
 			})
 			continue
 		}
 
 		hashPoint := blsSeed(s.configDigest, b, kd.PublicKey)
 		if _, present := vrfContributions[b]; !present {
-			vrfContributions[b] = make(map[commontypes.OracleID]kshare.PubShare)
+			vrfContributions[b] = make(map[commontypes.OracleID][]kshare.PubShare)
 		}
 
-		if !validateSignature(s.pairing, hashPoint, pubShare.V, contribution) {
-			s.logger.Warn(wrongShare, commontypes.LogFields{
-				"oracleID": observer, "sigShare": contribution,
-				"keyShare": pubShare.V, "hashPoint": hashPoint,
-				"pubKey": kd.PublicKey, "configDigest": s.configDigest, "block": b,
-			})
-			continue
+		contributions := make([]kshare.PubShare, 0, len(pubShares))
+		for i, pubShare := range pubShares {
+			sig := output.Sig.Sig[i*pointLen : (i+1)*pointLen]
+			contribution := s.pairing.G1().Point()
+			if err := contribution.UnmarshalBinary(sig); err != nil {
+				s.logger.Warn(failedReadContributionMsg, commontypes.LogFields{
+					"oracleID": observer, "error": err,
+					"contribution": fmt.Sprintf("0x%x", sig),
+				})
+				break
+			}
+			if !validateSignature(s.pairing, hashPoint, pubShare.V, contribution) {
+				s.logger.Warn(wrongShare, commontypes.LogFields{
+					"oracleID": observer, "sigShare": contribution,
+					"keyShare": pubShare.V, "hashPoint": hashPoint,
+					"pubKey": kd.PublicKey, "configDigest": s.configDigest, "block": b,
+				})
+				break
+			}
+			contributions = append(contributions, kshare.PubShare{pubShare.I, contribution})
+		}
+		if len(contributions) == len(pubShares) {
+			vrfContributions[b][observer] = contributions
 		}
-		vrfContributions[b][observer] = player.PubShare(contribution)
 	}
 }
 
 func (s *sigRequest) aggregateOutputs(
 	blocks vrf_types.Blocks,
-	vrfContributions map[vrf_types.Block]map[commontypes.OracleID]kshare.PubShare,
+	vrfContributions map[vrf_types.Block]map[commontypes.OracleID][]kshare.PubShare,
 	callbacksByBlock map[heightDelay]map[common.Hash]struct{},
 	callbackCounts map[common.Hash]uint64,
 	callbacks map[common.Hash]vrf_types.AbstractCostedCallbackRequest,
 ) (outputs []vrf_types.AbstractVRFOutput, err error) {
 	outputs = make([]vrf_types.AbstractVRFOutput, 0, len(vrfContributions))
+	kd := s.keyProvider.KeyLookup(s.keyID)
 	for _, b := range blocks {
 		hd := heightDelay{b.Height, b.ConfirmationDelay}
 
-		if len(vrfContributions[b]) <= int(s.t) {
+		shares := make([]*kshare.PubShare, 0, len(vrfContributions[b]))
+		for _, cs := range vrfContributions[b] {
+			for i := range cs {
+				shares = append(shares, &cs[i])
+			}
+		}
+		if len(shares) <= int(kd.T) {
 			s.logger.Debug(
 				notEnoughContributions,
 				commontypes.LogFields{
-					"block": b, "num contributions": len(vrfContributions[b]),
+					"block": b, "num contributions": len(shares),
 				})
 
 			continue
 		}
-		shares := make([]*kshare.PubShare, 0, len(vrfContributions[b]))
-		player_indices, err := player_idx.PlayerIdxs(s.n)
-		if err != nil {
-
-			return nil, util.WrapError(
-				err,
-				"could not construct player indices for share reconstruction",
-			)
-		}
-		for i, c := range vrfContributions[b] {
-			pubShare := player_indices[i].PubShare(c.V)
-			shares = append(shares, &pubShare)
-		}
-		kd := s.keyProvider.KeyLookup(s.keyID)
-
 		output, err := kshare.RecoverCommit(
-			s.pairing.G1(), shares, int(s.t)+1, len(shares),
+			s.pairing.G1(), shares, int(kd.T)+1, len(shares),
 		)
 		if err != nil {
 
 			s.logger.Error(
 				"failed to recover distributed VRF output",
-				commontypes.LogFields{"error": err, "shares": shares, "t": s.t},
+				commontypes.LogFields{"error": err, "shares": shares, "t": kd.T},
 			)
 
 			continue
@@ -299,12 +312,12 @@ func (s *sigRequest) aggregateOutputs(
 		for _, chs := range chashes {
 			ch := common.HexToHash(chs)
 
-			if callbackCounts[ch] > uint64(s.t) {
+			if callbackCounts[ch] > uint64(s.f) {
 				ccallbacks = append(ccallbacks, callbacks[ch])
 			} else {
 				s.logger.Error(
 					notEnoughAppearancesCallback,
-					commontypes.LogFields{"callback hash": ch, "t": s.t, "count": callbackCounts[ch]},
+					commontypes.LogFields{"callback hash": ch, "f": s.f, "count": callbackCounts[ch]},
 				)
 			}
 		}
@@ -50,6 +50,10 @@ func (v *vrfReportingPluginFactory) NewReportingPlugin(
 		return nil, types.ReportingPluginInfo{},
 			errors.Wrap(err, "could not determine local player DKG index")
 	}
+	if int(c.OracleID) >= c.N {
+		return nil, types.ReportingPluginInfo{},
+			errors.Errorf("oracle ID %d out of range for %d players", c.OracleID, c.N)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -10,7 +10,6 @@ import (
 
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/group/mod"
-	kshare "go.dedis.ch/kyber/v3/share"
 
 	"github.com/smartcontractkit/chainlink-vrf/altbn_128"
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg"
@@ -29,18 +28,40 @@ func affineCoordinates(p kyber.Point) (*big.Int, *big.Int) {
 
 func (s *sigRequest) computePartialSig(
 	block vrf_types.Block, kd dkg.KeyData,
-) (kyber.Point, error) {
+) ([]kyber.Point, error) {
 
 	seed := blsSeed(s.configDigest, block, kd.PublicKey)
 
-	output := kd.SecretShare.Mul(seed)
-
-	pk := s.i.Index(kd.Shares).(kshare.PubShare).V
+	pks, err := kd.PlayerShares(&s.i)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get own public shares")
+	}
+	secretShares := kd.HeldShares()
+	if len(pks) != len(secretShares) {
+		return nil, errors.Errorf(
+			"have %d secret shares for %d public shares", len(secretShares), len(pks),
+		)
+	}
+	outputs := make([]kyber.Point, len(secretShares))
+	for i, secretShare := range secretShares {
+		outputs[i] = secretShare.Mul(seed)
+		if !validateSignature(s.pairing, seed, pks[i].V, outputs[i]) {
+			return nil, errors.Errorf(failedVerifyOwnContributionMsg)
+		}
+	}
+	return outputs, nil
+}
 
-	if !validateSignature(s.pairing, seed, pk, output) {
-		return nil, errors.Errorf(failedVerifyOwnContributionMsg)
+func marshalPartialSigs(sigs []kyber.Point) ([]byte, error) {
+	var rv []byte
+	for _, sig := range sigs {
+		sigBytes, err := sig.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, sigBytes...)
 	}
-	return output, nil
+	return rv, nil
 }
 
 func blsSeed(
@@ -26,12 +26,12 @@ type sigRequest struct {
 	keyProvider KeyProvider
 	n           player_idx.Int
 
-	t            player_idx.Int
+	f            player_idx.Int
 	configDigest common.Hash
 	i            player_idx.PlayerIdx
 	pairing      pairing.Suite
 	serializer   vrf_types.ReportSerializer
-	blockProofs  map[vrf_types.Block]kyber.Point
+	blockProofs  map[vrf_types.Block][]kyber.Point
 	proofLock    sync.RWMutex
 
 	logger commontypes.Logger
@@ -51,7 +51,7 @@ func newSigRequest(
 	keyID dkg_contract.KeyID,
 	keyProvider KeyProvider,
 	n player_idx.Int,
-	t player_idx.Int,
+	f player_idx.Int,
 	configDigest common.Hash,
 	i player_idx.PlayerIdx,
 	pairing pairing.Suite,
@@ -64,7 +64,7 @@ func newSigRequest(
 	confirmationDelays map[uint32]struct{},
 	period uint16,
 ) (*sigRequest, error) {
-	if n <= t {
+	if n <= f {
 		return nil, errors.Errorf(
 			"committee size must be larger than the fault-tolerance threshold",
 		)
@@ -73,12 +73,12 @@ func newSigRequest(
 		keyID,
 		keyProvider,
 		n,
-		t,
+		f,
 		configDigest,
 		i,
 		pairing,
 		serializer,
-		map[vrf_types.Block]kyber.Point{},
+		map[vrf_types.Block][]kyber.Point{},
 		sync.RWMutex{},
 		logger,
 		retransmissionDelay,
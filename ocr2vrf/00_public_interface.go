@@ -1,10 +1,13 @@
 package ocr2vrf
 
 import (
+	"context"
+
 	"go.uber.org/multierr"
 
 	"github.com/pkg/errors"
 	offchainreporting "github.com/smartcontractkit/libocr/offchainreporting2plus"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
 	"github.com/smartcontractkit/chainlink-vrf/internal/dkg"
 	"github.com/smartcontractkit/chainlink-vrf/internal/util"
@@ -13,17 +16,22 @@ import (
 )
 
 type OCR2VRF struct {
-	dkg, vrf       offchainreporting.Oracle
-	keyTransceiver *vrf.KeyTransceiver
+	dkg            offchainreporting.Oracle
+	dkgFactory     types.ReportingPluginFactory
+	vrfs           []offchainreporting.Oracle
+	keyTransceiver *vrf.MultiKeyTransceiver
 }
 
 type EthereumReportSerializer = vrf.EthereumReportSerializer
 
 func NewOCR2VRF(a DKGVRFArgs) (*OCR2VRF, error) {
-	transceiver := vrf.NewKeyTransceiver(a.KeyID)
-	dkgReportingPluginFactory := dkg.NewReportingPluginFactory(
-		a.Esk,
-		a.Ssk,
+	beacons := a.beacons()
+	transceiver := vrf.NewMultiKeyTransceiver(a.DKGLogger)
+	for _, b := range beacons {
+		transceiver.AddKeyID(b.KeyID)
+	}
+	dkgReportingPluginFactory := dkg.NewReportingPluginFactoryWithKeyStore(
+		a.keyStore(),
 		a.KeyID,
 		a.DKGContract,
 		a.DKGLogger,
@@ -31,25 +39,19 @@ func NewOCR2VRF(a DKGVRFArgs) (*OCR2VRF, error) {
 		a.DKGSharePersistence,
 	)
 
-	vrfReportingPluginFactory, err := vrf.NewVRFReportingPluginFactory(
-		a.KeyID,
-		transceiver,
-		a.Coordinator,
-		a.Serializer,
-		a.VRFLogger,
-		a.JuelsPerFeeCoin,
-		a.ReasonableGasPrice,
-	)
+	err := dkg.SetTimeout(dkgReportingPluginFactory, a.DKGTimeout, a.DKGMaxRetries)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not instantiate VRF reporting plugin factory")
+		return nil, util.WrapError(err, "while configuring DKG timeout")
 	}
 
-	if a.DKGReportingPluginFactoryDecorator != nil {
-		dkgReportingPluginFactory = a.DKGReportingPluginFactoryDecorator(dkgReportingPluginFactory)
+	err = dkg.RestoreCompletedKeys(context.Background(), dkgReportingPluginFactory)
+	if err != nil {
+		return nil, util.WrapError(err, "while restoring completed DKG keys")
 	}
 
-	if a.VRFReportingPluginFactoryDecorator != nil {
-		vrfReportingPluginFactory = a.VRFReportingPluginFactoryDecorator(vrfReportingPluginFactory)
+	undecoratedDKGFactory := dkgReportingPluginFactory
+	if a.DKGReportingPluginFactoryDecorator != nil {
+		dkgReportingPluginFactory = a.DKGReportingPluginFactoryDecorator(dkgReportingPluginFactory)
 	}
 
 	deployedDKG, err := offchainreporting.NewOracle(offchainreporting.OCR2OracleArgs{
@@ -69,21 +71,49 @@ func NewOCR2VRF(a DKGVRFArgs) (*OCR2VRF, error) {
 	if err != nil {
 		return nil, util.WrapError(err, "while setting up new DKG oracle")
 	}
-	confirmationDelays := make(map[uint32]struct{}, len(a.ConfirmationDelays))
-	for _, d := range a.ConfirmationDelays {
-		confirmationDelays[d] = struct{}{}
+
+	vrfs := make([]offchainreporting.Oracle, len(beacons))
+	for i, b := range beacons {
+		deployedVRF, err := newVRFOracle(&a, &b, transceiver)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while setting up VRF beacon for key ID 0x%x", b.KeyID)
+		}
+		vrfs[i] = deployedVRF
+	}
+
+	return &OCR2VRF{deployedDKG, undecoratedDKGFactory, vrfs, transceiver}, nil
+}
+
+func newVRFOracle(
+	a *DKGVRFArgs, b *VRFBeaconArgs, transceiver *vrf.MultiKeyTransceiver,
+) (offchainreporting.Oracle, error) {
+	vrfReportingPluginFactory, err := vrf.NewVRFReportingPluginFactory(
+		b.KeyID,
+		transceiver,
+		b.Coordinator,
+		b.Serializer,
+		b.Logger,
+		b.JuelsPerFeeCoin,
+		b.ReasonableGasPrice,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not instantiate VRF reporting plugin factory")
+	}
+
+	if b.ReportingPluginFactoryDecorator != nil {
+		vrfReportingPluginFactory = b.ReportingPluginFactoryDecorator(vrfReportingPluginFactory)
 	}
 
 	deployedVRF, err := offchainreporting.NewOracle(offchainreporting.OCR2OracleArgs{
 		BinaryNetworkEndpointFactory: a.BinaryNetworkEndpointFactory,
 		V2Bootstrappers:              a.V2Bootstrappers,
-		ContractConfigTracker:        a.VRFContractConfigTracker,
-		ContractTransmitter:          a.VRFContractTransmitter,
-		Database:                     a.VRFDatabase,
-		LocalConfig:                  a.VRFLocalConfig,
-		Logger:                       a.VRFLogger,
-		MonitoringEndpoint:           a.VRFMonitoringEndpoint,
-		OffchainConfigDigester:       a.VRFOffchainConfigDigester,
+		ContractConfigTracker:        b.ContractConfigTracker,
+		ContractTransmitter:          b.ContractTransmitter,
+		Database:                     b.Database,
+		LocalConfig:                  b.LocalConfig,
+		Logger:                       b.Logger,
+		MonitoringEndpoint:           b.MonitoringEndpoint,
+		OffchainConfigDigester:       b.OffchainConfigDigester,
 		OffchainKeyring:              a.OffchainKeyring,
 		OnchainKeyring:               a.OnchainKeyring,
 		ReportingPluginFactory:       vrfReportingPluginFactory,
@@ -91,7 +121,7 @@ func NewOCR2VRF(a DKGVRFArgs) (*OCR2VRF, error) {
 	if err != nil {
 		return nil, util.WrapError(err, "while setting up VRF oracle")
 	}
-	return &OCR2VRF{deployedDKG, deployedVRF, transceiver}, nil
+	return deployedVRF, nil
 }
 
 func OffchainConfig(v *protobuf.CoordinatorConfig) []byte {
@@ -102,22 +132,63 @@ func OnchainConfig(confDelays map[uint32]struct{}) []byte {
 	return vrf.OnchainConfig(confDelays)
 }
 
+func (o *OCR2VRF) DKGStatus(ctx context.Context) (*dkg.Status, error) {
+	return dkg.PluginStatus(ctx, o.dkgFactory)
+}
+
+func (o *OCR2VRF) AbortDKG(reason string) error {
+	return dkg.Abort(o.dkgFactory, reason)
+}
+
+func (o *OCR2VRF) DKGAttempts() ([]dkg.Attempt, error) {
+	return dkg.Attempts(o.dkgFactory)
+}
+
+func (o *OCR2VRF) SubscribeDKGKeyEvents() (<-chan dkg.KeyEvent, func(), error) {
+	return dkg.SubscribeKeyEvents(o.dkgFactory)
+}
+
+func (o *OCR2VRF) AddDKGKeyConsumer(k dkg.KeyConsumer) error {
+	return dkg.AddKeyConsumer(o.dkgFactory, k)
+}
+
+func (o *OCR2VRF) ExportDKGKeyBackup(
+	ctx context.Context, passphrase []byte,
+) ([]byte, error) {
+	return dkg.ExportKeyBackup(ctx, o.dkgFactory, passphrase)
+}
+
+func (o *OCR2VRF) ImportDKGKeyBackup(
+	ctx context.Context, backup, passphrase []byte,
+) (*dkg.KeyData, error) {
+	return dkg.ImportKeyBackup(ctx, o.dkgFactory, backup, passphrase)
+}
+
 func (o *OCR2VRF) Start() error {
 	if err := o.dkg.Start(); err != nil {
 		return util.WrapError(err, "starting DKG oracle")
 	}
-	if err := util.WrapError(o.vrf.Start(), "starting VRF oracle"); err != nil {
-		return multierr.Append(err, util.WrapError(
-			o.dkg.Close(),
-			"closing DKG process after starting VRF process failed",
-		))
+	for i, v := range o.vrfs {
+		if err := util.WrapError(v.Start(), "starting VRF oracle"); err != nil {
+			for _, started := range o.vrfs[:i] {
+				err = multierr.Append(err, util.WrapError(
+					started.Close(),
+					"closing VRF process after starting another VRF process failed",
+				))
+			}
+			return multierr.Append(err, util.WrapError(
+				o.dkg.Close(),
+				"closing DKG process after starting VRF process failed",
+			))
+		}
 	}
 	return nil
 }
 
 func (o *OCR2VRF) Close() error {
-	return multierr.Append(
-		util.WrapError(o.dkg.Close(), "while closing DKG process"),
-		util.WrapError(o.vrf.Close(), "while closing VRF process"),
-	)
+	err := util.WrapError(o.dkg.Close(), "while closing DKG process")
+	for _, v := range o.vrfs {
+		err = multierr.Append(err, util.WrapError(v.Close(), "while closing VRF process"))
+	}
+	return err
 }
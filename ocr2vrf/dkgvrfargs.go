@@ -1,9 +1,12 @@
 package ocr2vrf
 
 import (
+	"time"
+
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 
+	"github.com/smartcontractkit/chainlink-vrf/internal/crypto/key_store"
 	dkg_contract "github.com/smartcontractkit/chainlink-vrf/internal/dkg/contract"
 	vrf_types "github.com/smartcontractkit/chainlink-vrf/types"
 )
@@ -48,6 +51,9 @@ type DKGVRFArgs struct {
 
 	DKGSharePersistence vrf_types.DKGSharePersistence
 
+	DKGTimeout    time.Duration
+	DKGMaxRetries int
+
 	Serializer         vrf_types.ReportSerializer
 	JuelsPerFeeCoin    vrf_types.JuelsPerFeeCoin
 	ReasonableGasPrice vrf_types.ReasonableGasPrice
@@ -59,6 +65,64 @@ type DKGVRFArgs struct {
 	Ssk   dkg_contract.SigningSecretKey
 	KeyID dkg_contract.KeyID
 
+	KeyStore key_store.KeyStore
+
 	DKGReportingPluginFactoryDecorator func(factory types.ReportingPluginFactory) types.ReportingPluginFactory
 	VRFReportingPluginFactoryDecorator func(factory types.ReportingPluginFactory) types.ReportingPluginFactory
+
+	AdditionalBeacons []VRFBeaconArgs
+}
+
+type VRFBeaconArgs struct {
+	Logger commontypes.Logger
+
+	OffchainConfigDigester types.OffchainConfigDigester
+
+	ContractConfigTracker types.ContractConfigTracker
+
+	ContractTransmitter types.ContractTransmitter
+
+	Database types.Database
+
+	LocalConfig types.LocalConfig
+
+	MonitoringEndpoint commontypes.MonitoringEndpoint
+
+	Serializer         vrf_types.ReportSerializer
+	JuelsPerFeeCoin    vrf_types.JuelsPerFeeCoin
+	ReasonableGasPrice vrf_types.ReasonableGasPrice
+	Coordinator        vrf_types.CoordinatorInterface
+
+	ConfirmationDelays []uint32
+
+	KeyID dkg_contract.KeyID
+
+	ReportingPluginFactoryDecorator func(factory types.ReportingPluginFactory) types.ReportingPluginFactory
+}
+
+func (a *DKGVRFArgs) keyStore() key_store.KeyStore {
+	if a.KeyStore != nil {
+		return a.KeyStore
+	}
+	return key_store.NewInProcessKeyStore(a.Esk, a.Ssk)
+}
+
+func (a *DKGVRFArgs) beacons() []VRFBeaconArgs {
+	primary := VRFBeaconArgs{
+		a.VRFLogger,
+		a.VRFOffchainConfigDigester,
+		a.VRFContractConfigTracker,
+		a.VRFContractTransmitter,
+		a.VRFDatabase,
+		a.VRFLocalConfig,
+		a.VRFMonitoringEndpoint,
+		a.Serializer,
+		a.JuelsPerFeeCoin,
+		a.ReasonableGasPrice,
+		a.Coordinator,
+		a.ConfirmationDelays,
+		a.KeyID,
+		a.VRFReportingPluginFactoryDecorator,
+	}
+	return append([]VRFBeaconArgs{primary}, a.AdditionalBeacons...)
 }
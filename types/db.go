@@ -23,6 +23,75 @@ type DKGSharePersistence interface {
 	) (retrievedShares []PersistentShareSetRecord, err error)
 }
 
+type PrunableDKGSharePersistence interface {
+	DKGSharePersistence
+
+	// ConfigDigests lists the config digests with records stored for the key ID,
+	// sorted by byte value. The order says nothing about which config is newer.
+	ConfigDigests(keyID [32]byte) ([]ocr_types.ConfigDigest, error)
+
+	DeleteShareRecords(
+		ctx context.Context,
+		cfgDgst ocr_types.ConfigDigest,
+		keyID [32]byte,
+	) error
+}
+
+type ReplaceableDKGSharePersistence interface {
+	DKGSharePersistence
+
+	// ReplaceShareRecords atomically replaces all share records stored for the
+	// config digest and key ID.
+	ReplaceShareRecords(
+		ctx context.Context,
+		cfgDgst ocr_types.ConfigDigest,
+		keyID [32]byte,
+		shareRecords []PersistentShareSetRecord,
+	) error
+}
+
+type KeySnapshotPersistence interface {
+	WriteKeySnapshot(
+		ctx context.Context,
+		cfgDgst ocr_types.ConfigDigest,
+		keyID [32]byte,
+		snapshot []byte,
+	) error
+
+	ReadKeySnapshot(
+		cfgDgst ocr_types.ConfigDigest,
+		keyID [32]byte,
+	) (snapshot []byte, err error)
+}
+
+// TranscriptPersistence stores the aggregate of a reported key's share sets.
+// A player missing some of the share sets accepts a transcript once more
+// oracles than can be faulty send it, so a stored transcript is trusted rather
+// than verified against the share records listed onchain.
+type TranscriptPersistence interface {
+	WriteTranscript(
+		ctx context.Context,
+		cfgDgst ocr_types.ConfigDigest,
+		keyID [32]byte,
+		transcript []byte,
+	) error
+
+	ReadTranscript(
+		cfgDgst ocr_types.ConfigDigest,
+		keyID [32]byte,
+	) (transcript []byte, err error)
+}
+
+type CompletedKeyPersistence interface {
+	// WriteCompletedKey stores the record of the latest completed DKG for the key
+	// ID, replacing any earlier record for it.
+	WriteCompletedKey(ctx context.Context, keyID [32]byte, record []byte) error
+
+	// CompletedKeys returns the completed key records by key ID. If some records
+	// can't be read, it returns the others along with an error.
+	CompletedKeys() (records map[[32]byte][]byte, err error)
+}
+
 type PersistentShareSetRecord struct {
 	Dealer               player_idx.PlayerIdx
 	MarshaledShareRecord []byte